@@ -1,16 +1,21 @@
 package agola
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"path"
 	"strings"
 	"time"
 
 	"wecode.sorint.it/opensource/papagaio-api/api"
+	"wecode.sorint.it/opensource/papagaio-api/api/transport"
 	"wecode.sorint.it/opensource/papagaio-api/config"
 	"wecode.sorint.it/opensource/papagaio-api/model"
 	"wecode.sorint.it/opensource/papagaio-api/repository"
@@ -18,35 +23,51 @@ import (
 )
 
 type AgolaApiInterface interface {
-	CheckOrganizationExists(organization *model.Organization) (bool, string, error)
-	CheckProjectExists(organization *model.Organization, projectName string) (bool, string)
-	CreateOrganization(organization *model.Organization, visibility types.VisibilityType) (string, error)
-	DeleteOrganization(organization *model.Organization, user *model.User) error
-	CreateProject(projectName string, agolaProjectRef string, organization *model.Organization, remoteSourceName string, user *model.User) (string, error)
-	DeleteProject(organization *model.Organization, agolaProjectRef string, user *model.User) error
-	AddOrUpdateOrganizationMember(organization *model.Organization, agolaUserRef string, role string) error
-	RemoveOrganizationMember(organization *model.Organization, agolaUserRef string) error
-	GetOrganizationMembers(organization *model.Organization) (*OrganizationMembersResponseDto, error)
-	ArchiveProject(organization *model.Organization, agolaProjectRef string) error
-	UnarchiveProject(organization *model.Organization, agolaProjectRef string) error
-	GetRuns(projectRef string, lastRun bool, phase string, startRunNumber *uint64, limit uint, asc bool) ([]*RunsDto, error)
-	GetRun(projectRef string, runNumber uint64) (*RunDto, error)
-	GetTask(projectRef string, runNumber uint64, taskID string) (*TaskDto, error)
-	GetLogs(projectRef string, runNumber uint64, taskID string, step int) (string, error)
-	GetRemoteSource(agolaRemoteSource string) (*RemoteSourceDto, error)
-	GetUsers() ([]*UserDto, error)
-	GetUser(userRef string) (*UserDto, error)
-	GetUsersFilterbyRemoteUser(remoteSourceID string, remoteUserID int64) ([]*UserDto, error)
-	GetOrganizations() ([]*OrganizationDto, error)
-	GetUserOrganizations(user *model.User, isAdminUser bool) ([]*UserOrgDto, error)
-	GetProjectgroupProjects(projectgroupref string) ([]*ProjectDto, error)
-	GetUserRuns(user *model.User, isAdminUser bool, userRef string, lastRun bool, phase string, startRunNumber *uint64, limit uint, asc bool) ([]*RunsDto, error)
-	GetProjectgroupSubgroups(projectgroupref string) ([]*ProjectGroupDto, error)
-
-	CreateUserToken(user *model.User) error
-	GetRemoteSources() (*[]RemoteSourceDto, error)
-	CreateRemoteSource(remoteSourceName string, gitType string, apiUrl string, oauth2ClientId string, oauth2ClientSecret string) error
-	DeleteRemotesource(remoteSourceName string) error
+	CheckOrganizationExists(ctx context.Context, organization *model.Organization) (bool, string, error)
+	CheckProjectExists(ctx context.Context, organization *model.Organization, projectName string) (bool, string, error)
+	CreateOrganization(ctx context.Context, organization *model.Organization, visibility types.VisibilityType) (string, error)
+	DeleteOrganization(ctx context.Context, organization *model.Organization, user *model.User) error
+	CreateProject(ctx context.Context, projectName string, agolaProjectRef string, organization *model.Organization, remoteSourceName string, user *model.User) (string, error)
+	DeleteProject(ctx context.Context, organization *model.Organization, agolaProjectRef string, user *model.User) error
+	RenameProject(ctx context.Context, organization *model.Organization, agolaProjectRef string, newAgolaProjectRef string, user *model.User) error
+	MoveProject(ctx context.Context, organization *model.Organization, agolaProjectRef string, newOrganization *model.Organization, newAgolaProjectRef string, user *model.User) error
+	AddOrUpdateOrganizationMember(ctx context.Context, organization *model.Organization, agolaUserRef string, role string) error
+	RemoveOrganizationMember(ctx context.Context, organization *model.Organization, agolaUserRef string) error
+	GetOrganizationMembers(ctx context.Context, organization *model.Organization) (*OrganizationMembersResponseDto, error)
+	ArchiveProject(ctx context.Context, organization *model.Organization, agolaProjectRef string) error
+	UnarchiveProject(ctx context.Context, organization *model.Organization, agolaProjectRef string) error
+	GetRuns(ctx context.Context, projectRef string, lastRun bool, phase string, startRunNumber *uint64, limit uint, asc bool) ([]*RunsDto, error)
+	GetRun(ctx context.Context, projectRef string, runNumber uint64) (*RunDto, error)
+	GetTask(ctx context.Context, projectRef string, runNumber uint64, taskID string) (*TaskDto, error)
+	GetLogs(ctx context.Context, projectRef string, runNumber uint64, taskID string, step int) (string, error)
+	GetLogsStream(ctx context.Context, projectRef string, runNumber uint64, taskID string, step int) (io.ReadCloser, error)
+	GetRunEventsStream(ctx context.Context) (io.ReadCloser, error)
+	RestartRun(ctx context.Context, user *model.User, projectRef string, runNumber uint64, fromStart bool) (*RunDto, error)
+	StopRun(ctx context.Context, user *model.User, projectRef string, runNumber uint64) (*RunDto, error)
+	CancelRun(ctx context.Context, user *model.User, projectRef string, runNumber uint64) (*RunDto, error)
+	ApproveTask(ctx context.Context, user *model.User, projectRef string, runNumber uint64, taskID string) error
+	GetRemoteSource(ctx context.Context, agolaRemoteSource string) (*RemoteSourceDto, error)
+	GetUsers(ctx context.Context) ([]*UserDto, error)
+	GetUser(ctx context.Context, userRef string) (*UserDto, error)
+	GetUsersFilterbyRemoteUser(ctx context.Context, remoteSourceID string, remoteUserID int64) ([]*UserDto, error)
+	GetOrganizations(ctx context.Context) ([]*OrganizationDto, error)
+	GetUserOrganizations(ctx context.Context, user *model.User, isAdminUser bool) ([]*UserOrgDto, error)
+	GetProjectgroupProjects(ctx context.Context, projectgroupref string) ([]*ProjectDto, error)
+	GetUserRuns(ctx context.Context, user *model.User, isAdminUser bool, userRef string, lastRun bool, phase string, startRunNumber *uint64, limit uint, asc bool) ([]*RunsDto, error)
+	GetProjectgroupSubgroups(ctx context.Context, projectgroupref string) ([]*ProjectGroupDto, error)
+	GetProjectSecrets(ctx context.Context, organization *model.Organization, agolaProjectRef string) ([]*SecretDto, error)
+	CreateProjectSecret(ctx context.Context, organization *model.Organization, agolaProjectRef string, secretName string, data map[string]string) error
+	UpdateProjectSecret(ctx context.Context, organization *model.Organization, agolaProjectRef string, secretName string, data map[string]string) error
+	DeleteProjectSecret(ctx context.Context, organization *model.Organization, agolaProjectRef string, secretName string) error
+	GetProjectVariables(ctx context.Context, organization *model.Organization, agolaProjectRef string) ([]*VariableDto, error)
+	CreateProjectVariable(ctx context.Context, organization *model.Organization, agolaProjectRef string, variableName string, values []VariableValueDto) error
+	UpdateProjectVariable(ctx context.Context, organization *model.Organization, agolaProjectRef string, variableName string, values []VariableValueDto) error
+	DeleteProjectVariable(ctx context.Context, organization *model.Organization, agolaProjectRef string, variableName string) error
+
+	CreateUserToken(ctx context.Context, user *model.User) error
+	GetRemoteSources(ctx context.Context) (*[]RemoteSourceDto, error)
+	CreateRemoteSource(ctx context.Context, remoteSourceName string, gitType string, apiUrl string, oauth2ClientId string, oauth2ClientSecret string) error
+	DeleteRemotesource(ctx context.Context, remoteSourceName string) error
 }
 
 type AgolaApi struct {
@@ -55,11 +76,11 @@ type AgolaApi struct {
 
 const baseTokenName string = "papagaioToken"
 
-func (agolaApi *AgolaApi) GetOrganizations() ([]*OrganizationDto, error) {
+func (agolaApi *AgolaApi) GetOrganizations(ctx context.Context) ([]*OrganizationDto, error) {
 	client := agolaApi.getClient(nil, true)
 	URLApi := getOrganizationsUrl()
 
-	req, _ := http.NewRequest("GET", URLApi, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", URLApi, nil)
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -68,8 +89,7 @@ func (agolaApi *AgolaApi) GetOrganizations() ([]*OrganizationDto, error) {
 	defer resp.Body.Close()
 
 	if !api.IsResponseOK(resp.StatusCode) {
-		respMessage, _ := ioutil.ReadAll(resp.Body)
-		return nil, errors.New(string(respMessage))
+		return nil, newResponseError(resp)
 	}
 
 	body, _ := ioutil.ReadAll(resp.Body)
@@ -83,11 +103,15 @@ func (agolaApi *AgolaApi) GetOrganizations() ([]*OrganizationDto, error) {
 	return jsonResponse, err
 }
 
-func (agolaApi *AgolaApi) CheckOrganizationExists(organization *model.Organization) (bool, string, error) {
+//For the user namespaces check the Agola user owning the projects
+func (agolaApi *AgolaApi) CheckOrganizationExists(ctx context.Context, organization *model.Organization) (bool, string, error) {
 	client := agolaApi.getClient(nil, true)
 	URLApi := getOrganizationUrl(organization.AgolaOrganizationRef)
+	if organization.UserNamespace {
+		URLApi = getUserUrl(organization.AgolaUserRef)
+	}
 
-	req, _ := http.NewRequest("GET", URLApi, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", URLApi, nil)
 	resp, err := client.Do(req)
 	if err != nil {
 		return false, "", err
@@ -95,56 +119,59 @@ func (agolaApi *AgolaApi) CheckOrganizationExists(organization *model.Organizati
 
 	defer resp.Body.Close()
 
-	var organizationID string
-	organizationExists := api.IsResponseOK(resp.StatusCode)
-	if organizationExists {
-		body, _ := ioutil.ReadAll(resp.Body)
-		var jsonResponse AgolaCreateORGDto
-		err := json.Unmarshal(body, &jsonResponse)
-		if err != nil {
-			return false, "", nil
-		}
+	if resp.StatusCode == http.StatusNotFound {
+		return false, "", nil
+	}
+	if !api.IsResponseOK(resp.StatusCode) {
+		return false, "", newResponseError(resp)
+	}
 
-		organizationID = jsonResponse.ID
+	body, _ := ioutil.ReadAll(resp.Body)
+	var jsonResponse AgolaCreateORGDto
+	err = json.Unmarshal(body, &jsonResponse)
+	if err != nil {
+		return false, "", err
 	}
 
-	return organizationExists, organizationID, nil
+	return true, jsonResponse.ID, nil
 }
 
-func (agolaApi *AgolaApi) CheckProjectExists(organization *model.Organization, agolaProjectRef string) (bool, string) {
+//Return false without error only if Agola answers that the project doesn't exist
+func (agolaApi *AgolaApi) CheckProjectExists(ctx context.Context, organization *model.Organization, agolaProjectRef string) (bool, string, error) {
 	log.Println("CheckProjectExists start")
 
 	client := agolaApi.getClient(nil, true)
-	URLApi := getProjectUrl(organization.AgolaOrganizationRef, agolaProjectRef)
-	req, _ := http.NewRequest("GET", URLApi, nil)
+	URLApi := getProjectUrl(organization.AgolaParentRef(), agolaProjectRef)
+	req, _ := http.NewRequestWithContext(ctx, "GET", URLApi, nil)
 	resp, err := client.Do(req)
 
 	if err != nil {
-		return false, ""
+		return false, "", err
 	}
 	defer resp.Body.Close()
 
-	var projectID string
-	projectExists := api.IsResponseOK(resp.StatusCode)
-	if projectExists {
-		body, _ := ioutil.ReadAll(resp.Body)
-		var jsonResponse CreateProjectResponseDto
-		err = json.Unmarshal(body, &jsonResponse)
-		if err != nil {
-			return false, ""
-		}
+	if resp.StatusCode == http.StatusNotFound {
+		return false, "", nil
+	}
+	if !api.IsResponseOK(resp.StatusCode) {
+		return false, "", newResponseError(resp)
+	}
 
-		projectID = jsonResponse.ID
+	body, _ := ioutil.ReadAll(resp.Body)
+	var jsonResponse CreateProjectResponseDto
+	err = json.Unmarshal(body, &jsonResponse)
+	if err != nil {
+		return false, "", err
 	}
 
-	return projectExists, projectID
+	return true, jsonResponse.ID, nil
 }
 
-func (agolaApi *AgolaApi) CreateOrganization(organization *model.Organization, visibility types.VisibilityType) (string, error) {
+func (agolaApi *AgolaApi) CreateOrganization(ctx context.Context, organization *model.Organization, visibility types.VisibilityType) (string, error) {
 	client := agolaApi.getClient(nil, true)
 	URLApi := getOrgUrl()
 	reqBody := strings.NewReader(`{"name": "` + organization.AgolaOrganizationRef + `", "visibility": "` + string(visibility) + `"}`)
-	req, _ := http.NewRequest("POST", URLApi, reqBody)
+	req, _ := http.NewRequestWithContext(ctx, "POST", URLApi, reqBody)
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -153,8 +180,7 @@ func (agolaApi *AgolaApi) CreateOrganization(organization *model.Organization, v
 	defer resp.Body.Close()
 
 	if !api.IsResponseOK(resp.StatusCode) {
-		respMessage, _ := ioutil.ReadAll(resp.Body)
-		return "", errors.New(string(respMessage))
+		return "", newResponseError(resp)
 	}
 
 	body, _ := ioutil.ReadAll(resp.Body)
@@ -168,10 +194,26 @@ func (agolaApi *AgolaApi) CreateOrganization(organization *model.Organization, v
 	return jsonResponse.ID, err
 }
 
-func (agolaApi *AgolaApi) DeleteOrganization(organization *model.Organization, user *model.User) error {
+//The user namespaces have no Agola organization, only their projects are deleted
+func (agolaApi *AgolaApi) DeleteOrganization(ctx context.Context, organization *model.Organization, user *model.User) error {
+	if organization.UserNamespace {
+		for _, project := range organization.Projects {
+			if !project.ExistsInAgola() {
+				continue
+			}
+
+			err := agolaApi.DeleteProject(ctx, organization, project.AgolaProjectRef, user)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
 	client := agolaApi.getClient(user, false)
 	URLApi := getOrganizationUrl(organization.AgolaOrganizationRef)
-	req, _ := http.NewRequest("DELETE", URLApi, nil)
+	req, _ := http.NewRequestWithContext(ctx, "DELETE", URLApi, nil)
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -184,26 +226,35 @@ func (agolaApi *AgolaApi) DeleteOrganization(organization *model.Organization, u
 	}
 
 	if !api.IsResponseOK(resp.StatusCode) {
-		respMessage, _ := ioutil.ReadAll(resp.Body)
-		return errors.New(string(respMessage))
+		return newResponseError(resp)
 	}
 
 	return nil
 }
 
-func (agolaApi *AgolaApi) CreateProject(projectName string, agolaProjectRef string, organization *model.Organization, remoteSourceName string, user *model.User) (string, error) {
+func (agolaApi *AgolaApi) CreateProject(ctx context.Context, projectName string, agolaProjectRef string, organization *model.Organization, remoteSourceName string, user *model.User) (string, error) {
 	log.Println("CreateProject start")
 
-	if exists, projectID := agolaApi.CheckProjectExists(organization, agolaProjectRef); exists {
+	exists, projectID, err := agolaApi.CheckProjectExists(ctx, organization, agolaProjectRef)
+	if err != nil {
+		return "", err
+	}
+	if exists {
 		log.Println("project already exists with ID:", projectID)
 		return projectID, nil
 	}
+	parentRef, name := getProjectParentRef(organization, agolaProjectRef)
+	err = agolaApi.createProjectgroups(ctx, organization, agolaProjectRef, user)
+	if err != nil {
+		return "", err
+	}
+
 	client := agolaApi.getClient(user, false)
 	URLApi := getCreateProjectUrl()
 
 	projectRequest := &CreateProjectRequestDto{
-		Name:             agolaProjectRef,
-		ParentRef:        "org/" + organization.AgolaOrganizationRef,
+		Name:             name,
+		ParentRef:        parentRef,
 		Visibility:       organization.Visibility,
 		RemoteSourceName: remoteSourceName,
 		RepoPath:         organization.GitPath + "/" + projectName,
@@ -212,7 +263,7 @@ func (agolaApi *AgolaApi) CreateProject(projectName string, agolaProjectRef stri
 	data, _ := json.Marshal(projectRequest)
 	reqBody := strings.NewReader(string(data))
 
-	req, _ := http.NewRequest("POST", URLApi, reqBody)
+	req, _ := http.NewRequestWithContext(ctx, "POST", URLApi, reqBody)
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -221,8 +272,7 @@ func (agolaApi *AgolaApi) CreateProject(projectName string, agolaProjectRef stri
 	defer resp.Body.Close()
 
 	if !api.IsResponseOK(resp.StatusCode) {
-		respMessage, _ := ioutil.ReadAll(resp.Body)
-		return "", errors.New(string(respMessage))
+		return "", newResponseError(resp)
 	}
 
 	body, _ := ioutil.ReadAll(resp.Body)
@@ -236,12 +286,81 @@ func (agolaApi *AgolaApi) CreateProject(projectName string, agolaProjectRef stri
 	return jsonResponse.ID, err
 }
 
-func (agolaApi *AgolaApi) DeleteProject(organization *model.Organization, agolaProjectRef string, user *model.User) error {
+//The projects of the git subgroups, like subgroup/project, are created in projectgroups with the path of the subgroups
+func getProjectParentRef(organization *model.Organization, agolaProjectRef string) (string, string) {
+	parentRef := organization.AgolaParentRef()
+	groupPath := path.Dir(agolaProjectRef)
+	if strings.Compare(groupPath, ".") != 0 {
+		parentRef += "/" + groupPath
+	}
+
+	return parentRef, path.Base(agolaProjectRef)
+}
+
+//Create the missing projectgroups of the project path
+func (agolaApi *AgolaApi) createProjectgroups(ctx context.Context, organization *model.Organization, agolaProjectRef string, user *model.User) error {
+	groupPath := path.Dir(agolaProjectRef)
+	if strings.Compare(groupPath, ".") == 0 {
+		return nil
+	}
+
+	client := agolaApi.getClient(user, false)
+	parentRef := organization.AgolaParentRef()
+	groupRef := ""
+	for _, groupName := range strings.Split(groupPath, "/") {
+		if len(groupRef) > 0 {
+			groupRef += "/"
+		}
+		groupRef += groupName
+
+		req, _ := http.NewRequestWithContext(ctx, "GET", getProjectgroupUrl(organization.AgolaParentRef(), groupRef), nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		if !api.IsResponseOK(resp.StatusCode) && resp.StatusCode != http.StatusNotFound {
+			err := newResponseError(resp)
+			resp.Body.Close()
+			return err
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusNotFound {
+			log.Println("create projectgroup", groupRef, "in", organization.AgolaOrganizationRef)
+
+			projectgroupRequest := &CreateProjectgroupRequestDto{
+				Name:       groupName,
+				ParentRef:  parentRef,
+				Visibility: organization.Visibility,
+			}
+			data, _ := json.Marshal(projectgroupRequest)
+
+			req, _ := http.NewRequestWithContext(ctx, "POST", getProjectgroupsUrl(), strings.NewReader(string(data)))
+			resp, err := client.Do(req)
+			if err != nil {
+				return err
+			}
+
+			if !api.IsResponseOK(resp.StatusCode) {
+				err := newResponseError(resp)
+				resp.Body.Close()
+				return err
+			}
+			resp.Body.Close()
+		}
+
+		parentRef += "/" + groupName
+	}
+
+	return nil
+}
+
+func (agolaApi *AgolaApi) DeleteProject(ctx context.Context, organization *model.Organization, agolaProjectRef string, user *model.User) error {
 	log.Println("DeleteProject start")
 
 	client := agolaApi.getClient(user, false)
-	URLApi := getProjectUrl(organization.AgolaOrganizationRef, agolaProjectRef)
-	req, _ := http.NewRequest("DELETE", URLApi, nil)
+	URLApi := getProjectUrl(organization.AgolaParentRef(), agolaProjectRef)
+	req, _ := http.NewRequestWithContext(ctx, "DELETE", URLApi, nil)
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -250,8 +369,7 @@ func (agolaApi *AgolaApi) DeleteProject(organization *model.Organization, agolaP
 	defer resp.Body.Close()
 
 	if !api.IsResponseOK(resp.StatusCode) {
-		respMessage, _ := ioutil.ReadAll(resp.Body)
-		return errors.New(string(respMessage))
+		return newResponseError(resp)
 	}
 
 	log.Println("DeleteProject end")
@@ -259,7 +377,102 @@ func (agolaApi *AgolaApi) DeleteProject(organization *model.Organization, agolaP
 	return err
 }
 
-func (agolaApi *AgolaApi) AddOrUpdateOrganizationMember(organization *model.Organization, agolaUserRef string, role string) error {
+//Rename the project keeping its ID, so the runs history is preserved
+func (agolaApi *AgolaApi) RenameProject(ctx context.Context, organization *model.Organization, agolaProjectRef string, newAgolaProjectRef string, user *model.User) error {
+	log.Println("RenameProject start:", agolaProjectRef, "to", newAgolaProjectRef)
+
+	err := agolaApi.updateProjectRef(ctx, agolaApi.getClient(user, false), organization, agolaProjectRef, organization, newAgolaProjectRef, user)
+	if err != nil {
+		return err
+	}
+
+	log.Println("RenameProject end")
+
+	return nil
+}
+
+//Move the project to the projectgroup of another organization keeping its ID, the admin token is used because the user could not own both of them
+func (agolaApi *AgolaApi) MoveProject(ctx context.Context, organization *model.Organization, agolaProjectRef string, newOrganization *model.Organization, newAgolaProjectRef string, user *model.User) error {
+	log.Println("MoveProject start:", organization.AgolaParentRef(), agolaProjectRef, "to", newOrganization.AgolaParentRef(), newAgolaProjectRef)
+
+	err := agolaApi.updateProjectRef(ctx, agolaApi.getClient(nil, true), organization, agolaProjectRef, newOrganization, newAgolaProjectRef, user)
+	if err != nil {
+		return err
+	}
+
+	log.Println("MoveProject end")
+
+	return nil
+}
+
+//Change the name and the parent of the project, its other fields are sent with their current values so they aren't reset
+func (agolaApi *AgolaApi) updateProjectRef(ctx context.Context, client *httpClient, organization *model.Organization, agolaProjectRef string, newOrganization *model.Organization, newAgolaProjectRef string, user *model.User) error {
+	project, err := agolaApi.getProject(ctx, organization, agolaProjectRef)
+	if err != nil {
+		return err
+	}
+
+	parentRef, name := getProjectParentRef(newOrganization, newAgolaProjectRef)
+	err = agolaApi.createProjectgroups(ctx, newOrganization, newAgolaProjectRef, user)
+	if err != nil {
+		return err
+	}
+
+	URLApi := getProjectUrl(organization.AgolaParentRef(), agolaProjectRef)
+
+	projectRequest := &UpdateProjectRequestDto{
+		Name:               name,
+		ParentRef:          parentRef,
+		Visibility:         types.VisibilityType(project.Visibility),
+		PassVarsToForkedPR: project.PassVarsToForkedPR,
+	}
+	if len(projectRequest.Visibility) == 0 {
+		projectRequest.Visibility = newOrganization.Visibility
+	}
+
+	data, _ := json.Marshal(projectRequest)
+	reqBody := strings.NewReader(string(data))
+
+	req, _ := http.NewRequestWithContext(ctx, "PUT", URLApi, reqBody)
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if !api.IsResponseOK(resp.StatusCode) {
+		return newResponseError(resp)
+	}
+
+	return nil
+}
+
+func (agolaApi *AgolaApi) getProject(ctx context.Context, organization *model.Organization, agolaProjectRef string) (*ProjectDto, error) {
+	client := agolaApi.getClient(nil, true)
+	URLApi := getProjectUrl(organization.AgolaParentRef(), agolaProjectRef)
+	req, _ := http.NewRequestWithContext(ctx, "GET", URLApi, nil)
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if !api.IsResponseOK(resp.StatusCode) {
+		return nil, newResponseError(resp)
+	}
+
+	var project ProjectDto
+	err = json.NewDecoder(resp.Body).Decode(&project)
+	if err != nil {
+		return nil, err
+	}
+
+	return &project, nil
+}
+
+func (agolaApi *AgolaApi) AddOrUpdateOrganizationMember(ctx context.Context, organization *model.Organization, agolaUserRef string, role string) error {
 	log.Println("AddOrUpdateOrganizationMember start")
 
 	log.Println("AddOrUpdateOrganizationMember", agolaUserRef, "for", organization.GitName, "with role:", role)
@@ -268,7 +481,7 @@ func (agolaApi *AgolaApi) AddOrUpdateOrganizationMember(organization *model.Orga
 	client := agolaApi.getClient(nil, true)
 	URLApi := getAddOrgMemberUrl(organization.AgolaOrganizationRef, agolaUserRef)
 	reqBody := strings.NewReader(`{"role": "` + role + `"}`)
-	req, _ := http.NewRequest("PUT", URLApi, reqBody)
+	req, _ := http.NewRequestWithContext(ctx, "PUT", URLApi, reqBody)
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -277,8 +490,7 @@ func (agolaApi *AgolaApi) AddOrUpdateOrganizationMember(organization *model.Orga
 	defer resp.Body.Close()
 
 	if !api.IsResponseOK(resp.StatusCode) {
-		respMessage, _ := ioutil.ReadAll(resp.Body)
-		return errors.New(string(respMessage))
+		return newResponseError(resp)
 	}
 
 	log.Println("AddOrUpdateOrganizationMember end")
@@ -286,7 +498,7 @@ func (agolaApi *AgolaApi) AddOrUpdateOrganizationMember(organization *model.Orga
 	return err
 }
 
-func (agolaApi *AgolaApi) RemoveOrganizationMember(organization *model.Organization, agolaUserRef string) error {
+func (agolaApi *AgolaApi) RemoveOrganizationMember(ctx context.Context, organization *model.Organization, agolaUserRef string) error {
 	log.Println("RemoveOrganizationMember", organization.GitName, "with agolaUserRef", agolaUserRef)
 
 	var err error
@@ -294,7 +506,7 @@ func (agolaApi *AgolaApi) RemoveOrganizationMember(organization *model.Organizat
 	URLApi := getAddOrgMemberUrl(organization.AgolaOrganizationRef, agolaUserRef)
 
 	reqBody := strings.NewReader(`{}`)
-	req, _ := http.NewRequest("DELETE", URLApi, reqBody)
+	req, _ := http.NewRequestWithContext(ctx, "DELETE", URLApi, reqBody)
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -305,20 +517,20 @@ func (agolaApi *AgolaApi) RemoveOrganizationMember(organization *model.Organizat
 	log.Println("RemoveOrganizationMember StatusCode:", resp.StatusCode)
 
 	if !api.IsResponseOK(resp.StatusCode) {
-		respMessage, _ := ioutil.ReadAll(resp.Body)
-		log.Println("RemoveOrganizationMember respMessage:", string(respMessage))
-		return errors.New(string(respMessage))
+		err := newResponseError(resp)
+		log.Println("RemoveOrganizationMember respMessage:", err)
+		return err
 	}
 
-	return errors.New("response status: " + resp.Status)
+	return nil
 }
 
-func (agolaApi *AgolaApi) GetOrganizationMembers(organization *model.Organization) (*OrganizationMembersResponseDto, error) {
+func (agolaApi *AgolaApi) GetOrganizationMembers(ctx context.Context, organization *model.Organization) (*OrganizationMembersResponseDto, error) {
 	log.Println("GetOrganizationMembers start")
 
 	client := agolaApi.getClient(nil, true)
 	URLApi := getOrganizationMembersUrl(organization.AgolaOrganizationRef)
-	req, _ := http.NewRequest("GET", URLApi, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", URLApi, nil)
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -327,8 +539,7 @@ func (agolaApi *AgolaApi) GetOrganizationMembers(organization *model.Organizatio
 	defer resp.Body.Close()
 
 	if !api.IsResponseOK(resp.StatusCode) {
-		respMessage, _ := ioutil.ReadAll(resp.Body)
-		return nil, errors.New(string(respMessage))
+		return nil, newResponseError(resp)
 	}
 
 	body, _ := ioutil.ReadAll(resp.Body)
@@ -343,26 +554,26 @@ func (agolaApi *AgolaApi) GetOrganizationMembers(organization *model.Organizatio
 }
 
 //TODO after Agola Issue
-func (agolaApi *AgolaApi) ArchiveProject(organization *model.Organization, projectName string) error {
+func (agolaApi *AgolaApi) ArchiveProject(ctx context.Context, organization *model.Organization, projectName string) error {
 	log.Println("ArchiveProject:", organization.AgolaOrganizationRef, projectName)
 
 	return nil
 }
 
 //TODO after Agola Issue
-func (agolaApi *AgolaApi) UnarchiveProject(organization *model.Organization, projectName string) error {
+func (agolaApi *AgolaApi) UnarchiveProject(ctx context.Context, organization *model.Organization, projectName string) error {
 	log.Println("UnarchiveProject:", organization.AgolaOrganizationRef, projectName)
 
 	return nil
 }
 
-func (agolaApi *AgolaApi) GetRuns(projectRef string, lastRun bool, phase string, startRunNumber *uint64, limit uint, asc bool) ([]*RunsDto, error) {
+func (agolaApi *AgolaApi) GetRuns(ctx context.Context, projectRef string, lastRun bool, phase string, startRunNumber *uint64, limit uint, asc bool) ([]*RunsDto, error) {
 	log.Println("GetRuns start:", projectRef)
 
 	client := agolaApi.getClient(nil, true)
 	URLApi := getRunsListUrl(projectRef, lastRun, phase, startRunNumber, limit, asc)
 
-	req, _ := http.NewRequest("GET", URLApi, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", URLApi, nil)
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -371,8 +582,7 @@ func (agolaApi *AgolaApi) GetRuns(projectRef string, lastRun bool, phase string,
 	defer resp.Body.Close()
 
 	if !api.IsResponseOK(resp.StatusCode) {
-		respMessage, _ := ioutil.ReadAll(resp.Body)
-		return nil, errors.New(string(respMessage))
+		return nil, newResponseError(resp)
 	}
 
 	body, _ := ioutil.ReadAll(resp.Body)
@@ -386,12 +596,12 @@ func (agolaApi *AgolaApi) GetRuns(projectRef string, lastRun bool, phase string,
 	return jsonResponse, err
 }
 
-func (agolaApi *AgolaApi) GetRun(projectRef string, runNumber uint64) (*RunDto, error) {
+func (agolaApi *AgolaApi) GetRun(ctx context.Context, projectRef string, runNumber uint64) (*RunDto, error) {
 	log.Println("GetRuns start")
 
 	client := agolaApi.getClient(nil, true)
 	URLApi := getRunUrl(projectRef, runNumber)
-	req, _ := http.NewRequest("GET", URLApi, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", URLApi, nil)
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -400,8 +610,7 @@ func (agolaApi *AgolaApi) GetRun(projectRef string, runNumber uint64) (*RunDto,
 	defer resp.Body.Close()
 
 	if !api.IsResponseOK(resp.StatusCode) {
-		respMessage, _ := ioutil.ReadAll(resp.Body)
-		return nil, errors.New(string(respMessage))
+		return nil, newResponseError(resp)
 	}
 
 	body, _ := ioutil.ReadAll(resp.Body)
@@ -415,12 +624,82 @@ func (agolaApi *AgolaApi) GetRun(projectRef string, runNumber uint64) (*RunDto,
 	return &jsonResponse, err
 }
 
-func (agolaApi *AgolaApi) GetTask(projectRef string, runNumber uint64, taskID string) (*TaskDto, error) {
+//Restart the run from scratch or from the failed tasks, return the new run
+func (agolaApi *AgolaApi) RestartRun(ctx context.Context, user *model.User, projectRef string, runNumber uint64, fromStart bool) (*RunDto, error) {
+	//every restart creates a new run
+	return agolaApi.runAction(transport.WithoutRetry(ctx), user, projectRef, runNumber, &RunActionsRequestDto{ActionType: RunActionTypeRestart, FromStart: fromStart})
+}
+
+//Stop the tasks of a running run
+func (agolaApi *AgolaApi) StopRun(ctx context.Context, user *model.User, projectRef string, runNumber uint64) (*RunDto, error) {
+	return agolaApi.runAction(ctx, user, projectRef, runNumber, &RunActionsRequestDto{ActionType: RunActionTypeStop})
+}
+
+//Cancel a queued run
+func (agolaApi *AgolaApi) CancelRun(ctx context.Context, user *model.User, projectRef string, runNumber uint64) (*RunDto, error) {
+	return agolaApi.runAction(ctx, user, projectRef, runNumber, &RunActionsRequestDto{ActionType: RunActionTypeCancel})
+}
+
+//The run actions are sent with the token of the user, so the Agola permissions of the user apply
+func (agolaApi *AgolaApi) runAction(ctx context.Context, user *model.User, projectRef string, runNumber uint64, runAction *RunActionsRequestDto) (*RunDto, error) {
+	log.Println("run action", runAction.ActionType, "on run", runNumber, "of project", projectRef)
+
+	client := agolaApi.getClient(user, false)
+	URLApi := getRunActionsUrl(projectRef, runNumber)
+
+	data, _ := json.Marshal(runAction)
+	req, _ := http.NewRequestWithContext(ctx, "PUT", URLApi, bytes.NewReader(data))
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if !api.IsResponseOK(resp.StatusCode) {
+		return nil, newResponseError(resp)
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var jsonResponse RunDto
+	err = json.Unmarshal(body, &jsonResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonResponse, nil
+}
+
+//Approve a task waiting for approval with the token of the user
+func (agolaApi *AgolaApi) ApproveTask(ctx context.Context, user *model.User, projectRef string, runNumber uint64, taskID string) error {
+	log.Println("approve task", taskID, "of run", runNumber, "of project", projectRef)
+
+	client := agolaApi.getClient(user, false)
+	URLApi := getTaskActionsUrl(projectRef, runNumber, taskID)
+
+	data, _ := json.Marshal(&TaskActionsRequestDto{ActionType: TaskActionTypeApprove})
+	req, _ := http.NewRequestWithContext(ctx, "PUT", URLApi, bytes.NewReader(data))
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if !api.IsResponseOK(resp.StatusCode) {
+		return newResponseError(resp)
+	}
+
+	return nil
+}
+
+func (agolaApi *AgolaApi) GetTask(ctx context.Context, projectRef string, runNumber uint64, taskID string) (*TaskDto, error) {
 	log.Println("GetRuns start")
 
 	client := agolaApi.getClient(nil, true)
 	URLApi := getTaskUrl(projectRef, runNumber, taskID)
-	req, _ := http.NewRequest("GET", URLApi, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", URLApi, nil)
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -429,8 +708,7 @@ func (agolaApi *AgolaApi) GetTask(projectRef string, runNumber uint64, taskID st
 	defer resp.Body.Close()
 
 	if !api.IsResponseOK(resp.StatusCode) {
-		respMessage, _ := ioutil.ReadAll(resp.Body)
-		return nil, errors.New(string(respMessage))
+		return nil, newResponseError(resp)
 	}
 
 	body, _ := ioutil.ReadAll(resp.Body)
@@ -444,12 +722,12 @@ func (agolaApi *AgolaApi) GetTask(projectRef string, runNumber uint64, taskID st
 	return &jsonResponse, err
 }
 
-func (agolaApi *AgolaApi) GetLogs(projectRef string, runNumber uint64, taskID string, step int) (string, error) {
+func (agolaApi *AgolaApi) GetLogs(ctx context.Context, projectRef string, runNumber uint64, taskID string, step int) (string, error) {
 	log.Println("GetRuns start")
 
 	client := agolaApi.getClient(nil, true)
 	URLApi := getLogsUrl(projectRef, runNumber, taskID, step)
-	req, _ := http.NewRequest("GET", URLApi, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", URLApi, nil)
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -458,8 +736,7 @@ func (agolaApi *AgolaApi) GetLogs(projectRef string, runNumber uint64, taskID st
 	defer resp.Body.Close()
 
 	if !api.IsResponseOK(resp.StatusCode) {
-		respMessage, _ := ioutil.ReadAll(resp.Body)
-		return "", errors.New(string(respMessage))
+		return "", newResponseError(resp)
 	}
 
 	logs, _ := ioutil.ReadAll(resp.Body)
@@ -467,13 +744,62 @@ func (agolaApi *AgolaApi) GetLogs(projectRef string, runNumber uint64, taskID st
 	return string(logs), err
 }
 
-func (agolaApi *AgolaApi) GetRemoteSource(agolaRemoteSource string) (*RemoteSourceDto, error) {
+//Follow the logs of the step while it runs, the stream ends when the step is finished or the ctx is canceled. The caller must close the stream
+func (agolaApi *AgolaApi) GetLogsStream(ctx context.Context, projectRef string, runNumber uint64, taskID string, step int) (io.ReadCloser, error) {
+	log.Println("GetLogsStream start")
+
+	client := agolaApi.getClient(nil, true)
+	//the stream lasts as long as the step, only the ctx ends it
+	client.c.Timeout = 0
+
+	URLApi := getLogsStreamUrl(projectRef, runNumber, taskID, step)
+	req, _ := http.NewRequestWithContext(ctx, "GET", URLApi, nil)
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !api.IsResponseOK(resp.StatusCode) {
+		defer resp.Body.Close()
+		return nil, newResponseError(resp)
+	}
+
+	return resp.Body, nil
+}
+
+//Server-sent events stream of the run changes of all the projects, it ends on disconnect or when the ctx is canceled. The caller must close the stream
+func (agolaApi *AgolaApi) GetRunEventsStream(ctx context.Context) (io.ReadCloser, error) {
+	log.Println("GetRunEventsStream start")
+
+	client := agolaApi.getClient(nil, true)
+	//the stream is kept open, only the ctx ends it
+	client.c.Timeout = 0
+
+	URLApi := getRunEventsUrl()
+	req, _ := http.NewRequestWithContext(ctx, "GET", URLApi, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !api.IsResponseOK(resp.StatusCode) {
+		defer resp.Body.Close()
+		return nil, newResponseError(resp)
+	}
+
+	return resp.Body, nil
+}
+
+func (agolaApi *AgolaApi) GetRemoteSource(ctx context.Context, agolaRemoteSource string) (*RemoteSourceDto, error) {
 	log.Println("GetRemoteSource start")
 
 	client := agolaApi.getClient(nil, true)
 	URLApi := getRemoteSourceUrl(agolaRemoteSource)
 
-	req, _ := http.NewRequest("GET", URLApi, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", URLApi, nil)
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -482,8 +808,7 @@ func (agolaApi *AgolaApi) GetRemoteSource(agolaRemoteSource string) (*RemoteSour
 	defer resp.Body.Close()
 
 	if !api.IsResponseOK(resp.StatusCode) {
-		respMessage, _ := ioutil.ReadAll(resp.Body)
-		return nil, errors.New(string(respMessage))
+		return nil, newResponseError(resp)
 	}
 
 	body, _ := ioutil.ReadAll(resp.Body)
@@ -496,13 +821,13 @@ func (agolaApi *AgolaApi) GetRemoteSource(agolaRemoteSource string) (*RemoteSour
 	return &jsonResponse, nil
 }
 
-func (agolaApi *AgolaApi) getUsers(start string, limit uint) ([]*UserDto, error) {
+func (agolaApi *AgolaApi) getUsers(ctx context.Context, start string, limit uint) ([]*UserDto, error) {
 	log.Println("GetRemoteSource start")
 
 	client := agolaApi.getClient(nil, true)
 	URLApi := getUsersUrl(start, limit)
 
-	req, _ := http.NewRequest("GET", URLApi, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", URLApi, nil)
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -511,8 +836,7 @@ func (agolaApi *AgolaApi) getUsers(start string, limit uint) ([]*UserDto, error)
 	defer resp.Body.Close()
 
 	if !api.IsResponseOK(resp.StatusCode) {
-		respMessage, _ := ioutil.ReadAll(resp.Body)
-		return nil, errors.New(string(respMessage))
+		return nil, newResponseError(resp)
 	}
 
 	body, _ := ioutil.ReadAll(resp.Body)
@@ -525,11 +849,11 @@ func (agolaApi *AgolaApi) getUsers(start string, limit uint) ([]*UserDto, error)
 	return jsonResponse, nil
 }
 
-func (agolaApi *AgolaApi) GetUser(userRef string) (*UserDto, error) {
+func (agolaApi *AgolaApi) GetUser(ctx context.Context, userRef string) (*UserDto, error) {
 	client := agolaApi.getClient(nil, true)
 	URLApi := getUserUrl(userRef)
 
-	req, _ := http.NewRequest("GET", URLApi, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", URLApi, nil)
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -538,8 +862,7 @@ func (agolaApi *AgolaApi) GetUser(userRef string) (*UserDto, error) {
 	defer resp.Body.Close()
 
 	if !api.IsResponseOK(resp.StatusCode) {
-		respMessage, _ := ioutil.ReadAll(resp.Body)
-		return nil, errors.New(string(respMessage))
+		return nil, newResponseError(resp)
 	}
 
 	body, _ := ioutil.ReadAll(resp.Body)
@@ -552,13 +875,13 @@ func (agolaApi *AgolaApi) GetUser(userRef string) (*UserDto, error) {
 	return &jsonResponse, nil
 }
 
-func (agolaApi *AgolaApi) GetUsersFilterbyRemoteUser(remoteSourceID string, remoteUserID int64) ([]*UserDto, error) {
+func (agolaApi *AgolaApi) GetUsersFilterbyRemoteUser(ctx context.Context, remoteSourceID string, remoteUserID int64) ([]*UserDto, error) {
 	log.Println("GetRemoteSource start")
 
 	client := agolaApi.getClient(nil, true)
 	URLApi := getUsersFilterbyRemoteUserUrl("", 0, remoteSourceID, remoteUserID)
 
-	req, _ := http.NewRequest("GET", URLApi, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", URLApi, nil)
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -567,8 +890,7 @@ func (agolaApi *AgolaApi) GetUsersFilterbyRemoteUser(remoteSourceID string, remo
 	defer resp.Body.Close()
 
 	if !api.IsResponseOK(resp.StatusCode) {
-		respMessage, _ := ioutil.ReadAll(resp.Body)
-		return nil, errors.New(string(respMessage))
+		return nil, newResponseError(resp)
 	}
 
 	body, _ := ioutil.ReadAll(resp.Body)
@@ -583,12 +905,12 @@ func (agolaApi *AgolaApi) GetUsersFilterbyRemoteUser(remoteSourceID string, remo
 
 const usersLimit = 20
 
-func (agolaApi *AgolaApi) GetUsers() ([]*UserDto, error) {
+func (agolaApi *AgolaApi) GetUsers(ctx context.Context) ([]*UserDto, error) {
 	retVal := make([]*UserDto, 0)
 
 	start := ""
 	for {
-		users, err := agolaApi.getUsers(start, usersLimit)
+		users, err := agolaApi.getUsers(ctx, start, usersLimit)
 		if err != nil {
 			return nil, err
 		}
@@ -604,7 +926,7 @@ func (agolaApi *AgolaApi) GetUsers() ([]*UserDto, error) {
 	return retVal, nil
 }
 
-func (agolaApi *AgolaApi) CreateUserToken(user *model.User) error {
+func (agolaApi *AgolaApi) CreateUserToken(ctx context.Context, user *model.User) error {
 	if user == nil || user.AgolaUserRef == nil {
 		log.Println("CreateUserToken error user nil")
 		return errors.New("user nil error")
@@ -624,7 +946,7 @@ func (agolaApi *AgolaApi) CreateUserToken(user *model.User) error {
 	tokenName := baseTokenName + "-" + fmt.Sprint(time.Now().Unix())
 	user.AgolaTokenName = &tokenName
 
-	client := &http.Client{}
+	client := newHTTPClient()
 	URLApi := getCreateTokenUrl(*user.AgolaUserRef)
 
 	tokenRequest := &TokenRequestDto{
@@ -633,18 +955,17 @@ func (agolaApi *AgolaApi) CreateUserToken(user *model.User) error {
 	data, _ := json.Marshal(tokenRequest)
 	reqBody := strings.NewReader(string(data))
 
-	req, _ := http.NewRequest("POST", URLApi, reqBody)
+	req, _ := http.NewRequestWithContext(ctx, "POST", URLApi, reqBody)
 	req.Header.Set("Authorization", "token "+config.Config.Agola.AdminToken)
 	resp, err := client.Do(req)
 
 	if err != nil {
-		return err
+		return newRequestError(err)
 	}
 	defer resp.Body.Close()
 
 	if !api.IsResponseOK(resp.StatusCode) {
-		respMessage, _ := ioutil.ReadAll(resp.Body)
-		return errors.New(string(respMessage))
+		return newResponseError(resp)
 	}
 
 	body, _ := ioutil.ReadAll(resp.Body)
@@ -660,13 +981,13 @@ func (agolaApi *AgolaApi) CreateUserToken(user *model.User) error {
 	return err
 }
 
-func (agolaApi *AgolaApi) GetRemoteSources() (*[]RemoteSourceDto, error) {
+func (agolaApi *AgolaApi) GetRemoteSources(ctx context.Context) (*[]RemoteSourceDto, error) {
 	log.Println("GetRemoteSources start")
 
 	client := agolaApi.getClient(nil, true)
 	URLApi := getRemoteSourcesUrl()
 
-	req, _ := http.NewRequest("GET", URLApi, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", URLApi, nil)
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -675,8 +996,7 @@ func (agolaApi *AgolaApi) GetRemoteSources() (*[]RemoteSourceDto, error) {
 	defer resp.Body.Close()
 
 	if !api.IsResponseOK(resp.StatusCode) {
-		respMessage, _ := ioutil.ReadAll(resp.Body)
-		return nil, errors.New(string(respMessage))
+		return nil, newResponseError(resp)
 	}
 
 	body, _ := ioutil.ReadAll(resp.Body)
@@ -689,7 +1009,7 @@ func (agolaApi *AgolaApi) GetRemoteSources() (*[]RemoteSourceDto, error) {
 	return &jsonResponse, nil
 }
 
-func (agolaApi *AgolaApi) CreateRemoteSource(remoteSourceName string, gitType string, apiUrl string, oauth2ClientId string, oauth2ClientSecret string) error {
+func (agolaApi *AgolaApi) CreateRemoteSource(ctx context.Context, remoteSourceName string, gitType string, apiUrl string, oauth2ClientId string, oauth2ClientSecret string) error {
 	log.Println("CreateRemoteSource start")
 
 	client := agolaApi.getClient(nil, true)
@@ -708,7 +1028,7 @@ func (agolaApi *AgolaApi) CreateRemoteSource(remoteSourceName string, gitType st
 	data, _ := json.Marshal(projectRequest)
 	reqBody := strings.NewReader(string(data))
 
-	req, _ := http.NewRequest("POST", URLApi, reqBody)
+	req, _ := http.NewRequestWithContext(ctx, "POST", URLApi, reqBody)
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -717,20 +1037,19 @@ func (agolaApi *AgolaApi) CreateRemoteSource(remoteSourceName string, gitType st
 	defer resp.Body.Close()
 
 	if !api.IsResponseOK(resp.StatusCode) {
-		respMessage, _ := ioutil.ReadAll(resp.Body)
-		return errors.New(string(respMessage))
+		return newResponseError(resp)
 	}
 
 	return nil
 }
 
-func (agolaApi *AgolaApi) DeleteRemotesource(remoteSourceName string) error {
+func (agolaApi *AgolaApi) DeleteRemotesource(ctx context.Context, remoteSourceName string) error {
 	log.Println("DeleteRemotesource ", remoteSourceName)
 
 	client := agolaApi.getClient(nil, true)
 	URLApi := getDeleteRemotesourceUrl(remoteSourceName)
 
-	req, _ := http.NewRequest("DELETE", URLApi, nil)
+	req, _ := http.NewRequestWithContext(ctx, "DELETE", URLApi, nil)
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -739,18 +1058,17 @@ func (agolaApi *AgolaApi) DeleteRemotesource(remoteSourceName string) error {
 	defer resp.Body.Close()
 
 	if !api.IsResponseOK(resp.StatusCode) {
-		respMessage, _ := ioutil.ReadAll(resp.Body)
-		return errors.New(string(respMessage))
+		return newResponseError(resp)
 	}
 
 	return nil
 }
 
-func (agolaApi *AgolaApi) GetUserOrganizations(user *model.User, isAdminUser bool) ([]*UserOrgDto, error) {
+func (agolaApi *AgolaApi) GetUserOrganizations(ctx context.Context, user *model.User, isAdminUser bool) ([]*UserOrgDto, error) {
 	client := agolaApi.getClient(user, isAdminUser)
 	URLApi := getUserOrganizationsUrl()
 
-	req, _ := http.NewRequest("GET", URLApi, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", URLApi, nil)
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -759,8 +1077,7 @@ func (agolaApi *AgolaApi) GetUserOrganizations(user *model.User, isAdminUser boo
 	defer resp.Body.Close()
 
 	if !api.IsResponseOK(resp.StatusCode) {
-		respMessage, _ := ioutil.ReadAll(resp.Body)
-		return nil, errors.New(string(respMessage))
+		return nil, newResponseError(resp)
 	}
 
 	body, _ := ioutil.ReadAll(resp.Body)
@@ -773,11 +1090,11 @@ func (agolaApi *AgolaApi) GetUserOrganizations(user *model.User, isAdminUser boo
 	return jsonResponse, nil
 }
 
-func (agolaApi *AgolaApi) GetProjectgroupProjects(projectgroupref string) ([]*ProjectDto, error) {
+func (agolaApi *AgolaApi) GetProjectgroupProjects(ctx context.Context, projectgroupref string) ([]*ProjectDto, error) {
 	client := agolaApi.getClient(nil, true)
 	URLApi := getProjectgroupProjectsUrl(projectgroupref)
 
-	req, _ := http.NewRequest("GET", URLApi, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", URLApi, nil)
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -786,8 +1103,7 @@ func (agolaApi *AgolaApi) GetProjectgroupProjects(projectgroupref string) ([]*Pr
 	defer resp.Body.Close()
 
 	if !api.IsResponseOK(resp.StatusCode) {
-		respMessage, _ := ioutil.ReadAll(resp.Body)
-		return nil, errors.New(string(respMessage))
+		return nil, newResponseError(resp)
 	}
 
 	body, _ := ioutil.ReadAll(resp.Body)
@@ -800,11 +1116,11 @@ func (agolaApi *AgolaApi) GetProjectgroupProjects(projectgroupref string) ([]*Pr
 	return jsonResponse, nil
 }
 
-func (agolaApi *AgolaApi) GetUserRuns(user *model.User, isAdminUser bool, userRef string, lastRun bool, phase string, startRunNumber *uint64, limit uint, asc bool) ([]*RunsDto, error) {
+func (agolaApi *AgolaApi) GetUserRuns(ctx context.Context, user *model.User, isAdminUser bool, userRef string, lastRun bool, phase string, startRunNumber *uint64, limit uint, asc bool) ([]*RunsDto, error) {
 	client := agolaApi.getClient(user, isAdminUser)
 	URLApi := getUserRunsUrl(userRef, lastRun, phase, startRunNumber, limit, asc)
 
-	req, _ := http.NewRequest("GET", URLApi, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", URLApi, nil)
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -813,8 +1129,7 @@ func (agolaApi *AgolaApi) GetUserRuns(user *model.User, isAdminUser bool, userRe
 	defer resp.Body.Close()
 
 	if !api.IsResponseOK(resp.StatusCode) {
-		respMessage, _ := ioutil.ReadAll(resp.Body)
-		return nil, errors.New(string(respMessage))
+		return nil, newResponseError(resp)
 	}
 
 	body, _ := ioutil.ReadAll(resp.Body)
@@ -827,11 +1142,11 @@ func (agolaApi *AgolaApi) GetUserRuns(user *model.User, isAdminUser bool, userRe
 	return jsonResponse, nil
 }
 
-func (agolaApi *AgolaApi) GetProjectgroupSubgroups(projectgroupref string) ([]*ProjectGroupDto, error) {
+func (agolaApi *AgolaApi) GetProjectgroupSubgroups(ctx context.Context, projectgroupref string) ([]*ProjectGroupDto, error) {
 	client := agolaApi.getClient(nil, true)
 	URLApi := getSubgroupsUrl(projectgroupref)
 
-	req, _ := http.NewRequest("GET", URLApi, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", URLApi, nil)
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -840,8 +1155,7 @@ func (agolaApi *AgolaApi) GetProjectgroupSubgroups(projectgroupref string) ([]*P
 	defer resp.Body.Close()
 
 	if !api.IsResponseOK(resp.StatusCode) {
-		respMessage, _ := ioutil.ReadAll(resp.Body)
-		return nil, errors.New(string(respMessage))
+		return nil, newResponseError(resp)
 	}
 
 	body, _ := ioutil.ReadAll(resp.Body)
@@ -856,8 +1170,140 @@ func (agolaApi *AgolaApi) GetProjectgroupSubgroups(projectgroupref string) ([]*P
 
 ///////////////
 
+//Secrets of the project only, the ones of the parent projectgroups are not returned
+func (agolaApi *AgolaApi) GetProjectSecrets(ctx context.Context, organization *model.Organization, agolaProjectRef string) ([]*SecretDto, error) {
+	client := agolaApi.getClient(nil, true)
+	URLApi := getProjectSecretsUrl(organization.AgolaParentRef(), agolaProjectRef)
+	req, _ := http.NewRequestWithContext(ctx, "GET", URLApi, nil)
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if !api.IsResponseOK(resp.StatusCode) {
+		return nil, newResponseError(resp)
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var jsonResponse []*SecretDto
+	err = json.Unmarshal(body, &jsonResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonResponse, nil
+}
+
+func (agolaApi *AgolaApi) CreateProjectSecret(ctx context.Context, organization *model.Organization, agolaProjectRef string, secretName string, data map[string]string) error {
+	log.Println("CreateProjectSecret", secretName, "in", agolaProjectRef)
+
+	secretRequest := &SecretRequestDto{Name: secretName, Type: internalSecretType, Data: data}
+
+	return agolaApi.sendProjectRequest(ctx, "POST", getProjectSecretsUrl(organization.AgolaParentRef(), agolaProjectRef), secretRequest)
+}
+
+func (agolaApi *AgolaApi) UpdateProjectSecret(ctx context.Context, organization *model.Organization, agolaProjectRef string, secretName string, data map[string]string) error {
+	log.Println("UpdateProjectSecret", secretName, "in", agolaProjectRef)
+
+	secretRequest := &SecretRequestDto{Name: secretName, Type: internalSecretType, Data: data}
+
+	return agolaApi.sendProjectRequest(ctx, "PUT", getProjectSecretUrl(organization.AgolaParentRef(), agolaProjectRef, secretName), secretRequest)
+}
+
+func (agolaApi *AgolaApi) DeleteProjectSecret(ctx context.Context, organization *model.Organization, agolaProjectRef string, secretName string) error {
+	log.Println("DeleteProjectSecret", secretName, "in", agolaProjectRef)
+
+	return agolaApi.sendProjectRequest(ctx, "DELETE", getProjectSecretUrl(organization.AgolaParentRef(), agolaProjectRef, secretName), nil)
+}
+
+//Variables of the project only, the ones of the parent projectgroups are not returned
+func (agolaApi *AgolaApi) GetProjectVariables(ctx context.Context, organization *model.Organization, agolaProjectRef string) ([]*VariableDto, error) {
+	client := agolaApi.getClient(nil, true)
+	URLApi := getProjectVariablesUrl(organization.AgolaParentRef(), agolaProjectRef)
+	req, _ := http.NewRequestWithContext(ctx, "GET", URLApi, nil)
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if !api.IsResponseOK(resp.StatusCode) {
+		return nil, newResponseError(resp)
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var jsonResponse []*VariableDto
+	err = json.Unmarshal(body, &jsonResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonResponse, nil
+}
+
+func (agolaApi *AgolaApi) CreateProjectVariable(ctx context.Context, organization *model.Organization, agolaProjectRef string, variableName string, values []VariableValueDto) error {
+	log.Println("CreateProjectVariable", variableName, "in", agolaProjectRef)
+
+	variableRequest := &VariableRequestDto{Name: variableName, Values: values}
+
+	return agolaApi.sendProjectRequest(ctx, "POST", getProjectVariablesUrl(organization.AgolaParentRef(), agolaProjectRef), variableRequest)
+}
+
+func (agolaApi *AgolaApi) UpdateProjectVariable(ctx context.Context, organization *model.Organization, agolaProjectRef string, variableName string, values []VariableValueDto) error {
+	log.Println("UpdateProjectVariable", variableName, "in", agolaProjectRef)
+
+	variableRequest := &VariableRequestDto{Name: variableName, Values: values}
+
+	return agolaApi.sendProjectRequest(ctx, "PUT", getProjectVariableUrl(organization.AgolaParentRef(), agolaProjectRef, variableName), variableRequest)
+}
+
+func (agolaApi *AgolaApi) DeleteProjectVariable(ctx context.Context, organization *model.Organization, agolaProjectRef string, variableName string) error {
+	log.Println("DeleteProjectVariable", variableName, "in", agolaProjectRef)
+
+	return agolaApi.sendProjectRequest(ctx, "DELETE", getProjectVariableUrl(organization.AgolaParentRef(), agolaProjectRef, variableName), nil)
+}
+
+//Send the request with the admin token, the response body is discarded
+func (agolaApi *AgolaApi) sendProjectRequest(ctx context.Context, method string, URLApi string, request interface{}) error {
+	var reqBody io.Reader
+	if request != nil {
+		data, _ := json.Marshal(request)
+		reqBody = bytes.NewReader(data)
+	}
+
+	client := agolaApi.getClient(nil, true)
+	req, _ := http.NewRequestWithContext(ctx, method, URLApi, reqBody)
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if !api.IsResponseOK(resp.StatusCode) {
+		return newResponseError(resp)
+	}
+
+	return nil
+}
+
+/*
+Client of the Agola api calls: every call lasts at most the configured timeout,
+the idempotent requests failed for network errors or 5xx are sent again
+*/
+func newHTTPClient() *http.Client {
+	retryTransport := transport.NewRetryTransport(http.DefaultTransport, config.GetAgolaMaxRetries(), config.GetAgolaRetryMaxDelay())
+
+	return &http.Client{Transport: retryTransport, Timeout: config.GetAgolaTimeout()}
+}
+
 func (agolaApi *AgolaApi) getClient(user *model.User, isAdminUser bool) *httpClient {
-	client := &httpClient{c: &http.Client{}, user: user, agolaApi: agolaApi, isAdminUser: isAdminUser}
+	client := &httpClient{c: newHTTPClient(), user: user, agolaApi: agolaApi, isAdminUser: isAdminUser}
 
 	return client
 }
@@ -872,7 +1318,7 @@ type httpClient struct {
 func (c *httpClient) Do(req *http.Request) (*http.Response, error) {
 	if c.isAdminUser {
 		req.Header.Set("Authorization", "token "+config.Config.Agola.AdminToken)
-		return c.c.Do(req)
+		return c.send(req)
 	}
 
 	var response *http.Response
@@ -880,22 +1326,42 @@ func (c *httpClient) Do(req *http.Request) (*http.Response, error) {
 
 	if c.user.AgolaToken != nil {
 		req.Header.Set("Authorization", "token "+*c.user.AgolaToken)
-		response, err = c.c.Do(req)
+		response, err = c.send(req)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	if response == nil || response.StatusCode == 401 {
-		err = c.agolaApi.CreateUserToken(c.user)
+		if response != nil {
+			response.Body.Close()
+		}
+
+		err = c.agolaApi.CreateUserToken(req.Context(), c.user)
 		if err != nil {
 			log.Println("error in agola CreateUserToken:", err)
 			return nil, err
 		}
 
+		if req.GetBody != nil {
+			req.Body, err = req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+		}
 		req.Header.Set("Authorization", "token "+*c.user.AgolaToken)
-		response, err = c.c.Do(req)
+		response, err = c.send(req)
 	}
 
 	return response, err
 }
+
+//The network errors are returned as ErrUnavailable, the context errors as they are
+func (c *httpClient) send(req *http.Request) (*http.Response, error) {
+	response, err := c.c.Do(req)
+	if err != nil {
+		return nil, newRequestError(err)
+	}
+
+	return response, nil
+}
@@ -1,6 +1,7 @@
 package agola
 
 import (
+	"strconv"
 	"strings"
 	"time"
 
@@ -25,6 +26,19 @@ type CreateProjectRequestDto struct {
 	RepoPath         string               `json:"repo_path"`
 }
 
+type UpdateProjectRequestDto struct {
+	Name               string               `json:"name"`
+	ParentRef          string               `json:"parent_ref"`
+	Visibility         types.VisibilityType `json:"visibility"`
+	PassVarsToForkedPR bool                 `json:"pass_vars_to_forked_pr"`
+}
+
+type CreateProjectgroupRequestDto struct {
+	Name       string               `json:"name"`
+	ParentRef  string               `json:"parent_ref"`
+	Visibility types.VisibilityType `json:"visibility"`
+}
+
 type CreateProjectResponseDto struct {
 	ID               string               `json:"id"`
 	Name             string               `json:"name"`
@@ -59,6 +73,9 @@ type RunsDto struct {
 
 	TasksWaitingApproval []string `json:"tasks_waiting_approval"`
 
+	//ID of the Agola project of the run, set by Papagaio
+	ProjectRef string `json:"projectRef,omitempty"`
+
 	EnqueueTime *time.Time `json:"enqueue_time"`
 	StartTime   *time.Time `json:"start_time"`
 	EndTime     *time.Time `json:"end_time"`
@@ -68,6 +85,24 @@ func (run *RunsDto) IsBranch() bool {
 	return strings.Compare(run.Annotations["ref_type"], "branch") == 0
 }
 
+func (run *RunsDto) IsPullRequest() bool {
+	return strings.Compare(run.Annotations["ref_type"], "pull_request") == 0
+}
+
+//Return false if the run isn't of a pull request
+func (run *RunsDto) GetPullRequestNumber() (int, bool) {
+	if !run.IsPullRequest() {
+		return 0, false
+	}
+
+	number, err := strconv.Atoi(run.Annotations["pull_request_id"])
+	if err != nil {
+		return 0, false
+	}
+
+	return number, true
+}
+
 func (run *RunsDto) GetBranchName() string {
 	return run.Annotations["branch"]
 }
@@ -96,6 +131,25 @@ type RunDto struct {
 	CanRestartFromFailedTasks bool `json:"can_restart_from_failed_tasks"`
 }
 
+//Event of the Agola run events stream, sent when the phase or the result of a run changes
+type RunEventDto struct {
+	Sequence uint64    `json:"sequence"`
+	RunID    string    `json:"runID"`
+	Group    string    `json:"group"`
+	Phase    RunPhase  `json:"phase"`
+	Result   RunResult `json:"result"`
+}
+
+//ID of the Agola project of the run from the run group /project/{projectID}/..., empty for the runs not of a project
+func (event *RunEventDto) GetProjectID() string {
+	parts := strings.Split(strings.TrimPrefix(event.Group, "/"), "/")
+	if len(parts) < 2 || strings.Compare(parts[0], "project") != 0 {
+		return ""
+	}
+
+	return parts[1]
+}
+
 type RunPhase string
 
 const (
@@ -106,6 +160,27 @@ const (
 	RunPhaseFinished   RunPhase = "finished"
 )
 
+type RunActionType string
+
+const (
+	RunActionTypeRestart RunActionType = "restart"
+	RunActionTypeStop    RunActionType = "stop"
+	RunActionTypeCancel  RunActionType = "cancel"
+)
+
+type RunActionsRequestDto struct {
+	ActionType RunActionType `json:"action_type"`
+	FromStart  bool          `json:"from_start"`
+}
+
+type TaskActionType string
+
+const TaskActionTypeApprove TaskActionType = "approve"
+
+type TaskActionsRequestDto struct {
+	ActionType TaskActionType `json:"action_type"`
+}
+
 type RunResult string
 
 const (
@@ -148,7 +223,7 @@ type TaskDto struct {
 	StartTime *time.Time `json:"start_time"`
 	EndTime   *time.Time `json:"end_time"`
 
-	TaskTimeoutInterval time.Duration `json:"task_timeout_interval"`
+	TaskTimeoutInterval time.Duration `json:"task_timeout_interval" swaggertype:"integer"`
 }
 
 type RunTaskResponseContainer struct {
@@ -295,3 +370,35 @@ type ProjectGroupDto struct {
 	Visibility       string `json:"visibility"`
 	GlobalVisibility string `json:"global_visibility"`
 }
+
+type SecretDto struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	ParentPath string `json:"parent_path"`
+}
+
+//Papagaio creates only internal secrets, their data is stored by Agola
+type SecretRequestDto struct {
+	Name string            `json:"name"`
+	Type string            `json:"type"`
+	Data map[string]string `json:"data"`
+}
+
+const internalSecretType string = "internal"
+
+type VariableDto struct {
+	ID         string             `json:"id"`
+	Name       string             `json:"name"`
+	Values     []VariableValueDto `json:"values"`
+	ParentPath string             `json:"parent_path"`
+}
+
+type VariableValueDto struct {
+	SecretName string `json:"secret_name"`
+	SecretVar  string `json:"secret_var"`
+}
+
+type VariableRequestDto struct {
+	Name   string             `json:"name"`
+	Values []VariableValueDto `json:"values"`
+}
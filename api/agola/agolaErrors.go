@@ -0,0 +1,54 @@
+package agola
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+//Errors of the Agola api calls, checked with errors.Is
+var (
+	ErrNotFound = errors.New("agola resource not found")
+	ErrConflict = errors.New("agola resource already exists")
+	//Agola is not reachable, timed out or answered with a 5xx: the call can be done again later
+	ErrUnavailable = errors.New("agola unavailable")
+)
+
+//Error response of the Agola api, the message is the body of the response
+type ResponseError struct {
+	StatusCode int
+	Message    string
+}
+
+func (responseError *ResponseError) Error() string {
+	return responseError.Message
+}
+
+func (responseError *ResponseError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return responseError.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return responseError.StatusCode == http.StatusConflict
+	case ErrUnavailable:
+		return responseError.StatusCode == http.StatusTooManyRequests || responseError.StatusCode >= http.StatusInternalServerError
+	}
+
+	return false
+}
+
+func newResponseError(resp *http.Response) error {
+	respMessage, _ := ioutil.ReadAll(resp.Body)
+	return &ResponseError{StatusCode: resp.StatusCode, Message: string(respMessage)}
+}
+
+//The canceled calls return the context error, the others are unavailable errors
+func newRequestError(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	return fmt.Errorf("%w: %v", ErrUnavailable, err)
+}
@@ -15,8 +15,11 @@ const projectPath string = "%s/api/v1alpha/projects/%s"
 const organizationMembersPath string = "%s/api/v1alpha/orgs/%s/members"
 const runsListPath string = "%s/api/v1alpha/projects/%s/runs?%s"
 const runPath string = "%s/api/v1alpha/projects/%s/runs/%d"
+const runActionsPath string = "%s/api/v1alpha/projects/%s/runs/%d/actions"
+const taskActionsPath string = "%s/api/v1alpha/projects/%s/runs/%d/tasks/%s/actions"
 const taskPath string = "%s/api/v1alpha/projects/%s/runs/%d/tasks/%s"
 const logsPath string = "%s/api/v1alpha/projects/%s/runs/%d/tasks/%s/logs?%s"
+const runEventsPath string = "%s/v1alpha/runs/events"
 const remoteSourcePath string = "%s/api/v1alpha/remotesources/%s"
 const remoteSourcesPath string = "%s/api/v1alpha/remotesources"
 const usersPath string = "%s/api/v1alpha/users?start=%s&limit=%d"
@@ -28,6 +31,12 @@ const userOrganizationsPath = "%s/api/v1alpha/user/orgs"
 const projectgroupProjectsPath = "%s/api/v1alpha/projectgroups/%s/projects"
 const userRunsPath = "%s/api/v1alpha/users/%s/runs?%s"
 const subgroupsPath = "%s/api/v1alpha/projectgroups/%s/subgroups"
+const projectgroupsPath = "%s/api/v1alpha/projectgroups"
+const projectgroupPath = "%s/api/v1alpha/projectgroups/%s"
+const projectSecretsPath = "%s/api/v1alpha/projects/%s/secrets"
+const projectSecretPath = "%s/api/v1alpha/projects/%s/secrets/%s"
+const projectVariablesPath = "%s/api/v1alpha/projects/%s/variables"
+const projectVariablePath = "%s/api/v1alpha/projects/%s/variables/%s"
 
 const createTokenPath = "%s/api/v1alpha/users/%s/tokens"
 
@@ -51,8 +60,9 @@ func getCreateProjectUrl() string {
 	return fmt.Sprintf(createProjectPath, config.Config.Agola.AgolaAddr)
 }
 
-func getProjectUrl(organizationName string, projectName string) string {
-	projectref := url.QueryEscape("org/" + organizationName + "/" + projectName)
+//The parentRef is the projectgroup of the organization or of the user
+func getProjectUrl(parentRef string, projectName string) string {
+	projectref := url.QueryEscape(parentRef + "/" + projectName)
 	return fmt.Sprintf(projectPath, config.Config.Agola.AgolaAddr, projectref)
 }
 
@@ -85,6 +95,14 @@ func getRunUrl(projectRef string, runNumber uint64) string {
 	return fmt.Sprintf(runPath, config.Config.Agola.AgolaAddr, projectRef, runNumber)
 }
 
+func getRunActionsUrl(projectRef string, runNumber uint64) string {
+	return fmt.Sprintf(runActionsPath, config.Config.Agola.AgolaAddr, url.QueryEscape(projectRef), runNumber)
+}
+
+func getTaskActionsUrl(projectRef string, runNumber uint64, taskID string) string {
+	return fmt.Sprintf(taskActionsPath, config.Config.Agola.AgolaAddr, url.QueryEscape(projectRef), runNumber, url.PathEscape(taskID))
+}
+
 func getTaskUrl(projectRef string, runNumber uint64, taskID string) string {
 	return fmt.Sprintf(taskPath, config.Config.Agola.AgolaAddr, projectRef, runNumber, taskID)
 }
@@ -98,6 +116,14 @@ func getLogsUrl(projectRef string, runNumber uint64, taskID string, step int) st
 	return fmt.Sprintf(logsPath, config.Config.Agola.AgolaAddr, projectRef, runNumber, taskID, stepParam)
 }
 
+func getLogsStreamUrl(projectRef string, runNumber uint64, taskID string, step int) string {
+	return getLogsUrl(projectRef, runNumber, taskID, step) + "&follow"
+}
+
+func getRunEventsUrl() string {
+	return fmt.Sprintf(runEventsPath, config.Config.Agola.RunserviceAddr)
+}
+
 func getRemoteSourceUrl(agolaRemoteSource string) string {
 	return fmt.Sprintf(remoteSourcePath, config.Config.Agola.AgolaAddr, agolaRemoteSource)
 }
@@ -158,3 +184,32 @@ func getUserRunsUrl(userRef string, lastRun bool, phase string, startRunNumber *
 func getSubgroupsUrl(projectgroupref string) string {
 	return fmt.Sprintf(subgroupsPath, config.Config.Agola.AgolaAddr, projectgroupref)
 }
+
+func getProjectgroupsUrl() string {
+	return fmt.Sprintf(projectgroupsPath, config.Config.Agola.AgolaAddr)
+}
+
+func getProjectgroupUrl(parentRef string, groupPath string) string {
+	projectgroupref := url.QueryEscape(parentRef + "/" + groupPath)
+	return fmt.Sprintf(projectgroupPath, config.Config.Agola.AgolaAddr, projectgroupref)
+}
+
+func getProjectSecretsUrl(parentRef string, projectName string) string {
+	projectref := url.QueryEscape(parentRef + "/" + projectName)
+	return fmt.Sprintf(projectSecretsPath, config.Config.Agola.AgolaAddr, projectref)
+}
+
+func getProjectSecretUrl(parentRef string, projectName string, secretName string) string {
+	projectref := url.QueryEscape(parentRef + "/" + projectName)
+	return fmt.Sprintf(projectSecretPath, config.Config.Agola.AgolaAddr, projectref, url.PathEscape(secretName))
+}
+
+func getProjectVariablesUrl(parentRef string, projectName string) string {
+	projectref := url.QueryEscape(parentRef + "/" + projectName)
+	return fmt.Sprintf(projectVariablesPath, config.Config.Agola.AgolaAddr, projectref)
+}
+
+func getProjectVariableUrl(parentRef string, projectName string, variableName string) string {
+	projectref := url.QueryEscape(parentRef + "/" + projectName)
+	return fmt.Sprintf(projectVariablePath, config.Config.Agola.AgolaAddr, projectref, url.PathEscape(variableName))
+}
@@ -0,0 +1,737 @@
+package bitbucket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"wecode.sorint.it/opensource/papagaio-api/api"
+	"wecode.sorint.it/opensource/papagaio-api/api/git/dto"
+	"wecode.sorint.it/opensource/papagaio-api/api/git/transport"
+	"wecode.sorint.it/opensource/papagaio-api/common"
+	"wecode.sorint.it/opensource/papagaio-api/config"
+	"wecode.sorint.it/opensource/papagaio-api/controller"
+	"wecode.sorint.it/opensource/papagaio-api/model"
+	"wecode.sorint.it/opensource/papagaio-api/repository"
+)
+
+type BitbucketInterface interface {
+	CreateWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, organizationRef string, webHookSecret string) (int64, error)
+	DeleteWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) error
+	GetWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) (*dto.WebHookDto, error)
+	GetRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error)
+	GetEmailsRepositoryUsersOwner(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (*[]string, error)
+	GetOrganizationMembers(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]BitbucketUser, error)
+	GetBranches(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (map[string]bool, error)
+	GetAgolaConfBranches(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (map[string]bool, error)
+	CheckBranchAgolaConfExists(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, branchName string) (bool, error)
+	GetCommitMetadata(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, commitSha string) (*dto.CommitMetadataDto, error)
+	GetPullRequest(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, pullRequestNumber int) (*dto.PullRequestDto, error)
+	GetOrganization(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*dto.OrganizationDto, error)
+	GetOrganizations(gitSource *model.GitSource, user *model.User) (*[]dto.OrganizationDto, error)
+	IsUserOwner(gitSource *model.GitSource, user *model.User, gitOrgRef string) (bool, error)
+
+	GetUserInfo(gitSource *model.GitSource, user *model.User) (*dto.UserInfoDto, error)
+	GetUserByLogin(gitSource *model.GitSource, login string) (*dto.UserInfoDto, error)
+
+	GetOauth2AccessToken(gitSource *model.GitSource, code string) (*common.Token, error)
+	RefreshToken(gitSource *model.GitSource, refreshToken string) (*common.Token, error)
+}
+
+//The members of a group with a permission couldn't be listed, the members returned are incomplete
+var ErrGroupMembersNotListed = errors.New("bitbucket group members not listed")
+
+/*
+Bitbucket Server / Data Center api. The papagaio organizations are the Bitbucket projects, gitOrgRef is the project key
+and repositoryRef the repository slug
+*/
+type BitbucketApi struct {
+	Db repository.Database
+}
+
+const restApiPath string = "%s/rest/api/1.0"
+const oauth2AuthorizePath string = "%s/rest/oauth2/latest/authorize?client_id=%s&redirect_uri=%s&response_type=code&state=%s&scope=%s"
+const oauth2AccessTokenPath string = "%s/rest/oauth2/latest/token"
+const webHookSignatureHeader string = "X-Hub-Signature"
+const webHookSignaturePrefix string = "sha256="
+const webHookEventHeader string = "X-Event-Key"
+const userNameHeader string = "X-AUSERNAME"
+
+func (bitbucketApi *BitbucketApi) CreateWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, organizationRef string, webHookSecret string) (int64, error) {
+	webHook := webHookDto{
+		Name:          "papagaio",
+		URL:           controller.GetWebHookURL(organizationRef),
+		Active:        true,
+		Events:        GetWebHookEvents(),
+		Configuration: map[string]string{"secret": webHookSecret},
+	}
+
+	var response webHookDto
+	_, err := bitbucketApi.doRequest(gitSource, user, "POST", "/projects/"+url.PathEscape(gitOrgRef)+"/webhooks", webHook, &response)
+	if err != nil {
+		return -1, err
+	}
+
+	return response.ID, nil
+}
+
+func (bitbucketApi *BitbucketApi) DeleteWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) error {
+	_, err := bitbucketApi.doRequest(gitSource, user, "DELETE", fmt.Sprintf("/projects/%s/webhooks/%d", url.PathEscape(gitOrgRef), webHookID), nil, nil)
+	return err
+}
+
+//Return nil if the webhook doesn't exist
+func (bitbucketApi *BitbucketApi) GetWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) (*dto.WebHookDto, error) {
+	var webHook webHookDto
+	statusCode, err := bitbucketApi.doRequest(gitSource, user, "GET", fmt.Sprintf("/projects/%s/webhooks/%d", url.PathEscape(gitOrgRef), webHookID), nil, &webHook)
+	if statusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.WebHookDto{ID: webHook.ID, URL: webHook.URL, Active: webHook.Active, Events: webHook.Events}, nil
+}
+
+func (bitbucketApi *BitbucketApi) GetRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error) {
+	retVal := make([]dto.RepositoryDto, 0)
+
+	err := bitbucketApi.getAllPages(gitSource, user, "/projects/"+url.PathEscape(gitOrgRef)+"/repos", func(values []byte) error {
+		var repositories []RepositoryDto
+		if err := json.Unmarshal(values, &repositories); err != nil {
+			return err
+		}
+		for _, repository := range repositories {
+			retVal = append(retVal, dto.RepositoryDto{ID: repository.ID, Name: repository.Slug, FullName: repository.Project.Key + "/" + repository.Slug})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &retVal, nil
+}
+
+//Return the emails of the project and repository administrators
+func (bitbucketApi *BitbucketApi) GetEmailsRepositoryUsersOwner(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (*[]string, error) {
+	members, err := bitbucketApi.GetOrganizationMembers(gitSource, user, gitOrgRef)
+	if members == nil {
+		return nil, err
+	}
+
+	emails := make(map[string]bool)
+	for _, member := range *members {
+		if member.HasOwnerPermission() && len(member.Email) > 0 {
+			emails[member.Email] = true
+		}
+	}
+
+	permissions, err := bitbucketApi.getUserPermissions(gitSource, user, "/projects/"+url.PathEscape(gitOrgRef)+"/repos/"+url.PathEscape(repositoryRef)+"/permissions/users")
+	if err != nil {
+		return nil, err
+	}
+	for _, permission := range permissions {
+		if strings.Compare(permission.Permission, repoAdminPermission) == 0 && len(permission.User.EmailAddress) > 0 {
+			emails[permission.User.EmailAddress] = true
+		}
+	}
+
+	retVal := make([]string, 0)
+	for email := range emails {
+		retVal = append(retVal, email)
+	}
+
+	return &retVal, nil
+}
+
+/*
+Return the users with a permission on the project or on one of its repositories, the members of the groups with a permission included.
+The members of the groups can be listed only by an administrator: when a group can't be listed the users found are returned
+with ErrGroupMembersNotListed, the listing is incomplete
+*/
+func (bitbucketApi *BitbucketApi) GetOrganizationMembers(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]BitbucketUser, error) {
+	membersMap := make(map[int]BitbucketUser)
+	addMember := func(gitUser userDto, permission string) {
+		member, ok := membersMap[int(gitUser.ID)]
+		if ok && member.HasOwnerPermission() {
+			return
+		}
+		membersMap[int(gitUser.ID)] = BitbucketUser{ID: int(gitUser.ID), Username: gitUser.Name, Email: gitUser.EmailAddress, Permission: permission}
+	}
+
+	var groupsErr error
+	groupsMembers := make(map[string][]userDto)
+	getGroupMembers := func(groupName string) []userDto {
+		if members, ok := groupsMembers[groupName]; ok {
+			return members
+		}
+		members, err := bitbucketApi.getGroupMembers(gitSource, user, groupName)
+		if err != nil {
+			log.Println("GetOrganizationMembers members of the group", groupName, "not listed:", err)
+			groupsErr = err
+		}
+		groupsMembers[groupName] = members
+		return members
+	}
+
+	projectPath := "/projects/" + url.PathEscape(gitOrgRef)
+	userPermissions, err := bitbucketApi.getUserPermissions(gitSource, user, projectPath+"/permissions/users")
+	if err != nil {
+		return nil, err
+	}
+	for _, permission := range userPermissions {
+		addMember(permission.User, permission.Permission)
+	}
+
+	groupPermissions, err := bitbucketApi.getGroupPermissions(gitSource, user, projectPath+"/permissions/groups")
+	if err != nil {
+		return nil, err
+	}
+	//a group permission doesn't downgrade the user permission
+	for _, permission := range groupPermissions {
+		for _, groupMember := range getGroupMembers(permission.Group.Name) {
+			if _, ok := membersMap[int(groupMember.ID)]; !ok || strings.Compare(permission.Permission, projectAdminPermission) == 0 {
+				addMember(groupMember, permission.Permission)
+			}
+		}
+	}
+
+	repositories, err := bitbucketApi.GetRepositories(gitSource, user, gitOrgRef)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, repository := range *repositories {
+		repositoryPath := projectPath + "/repos/" + url.PathEscape(repository.Name)
+		repositoryPermissions, err := bitbucketApi.getUserPermissions(gitSource, user, repositoryPath+"/permissions/users")
+		if err != nil {
+			return nil, err
+		}
+		for _, permission := range repositoryPermissions {
+			if _, ok := membersMap[int(permission.User.ID)]; !ok {
+				addMember(permission.User, permission.Permission)
+			}
+		}
+
+		repositoryGroupPermissions, err := bitbucketApi.getGroupPermissions(gitSource, user, repositoryPath+"/permissions/groups")
+		if err != nil {
+			return nil, err
+		}
+		for _, permission := range repositoryGroupPermissions {
+			for _, groupMember := range getGroupMembers(permission.Group.Name) {
+				if _, ok := membersMap[int(groupMember.ID)]; !ok {
+					addMember(groupMember, permission.Permission)
+				}
+			}
+		}
+	}
+
+	retVal := make([]BitbucketUser, 0)
+	for _, member := range membersMap {
+		retVal = append(retVal, member)
+	}
+
+	if groupsErr != nil {
+		return &retVal, fmt.Errorf("%w: %v", ErrGroupMembersNotListed, groupsErr)
+	}
+	return &retVal, nil
+}
+
+func (bitbucketApi *BitbucketApi) GetBranches(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (map[string]bool, error) {
+	retVal := make(map[string]bool)
+
+	err := bitbucketApi.getAllPages(gitSource, user, "/projects/"+url.PathEscape(gitOrgRef)+"/repos/"+url.PathEscape(repositoryRef)+"/branches", func(values []byte) error {
+		var branches []branchDto
+		if err := json.Unmarshal(values, &branches); err != nil {
+			return err
+		}
+		for _, branch := range branches {
+			retVal[branch.DisplayID] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return retVal, nil
+}
+
+func (bitbucketApi *BitbucketApi) GetAgolaConfBranches(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (map[string]bool, error) {
+	branchList, err := bitbucketApi.GetBranches(gitSource, user, gitOrgRef, repositoryRef)
+	if err != nil {
+		return nil, err
+	}
+
+	retVal := make(map[string]bool)
+	for branch := range branchList {
+		agolaConfExists, err := bitbucketApi.CheckBranchAgolaConfExists(gitSource, user, gitOrgRef, repositoryRef, branch)
+		if err != nil {
+			return nil, err
+		}
+		if agolaConfExists {
+			retVal[branch] = true
+		}
+	}
+
+	return retVal, nil
+}
+
+func (bitbucketApi *BitbucketApi) CheckBranchAgolaConfExists(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, branchName string) (bool, error) {
+	files := make([]string, 0)
+	apiPath := "/projects/" + url.PathEscape(gitOrgRef) + "/repos/" + url.PathEscape(repositoryRef) + "/files/.agola?at=" + url.QueryEscape("refs/heads/"+branchName)
+	err := bitbucketApi.getAllPages(gitSource, user, apiPath, func(values []byte) error {
+		var page []string
+		if err := json.Unmarshal(values, &page); err != nil {
+			return err
+		}
+		files = append(files, page...)
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, transport.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	for _, file := range files {
+		if dto.IsAgolaConfFile(file) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (bitbucketApi *BitbucketApi) GetCommitMetadata(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, commitSha string) (*dto.CommitMetadataDto, error) {
+	var commit commitDto
+	_, err := bitbucketApi.doRequest(gitSource, user, "GET", "/projects/"+url.PathEscape(gitOrgRef)+"/repos/"+url.PathEscape(repositoryRef)+"/commits/"+url.PathEscape(commitSha), nil, &commit)
+	if err != nil {
+		return nil, err
+	}
+
+	author := make(map[string]string)
+	author["email"] = commit.Author.EmailAddress
+
+	retVal := dto.CommitMetadataDto{
+		Sha:     commit.ID,
+		Author:  author,
+		Parents: make([]dto.CommitParentDto, 0),
+	}
+	for _, parent := range commit.Parents {
+		retVal.Parents = append(retVal.Parents, dto.CommitParentDto{Sha: parent.ID})
+	}
+
+	return &retVal, nil
+}
+
+func (bitbucketApi *BitbucketApi) GetPullRequest(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, pullRequestNumber int) (*dto.PullRequestDto, error) {
+	var pullRequest pullRequestDto
+	_, err := bitbucketApi.doRequest(gitSource, user, "GET", fmt.Sprintf("/projects/%s/repos/%s/pull-requests/%d", url.PathEscape(gitOrgRef), url.PathEscape(repositoryRef), pullRequestNumber), nil, &pullRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	retVal := dto.PullRequestDto{
+		Number:       pullRequestNumber,
+		Title:        pullRequest.Title,
+		AuthorLogin:  pullRequest.Author.User.Name,
+		AuthorEmail:  pullRequest.Author.User.EmailAddress,
+		SourceBranch: pullRequest.FromRef.DisplayID,
+	}
+
+	return &retVal, nil
+}
+
+func (bitbucketApi *BitbucketApi) GetOrganization(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*dto.OrganizationDto, error) {
+	var project projectDto
+	statusCode, err := bitbucketApi.doRequest(gitSource, user, "GET", "/projects/"+url.PathEscape(gitOrgRef), nil, &project)
+	if statusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	retVal := toOrganizationDto(gitSource, &project)
+
+	return &retVal, nil
+}
+
+func (bitbucketApi *BitbucketApi) GetOrganizations(gitSource *model.GitSource, user *model.User) (*[]dto.OrganizationDto, error) {
+	retVal := make([]dto.OrganizationDto, 0)
+
+	err := bitbucketApi.getAllPages(gitSource, user, "/projects", func(values []byte) error {
+		var projects []projectDto
+		if err := json.Unmarshal(values, &projects); err != nil {
+			return err
+		}
+		for _, project := range projects {
+			retVal = append(retVal, toOrganizationDto(gitSource, &project))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &retVal, nil
+}
+
+func (bitbucketApi *BitbucketApi) IsUserOwner(gitSource *model.GitSource, user *model.User, gitOrgRef string) (bool, error) {
+	members, err := bitbucketApi.GetOrganizationMembers(gitSource, user, gitOrgRef)
+	if members == nil {
+		log.Println("IsUserOwner error in GetOrganizationMembers:", err)
+		return false, err
+	}
+
+	for _, member := range *members {
+		if uint64(member.ID) == user.ID && member.HasOwnerPermission() {
+			return true, nil
+		}
+	}
+
+	//the user could be an owner by a group not listed
+	if err != nil {
+		log.Println("IsUserOwner error in GetOrganizationMembers:", err)
+		return false, err
+	}
+	return false, nil
+}
+
+//Bitbucket Server hasn't an endpoint for the current user, the username is read from the response header
+func (bitbucketApi *BitbucketApi) GetUserInfo(gitSource *model.GitSource, user *model.User) (*dto.UserInfoDto, error) {
+	log.Println("GetUserInfo start")
+
+	resp, err := bitbucketApi.sendRequest(gitSource, user, "GET", "/application-properties", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	login := resp.Header.Get(userNameHeader)
+	if len(login) == 0 {
+		return nil, errors.New("bitbucket user not authenticated")
+	}
+
+	return bitbucketApi.getUser(gitSource, user, login)
+}
+
+func (bitbucketApi *BitbucketApi) GetUserByLogin(gitSource *model.GitSource, login string) (*dto.UserInfoDto, error) {
+	log.Println("GetUserByLogin start")
+
+	return bitbucketApi.getUser(gitSource, nil, login)
+}
+
+func (bitbucketApi *BitbucketApi) GetOauth2AccessToken(gitSource *model.GitSource, code string) (*common.Token, error) {
+	log.Println("GetOauth2AccessToken start")
+
+	params := url.Values{}
+	params.Set("client_id", gitSource.GitClientID)
+	params.Set("client_secret", gitSource.GitSecret)
+	params.Set("grant_type", "authorization_code")
+	params.Set("code", code)
+	params.Set("redirect_uri", controller.GetRedirectUrl())
+
+	return requestToken(gitSource, params)
+}
+
+func (bitbucketApi *BitbucketApi) RefreshToken(gitSource *model.GitSource, refreshToken string) (*common.Token, error) {
+	params := url.Values{}
+	params.Set("client_id", gitSource.GitClientID)
+	params.Set("client_secret", gitSource.GitSecret)
+	params.Set("grant_type", "refresh_token")
+	params.Set("refresh_token", refreshToken)
+	params.Set("redirect_uri", controller.GetRedirectUrl())
+
+	return requestToken(gitSource, params)
+}
+
+func GetOauth2AuthorizeUrl(gitApiUrl string, gitClientId string, redirectUrl string, state string) string {
+	return fmt.Sprintf(oauth2AuthorizePath, gitApiUrl, gitClientId, url.QueryEscape(redirectUrl), state, "PROJECT_ADMIN")
+}
+
+func ValidateWebHookSignature(header http.Header, payload []byte, webHookSecret string) bool {
+	signature := header.Get(webHookSignatureHeader)
+	if !strings.HasPrefix(signature, webHookSignaturePrefix) {
+		return false
+	}
+
+	return common.IsHmacSha256SignatureValid(webHookSecret, payload, strings.TrimPrefix(signature, webHookSignaturePrefix))
+}
+
+//Events sent by the project webhook
+func GetWebHookEvents() []string {
+	return []string{RefsChangedEvent, RepoModifiedEvent}
+}
+
+//Unique id of the delivery, empty if not sent
+func GetWebHookDeliveryID(header http.Header) string {
+	return header.Get("X-Request-Id")
+}
+
+func GetWebHookEventType(header http.Header) string {
+	return header.Get(webHookEventHeader)
+}
+
+///////////////
+
+func toOrganizationDto(gitSource *model.GitSource, project *projectDto) dto.OrganizationDto {
+	return dto.OrganizationDto{
+		Path:      project.Key,
+		Name:      project.Name,
+		AvatarURL: gitSource.GitAPIURL + "/projects/" + url.PathEscape(project.Key) + "/avatar.png",
+		ID:        project.ID,
+	}
+}
+
+func (bitbucketApi *BitbucketApi) getUser(gitSource *model.GitSource, user *model.User, login string) (*dto.UserInfoDto, error) {
+	var userInfo userDto
+	_, err := bitbucketApi.doRequest(gitSource, user, "GET", "/users/"+url.PathEscape(login), nil, &userInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	retVal := dto.UserInfoDto{
+		ID:          userInfo.ID,
+		Login:       userInfo.Name,
+		Email:       userInfo.EmailAddress,
+		FullName:    userInfo.DisplayName,
+		AvatarURL:   gitSource.GitAPIURL + "/users/" + url.PathEscape(userInfo.Slug) + "/avatar.png",
+		IsAdmin:     bitbucketApi.isAdmin(gitSource, user, userInfo.Name),
+		UserPageURL: gitSource.GitAPIURL + "/users/" + url.PathEscape(userInfo.Slug),
+	}
+
+	return &retVal, nil
+}
+
+//Only the administrators can read the global permissions, for the other users the request fails
+func (bitbucketApi *BitbucketApi) isAdmin(gitSource *model.GitSource, user *model.User, login string) bool {
+	if user == nil {
+		return false
+	}
+
+	isAdmin := false
+	err := bitbucketApi.getAllPages(gitSource, user, "/admin/permissions/users?filter="+url.QueryEscape(login), func(values []byte) error {
+		var permissions []adminPermissionDto
+		if err := json.Unmarshal(values, &permissions); err != nil {
+			return err
+		}
+		for _, permission := range permissions {
+			if strings.Compare(permission.User.Name, login) == 0 && (permission.Permission == "ADMIN" || permission.Permission == "SYS_ADMIN") {
+				isAdmin = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return false
+	}
+
+	return isAdmin
+}
+
+func (bitbucketApi *BitbucketApi) getGroupPermissions(gitSource *model.GitSource, user *model.User, apiPath string) ([]groupPermissionDto, error) {
+	retVal := make([]groupPermissionDto, 0)
+
+	err := bitbucketApi.getAllPages(gitSource, user, apiPath, func(values []byte) error {
+		var page []groupPermissionDto
+		if err := json.Unmarshal(values, &page); err != nil {
+			return err
+		}
+		retVal = append(retVal, page...)
+		return nil
+	})
+
+	return retVal, err
+}
+
+//The members of a group are listed by the admin api, an administrator is required
+func (bitbucketApi *BitbucketApi) getGroupMembers(gitSource *model.GitSource, user *model.User, groupName string) ([]userDto, error) {
+	retVal := make([]userDto, 0)
+
+	err := bitbucketApi.getAllPages(gitSource, user, "/admin/groups/more-members?context="+url.QueryEscape(groupName), func(values []byte) error {
+		var page []userDto
+		if err := json.Unmarshal(values, &page); err != nil {
+			return err
+		}
+		retVal = append(retVal, page...)
+		return nil
+	})
+
+	return retVal, err
+}
+
+func (bitbucketApi *BitbucketApi) getUserPermissions(gitSource *model.GitSource, user *model.User, apiPath string) ([]userPermissionDto, error) {
+	retVal := make([]userPermissionDto, 0)
+
+	err := bitbucketApi.getAllPages(gitSource, user, apiPath, func(values []byte) error {
+		var page []userPermissionDto
+		if err := json.Unmarshal(values, &page); err != nil {
+			return err
+		}
+		retVal = append(retVal, page...)
+		return nil
+	})
+
+	return retVal, err
+}
+
+//Read all the pages of a paged api, appendPage is called with the values of every page
+func (bitbucketApi *BitbucketApi) getAllPages(gitSource *model.GitSource, user *model.User, apiPath string, appendPage func(values []byte) error) error {
+	separator := "?"
+	if strings.Contains(apiPath, "?") {
+		separator = "&"
+	}
+
+	start := 0
+	for {
+		var page pageDto
+		_, err := bitbucketApi.doRequest(gitSource, user, "GET", fmt.Sprintf("%s%sstart=%d&limit=%d", apiPath, separator, start, config.GetGitPageSize()), nil, &page)
+		if err != nil {
+			return err
+		}
+
+		if len(page.Values) > 0 {
+			if err := appendPage(page.Values); err != nil {
+				return err
+			}
+		}
+
+		if page.IsLastPage || page.NextPageStart <= start {
+			return nil
+		}
+		start = page.NextPageStart
+	}
+}
+
+//Send the request and decode the response in responseBody. Return the response status code
+func (bitbucketApi *BitbucketApi) doRequest(gitSource *model.GitSource, user *model.User, method string, apiPath string, requestBody interface{}, responseBody interface{}) (int, error) {
+	var reqBody io.Reader
+	if requestBody != nil {
+		data, err := json.Marshal(requestBody)
+		if err != nil {
+			return -1, err
+		}
+		reqBody = strings.NewReader(string(data))
+	}
+
+	resp, err := bitbucketApi.sendRequest(gitSource, user, method, apiPath, reqBody)
+	if err != nil {
+		return -1, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	if resp.StatusCode == http.StatusNotFound {
+		return resp.StatusCode, fmt.Errorf("%w: %s", transport.ErrNotFound, apiPath)
+	}
+	if !api.IsResponseOK(resp.StatusCode) {
+		return resp.StatusCode, transport.WrapError(resp, errors.New(string(body)))
+	}
+
+	if responseBody != nil && len(body) > 0 {
+		if err := json.Unmarshal(body, responseBody); err != nil {
+			return resp.StatusCode, err
+		}
+	}
+
+	return resp.StatusCode, nil
+}
+
+func (bitbucketApi *BitbucketApi) sendRequest(gitSource *model.GitSource, user *model.User, method string, apiPath string, reqBody io.Reader) (*http.Response, error) {
+	accessToken, err := bitbucketApi.getAccessToken(gitSource, user)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(method, fmt.Sprintf(restApiPath, gitSource.GitAPIURL)+apiPath, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if reqBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if len(accessToken) > 0 {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	resp, err := transport.NewHTTPClient().Do(req)
+	return resp, transport.WrapError(resp, err)
+}
+
+//Return the access token of the user, refreshed if expired. Empty for the anonymous requests
+func (bitbucketApi *BitbucketApi) getAccessToken(gitSource *model.GitSource, user *model.User) (string, error) {
+	if user == nil {
+		return "", nil
+	}
+
+	if common.IsAccessTokenExpired(user.Oauth2AccessTokenExpiresAt) {
+		log.Println("Token expired is to refresh")
+		token, err := bitbucketApi.RefreshToken(gitSource, user.Oauth2RefreshToken)
+
+		if err != nil {
+			log.Println("error during refresh token")
+			return "", err
+		}
+
+		user.Oauth2AccessToken = token.AccessToken
+		user.Oauth2RefreshToken = token.RefreshToken
+		user.Oauth2AccessTokenExpiresAt = token.ExpiryAt
+
+		err = bitbucketApi.Db.SaveUser(user)
+
+		if err != nil {
+			log.Println("error in SaveUser:", err)
+			return "", err
+		}
+	}
+
+	return user.Oauth2AccessToken, nil
+}
+
+func requestToken(gitSource *model.GitSource, params url.Values) (*common.Token, error) {
+	client := &http.Client{}
+
+	URLApi := fmt.Sprintf(oauth2AccessTokenPath, gitSource.GitAPIURL)
+	req, _ := http.NewRequest("POST", URLApi, strings.NewReader(params.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if api.IsResponseOK(resp.StatusCode) {
+		body, _ := ioutil.ReadAll(resp.Body)
+		var response common.Token
+		err = json.Unmarshal(body, &response)
+		if err != nil {
+			return nil, err
+		}
+
+		if response.Expiry > 0 {
+			response.ExpiryAt = time.Now().Add(time.Second * time.Duration(response.Expiry))
+		}
+
+		return &response, nil
+	} else {
+		respMessage, _ := ioutil.ReadAll(resp.Body)
+		return nil, errors.New(string(respMessage))
+	}
+}
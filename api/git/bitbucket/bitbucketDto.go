@@ -0,0 +1,121 @@
+package bitbucket
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+type BitbucketUser struct {
+	ID         int
+	Username   string
+	Permission string
+	Email      string
+}
+
+func (user *BitbucketUser) HasOwnerPermission() bool {
+	return strings.Compare(user.Permission, projectAdminPermission) == 0
+}
+
+const (
+	projectAdminPermission string = "PROJECT_ADMIN"
+	repoAdminPermission    string = "REPO_ADMIN"
+)
+
+//Paged response of the Bitbucket Server rest api
+type pageDto struct {
+	Values        json.RawMessage `json:"values"`
+	IsLastPage    bool            `json:"isLastPage"`
+	NextPageStart int             `json:"nextPageStart"`
+}
+
+type projectDto struct {
+	ID   int64  `json:"id"`
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+type RepositoryDto struct {
+	ID      int        `json:"id"`
+	Slug    string     `json:"slug"`
+	Name    string     `json:"name"`
+	Project projectDto `json:"project"`
+}
+
+type userDto struct {
+	ID           int64  `json:"id"`
+	Name         string `json:"name"`
+	Slug         string `json:"slug"`
+	EmailAddress string `json:"emailAddress"`
+	DisplayName  string `json:"displayName"`
+}
+
+type userPermissionDto struct {
+	User       userDto `json:"user"`
+	Permission string  `json:"permission"`
+}
+
+type groupDto struct {
+	Name string `json:"name"`
+}
+
+type groupPermissionDto struct {
+	Group      groupDto `json:"group"`
+	Permission string   `json:"permission"`
+}
+
+type branchDto struct {
+	DisplayID string `json:"displayId"`
+}
+
+type commitDto struct {
+	ID      string  `json:"id"`
+	Author  userDto `json:"author"`
+	Parents []struct {
+		ID string `json:"id"`
+	} `json:"parents"`
+}
+
+type pullRequestDto struct {
+	ID     int    `json:"id"`
+	Title  string `json:"title"`
+	Author struct {
+		User userDto `json:"user"`
+	} `json:"author"`
+	FromRef struct {
+		DisplayID string `json:"displayId"`
+	} `json:"fromRef"`
+}
+
+type webHookDto struct {
+	ID            int64             `json:"id,omitempty"`
+	Name          string            `json:"name"`
+	URL           string            `json:"url"`
+	Active        bool              `json:"active"`
+	Events        []string          `json:"events"`
+	Configuration map[string]string `json:"configuration"`
+}
+
+type adminPermissionDto struct {
+	User       userDto `json:"user"`
+	Permission string  `json:"permission"`
+}
+
+//Event sent by the project webhook
+type WebHookEvent struct {
+	EventKey   string        `json:"eventKey"`
+	Repository RepositoryDto `json:"repository"`
+	Changes    []struct {
+		RefID    string `json:"refId"`
+		FromHash string `json:"fromHash"`
+		ToHash   string `json:"toHash"`
+		Type     string `json:"type"`
+	} `json:"changes"`
+	Old *RepositoryDto `json:"old"`
+	New *RepositoryDto `json:"new"`
+}
+
+const (
+	RefsChangedEvent  string = "repo:refs_changed"
+	RepoModifiedEvent string = "repo:modified"
+	RefDeletedChange  string = "DELETE"
+)
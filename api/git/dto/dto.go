@@ -63,3 +63,37 @@ type UserInfoDto struct {
 	IsAdmin     bool   `json:"is_admin"`
 	UserPageURL string `json:"user_page_url"`
 }
+
+type WebHookDto struct {
+	ID     int64    `json:"id"`
+	URL    string   `json:"url"`
+	Active bool     `json:"active"`
+	Events []string `json:"events"`
+}
+
+type PullRequestDto struct {
+	Number       int    `json:"number"`
+	Title        string `json:"title"`
+	AuthorLogin  string `json:"authorLogin"`
+	AuthorEmail  string `json:"authorEmail"`
+	SourceBranch string `json:"sourceBranch"`
+}
+
+type RepositoryDto struct {
+	ID       int    `json:"id"` //stable ID, it doesn't change when the repository is renamed or transferred
+	Name     string `json:"name"`
+	FullName string `json:"fullName"`
+}
+
+//Files of the .agola directory recognized as the Agola config
+var agolaConfFiles = []string{"config.jsonnet", "config.yml", "config.json"}
+
+func IsAgolaConfFile(name string) bool {
+	for _, agolaConfFile := range agolaConfFiles {
+		if strings.Compare(name, agolaConfFile) == 0 {
+			return true
+		}
+	}
+
+	return false
+}
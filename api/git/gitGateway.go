@@ -1,167 +1,347 @@
 package git
 
 import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"wecode.sorint.it/opensource/papagaio-api/api/git/bitbucket"
 	"wecode.sorint.it/opensource/papagaio-api/api/git/dto"
 	"wecode.sorint.it/opensource/papagaio-api/api/git/gitea"
 	"wecode.sorint.it/opensource/papagaio-api/api/git/github"
 	"wecode.sorint.it/opensource/papagaio-api/api/git/gitlab"
+	"wecode.sorint.it/opensource/papagaio-api/api/git/transport"
 	"wecode.sorint.it/opensource/papagaio-api/common"
 	"wecode.sorint.it/opensource/papagaio-api/model"
-	"wecode.sorint.it/opensource/papagaio-api/types"
 )
 
 type GitGateway struct {
-	GiteaApi  gitea.GiteaInterface
-	GithubApi github.GithubInterface
-	GitlabApi gitlab.GitlabInterface
+	GiteaApi     gitea.GiteaInterface
+	GithubApi    github.GithubInterface
+	GitlabApi    gitlab.GitlabInterface
+	BitbucketApi bitbucket.BitbucketInterface
+}
+
+//Create the organization webhook with a new secret. Return the webhook id and the secret used to sign the deliveries
+func (gitGateway *GitGateway) CreateWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, organizationRef string) (int64, string, error) {
+	provider, err := gitGateway.GetProvider(gitSource)
+	if err != nil {
+		return -1, "", err
+	}
+	if !hasCapability(gitSource, CapabilityGroupHooks) {
+		return -1, "", fmt.Errorf("%w: %s %s", ErrUnsupportedCapability, gitSource.GitType, CapabilityGroupHooks)
+	}
+
+	webHookSecret, err := common.GenerateWebHookSecret()
+	if err != nil {
+		return -1, "", err
+	}
+
+	webHookID, err := provider.CreateWebHook(gitSource, user, gitOrgRef, organizationRef, webHookSecret)
+
+	return webHookID, webHookSecret, err
+}
+
+//Create the system hook of the git source with a new secret. Return the system hook id and its secret
+func (gitGateway *GitGateway) CreateSystemHook(gitSource *model.GitSource, user *model.User, webHookURL string) (int64, string, error) {
+	systemHooksProvider, err := gitGateway.getSystemHooksProvider(gitSource)
+	if err != nil {
+		return -1, "", err
+	}
+
+	webHookSecret, err := common.GenerateWebHookSecret()
+	if err != nil {
+		return -1, "", err
+	}
+
+	systemHookID, err := systemHooksProvider.CreateSystemHook(gitSource, user, webHookURL, webHookSecret)
+
+	return systemHookID, webHookSecret, err
+}
+
+func (gitGateway *GitGateway) DeleteSystemHook(gitSource *model.GitSource, user *model.User, systemHookID int64) error {
+	provider, err := gitGateway.getSystemHooksProvider(gitSource)
+	if err != nil {
+		return err
+	}
+
+	return provider.DeleteSystemHook(gitSource, user, systemHookID)
+}
+
+//Return nil if the system hook doesn't exist
+func (gitGateway *GitGateway) GetSystemHook(gitSource *model.GitSource, user *model.User, systemHookID int64) (*dto.WebHookDto, error) {
+	provider, err := gitGateway.getSystemHooksProvider(gitSource)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.GetSystemHook(gitSource, user, systemHookID)
+}
+
+//Events the system hook must send
+func (gitGateway *GitGateway) GetSystemHookEvents(gitSource *model.GitSource) []string {
+	registration, err := getGitProviderRegistration(gitSource)
+	if err != nil {
+		log.Println("GetSystemHookEvents error:", err)
+		return nil
+	}
+	if registration.systemHookEvents == nil {
+		return nil
+	}
+
+	return registration.systemHookEvents()
 }
 
-func (gitGateway *GitGateway) CreateWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, organizationRef string) (int64, error) {
-	if gitSource.GitType == types.Gitea {
-		return gitGateway.GiteaApi.CreateWebHook(gitSource, user, gitOrgRef, organizationRef)
-	} else if gitSource.GitType == types.Github {
-		return gitGateway.GithubApi.CreateWebHook(gitSource, user, gitOrgRef, organizationRef)
-	} else {
-		return gitGateway.GitlabApi.CreateWebHook(gitSource, user, gitOrgRef, organizationRef)
+func (gitGateway *GitGateway) getSystemHooksProvider(gitSource *model.GitSource) (SystemHooksProvider, error) {
+	provider, err := gitGateway.GetProvider(gitSource)
+	if err != nil {
+		return nil, err
 	}
+
+	systemHooksProvider, ok := provider.(SystemHooksProvider)
+	if !ok || !hasCapability(gitSource, CapabilitySystemHooks) {
+		return nil, fmt.Errorf("%w: %s %s", ErrUnsupportedCapability, gitSource.GitType, CapabilitySystemHooks)
+	}
+
+	return systemHooksProvider, nil
+}
+
+//Return nil if the webhook doesn't exist
+func (gitGateway *GitGateway) GetWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) (*dto.WebHookDto, error) {
+	provider, err := gitGateway.GetProvider(gitSource)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.GetWebHook(gitSource, user, gitOrgRef, webHookID)
+}
+
+//Events the organization webhook must send
+func (gitGateway *GitGateway) GetWebHookEvents(gitSource *model.GitSource) []string {
+	registration, err := getGitProviderRegistration(gitSource)
+	if err != nil {
+		log.Println("GetWebHookEvents error:", err)
+		return nil
+	}
+
+	return registration.webHookEvents()
+}
+
+func (gitGateway *GitGateway) ValidateWebHookSignature(gitSource *model.GitSource, header http.Header, payload []byte, webHookSecret string) bool {
+	registration, err := getGitProviderRegistration(gitSource)
+	if err != nil {
+		log.Println("ValidateWebHookSignature error:", err)
+		return false
+	}
+
+	return registration.validateWebHookSignature(header, payload, webHookSecret)
+}
+
+func (gitGateway *GitGateway) GetWebHookEventType(gitSource *model.GitSource, header http.Header) string {
+	registration, err := getGitProviderRegistration(gitSource)
+	if err != nil {
+		log.Println("GetWebHookEventType error:", err)
+		return ""
+	}
+
+	return registration.webHookEventType(header)
+}
+
+func (gitGateway *GitGateway) GetWebHookDeliveryID(gitSource *model.GitSource, header http.Header) string {
+	registration, err := getGitProviderRegistration(gitSource)
+	if err != nil {
+		log.Println("GetWebHookDeliveryID error:", err)
+		return ""
+	}
+
+	return registration.webHookDeliveryID(header)
+}
+
+//The webhook notifies a change in the organization, its git api responses must not be served from the cache
+func (gitGateway *GitGateway) InvalidateCache(organization *model.Organization) {
+	transport.InvalidateCache(organization.GitPath)
 }
 
 func (gitGateway *GitGateway) DeleteWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) error {
-	if gitSource.GitType == types.Gitea {
-		return gitGateway.GiteaApi.DeleteWebHook(gitSource, user, gitOrgRef, webHookID)
-	} else if gitSource.GitType == types.Github {
-		return gitGateway.GithubApi.DeleteWebHook(gitSource, user, gitOrgRef, webHookID)
-	} else {
-		return gitGateway.GitlabApi.DeleteWebHook(gitSource, user, gitOrgRef, webHookID)
+	provider, err := gitGateway.GetProvider(gitSource)
+	if err != nil {
+		return err
 	}
+
+	return provider.DeleteWebHook(gitSource, user, gitOrgRef, webHookID)
 }
 
-func (gitGateway *GitGateway) GetRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]string, error) {
-	if gitSource.GitType == types.Gitea {
-		return gitGateway.GiteaApi.GetRepositories(gitSource, user, gitOrgRef)
-	} else if gitSource.GitType == types.Github {
-		return gitGateway.GithubApi.GetRepositories(gitSource, user, gitOrgRef)
-	} else {
-		return gitGateway.GitlabApi.GetRepositories(gitSource, user, gitOrgRef)
+func (gitGateway *GitGateway) GetRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error) {
+	provider, err := gitGateway.GetProvider(gitSource)
+	if err != nil {
+		return nil, err
 	}
+
+	return provider.GetRepositories(gitSource, user, gitOrgRef)
+}
+
+//Repositories of the organization or of the user namespace, with the ones of the descendant subgroups when the organization includes them
+func (gitGateway *GitGateway) GetOrganizationRepositories(gitSource *model.GitSource, user *model.User, organization *model.Organization) (*[]dto.RepositoryDto, error) {
+	if organization.UserNamespace {
+		provider, err := gitGateway.getUserNamespaceProvider(gitSource)
+		if err != nil {
+			return nil, err
+		}
+
+		return provider.GetUserRepositories(gitSource, user, organization.GitPath)
+	}
+
+	provider, err := gitGateway.GetProvider(gitSource)
+	if err != nil {
+		return nil, err
+	}
+
+	if subgroupsProvider, ok := provider.(SubgroupsProvider); ok && organization.IncludeSubgroups && hasCapability(gitSource, CapabilitySubgroups) {
+		return subgroupsProvider.GetRepositoriesWithSubgroups(gitSource, user, organization.GitPath)
+	}
+
+	return provider.GetRepositories(gitSource, user, organization.GitPath)
 }
 
 func (gitGateway *GitGateway) GetEmailsRepositoryUsersOwner(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (*[]string, error) {
-	if gitSource.GitType == types.Gitea {
-		return gitGateway.GiteaApi.GetEmailsRepositoryUsersOwner(gitSource, user, gitOrgRef, repositoryRef)
-	} else if gitSource.GitType == types.Github {
-		return gitGateway.GithubApi.GetEmailsRepositoryUsersOwner(gitSource, user, gitOrgRef, repositoryRef)
-	} else {
-		return gitGateway.GitlabApi.GetEmailsRepositoryUsersOwner(gitSource, user, gitOrgRef, repositoryRef)
+	provider, err := gitGateway.GetProvider(gitSource)
+	if err != nil {
+		return nil, err
 	}
+
+	return provider.GetEmailsRepositoryUsersOwner(gitSource, user, gitOrgRef, repositoryRef)
 }
 
-func (gitGateway *GitGateway) CheckRepositoryAgolaConfExists(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (bool, error) {
-	if gitSource.GitType == types.Gitea {
-		return gitGateway.GiteaApi.CheckRepositoryAgolaConfExists(gitSource, user, gitOrgRef, repositoryRef)
-	} else if gitSource.GitType == types.Github {
-		return gitGateway.GithubApi.CheckRepositoryAgolaConfExists(gitSource, user, gitOrgRef, repositoryRef)
-	} else {
-		return gitGateway.GitlabApi.CheckRepositoryAgolaConfExists(gitSource, user, gitOrgRef, repositoryRef)
+//Branches of the repository with the Agola config
+func (gitGateway *GitGateway) GetAgolaConfBranches(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (map[string]bool, error) {
+	provider, err := gitGateway.GetProvider(gitSource)
+	if err != nil {
+		return nil, err
 	}
+
+	return provider.GetAgolaConfBranches(gitSource, user, gitOrgRef, repositoryRef)
+}
+
+func (gitGateway *GitGateway) CheckBranchAgolaConfExists(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, branchName string) (bool, error) {
+	provider, err := gitGateway.GetProvider(gitSource)
+	if err != nil {
+		return false, err
+	}
+
+	return provider.CheckBranchAgolaConfExists(gitSource, user, gitOrgRef, repositoryRef, branchName)
+}
+
+//Users with access to the repository, also the ones that aren't members of the organization
+func (gitGateway *GitGateway) GetRepositoryCollaborators(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (*[]dto.UserTeamResponseDto, error) {
+	provider, err := gitGateway.GetProvider(gitSource)
+	if err != nil {
+		return nil, err
+	}
+
+	collaboratorsProvider, ok := provider.(CollaboratorsProvider)
+	if !ok || !hasCapability(gitSource, CapabilityCollaborators) {
+		return nil, fmt.Errorf("%w: %s %s", ErrUnsupportedCapability, gitSource.GitType, CapabilityCollaborators)
+	}
+
+	return collaboratorsProvider.GetRepositoryCollaborators(gitSource, user, gitOrgRef, repositoryRef)
+}
+
+func (gitGateway *GitGateway) GetPullRequest(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, pullRequestNumber int) (*dto.PullRequestDto, error) {
+	provider, err := gitGateway.GetProvider(gitSource)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.GetPullRequest(gitSource, user, gitOrgRef, repositoryRef, pullRequestNumber)
 }
 
 func (gitGateway *GitGateway) GetCommitMetadata(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, commitSha string) (*dto.CommitMetadataDto, error) {
-	if gitSource.GitType == types.Gitea {
-		return gitGateway.GiteaApi.GetCommitMetadata(gitSource, user, gitOrgRef, repositoryRef, commitSha)
-	} else if gitSource.GitType == types.Github {
-		return gitGateway.GithubApi.GetCommitMetadata(gitSource, user, gitOrgRef, repositoryRef, commitSha)
-	} else {
-		return gitGateway.GitlabApi.GetCommitMetadata(gitSource, user, gitOrgRef, repositoryRef, commitSha)
+	provider, err := gitGateway.GetProvider(gitSource)
+	if err != nil {
+		return nil, err
 	}
+
+	return provider.GetCommitMetadata(gitSource, user, gitOrgRef, repositoryRef, commitSha)
 }
 
 func (gitGateway *GitGateway) GetBranches(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (map[string]bool, error) {
-	if gitSource.GitType == types.Gitea {
-		return gitGateway.GiteaApi.GetBranches(gitSource, user, gitOrgRef, repositoryRef)
-	} else if gitSource.GitType == types.Github {
-		return gitGateway.GithubApi.GetBranches(gitSource, user, gitOrgRef, repositoryRef)
-	} else {
-		return gitGateway.GitlabApi.GetBranches(gitSource, user, gitOrgRef, repositoryRef)
+	provider, err := gitGateway.GetProvider(gitSource)
+	if err != nil {
+		return nil, err
 	}
+
+	return provider.GetBranches(gitSource, user, gitOrgRef, repositoryRef)
 }
 
 func (gitGateway *GitGateway) GetOrganization(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*dto.OrganizationDto, error) {
-	if gitSource.GitType == types.Gitea {
-		return gitGateway.GiteaApi.GetOrganization(gitSource, user, gitOrgRef)
-	} else if gitSource.GitType == types.Github {
-		return gitGateway.GithubApi.GetOrganization(gitSource, user, gitOrgRef)
-	} else {
-		return gitGateway.GitlabApi.GetOrganization(gitSource, user, gitOrgRef)
+	provider, err := gitGateway.GetProvider(gitSource)
+	if err != nil {
+		return nil, err
 	}
+
+	return provider.GetOrganization(gitSource, user, gitOrgRef)
 }
 
 func (gitGateway *GitGateway) GetOrganizations(gitSource *model.GitSource, user *model.User) (*[]dto.OrganizationDto, error) {
-	if gitSource.GitType == types.Gitea {
-		return gitGateway.GiteaApi.GetOrganizations(gitSource, user)
-	} else if gitSource.GitType == types.Github {
-		return gitGateway.GithubApi.GetOrganizations(gitSource, user)
-	} else {
-		return gitGateway.GitlabApi.GetOrganizations(gitSource, user)
+	provider, err := gitGateway.GetProvider(gitSource)
+	if err != nil {
+		return nil, err
 	}
+
+	return provider.GetOrganizations(gitSource, user)
 }
 
 func (gitGateway *GitGateway) IsUserOwner(gitSource *model.GitSource, user *model.User, gitOrgRef string) (bool, error) {
-	if gitSource.GitType == types.Gitea {
-		return gitGateway.GiteaApi.IsUserOwner(gitSource, user, gitOrgRef)
-	} else if gitSource.GitType == types.Github {
-		return gitGateway.GithubApi.IsUserOwner(gitSource, user, gitOrgRef)
-	} else {
-		return gitGateway.GitlabApi.IsUserOwner(gitSource, user, gitOrgRef)
+	provider, err := gitGateway.GetProvider(gitSource)
+	if err != nil {
+		return false, err
 	}
+
+	return provider.IsUserOwner(gitSource, user, gitOrgRef)
 }
 
 func (gitGateway *GitGateway) GetUserInfo(gitSource *model.GitSource, user *model.User) (*dto.UserInfoDto, error) {
-	if gitSource.GitType == types.Gitea {
-		return gitGateway.GiteaApi.GetUserInfo(gitSource, user)
-	} else if gitSource.GitType == types.Github {
-		return gitGateway.GithubApi.GetUserInfo(gitSource, user)
-	} else {
-		return gitGateway.GitlabApi.GetUserInfo(gitSource, user)
+	provider, err := gitGateway.GetProvider(gitSource)
+	if err != nil {
+		return nil, err
 	}
+
+	return provider.GetUserInfo(gitSource, user)
 }
 
 func (gitGateway *GitGateway) GetUserByLogin(gitSource *model.GitSource, user *model.User) (*dto.UserInfoDto, error) {
-	if gitSource.GitType == types.Gitea {
-		return gitGateway.GiteaApi.GetUserByLogin(gitSource, user.Login)
-	} else if gitSource.GitType == types.Github {
-		return gitGateway.GithubApi.GetUserByLogin(gitSource, user.Login)
-	} else {
-		return gitGateway.GitlabApi.GetUserByLogin(gitSource, int(user.ID))
+	registration, err := getGitProviderRegistration(gitSource)
+	if err != nil {
+		return nil, err
 	}
+
+	return registration.getUserByLogin(gitGateway, gitSource, user)
 }
 
 func (gitGateway *GitGateway) GetOauth2AuthorizePathUrl(gitSource *model.GitSource, redirectUrl string, state string) string {
-	if gitSource.GitType == types.Gitea {
-		return gitea.GetOauth2AuthorizeUrl(gitSource.GitAPIURL, gitSource.GitClientID, redirectUrl, state)
-	} else if gitSource.GitType == types.Github {
-		return github.GetOauth2AuthorizeUrl(gitSource.GitClientID, redirectUrl, state)
-	} else {
-		return gitlab.GetOauth2AuthorizeUrl(gitSource.GitClientID, redirectUrl, state)
+	registration, err := getGitProviderRegistration(gitSource)
+	if err != nil {
+		log.Println("GetOauth2AuthorizePathUrl error:", err)
+		return ""
 	}
+
+	return registration.oauth2AuthorizeUrl(gitSource, redirectUrl, state)
 }
 
 func (gitGateway *GitGateway) GetOauth2AccessToken(gitSource *model.GitSource, code string) (*common.Token, error) {
-	if gitSource.GitType == types.Gitea {
-		return gitGateway.GiteaApi.GetOauth2AccessToken(gitSource, code)
-	} else if gitSource.GitType == types.Github {
-		return gitGateway.GithubApi.GetOauth2AccessToken(gitSource, code)
-	} else {
-		return gitGateway.GitlabApi.GetOauth2AccessToken(gitSource, code)
+	provider, err := gitGateway.GetProvider(gitSource)
+	if err != nil {
+		return nil, err
 	}
+
+	return provider.GetOauth2AccessToken(gitSource, code)
 }
 
 func (gitGateway *GitGateway) RefreshToken(gitSource *model.GitSource, refreshToken string) (*common.Token, error) {
-	if gitSource.GitType == types.Gitea {
-		return gitGateway.GiteaApi.RefreshToken(gitSource, refreshToken)
-	} else if gitSource.GitType == types.Github {
-		return gitGateway.GithubApi.RefreshToken(gitSource, refreshToken)
-	} else {
-		return gitGateway.GitlabApi.RefreshToken(gitSource, refreshToken)
+	provider, err := gitGateway.GetProvider(gitSource)
+	if err != nil {
+		return nil, err
 	}
+
+	return provider.RefreshToken(gitSource, refreshToken)
 }
@@ -0,0 +1,228 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"wecode.sorint.it/opensource/papagaio-api/api/git/bitbucket"
+	"wecode.sorint.it/opensource/papagaio-api/api/git/dto"
+	"wecode.sorint.it/opensource/papagaio-api/api/git/gitea"
+	"wecode.sorint.it/opensource/papagaio-api/api/git/github"
+	"wecode.sorint.it/opensource/papagaio-api/api/git/gitlab"
+	"wecode.sorint.it/opensource/papagaio-api/api/git/transport"
+	"wecode.sorint.it/opensource/papagaio-api/common"
+	"wecode.sorint.it/opensource/papagaio-api/model"
+	"wecode.sorint.it/opensource/papagaio-api/types"
+)
+
+//Api calls implemented by the client of every git type, GitGateway dispatches the calls to the client of the gitSource type
+type GitProvider interface {
+	CreateWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, organizationRef string, webHookSecret string) (int64, error)
+	DeleteWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) error
+	GetWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) (*dto.WebHookDto, error)
+
+	GetRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error)
+	GetEmailsRepositoryUsersOwner(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (*[]string, error)
+	GetBranches(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (map[string]bool, error)
+	GetAgolaConfBranches(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (map[string]bool, error)
+	CheckBranchAgolaConfExists(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, branchName string) (bool, error)
+	GetCommitMetadata(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, commitSha string) (*dto.CommitMetadataDto, error)
+	GetPullRequest(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, pullRequestNumber int) (*dto.PullRequestDto, error)
+	GetOrganization(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*dto.OrganizationDto, error)
+	GetOrganizations(gitSource *model.GitSource, user *model.User) (*[]dto.OrganizationDto, error)
+	IsUserOwner(gitSource *model.GitSource, user *model.User, gitOrgRef string) (bool, error)
+
+	GetUserInfo(gitSource *model.GitSource, user *model.User) (*dto.UserInfoDto, error)
+
+	GetOauth2AccessToken(gitSource *model.GitSource, code string) (*common.Token, error)
+	RefreshToken(gitSource *model.GitSource, refreshToken string) (*common.Token, error)
+}
+
+//Implemented by the providers with the subgroups capability
+type SubgroupsProvider interface {
+	GetRepositoriesWithSubgroups(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error)
+}
+
+//Implemented by the providers with the userNamespaces capability, the user namespaces have no organization webhook
+type UserNamespaceProvider interface {
+	GetUserRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error)
+	CreateRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, organizationRef string, webHookSecret string) (int64, error)
+	DeleteRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, webHookID int64) error
+	GetRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, webHookID int64) (*dto.WebHookDto, error)
+}
+
+//Implemented by the providers with the collaborators capability
+type CollaboratorsProvider interface {
+	GetRepositoryCollaborators(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (*[]dto.UserTeamResponseDto, error)
+}
+
+//Implemented by the providers with the systemHooks capability
+type SystemHooksProvider interface {
+	CreateSystemHook(gitSource *model.GitSource, user *model.User, webHookURL string, webHookSecret string) (int64, error)
+	DeleteSystemHook(gitSource *model.GitSource, user *model.User, systemHookID int64) error
+	GetSystemHook(gitSource *model.GitSource, user *model.User, systemHookID int64) (*dto.WebHookDto, error)
+}
+
+//Optional features of a git provider
+type Capability string
+
+const (
+	CapabilityTeams          Capability = "teams"          //the organization members are listed by team
+	CapabilityGroupHooks     Capability = "groupHooks"     //a single webhook for all the repositories of the organization
+	CapabilityCommitStatuses Capability = "commitStatuses" //the build result can be reported on the commits
+	CapabilitySubgroups      Capability = "subgroups"      //the organizations can contain nested groups of repositories
+	CapabilityUserNamespaces Capability = "userNamespaces" //the repositories of a user can be added like an organization
+	CapabilityCollaborators  Capability = "collaborators"  //the users with access to a repository can be listed
+	CapabilitySystemHooks    Capability = "systemHooks"    //the repositories events are sent by a hook of the git source, it requires an administrator
+)
+
+var ErrUnsupportedGitType = errors.New("unsupported git type")
+var ErrUnsupportedCapability = errors.New("capability not supported by the git provider")
+var ErrSystemHookForbidden = gitlab.ErrSystemHookForbidden
+
+//Kinds of the provider errors, the destructive actions are skipped when the error is transient
+var ErrNotFound = transport.ErrNotFound
+var ErrTransient = transport.ErrTransient
+
+//A git type supported: its api client and the functions that don't call the git server
+type gitProviderRegistration struct {
+	newProvider  func(gitGateway *GitGateway) GitProvider
+	capabilities []Capability
+
+	webHookEvents            func() []string
+	systemHookEvents         func() []string
+	validateWebHookSignature func(header http.Header, payload []byte, webHookSecret string) bool
+	webHookEventType         func(header http.Header) string
+	webHookDeliveryID        func(header http.Header) string
+
+	getUserByLogin     func(gitGateway *GitGateway, gitSource *model.GitSource, user *model.User) (*dto.UserInfoDto, error)
+	oauth2AuthorizeUrl func(gitSource *model.GitSource, redirectUrl string, state string) string
+}
+
+var gitProviders = map[types.GitType]gitProviderRegistration{
+	types.Gitea: {
+		newProvider: func(gitGateway *GitGateway) GitProvider {
+			return gitGateway.GiteaApi
+		},
+		capabilities: []Capability{CapabilityTeams, CapabilityGroupHooks, CapabilityCommitStatuses, CapabilityUserNamespaces, CapabilityCollaborators},
+
+		webHookEvents:            gitea.GetWebHookEvents,
+		validateWebHookSignature: gitea.ValidateWebHookSignature,
+		webHookEventType:         gitea.GetWebHookEventType,
+		webHookDeliveryID:        gitea.GetWebHookDeliveryID,
+
+		getUserByLogin: func(gitGateway *GitGateway, gitSource *model.GitSource, user *model.User) (*dto.UserInfoDto, error) {
+			return gitGateway.GiteaApi.GetUserByLogin(gitSource, user.Login)
+		},
+		oauth2AuthorizeUrl: func(gitSource *model.GitSource, redirectUrl string, state string) string {
+			return gitea.GetOauth2AuthorizeUrl(gitSource.GitAPIURL, gitSource.GitClientID, redirectUrl, state)
+		},
+	},
+	types.Github: {
+		newProvider: func(gitGateway *GitGateway) GitProvider {
+			return gitGateway.GithubApi
+		},
+		capabilities: []Capability{CapabilityTeams, CapabilityGroupHooks, CapabilityCommitStatuses, CapabilityUserNamespaces, CapabilityCollaborators},
+
+		webHookEvents:            github.GetWebHookEvents,
+		validateWebHookSignature: github.ValidateWebHookSignature,
+		webHookEventType:         github.GetWebHookEventType,
+		webHookDeliveryID:        github.GetWebHookDeliveryID,
+
+		getUserByLogin: func(gitGateway *GitGateway, gitSource *model.GitSource, user *model.User) (*dto.UserInfoDto, error) {
+			return gitGateway.GithubApi.GetUserByLogin(gitSource, user.Login)
+		},
+		oauth2AuthorizeUrl: func(gitSource *model.GitSource, redirectUrl string, state string) string {
+			return github.GetOauth2AuthorizeUrl(gitSource.GitClientID, redirectUrl, state)
+		},
+	},
+	types.Gitlab: {
+		newProvider: func(gitGateway *GitGateway) GitProvider {
+			return gitGateway.GitlabApi
+		},
+		capabilities: []Capability{CapabilityGroupHooks, CapabilityCommitStatuses, CapabilitySubgroups, CapabilityUserNamespaces, CapabilityCollaborators, CapabilitySystemHooks},
+
+		webHookEvents:    gitlab.GetWebHookEvents,
+		systemHookEvents: gitlab.GetSystemHookEvents,
+		validateWebHookSignature: func(header http.Header, payload []byte, webHookSecret string) bool {
+			return gitlab.ValidateWebHookSignature(header, webHookSecret)
+		},
+		webHookEventType:  gitlab.GetWebHookEventType,
+		webHookDeliveryID: gitlab.GetWebHookDeliveryID,
+
+		getUserByLogin: func(gitGateway *GitGateway, gitSource *model.GitSource, user *model.User) (*dto.UserInfoDto, error) {
+			return gitGateway.GitlabApi.GetUserByLogin(gitSource, int(user.ID))
+		},
+		oauth2AuthorizeUrl: func(gitSource *model.GitSource, redirectUrl string, state string) string {
+			return gitlab.GetOauth2AuthorizeUrl(gitSource.GitClientID, redirectUrl, state)
+		},
+	},
+	types.Bitbucket: {
+		newProvider: func(gitGateway *GitGateway) GitProvider {
+			return gitGateway.BitbucketApi
+		},
+		capabilities: []Capability{CapabilityGroupHooks, CapabilityCommitStatuses},
+
+		webHookEvents:            bitbucket.GetWebHookEvents,
+		validateWebHookSignature: bitbucket.ValidateWebHookSignature,
+		webHookEventType:         bitbucket.GetWebHookEventType,
+		webHookDeliveryID:        bitbucket.GetWebHookDeliveryID,
+
+		getUserByLogin: func(gitGateway *GitGateway, gitSource *model.GitSource, user *model.User) (*dto.UserInfoDto, error) {
+			return gitGateway.BitbucketApi.GetUserByLogin(gitSource, user.Login)
+		},
+		oauth2AuthorizeUrl: func(gitSource *model.GitSource, redirectUrl string, state string) string {
+			return bitbucket.GetOauth2AuthorizeUrl(gitSource.GitAPIURL, gitSource.GitClientID, redirectUrl, state)
+		},
+	},
+}
+
+func IsGitTypeSupported(gitType types.GitType) bool {
+	_, ok := gitProviders[gitType]
+	return ok
+}
+
+//Return ErrUnsupportedGitType if the gitSource type isn't registered
+func getGitProviderRegistration(gitSource *model.GitSource) (*gitProviderRegistration, error) {
+	registration, ok := gitProviders[gitSource.GitType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedGitType, gitSource.GitType)
+	}
+
+	return &registration, nil
+}
+
+//Return ErrUnsupportedGitType if the gitSource type isn't registered
+func (gitGateway *GitGateway) GetProvider(gitSource *model.GitSource) (GitProvider, error) {
+	registration, err := getGitProviderRegistration(gitSource)
+	if err != nil {
+		return nil, err
+	}
+
+	return registration.newProvider(gitGateway), nil
+}
+
+func (gitGateway *GitGateway) HasCapability(gitSource *model.GitSource, capability Capability) bool {
+	return hasCapability(gitSource, capability)
+}
+
+func hasCapability(gitSource *model.GitSource, capability Capability) bool {
+	registration, err := getGitProviderRegistration(gitSource)
+	if err != nil {
+		return false
+	}
+
+	for _, providerCapability := range registration.capabilities {
+		if providerCapability == capability {
+			return true
+		}
+	}
+
+	return false
+}
+
+//Return an error if the private key file of the GitHub App can't be used to sign the app tokens
+func CheckGithubAppPrivateKey(privateKeyPath string) error {
+	return github.CheckAppPrivateKey(privateKeyPath)
+}
@@ -0,0 +1,88 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	"wecode.sorint.it/opensource/papagaio-api/api/git/dto"
+	"wecode.sorint.it/opensource/papagaio-api/model"
+)
+
+func (gitGateway *GitGateway) getUserNamespaceProvider(gitSource *model.GitSource) (UserNamespaceProvider, error) {
+	provider, err := gitGateway.GetProvider(gitSource)
+	if err != nil {
+		return nil, err
+	}
+
+	userNamespaceProvider, ok := provider.(UserNamespaceProvider)
+	if !ok || !hasCapability(gitSource, CapabilityUserNamespaces) {
+		return nil, fmt.Errorf("%w: %s %s", ErrUnsupportedCapability, gitSource.GitType, CapabilityUserNamespaces)
+	}
+
+	return userNamespaceProvider, nil
+}
+
+//The personal namespace of the user as an organization, nil if gitPath isn't the login of the user
+func (gitGateway *GitGateway) GetUserNamespace(gitSource *model.GitSource, user *model.User, gitPath string) (*dto.OrganizationDto, error) {
+	userInfo, err := gitGateway.GetUserInfo(gitSource, user)
+	if err != nil {
+		return nil, err
+	}
+	if userInfo == nil || !strings.EqualFold(userInfo.Login, gitPath) {
+		return nil, nil
+	}
+
+	return &dto.OrganizationDto{Name: userInfo.FullName, Path: userInfo.Login, ID: userInfo.ID, AvatarURL: userInfo.AvatarURL}, nil
+}
+
+//The git organization or the user namespace, nil if it doesn't exist
+func (gitGateway *GitGateway) GetNamespace(gitSource *model.GitSource, user *model.User, organization *model.Organization) (*dto.OrganizationDto, error) {
+	if organization.UserNamespace {
+		return gitGateway.GetUserNamespace(gitSource, user, organization.GitPath)
+	}
+
+	return gitGateway.GetOrganization(gitSource, user, organization.GitPath)
+}
+
+//Only the user of a personal namespace is its owner
+func (gitGateway *GitGateway) IsNamespaceOwner(gitSource *model.GitSource, user *model.User, organization *model.Organization) (bool, error) {
+	if organization.UserNamespace {
+		return user.ID == uint64(organization.GitOrganizationID), nil
+	}
+
+	return gitGateway.IsUserOwner(gitSource, user, organization.GitPath)
+}
+
+//The repository webhooks of a user namespace share the secret of the organization
+func (gitGateway *GitGateway) CreateRepositoryWebHook(gitSource *model.GitSource, user *model.User, organization *model.Organization, repositoryRef string) (int64, error) {
+	provider, err := gitGateway.getUserNamespaceProvider(gitSource)
+	if err != nil {
+		return -1, err
+	}
+
+	webHookSecret, err := organization.GetWebHookSecret()
+	if err != nil {
+		return -1, err
+	}
+
+	return provider.CreateRepositoryWebHook(gitSource, user, organization.GitPath, repositoryRef, organization.AgolaOrganizationRef, webHookSecret)
+}
+
+func (gitGateway *GitGateway) DeleteRepositoryWebHook(gitSource *model.GitSource, user *model.User, organization *model.Organization, repositoryRef string, webHookID int64) error {
+	provider, err := gitGateway.getUserNamespaceProvider(gitSource)
+	if err != nil {
+		return err
+	}
+
+	return provider.DeleteRepositoryWebHook(gitSource, user, organization.GitPath, repositoryRef, webHookID)
+}
+
+//Return nil if the webhook doesn't exist
+func (gitGateway *GitGateway) GetRepositoryWebHook(gitSource *model.GitSource, user *model.User, organization *model.Organization, repositoryRef string, webHookID int64) (*dto.WebHookDto, error) {
+	provider, err := gitGateway.getUserNamespaceProvider(gitSource)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.GetRepositoryWebHook(gitSource, user, organization.GitPath, repositoryRef, webHookID)
+}
@@ -14,6 +14,7 @@ import (
 	"code.gitea.io/sdk/gitea"
 	"wecode.sorint.it/opensource/papagaio-api/api"
 	"wecode.sorint.it/opensource/papagaio-api/api/git/dto"
+	"wecode.sorint.it/opensource/papagaio-api/api/git/transport"
 	"wecode.sorint.it/opensource/papagaio-api/common"
 	"wecode.sorint.it/opensource/papagaio-api/config"
 	"wecode.sorint.it/opensource/papagaio-api/controller"
@@ -22,17 +23,25 @@ import (
 )
 
 type GiteaInterface interface {
-	CreateWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, organizationRef string) (int64, error)
+	CreateWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, organizationRef string, webHookSecret string) (int64, error)
 	DeleteWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) error
-	GetRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]string, error)
+	GetWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) (*dto.WebHookDto, error)
+	GetRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error)
+	GetUserRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error)
+	CreateRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, organizationRef string, webHookSecret string) (int64, error)
+	DeleteRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, webHookID int64) error
+	GetRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, webHookID int64) (*dto.WebHookDto, error)
 	GetOrganization(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*dto.OrganizationDto, error)
 	GetEmailsRepositoryUsersOwner(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (*[]string, error)
 	GetRepositoryTeams(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (*[]dto.TeamResponseDto, error)
 	GetOrganizationTeams(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.TeamResponseDto, error)
 	GetTeamMembers(gitSource *model.GitSource, user *model.User, teamId int64) (*[]dto.UserTeamResponseDto, error)
+	GetRepositoryCollaborators(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (*[]dto.UserTeamResponseDto, error)
 	GetBranches(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (map[string]bool, error)
-	CheckRepositoryAgolaConfExists(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (bool, error)
+	GetAgolaConfBranches(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (map[string]bool, error)
+	CheckBranchAgolaConfExists(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, branchName string) (bool, error)
 	GetCommitMetadata(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, commitSha string) (*dto.CommitMetadataDto, error)
+	GetPullRequest(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, pullRequestNumber int) (*dto.PullRequestDto, error)
 	GetOrganizations(gitSource *model.GitSource, user *model.User) (*[]dto.OrganizationDto, error)
 	IsUserOwner(gitSource *model.GitSource, user *model.User, gitOrgRef string) (bool, error)
 
@@ -49,28 +58,75 @@ type GiteaApi struct {
 
 const oauth2AuthorizePath string = "%s/login/oauth/authorize?client_id=%s&redirect_uri=%s&response_type=code&scope=&state=%s"
 const oauth2AccessTokenPath string = "%s/login/oauth/access_token"
+const webHookSignatureHeader string = "X-Gitea-Signature"
+const webHookEventHeader string = "X-Gitea-Event"
 
-func (giteaApi *GiteaApi) CreateWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, organizationRef string) (int64, error) {
+func (giteaApi *GiteaApi) CreateWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, organizationRef string, webHookSecret string) (int64, error) {
 	client, err := giteaApi.getClient(gitSource, user)
 	if err != nil {
 		return -1, err
 	}
 
+	hook, _, err := client.CreateOrgHook(gitOrgRef, makeCreateHookOption(organizationRef, webHookSecret))
+	if err != nil {
+		return -1, err
+	}
+
+	return hook.ID, nil
+}
+
+func makeCreateHookOption(organizationRef string, webHookSecret string) gitea.CreateHookOption {
 	optConf := map[string]string{
 		"content_type": "json",
-		"url":          config.Config.Server.ApiExposedURL + controller.GetWebHookPath() + "/" + organizationRef,
+		"url":          controller.GetWebHookURL(organizationRef),
 		"http_method":  "post",
+		"secret":       webHookSecret,
 	}
 
-	opt := gitea.CreateHookOption{
+	return gitea.CreateHookOption{
 		Type:         "gitea",
 		Config:       optConf,
-		Events:       []string{"repository", "push", "create", "delete"},
+		Events:       GetWebHookEvents(),
 		Active:       true,
 		BranchFilter: "*",
 	}
+}
+
+func (giteaApi *GiteaApi) DeleteWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) error {
+	client, err := giteaApi.getClient(gitSource, user)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.DeleteOrgHook(gitOrgRef, webHookID)
+	return err
+}
+
+//Return nil if the webhook doesn't exist
+func (giteaApi *GiteaApi) GetWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) (*dto.WebHookDto, error) {
+	client, err := giteaApi.getClient(gitSource, user)
+	if err != nil {
+		return nil, err
+	}
 
-	hook, _, err := client.CreateOrgHook(gitOrgRef, opt)
+	hook, resp, err := client.GetOrgHook(gitOrgRef, webHookID)
+	if resp != nil && resp.StatusCode == 404 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.WebHookDto{ID: hook.ID, URL: hook.Config["url"], Active: hook.Active, Events: hook.Events}, nil
+}
+
+func (giteaApi *GiteaApi) CreateRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, organizationRef string, webHookSecret string) (int64, error) {
+	client, err := giteaApi.getClient(gitSource, user)
+	if err != nil {
+		return -1, err
+	}
+
+	hook, _, err := client.CreateRepoHook(gitOrgRef, repositoryRef, makeCreateHookOption(organizationRef, webHookSecret))
 	if err != nil {
 		return -1, err
 	}
@@ -78,46 +134,98 @@ func (giteaApi *GiteaApi) CreateWebHook(gitSource *model.GitSource, user *model.
 	return hook.ID, nil
 }
 
-func (giteaApi *GiteaApi) DeleteWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) error {
+func (giteaApi *GiteaApi) DeleteRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, webHookID int64) error {
 	client, err := giteaApi.getClient(gitSource, user)
 	if err != nil {
 		return err
 	}
 
-	_, err = client.DeleteOrgHook(gitOrgRef, webHookID)
+	_, err = client.DeleteRepoHook(gitOrgRef, repositoryRef, webHookID)
 	return err
 }
 
-func (giteaApi *GiteaApi) GetRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]string, error) {
+//Return nil if the webhook doesn't exist
+func (giteaApi *GiteaApi) GetRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, webHookID int64) (*dto.WebHookDto, error) {
 	client, err := giteaApi.getClient(gitSource, user)
 	if err != nil {
 		return nil, err
 	}
 
-	repoList, _, err := client.ListOrgRepos(gitOrgRef, gitea.ListOrgReposOptions{})
+	hook, resp, err := client.GetRepoHook(gitOrgRef, repositoryRef, webHookID)
+	if resp != nil && resp.StatusCode == 404 {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	retVal := make([]string, 0)
-	for _, repo := range repoList {
-		retVal = append(retVal, repo.Name)
+	return &dto.WebHookDto{ID: hook.ID, URL: hook.Config["url"], Active: hook.Active, Events: hook.Events}, nil
+}
+
+func (giteaApi *GiteaApi) GetRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error) {
+	client, err := giteaApi.getClient(gitSource, user)
+	if err != nil {
+		return nil, err
+	}
+
+	retVal := make([]dto.RepositoryDto, 0)
+	pageSize := config.GetGitPageSize()
+	for page := 1; ; page++ {
+		repoList, resp, err := client.ListOrgRepos(gitOrgRef, gitea.ListOrgReposOptions{ListOptions: gitea.ListOptions{Page: page, PageSize: pageSize}})
+		if err != nil {
+			return nil, wrapError(resp, err)
+		}
+
+		for _, repo := range repoList {
+			retVal = append(retVal, dto.RepositoryDto{ID: int(repo.ID), Name: repo.Name, FullName: repo.FullName})
+		}
+
+		if !hasNextPage(resp, len(repoList), pageSize) {
+			break
+		}
 	}
 
 	return &retVal, nil
 }
 
-func (giteaApi *GiteaApi) GetOrganization(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*dto.OrganizationDto, error) {
+//Repositories of the personal namespace of the user
+func (giteaApi *GiteaApi) GetUserRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error) {
 	client, err := giteaApi.getClient(gitSource, user)
 	if err != nil {
 		return nil, err
 	}
 
-	org, _, err := client.GetOrg(gitOrgRef)
+	retVal := make([]dto.RepositoryDto, 0)
+	pageSize := config.GetGitPageSize()
+	for page := 1; ; page++ {
+		repoList, resp, err := client.ListUserRepos(gitOrgRef, gitea.ListReposOptions{ListOptions: gitea.ListOptions{Page: page, PageSize: pageSize}})
+		if err != nil {
+			return nil, wrapError(resp, err)
+		}
+
+		for _, repo := range repoList {
+			retVal = append(retVal, dto.RepositoryDto{ID: int(repo.ID), Name: repo.Name, FullName: repo.FullName})
+		}
+
+		if !hasNextPage(resp, len(repoList), pageSize) {
+			break
+		}
+	}
+
+	return &retVal, nil
+}
+
+func (giteaApi *GiteaApi) GetOrganization(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*dto.OrganizationDto, error) {
+	client, err := giteaApi.getClient(gitSource, user)
 	if err != nil {
 		return nil, err
 	}
 
+	org, resp, err := client.GetOrg(gitOrgRef)
+	if err != nil {
+		return nil, wrapError(resp, err)
+	}
+
 	if org != nil {
 		retVal := dto.OrganizationDto{
 			Path:      org.UserName,
@@ -137,14 +245,21 @@ func (giteaApi *GiteaApi) GetRepositoryTeams(gitSource *model.GitSource, user *m
 		return nil, err
 	}
 
-	teams, _, err := client.ListOrgTeams(gitOrgRef+"/"+repositoryRef, gitea.ListTeamsOptions{})
-	if err != nil {
-		return nil, err
-	}
-
 	teamsResponse := make([]dto.TeamResponseDto, 0)
-	for _, team := range teams {
-		teamsResponse = append(teamsResponse, dto.TeamResponseDto{ID: team.ID, Name: team.Name, Permission: string(team.Permission)})
+	pageSize := config.GetGitPageSize()
+	for page := 1; ; page++ {
+		teams, resp, err := client.ListOrgTeams(gitOrgRef+"/"+repositoryRef, gitea.ListTeamsOptions{ListOptions: gitea.ListOptions{Page: page, PageSize: pageSize}})
+		if err != nil {
+			return nil, wrapError(resp, err)
+		}
+
+		for _, team := range teams {
+			teamsResponse = append(teamsResponse, dto.TeamResponseDto{ID: team.ID, Name: team.Name, Permission: string(team.Permission)})
+		}
+
+		if !hasNextPage(resp, len(teams), pageSize) {
+			break
+		}
 	}
 
 	return &teamsResponse, nil
@@ -182,14 +297,21 @@ func (giteaApi *GiteaApi) GetOrganizationTeams(gitSource *model.GitSource, user
 		return nil, err
 	}
 
-	teams, _, err := client.ListOrgTeams(gitOrgRef, gitea.ListTeamsOptions{})
-	if err != nil {
-		return nil, err
-	}
-
 	teamsResponse := make([]dto.TeamResponseDto, 0)
-	for _, team := range teams {
-		teamsResponse = append(teamsResponse, dto.TeamResponseDto{ID: team.ID, Name: team.Name, Permission: string(team.Permission)})
+	pageSize := config.GetGitPageSize()
+	for page := 1; ; page++ {
+		teams, resp, err := client.ListOrgTeams(gitOrgRef, gitea.ListTeamsOptions{ListOptions: gitea.ListOptions{Page: page, PageSize: pageSize}})
+		if err != nil {
+			return nil, wrapError(resp, err)
+		}
+
+		for _, team := range teams {
+			teamsResponse = append(teamsResponse, dto.TeamResponseDto{ID: team.ID, Name: team.Name, Permission: string(team.Permission)})
+		}
+
+		if !hasNextPage(resp, len(teams), pageSize) {
+			break
+		}
 	}
 
 	return &teamsResponse, nil
@@ -201,19 +323,52 @@ func (giteaApi *GiteaApi) GetTeamMembers(gitSource *model.GitSource, user *model
 		return nil, err
 	}
 
-	members, _, err := client.ListTeamMembers(teamId, gitea.ListTeamMembersOptions{})
+	retVal := make([]dto.UserTeamResponseDto, 0)
+	pageSize := config.GetGitPageSize()
+	for page := 1; ; page++ {
+		members, resp, err := client.ListTeamMembers(teamId, gitea.ListTeamMembersOptions{ListOptions: gitea.ListOptions{Page: page, PageSize: pageSize}})
+		if err != nil {
+			return nil, wrapError(resp, err)
+		}
+
+		for _, member := range members {
+			memberDto := dto.UserTeamResponseDto{
+				ID:       member.ID,
+				Username: member.UserName,
+				Email:    member.Email,
+			}
+			retVal = append(retVal, memberDto)
+		}
+
+		if !hasNextPage(resp, len(members), pageSize) {
+			break
+		}
+	}
+
+	return &retVal, nil
+}
+
+func (giteaApi *GiteaApi) GetRepositoryCollaborators(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (*[]dto.UserTeamResponseDto, error) {
+	client, err := giteaApi.getClient(gitSource, user)
 	if err != nil {
 		return nil, err
 	}
 
 	retVal := make([]dto.UserTeamResponseDto, 0)
-	for _, member := range members {
-		memberDto := dto.UserTeamResponseDto{
-			ID:       member.ID,
-			Username: member.UserName,
-			Email:    member.Email,
+	pageSize := config.GetGitPageSize()
+	for page := 1; ; page++ {
+		collaborators, resp, err := client.ListCollaborators(gitOrgRef, repositoryRef, gitea.ListCollaboratorsOptions{ListOptions: gitea.ListOptions{Page: page, PageSize: pageSize}})
+		if err != nil {
+			return nil, wrapError(resp, err)
+		}
+
+		for _, collaborator := range collaborators {
+			retVal = append(retVal, dto.UserTeamResponseDto{ID: collaborator.ID, Username: collaborator.UserName, Email: collaborator.Email})
+		}
+
+		if !hasNextPage(resp, len(collaborators), pageSize) {
+			break
 		}
-		retVal = append(retVal, memberDto)
 	}
 
 	return &retVal, nil
@@ -225,15 +380,21 @@ func (giteaApi *GiteaApi) GetBranches(gitSource *model.GitSource, user *model.Us
 		return nil, err
 	}
 
-	branchList, _, err := client.ListRepoBranches(gitOrgRef, repositoryRef, gitea.ListRepoBranchesOptions{})
-	if err != nil {
-		return nil, err
-	}
-
 	retVal := make(map[string]bool)
+	pageSize := config.GetGitPageSize()
+	for page := 1; ; page++ {
+		branchList, resp, err := client.ListRepoBranches(gitOrgRef, repositoryRef, gitea.ListRepoBranchesOptions{ListOptions: gitea.ListOptions{Page: page, PageSize: pageSize}})
+		if err != nil {
+			return nil, wrapError(resp, err)
+		}
+
+		for _, branche := range branchList {
+			retVal[branche.Name] = true
+		}
 
-	for _, branche := range branchList {
-		retVal[branche.Name] = true
+		if !hasNextPage(resp, len(branchList), pageSize) {
+			break
+		}
 	}
 
 	return retVal, nil
@@ -245,9 +406,9 @@ func (giteaApi *GiteaApi) getRepositoryAgolaMetadata(gitSource *model.GitSource,
 		return nil, err
 	}
 
-	contents, _, err := client.ListContents(gitOrgRef, repositoryRef, branchName, ".agola")
+	contents, resp, err := client.ListContents(gitOrgRef, repositoryRef, branchName, ".agola")
 	if err != nil {
-		return nil, err
+		return nil, wrapError(resp, err)
 	}
 
 	retVal := make([]MetadataResponseDto, 0)
@@ -263,28 +424,67 @@ func (giteaApi *GiteaApi) getRepositoryAgolaMetadata(gitSource *model.GitSource,
 	return &retVal, nil
 }
 
-func (giteaApi *GiteaApi) CheckRepositoryAgolaConfExists(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (bool, error) {
+func (giteaApi *GiteaApi) GetAgolaConfBranches(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (map[string]bool, error) {
 	branchList, err := giteaApi.GetBranches(gitSource, user, gitOrgRef, repositoryRef)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
+	retVal := make(map[string]bool)
 	for branch := range branchList {
-		metadata, err := giteaApi.getRepositoryAgolaMetadata(gitSource, user, gitOrgRef, repositoryRef, branch)
+		agolaConfExists, err := giteaApi.CheckBranchAgolaConfExists(gitSource, user, gitOrgRef, repositoryRef, branch)
 		if err != nil {
-			return false, err
+			return nil, err
+		}
+		if agolaConfExists {
+			retVal[branch] = true
 		}
+	}
 
-		for _, file := range *metadata {
-			if strings.Compare(file.Type, "file") == 0 && (strings.Compare(file.Name, "config.jsonnet") == 0 || strings.Compare(file.Name, "config.yml") == 0 || strings.Compare(file.Name, "config.json") == 0) {
-				return true, nil
-			}
+	return retVal, nil
+}
+
+func (giteaApi *GiteaApi) CheckBranchAgolaConfExists(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, branchName string) (bool, error) {
+	metadata, err := giteaApi.getRepositoryAgolaMetadata(gitSource, user, gitOrgRef, repositoryRef, branchName)
+	if errors.Is(err, transport.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	for _, file := range *metadata {
+		if strings.Compare(file.Type, "file") == 0 && dto.IsAgolaConfFile(file.Name) {
+			return true, nil
 		}
 	}
 
 	return false, nil
 }
 
+func (giteaApi *GiteaApi) GetPullRequest(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, pullRequestNumber int) (*dto.PullRequestDto, error) {
+	client, err := giteaApi.getClient(gitSource, user)
+	if err != nil {
+		return nil, err
+	}
+
+	pullRequest, _, err := client.GetPullRequest(gitOrgRef, repositoryRef, int64(pullRequestNumber))
+	if err != nil {
+		return nil, err
+	}
+
+	retVal := dto.PullRequestDto{Number: pullRequestNumber, Title: pullRequest.Title}
+	if pullRequest.Poster != nil {
+		retVal.AuthorLogin = pullRequest.Poster.UserName
+		retVal.AuthorEmail = pullRequest.Poster.Email
+	}
+	if pullRequest.Head != nil {
+		retVal.SourceBranch = pullRequest.Head.Ref
+	}
+
+	return &retVal, nil
+}
+
 func (giteaApi *GiteaApi) GetCommitMetadata(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, commitSha string) (*dto.CommitMetadataDto, error) {
 	client, err := giteaApi.getClient(gitSource, user)
 	if err != nil {
@@ -325,20 +525,27 @@ func (giteaApi *GiteaApi) GetOrganizations(gitSource *model.GitSource, user *mod
 		return nil, err
 	}
 
-	organizations, _, err := client.ListMyOrgs(gitea.ListOrgsOptions{})
-	if err != nil {
-		return nil, err
-	}
-
 	retVal := make([]dto.OrganizationDto, 0)
-	for _, org := range organizations {
-		orgDto := dto.OrganizationDto{
-			Path:      org.UserName,
-			Name:      org.FullName,
-			AvatarURL: org.AvatarURL,
-			ID:        org.ID,
+	pageSize := config.GetGitPageSize()
+	for page := 1; ; page++ {
+		organizations, resp, err := client.ListMyOrgs(gitea.ListOrgsOptions{ListOptions: gitea.ListOptions{Page: page, PageSize: pageSize}})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, org := range organizations {
+			orgDto := dto.OrganizationDto{
+				Path:      org.UserName,
+				Name:      org.FullName,
+				AvatarURL: org.AvatarURL,
+				ID:        org.ID,
+			}
+			retVal = append(retVal, orgDto)
+		}
+
+		if !hasNextPage(resp, len(organizations), pageSize) {
+			break
 		}
-		retVal = append(retVal, orgDto)
 	}
 
 	return &retVal, nil
@@ -500,6 +707,40 @@ func GetOauth2AuthorizeUrl(gitApiUrl string, gitClientId string, redirectUrl str
 	return fmt.Sprintf(oauth2AuthorizePath, gitApiUrl, gitClientId, url.QueryEscape(redirectUrl), state)
 }
 
+func ValidateWebHookSignature(header http.Header, payload []byte, webHookSecret string) bool {
+	return common.IsHmacSha256SignatureValid(webHookSecret, payload, header.Get(webHookSignatureHeader))
+}
+
+//Events sent by the organization webhook
+func GetWebHookEvents() []string {
+	return []string{"repository", "push", "create", "delete"}
+}
+
+//Unique id of the delivery, empty if not sent
+func GetWebHookDeliveryID(header http.Header) string {
+	return header.Get("X-Gitea-Delivery")
+}
+
+func GetWebHookEventType(header http.Header) string {
+	return header.Get(webHookEventHeader)
+}
+
+func wrapError(resp *gitea.Response, err error) error {
+	if resp == nil {
+		return transport.WrapError(nil, err)
+	}
+	return transport.WrapError(resp.Response, err)
+}
+
+//Gitea sends the Link header with the next page, without it the last page is the one with less items than requested
+func hasNextPage(resp *gitea.Response, items int, pageSize int) bool {
+	if resp != nil && resp.Response != nil && len(resp.Header.Get("Link")) > 0 {
+		return strings.Contains(resp.Header.Get("Link"), `rel="next"`)
+	}
+
+	return items >= pageSize
+}
+
 type Extra struct {
 	Expiry int `json:"expires_in,omitempty"`
 }
@@ -508,7 +749,7 @@ type Extra struct {
 
 func (giteabApi *GiteaApi) getClient(gitSource *model.GitSource, user *model.User) (*gitea.Client, error) {
 	if user == nil {
-		return gitea.NewClient(gitSource.GitAPIURL)
+		return newClient(gitSource)
 	}
 
 	if common.IsAccessTokenExpired(user.Oauth2AccessTokenExpiresAt) {
@@ -532,5 +773,16 @@ func (giteabApi *GiteaApi) getClient(gitSource *model.GitSource, user *model.Use
 		}
 	}
 
-	return gitea.NewClient(gitSource.GitAPIURL, gitea.SetToken(user.Oauth2AccessToken))
+	return newClient(gitSource, gitea.SetToken(user.Oauth2AccessToken))
+}
+
+//The client asks the server version when created, the failure is a connection error
+func newClient(gitSource *model.GitSource, options ...func(*gitea.Client)) (*gitea.Client, error) {
+	options = append(options, gitea.SetHTTPClient(transport.NewHTTPClient()))
+	client, err := gitea.NewClient(gitSource.GitAPIURL, options...)
+	if err != nil {
+		return nil, transport.TransientError(err)
+	}
+
+	return client, nil
 }
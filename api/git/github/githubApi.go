@@ -3,6 +3,7 @@ package github
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -15,6 +16,7 @@ import (
 	"golang.org/x/oauth2"
 	"wecode.sorint.it/opensource/papagaio-api/api"
 	"wecode.sorint.it/opensource/papagaio-api/api/git/dto"
+	"wecode.sorint.it/opensource/papagaio-api/api/git/transport"
 	"wecode.sorint.it/opensource/papagaio-api/common"
 	"wecode.sorint.it/opensource/papagaio-api/config"
 	"wecode.sorint.it/opensource/papagaio-api/controller"
@@ -23,14 +25,22 @@ import (
 )
 
 type GithubInterface interface {
-	CreateWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, organizationRef string) (int64, error)
+	CreateWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, organizationRef string, webHookSecret string) (int64, error)
 	DeleteWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) error
-	GetRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]string, error)
+	GetWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) (*dto.WebHookDto, error)
+	GetRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error)
+	GetUserRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error)
+	CreateRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, organizationRef string, webHookSecret string) (int64, error)
+	DeleteRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, webHookID int64) error
+	GetRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, webHookID int64) (*dto.WebHookDto, error)
 	GetEmailsRepositoryUsersOwner(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (*[]string, error)
 	GetOrganizationMembers(gitSource *model.GitSource, user *model.User, organizationName string) (*[]GitHubUser, error)
+	GetRepositoryCollaborators(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (*[]dto.UserTeamResponseDto, error)
 	GetBranches(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (map[string]bool, error)
-	CheckRepositoryAgolaConfExists(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (bool, error)
+	GetAgolaConfBranches(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (map[string]bool, error)
+	CheckBranchAgolaConfExists(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, branchName string) (bool, error)
 	GetCommitMetadata(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, commitSha string) (*dto.CommitMetadataDto, error)
+	GetPullRequest(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, pullRequestNumber int) (*dto.PullRequestDto, error)
 	GetOrganization(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*dto.OrganizationDto, error)
 	GetOrganizations(gitSource *model.GitSource, user *model.User) (*[]dto.OrganizationDto, error)
 	IsUserOwner(gitSource *model.GitSource, user *model.User, gitOrgRef string) (bool, error)
@@ -46,16 +56,13 @@ type GithubApi struct {
 	Db repository.Database
 }
 
-func (githubApi *GithubApi) CreateWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, organizationRef string) (int64, error) {
-	client, _ := githubApi.getClient(gitSource, user)
+func (githubApi *GithubApi) CreateWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, organizationRef string, webHookSecret string) (int64, error) {
+	client, err := githubApi.getOrganizationClient(gitSource, user, gitOrgRef)
+	if err != nil {
+		return -1, err
+	}
 
-	webHookName := "web"
-	active := true
-	conf := make(map[string]interface{})
-	conf["url"] = config.Config.Server.ApiExposedURL + controller.GetWebHookPath() + "/" + organizationRef
-	conf["content_type"] = "json"
-	hook := &github.Hook{Name: &webHookName, Events: []string{"repository", "push", "create", "delete"}, Active: &active, Config: conf}
-	hook, _, err := client.Organizations.CreateHook(context.Background(), gitOrgRef, hook)
+	hook, _, err := client.Organizations.CreateHook(context.Background(), gitOrgRef, makeHook(organizationRef, webHookSecret))
 	hookID := int64(-1)
 	if err == nil {
 		hookID = *hook.ID
@@ -64,25 +71,152 @@ func (githubApi *GithubApi) CreateWebHook(gitSource *model.GitSource, user *mode
 	return hookID, err
 }
 
+func makeHook(organizationRef string, webHookSecret string) *github.Hook {
+	webHookName := "web"
+	active := true
+	conf := make(map[string]interface{})
+	conf["url"] = controller.GetWebHookURL(organizationRef)
+	conf["content_type"] = "json"
+	conf["secret"] = webHookSecret
+
+	return &github.Hook{Name: &webHookName, Events: GetWebHookEvents(), Active: &active, Config: conf}
+}
+
 func (githubApi *GithubApi) DeleteWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) error {
-	client, _ := githubApi.getClient(gitSource, user)
-	_, err := client.Organizations.DeleteHook(context.Background(), gitOrgRef, webHookID)
+	client, err := githubApi.getOrganizationClient(gitSource, user, gitOrgRef)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Organizations.DeleteHook(context.Background(), gitOrgRef, webHookID)
 	return err
 }
 
-func (githubApi *GithubApi) GetRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]string, error) {
-	client, _ := githubApi.getClient(gitSource, user)
+//Return nil if the webhook doesn't exist
+func (githubApi *GithubApi) GetWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) (*dto.WebHookDto, error) {
+	client, err := githubApi.getOrganizationClient(gitSource, user, gitOrgRef)
+	if err != nil {
+		return nil, err
+	}
+
+	hook, resp, err := client.Organizations.GetHook(context.Background(), gitOrgRef, webHookID)
+	if resp != nil && resp.StatusCode == 404 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	opt := &github.RepositoryListByOrgOptions{Type: "all"}
-	repos, _, err := client.Repositories.ListByOrg(context.Background(), gitOrgRef, opt)
+	webHookURL, _ := hook.Config["url"].(string)
 
-	retVal := make([]string, 0)
+	return &dto.WebHookDto{ID: hook.GetID(), URL: webHookURL, Active: hook.GetActive(), Events: hook.Events}, nil
+}
+
+func (githubApi *GithubApi) CreateRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, organizationRef string, webHookSecret string) (int64, error) {
+	client, err := githubApi.getOrganizationClient(gitSource, user, gitOrgRef)
+	if err != nil {
+		return -1, err
+	}
+
+	hook, _, err := client.Repositories.CreateHook(context.Background(), gitOrgRef, repositoryRef, makeHook(organizationRef, webHookSecret))
+	if err != nil {
+		return -1, err
+	}
+
+	return hook.GetID(), nil
+}
+
+func (githubApi *GithubApi) DeleteRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, webHookID int64) error {
+	client, err := githubApi.getOrganizationClient(gitSource, user, gitOrgRef)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Repositories.DeleteHook(context.Background(), gitOrgRef, repositoryRef, webHookID)
+	return err
+}
+
+//Return nil if the webhook doesn't exist
+func (githubApi *GithubApi) GetRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, webHookID int64) (*dto.WebHookDto, error) {
+	client, err := githubApi.getOrganizationClient(gitSource, user, gitOrgRef)
+	if err != nil {
+		return nil, err
+	}
+
+	hook, resp, err := client.Repositories.GetHook(context.Background(), gitOrgRef, repositoryRef, webHookID)
+	if resp != nil && resp.StatusCode == 404 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	webHookURL, _ := hook.Config["url"].(string)
+
+	return &dto.WebHookDto{ID: hook.GetID(), URL: webHookURL, Active: hook.GetActive(), Events: hook.Events}, nil
+}
+
+func (githubApi *GithubApi) GetRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error) {
+	client, err := githubApi.getOrganizationClient(gitSource, user, gitOrgRef)
+	if err != nil {
+		return nil, err
+	}
+
+	retVal := make([]dto.RepositoryDto, 0)
+
+	opt := &github.RepositoryListByOrgOptions{Type: "all", ListOptions: github.ListOptions{PerPage: config.GetGitPageSize()}}
+	for {
+		repos, resp, err := client.Repositories.ListByOrg(context.Background(), gitOrgRef, opt)
+		if err != nil {
+			return nil, wrapError(resp, err)
+		}
+
+		for _, repo := range repos {
+			retVal = append(retVal, dto.RepositoryDto{ID: int(repo.GetID()), Name: repo.GetName(), FullName: repo.GetFullName()})
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return &retVal, nil
+}
+
+//Repositories owned by the connected user, the private ones are listed only for the authenticated user
+func (githubApi *GithubApi) GetUserRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error) {
+	if gitSource.IsGithubApp() {
+		return getInstallationRepositories(gitSource, gitOrgRef)
+	}
+
+	client, err := githubApi.getClient(gitSource, user)
+	if err != nil {
+		return nil, err
+	}
+
+	retVal := make([]dto.RepositoryDto, 0)
 
-	for _, repo := range repos {
-		retVal = append(retVal, *repo.Name)
+	opt := &github.RepositoryListOptions{Affiliation: "owner", ListOptions: github.ListOptions{PerPage: config.GetGitPageSize()}}
+	for {
+		repos, resp, err := client.Repositories.List(context.Background(), "", opt)
+		if err != nil {
+			return nil, wrapError(resp, err)
+		}
+
+		for _, repo := range repos {
+			if strings.EqualFold(repo.GetOwner().GetLogin(), gitOrgRef) {
+				retVal = append(retVal, dto.RepositoryDto{ID: int(repo.GetID()), Name: repo.GetName(), FullName: repo.GetFullName()})
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
 	}
 
-	return &retVal, err
+	return &retVal, nil
 }
 
 func (githubApi *GithubApi) GetEmailsRepositoryUsersOwner(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (*[]string, error) {
@@ -102,16 +236,33 @@ func (githubApi *GithubApi) GetEmailsRepositoryUsersOwner(gitSource *model.GitSo
 	return &retVal, nil
 }
 
+//Return the members read until the first error together with the error
 func (githubApi *GithubApi) GetOrganizationMembers(gitSource *model.GitSource, user *model.User, organizationName string) (*[]GitHubUser, error) {
-	client, _ := githubApi.getClient(gitSource, user)
-	users, _, err := client.Organizations.ListMembers(context.Background(), organizationName, nil)
+	client, err := githubApi.getOrganizationClient(gitSource, user, organizationName)
+	if err != nil {
+		return nil, err
+	}
 
 	retVal := make([]GitHubUser, 0)
 
-	for _, user := range users {
-		userMembership, _, err := client.Organizations.GetOrgMembership(context.Background(), *user.Login, organizationName)
+	opt := &github.ListMembersOptions{ListOptions: github.ListOptions{PerPage: config.GetGitPageSize()}}
+	for {
+		users, resp, err := client.Organizations.ListMembers(context.Background(), organizationName, opt)
+		if err != nil {
+			return &retVal, wrapError(resp, err)
+		}
+
+		for _, user := range users {
+			//a member without the membership is skipped, the others are listed anyway
+			userMembership, _, err := client.Organizations.GetOrgMembership(context.Background(), *user.Login, organizationName)
+			if err != nil {
+				log.Println("GetOrgMembership of", *user.Login, "in", organizationName, "error:", err)
+				continue
+			}
+			if userMembership == nil {
+				continue
+			}
 
-		if err == nil && userMembership != nil {
 			var role string
 			if strings.Compare(*userMembership.Role, "admin") == 0 {
 				role = "owner"
@@ -125,37 +276,85 @@ func (githubApi *GithubApi) GetOrganizationMembers(gitSource *model.GitSource, u
 			}
 			retVal = append(retVal, githubUser)
 		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
 	}
 
-	return &retVal, err
+	return &retVal, nil
 }
 
 func (githubApi *GithubApi) getRepositoryMembers(gitSource *model.GitSource, user *model.User, organizationName string, repositoryRef string) (*[]GitHubUser, error) {
-	client, _ := githubApi.getClient(gitSource, user)
-	users, _, err := client.Repositories.ListCollaborators(context.Background(), organizationName, repositoryRef, nil)
+	client, err := githubApi.getOrganizationClient(gitSource, user, organizationName)
+	if err != nil {
+		return nil, err
+	}
 
 	retVal := make([]GitHubUser, 0)
 
-	for _, user := range users {
-		userMembership, _, err := client.Organizations.GetOrgMembership(context.Background(), *user.Login, organizationName)
-		if err == nil {
-			var role string
-			if strings.Compare(*userMembership.Role, "admin") == 0 {
-				role = "owner"
-			} else {
-				role = "member"
+	opt := &github.ListCollaboratorsOptions{ListOptions: github.ListOptions{PerPage: config.GetGitPageSize()}}
+	for {
+		users, resp, err := client.Repositories.ListCollaborators(context.Background(), organizationName, repositoryRef, opt)
+		if err != nil {
+			return nil, wrapError(resp, err)
+		}
+
+		for _, user := range users {
+			userMembership, _, err := client.Organizations.GetOrgMembership(context.Background(), *user.Login, organizationName)
+			if err == nil {
+				var role string
+				if strings.Compare(*userMembership.Role, "admin") == 0 {
+					role = "owner"
+				} else {
+					role = "member"
+				}
+
+				retVal = append(retVal, GitHubUser{ID: int(*user.ID), Username: *user.Login, Role: role, Email: user.GetEmail()})
 			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return &retVal, nil
+}
+
+//Outside collaborators and users with access by a team of the repository are included
+func (githubApi *GithubApi) GetRepositoryCollaborators(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (*[]dto.UserTeamResponseDto, error) {
+	client, err := githubApi.getOrganizationClient(gitSource, user, gitOrgRef)
+	if err != nil {
+		return nil, err
+	}
+
+	retVal := make([]dto.UserTeamResponseDto, 0)
 
-			retVal = append(retVal, GitHubUser{ID: int(*user.ID), Username: *user.Login, Role: role, Email: *user.Email})
+	opt := &github.ListCollaboratorsOptions{Affiliation: "all", ListOptions: github.ListOptions{PerPage: config.GetGitPageSize()}}
+	for {
+		collaborators, resp, err := client.Repositories.ListCollaborators(context.Background(), gitOrgRef, repositoryRef, opt)
+		if err != nil {
+			return nil, wrapError(resp, err)
+		}
+
+		for _, collaborator := range collaborators {
+			retVal = append(retVal, dto.UserTeamResponseDto{ID: collaborator.GetID(), Username: collaborator.GetLogin(), Email: collaborator.GetEmail()})
 		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
 	}
 
-	return &retVal, err
+	return &retVal, nil
 }
 
 func (githubApi *GithubApi) GetBranches(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (map[string]bool, error) {
-	client, _ := githubApi.getClient(gitSource, user)
-	branchList, _, err := client.Repositories.ListBranches(context.Background(), gitOrgRef, repositoryRef, nil)
+	branchList, err := githubApi.listBranches(gitSource, user, gitOrgRef, repositoryRef)
 	if err != nil {
 		return nil, err
 	}
@@ -169,36 +368,98 @@ func (githubApi *GithubApi) GetBranches(gitSource *model.GitSource, user *model.
 	return retVal, nil
 }
 
-func (githubApi *GithubApi) CheckRepositoryAgolaConfExists(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (bool, error) {
-	client, _ := githubApi.getClient(gitSource, user)
-	branchList, _, err := client.Repositories.ListBranches(context.Background(), gitOrgRef, repositoryRef, nil)
+func (githubApi *GithubApi) listBranches(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) ([]*github.Branch, error) {
+	client, err := githubApi.getOrganizationClient(gitSource, user, gitOrgRef)
+	if err != nil {
+		return nil, err
+	}
+
+	retVal := make([]*github.Branch, 0)
+	opt := &github.BranchListOptions{ListOptions: github.ListOptions{PerPage: config.GetGitPageSize()}}
+	for {
+		branchList, resp, err := client.Repositories.ListBranches(context.Background(), gitOrgRef, repositoryRef, opt)
+		if err != nil {
+			return nil, wrapError(resp, err)
+		}
+		retVal = append(retVal, branchList...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return retVal, nil
+}
 
+func (githubApi *GithubApi) GetAgolaConfBranches(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (map[string]bool, error) {
+	branchList, err := githubApi.listBranches(gitSource, user, gitOrgRef, repositoryRef)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
+	retVal := make(map[string]bool)
 	for _, branch := range branchList {
+		agolaConfExists, err := githubApi.CheckBranchAgolaConfExists(gitSource, user, gitOrgRef, repositoryRef, branch.GetName())
 		if err != nil {
-			return false, err
+			return nil, err
+		}
+		if agolaConfExists {
+			retVal[branch.GetName()] = true
 		}
+	}
 
-		tree, _, err := client.Git.GetTree(context.Background(), gitOrgRef, repositoryRef, *branch.Commit.SHA, true)
-		if err != nil {
-			return false, err
+	return retVal, nil
+}
+
+func (githubApi *GithubApi) CheckBranchAgolaConfExists(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, branchName string) (bool, error) {
+	client, err := githubApi.getOrganizationClient(gitSource, user, gitOrgRef)
+	if err != nil {
+		return false, err
+	}
+
+	_, files, resp, err := client.Repositories.GetContents(context.Background(), gitOrgRef, repositoryRef, ".agola", &github.RepositoryContentGetOptions{Ref: branchName})
+	if err != nil {
+		err = wrapError(resp, err)
+		if errors.Is(err, transport.ErrNotFound) {
+			return false, nil
 		}
+		return false, err
+	}
 
-		for _, file := range tree.Entries {
-			if strings.Compare(*file.Type, "blob") == 0 && (strings.Compare(*file.Path, ".agola/config.jsonnet") == 0 || strings.Compare(*file.Path, ".agola/config.yml") == 0 || strings.Compare(*file.Path, ".agola/config.json") == 0) {
-				return true, nil
-			}
+	for _, file := range files {
+		if strings.Compare(file.GetType(), "file") == 0 && dto.IsAgolaConfFile(file.GetName()) {
+			return true, nil
 		}
 	}
 
 	return false, nil
 }
 
+func (githubApi *GithubApi) GetPullRequest(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, pullRequestNumber int) (*dto.PullRequestDto, error) {
+	client, err := githubApi.getOrganizationClient(gitSource, user, gitOrgRef)
+	if err != nil {
+		return nil, err
+	}
+	pullRequest, _, err := client.PullRequests.Get(context.Background(), gitOrgRef, repositoryRef, pullRequestNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PullRequestDto{
+		Number:       pullRequestNumber,
+		Title:        pullRequest.GetTitle(),
+		AuthorLogin:  pullRequest.GetUser().GetLogin(),
+		AuthorEmail:  pullRequest.GetUser().GetEmail(),
+		SourceBranch: pullRequest.GetHead().GetRef(),
+	}, nil
+}
+
 func (githubApi *GithubApi) GetCommitMetadata(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, commitSha string) (*dto.CommitMetadataDto, error) {
-	client, _ := githubApi.getClient(gitSource, user)
+	client, err := githubApi.getOrganizationClient(gitSource, user, gitOrgRef)
+	if err != nil {
+		return nil, err
+	}
 	commit, _, err := client.Repositories.GetCommit(context.Background(), gitOrgRef, repositoryRef, commitSha)
 	if err != nil {
 		return nil, err
@@ -219,11 +480,14 @@ func (githubApi *GithubApi) GetCommitMetadata(gitSource *model.GitSource, user *
 }
 
 func (githubApi *GithubApi) GetOrganization(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*dto.OrganizationDto, error) {
-	client, _ := githubApi.getClient(gitSource, user)
+	client, err := githubApi.getOrganizationClient(gitSource, user, gitOrgRef)
+	if err != nil {
+		return nil, err
+	}
 	org, resp, err := client.Organizations.Get(context.Background(), gitOrgRef)
 
 	if err != nil {
-		return nil, err
+		return nil, wrapError(resp, err)
 	}
 	if resp.StatusCode == 404 {
 		return nil, nil
@@ -247,10 +511,20 @@ func (githubApi *GithubApi) GetOrganization(gitSource *model.GitSource, user *mo
 
 func (githubApi *GithubApi) GetOrganizations(gitSource *model.GitSource, user *model.User) (*[]dto.OrganizationDto, error) {
 	client, _ := githubApi.getClient(gitSource, user)
-	organizations, _, err := client.Organizations.List(context.Background(), "", nil)
 
-	if err != nil {
-		return nil, err
+	organizations := make([]*github.Organization, 0)
+	opt := &github.ListOptions{PerPage: config.GetGitPageSize()}
+	for {
+		page, resp, err := client.Organizations.List(context.Background(), "", opt)
+		if err != nil {
+			return nil, err
+		}
+		organizations = append(organizations, page...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
 	}
 
 	retVal := make([]dto.OrganizationDto, 0)
@@ -341,11 +615,23 @@ func (githubApi *GithubApi) GetUserByLogin(gitSource *model.GitSource, login str
 	return &userInfo, nil
 }
 
+//The rate limit errors are returned by the client also without sending the request
+func wrapError(resp *github.Response, err error) error {
+	var rateLimitError *github.RateLimitError
+	var abuseRateLimitError *github.AbuseRateLimitError
+	if errors.As(err, &rateLimitError) || errors.As(err, &abuseRateLimitError) {
+		return transport.TransientError(err)
+	}
+
+	if resp == nil {
+		return transport.WrapError(nil, err)
+	}
+	return transport.WrapError(resp.Response, err)
+}
+
 func (githubApi *GithubApi) getClient(gitSource *model.GitSource, user *model.User) (*github.Client, error) {
 	if user == nil {
-		ctx := context.Background()
-		tc := oauth2.NewClient(ctx, nil)
-		return github.NewClient(tc), nil
+		return github.NewClient(transport.NewHTTPClient()), nil
 	}
 
 	if common.IsAccessTokenExpired(user.Oauth2AccessTokenExpiresAt) {
@@ -369,7 +655,7 @@ func (githubApi *GithubApi) getClient(gitSource *model.GitSource, user *model.Us
 		}
 	}
 
-	ctx := context.Background()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, transport.NewHTTPClient())
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{
 			AccessToken:  user.Oauth2AccessToken,
@@ -381,15 +667,51 @@ func (githubApi *GithubApi) getClient(gitSource *model.GitSource, user *model.Us
 	return github.NewClient(tc), nil
 }
 
+//The organization calls of a GitHub App gitSource are done by the app installation, the other ones by the user
+func (githubApi *GithubApi) getOrganizationClient(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*github.Client, error) {
+	if gitSource.IsGithubApp() {
+		return getInstallationClient(gitSource, gitOrgRef)
+	}
+
+	return githubApi.getClient(gitSource, user)
+}
+
 const oauth2AuthorizePath string = "https://github.com/login/oauth/authorize?client_id=%s&redirect_uri=%s&response_type=code&scope=%s&state=%s"
 
 const oauth2AccessTokenPath string = "https://github.com/login/oauth/access_token?client_id=%s&client_secret=%s&code=%s&redirect_uri=%s"
 const oauth2RefreshTokenPath string = "https://github.com/login/oauth/access_token?client_id=%s&client_secret=%s&grant_type=refresh_token&refresh_token=%s"
 
+const webHookSignatureHeader string = "X-Hub-Signature-256"
+const webHookSignaturePrefix string = "sha256="
+const webHookEventHeader string = "X-GitHub-Event"
+
 func GetOauth2AuthorizeUrl(gitClientId string, redirectUrl string, state string) string {
 	return fmt.Sprintf(oauth2AuthorizePath, gitClientId, url.QueryEscape(redirectUrl), "admin:org%20admin:org_hook%20repo", state)
 }
 
+func ValidateWebHookSignature(header http.Header, payload []byte, webHookSecret string) bool {
+	signature := header.Get(webHookSignatureHeader)
+	if !strings.HasPrefix(signature, webHookSignaturePrefix) {
+		return false
+	}
+
+	return common.IsHmacSha256SignatureValid(webHookSecret, payload, strings.TrimPrefix(signature, webHookSignaturePrefix))
+}
+
+//Events sent by the organization webhook
+func GetWebHookEvents() []string {
+	return []string{"repository", "push", "create", "delete"}
+}
+
+//Unique id of the delivery, empty if not sent
+func GetWebHookDeliveryID(header http.Header) string {
+	return header.Get("X-GitHub-Delivery")
+}
+
+func GetWebHookEventType(header http.Header) string {
+	return header.Get(webHookEventHeader)
+}
+
 func (githubApi *GithubApi) GetOauth2AccessToken(gitSource *model.GitSource, code string) (*common.Token, error) {
 	client := &http.Client{}
 
@@ -0,0 +1,153 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/go-github/v37/github"
+	"golang.org/x/oauth2"
+	"wecode.sorint.it/opensource/papagaio-api/api/git/dto"
+	"wecode.sorint.it/opensource/papagaio-api/api/git/transport"
+	"wecode.sorint.it/opensource/papagaio-api/config"
+	"wecode.sorint.it/opensource/papagaio-api/model"
+)
+
+//GitHub accepts app tokens valid at most 10 minutes
+const appTokenDuration = 9 * time.Minute
+
+//An installation token is renewed when it expires within this time
+const installationTokenRenewMargin = 5 * time.Minute
+
+type installationToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+//Installation tokens by gitSource and organization
+var installationTokens = struct {
+	sync.Mutex
+	tokens map[string]installationToken
+}{tokens: make(map[string]installationToken)}
+
+//Return an error if the private key of the GitHub App can't be used to sign the app tokens
+func CheckAppPrivateKey(privateKeyPath string) error {
+	_, err := readAppPrivateKey(privateKeyPath)
+	return err
+}
+
+//Client authenticated as the GitHub App installation of the organization
+func getInstallationClient(gitSource *model.GitSource, gitOrgRef string) (*github.Client, error) {
+	token, err := getInstallationToken(gitSource, gitOrgRef)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTokenClient(token), nil
+}
+
+func getInstallationToken(gitSource *model.GitSource, gitOrgRef string) (string, error) {
+	installationTokens.Lock()
+	defer installationTokens.Unlock()
+
+	key := gitSource.Name + "/" + gitOrgRef
+	if cached, ok := installationTokens.tokens[key]; ok && time.Until(cached.expiresAt) > installationTokenRenewMargin {
+		return cached.token, nil
+	}
+
+	appClient, err := getAppClient(gitSource)
+	if err != nil {
+		return "", err
+	}
+
+	installation, resp, err := appClient.Apps.FindOrganizationInstallation(context.Background(), gitOrgRef)
+	if err != nil && errors.Is(wrapError(resp, err), transport.ErrNotFound) {
+		//the app can be installed also on a user account
+		installation, resp, err = appClient.Apps.FindUserInstallation(context.Background(), gitOrgRef)
+	}
+	if err != nil {
+		return "", wrapError(resp, err)
+	}
+
+	token, resp, err := appClient.Apps.CreateInstallationToken(context.Background(), installation.GetID(), nil)
+	if err != nil {
+		return "", wrapError(resp, err)
+	}
+
+	installationTokens.tokens[key] = installationToken{token: token.GetToken(), expiresAt: token.GetExpiresAt()}
+
+	return token.GetToken(), nil
+}
+
+//Client authenticated as the GitHub App, used only to create the installation tokens
+func getAppClient(gitSource *model.GitSource) (*github.Client, error) {
+	privateKey, err := readAppPrivateKey(gitSource.GithubAppPrivateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	claims := jwt.StandardClaims{
+		Issuer:    strconv.FormatInt(gitSource.GithubAppID, 10),
+		IssuedAt:  now.Add(-time.Minute).Unix(),
+		ExpiresAt: now.Add(appTokenDuration).Unix(),
+	}
+
+	appToken, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTokenClient(appToken), nil
+}
+
+func readAppPrivateKey(privateKeyPath string) (interface{}, error) {
+	privateKeyData, err := ioutil.ReadFile(privateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return jwt.ParseRSAPrivateKeyFromPEM(privateKeyData)
+}
+
+func newTokenClient(token string) *github.Client {
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, transport.NewHTTPClient())
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token, TokenType: "bearer"})
+	return github.NewClient(oauth2.NewClient(ctx, ts))
+}
+
+//Repositories of the user account where the app is installed
+func getInstallationRepositories(gitSource *model.GitSource, gitOrgRef string) (*[]dto.RepositoryDto, error) {
+	client, err := getInstallationClient(gitSource, gitOrgRef)
+	if err != nil {
+		return nil, err
+	}
+
+	retVal := make([]dto.RepositoryDto, 0)
+
+	opt := &github.ListOptions{PerPage: config.GetGitPageSize()}
+	for {
+		repos, resp, err := client.Apps.ListRepos(context.Background(), opt)
+		if err != nil {
+			return nil, wrapError(resp, err)
+		}
+
+		for _, repo := range repos.Repositories {
+			if strings.EqualFold(repo.GetOwner().GetLogin(), gitOrgRef) {
+				retVal = append(retVal, dto.RepositoryDto{ID: int(repo.GetID()), Name: repo.GetName(), FullName: repo.GetFullName()})
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return &retVal, nil
+}
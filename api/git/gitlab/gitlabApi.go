@@ -1,7 +1,9 @@
 package gitlab
 
 import (
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -13,6 +15,7 @@ import (
 	"github.com/xanzy/go-gitlab"
 	"wecode.sorint.it/opensource/papagaio-api/api"
 	"wecode.sorint.it/opensource/papagaio-api/api/git/dto"
+	"wecode.sorint.it/opensource/papagaio-api/api/git/transport"
 	"wecode.sorint.it/opensource/papagaio-api/common"
 	"wecode.sorint.it/opensource/papagaio-api/config"
 	"wecode.sorint.it/opensource/papagaio-api/controller"
@@ -21,14 +24,26 @@ import (
 )
 
 type GitlabInterface interface {
-	CreateWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, organizationRef string) (int64, error)
+	CreateWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, organizationRef string, webHookSecret string) (int64, error)
 	DeleteWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) error
-	GetRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]string, error)
+	CreateSystemHook(gitSource *model.GitSource, user *model.User, webHookURL string, webHookSecret string) (int64, error)
+	DeleteSystemHook(gitSource *model.GitSource, user *model.User, systemHookID int64) error
+	GetSystemHook(gitSource *model.GitSource, user *model.User, systemHookID int64) (*dto.WebHookDto, error)
+	GetWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) (*dto.WebHookDto, error)
+	GetRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error)
+	GetRepositoriesWithSubgroups(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error)
+	GetUserRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error)
+	CreateRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, organizationRef string, webHookSecret string) (int64, error)
+	DeleteRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, webHookID int64) error
+	GetRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, webHookID int64) (*dto.WebHookDto, error)
 	GetEmailsRepositoryUsersOwner(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (*[]string, error)
 	GetOrganizationMembers(gitSource *model.GitSource, user *model.User, organizationName string) (*[]GitlabUser, error)
+	GetRepositoryCollaborators(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (*[]dto.UserTeamResponseDto, error)
 	GetBranches(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (map[string]bool, error)
-	CheckRepositoryAgolaConfExists(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (bool, error)
+	GetAgolaConfBranches(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (map[string]bool, error)
+	CheckBranchAgolaConfExists(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, branchName string) (bool, error)
 	GetCommitMetadata(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, commitSha string) (*dto.CommitMetadataDto, error)
+	GetPullRequest(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, pullRequestNumber int) (*dto.PullRequestDto, error)
 	GetOrganization(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*dto.OrganizationDto, error)
 	GetOrganizations(gitSource *model.GitSource, user *model.User) (*[]dto.OrganizationDto, error)
 	IsUserOwner(gitSource *model.GitSource, user *model.User, gitOrgRef string) (bool, error)
@@ -40,58 +55,333 @@ type GitlabInterface interface {
 	RefreshToken(gitSource *model.GitSource, refreshToken string) (*common.Token, error)
 }
 
+var ErrSystemHookForbidden = errors.New("gitlab system hooks can be managed only by an administrator user")
+
 type GitlabApi struct {
 	Db repository.Database
 }
 
-func (gitlabApi *GitlabApi) CreateWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, organizationRef string) (int64, error) {
+func (gitlabApi *GitlabApi) CreateWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, organizationRef string, webHookSecret string) (int64, error) {
 	client, _ := gitlabApi.getClient(gitSource, user)
 
+	webHookURL := controller.GetWebHookURL(organizationRef)
 	groupHook, _, err := client.Groups.AddGroupHook(gitOrgRef, &gitlab.AddGroupHookOptions{
-		URL:        gitlab.String(config.Config.Server.ApiExposedURL + controller.GetWebHookPath() + "/" + organizationRef),
+		URL:        gitlab.String(webHookURL),
 		PushEvents: gitlab.Bool(true),
+		Token:      gitlab.String(webHookSecret),
 	})
 	hookID := int64(-1)
-	if err == nil {
-		hookID = int64(groupHook.ID)
+	if err != nil {
+		return hookID, err
 	}
+	hookID = int64(groupHook.ID)
 
-	return hookID, err
+	return hookID, nil
 }
 
 func (gitlabApi *GitlabApi) DeleteWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) error {
 	client, _ := gitlabApi.getClient(gitSource, user)
+
 	_, err := client.Groups.DeleteGroupHook(gitOrgRef, int(webHookID))
 	return err
 }
 
-func (gitlabApi *GitlabApi) GetRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]string, error) {
+/*
+Project create, destroy, rename and transfer events are sent only by the system hooks, for all the projects of the instance.
+A single system hook is created for the git source, it requires an administrator user
+*/
+func (gitlabApi *GitlabApi) CreateSystemHook(gitSource *model.GitSource, user *model.User, webHookURL string, webHookSecret string) (int64, error) {
 	client, _ := gitlabApi.getClient(gitSource, user)
 
-	projectList, _, err := client.Groups.ListGroupProjects(gitOrgRef, nil)
+	//a system hook of the git source created before is replaced
+	gitlabApi.deleteSystemHooks(client, webHookURL)
 
-	retVal := make([]string, 0)
+	systemHook, resp, err := client.SystemHooks.AddHook(&gitlab.AddHookOptions{
+		URL:                    gitlab.String(webHookURL),
+		Token:                  gitlab.String(webHookSecret),
+		PushEvents:             gitlab.Bool(false),
+		TagPushEvents:          gitlab.Bool(false),
+		MergeRequestsEvents:    gitlab.Bool(false),
+		RepositoryUpdateEvents: gitlab.Bool(false),
+	})
+	if resp != nil && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) {
+		return -1, fmt.Errorf("%w: %v", ErrSystemHookForbidden, err)
+	}
+	if err != nil {
+		return -1, err
+	}
+
+	return int64(systemHook.ID), nil
+}
 
-	for _, project := range projectList {
-		retVal = append(retVal, project.Name)
+func (gitlabApi *GitlabApi) DeleteSystemHook(gitSource *model.GitSource, user *model.User, systemHookID int64) error {
+	client, _ := gitlabApi.getClient(gitSource, user)
+
+	_, err := client.SystemHooks.DeleteHook(int(systemHookID))
+	return err
+}
+
+//Return nil if the webhook doesn't exist
+func (gitlabApi *GitlabApi) GetWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) (*dto.WebHookDto, error) {
+	client, _ := gitlabApi.getClient(gitSource, user)
+
+	return getHook(client, fmt.Sprintf("groups/%s/hooks/%d", pathEscape(gitOrgRef), webHookID))
+}
+
+//Return nil if the system hook doesn't exist
+func (gitlabApi *GitlabApi) GetSystemHook(gitSource *model.GitSource, user *model.User, systemHookID int64) (*dto.WebHookDto, error) {
+	client, _ := gitlabApi.getClient(gitSource, user)
+
+	req, err := client.NewRequest(http.MethodGet, "hooks", nil, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	return &retVal, err
+	var systemHooks []gitlabHook
+	resp, err := client.Do(req, &systemHooks)
+	if resp != nil && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) {
+		return nil, fmt.Errorf("%w: %v", ErrSystemHookForbidden, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, systemHook := range systemHooks {
+		if int64(systemHook.ID) == systemHookID {
+			return systemHook.toWebHookDto(), nil
+		}
+	}
+
+	return nil, nil
 }
 
-func (gitlabApi *GitlabApi) GetEmailsRepositoryUsersOwner(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (*[]string, error) {
+//Project hooks are used for the user namespaces, they have no group hooks
+func (gitlabApi *GitlabApi) CreateRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, organizationRef string, webHookSecret string) (int64, error) {
+	client, _ := gitlabApi.getClient(gitSource, user)
+
+	projectHook, _, err := client.Projects.AddProjectHook(gitOrgRef+"/"+repositoryRef, &gitlab.AddProjectHookOptions{
+		URL:        gitlab.String(controller.GetWebHookURL(organizationRef)),
+		PushEvents: gitlab.Bool(true),
+		Token:      gitlab.String(webHookSecret),
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	return int64(projectHook.ID), nil
+}
+
+func (gitlabApi *GitlabApi) DeleteRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, webHookID int64) error {
 	client, _ := gitlabApi.getClient(gitSource, user)
-	users, _, err := client.ProjectMembers.ListAllProjectMembers(gitOrgRef+"/"+repositoryRef, nil)
+
+	_, err := client.Projects.DeleteProjectHook(gitOrgRef+"/"+repositoryRef, int(webHookID))
+	return err
+}
+
+//Return nil if the webhook doesn't exist
+func (gitlabApi *GitlabApi) GetRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, webHookID int64) (*dto.WebHookDto, error) {
+	client, _ := gitlabApi.getClient(gitSource, user)
+
+	return getHook(client, fmt.Sprintf("projects/%s/hooks/%d", pathEscape(gitOrgRef+"/"+repositoryRef), webHookID))
+}
+
+/*
+Group, project and system hook: the go-gitlab hooks have no alert_status and disabled_until,
+set by gitlab when it disables a hook failing too many times
+*/
+type gitlabHook struct {
+	ID                     int        `json:"id"`
+	URL                    string     `json:"url"`
+	PushEvents             bool       `json:"push_events"`
+	TagPushEvents          bool       `json:"tag_push_events"`
+	MergeRequestsEvents    bool       `json:"merge_requests_events"`
+	RepositoryUpdateEvents bool       `json:"repository_update_events"`
+	IssuesEvents           bool       `json:"issues_events"`
+	NoteEvents             bool       `json:"note_events"`
+	JobEvents              bool       `json:"job_events"`
+	PipelineEvents         bool       `json:"pipeline_events"`
+	WikiPageEvents         bool       `json:"wiki_page_events"`
+	DeploymentEvents       bool       `json:"deployment_events"`
+	ReleasesEvents         bool       `json:"releases_events"`
+	AlertStatus            string     `json:"alert_status"`
+	DisabledUntil          *time.Time `json:"disabled_until"`
+}
+
+func (hook *gitlabHook) toWebHookDto() *dto.WebHookDto {
+	enabledEvents := []struct {
+		name    string
+		enabled bool
+	}{
+		{"push", hook.PushEvents},
+		{"tag_push", hook.TagPushEvents},
+		{"merge_requests", hook.MergeRequestsEvents},
+		{"repository_update", hook.RepositoryUpdateEvents},
+		{"issues", hook.IssuesEvents},
+		{"note", hook.NoteEvents},
+		{"job", hook.JobEvents},
+		{"pipeline", hook.PipelineEvents},
+		{"wiki_page", hook.WikiPageEvents},
+		{"deployment", hook.DeploymentEvents},
+		{"releases", hook.ReleasesEvents},
+	}
+
+	events := make([]string, 0)
+	for _, event := range enabledEvents {
+		if event.enabled {
+			events = append(events, event.name)
+		}
+	}
+
+	active := strings.Compare(hook.AlertStatus, "disabled") != 0 && strings.Compare(hook.AlertStatus, "temporarily_disabled") != 0
+	if hook.DisabledUntil != nil && hook.DisabledUntil.After(time.Now()) {
+		active = false
+	}
+
+	return &dto.WebHookDto{ID: int64(hook.ID), URL: hook.URL, Active: active, Events: events}
+}
+
+//Return nil if the hook doesn't exist
+func getHook(client *gitlab.Client, hookPath string) (*dto.WebHookDto, error) {
+	req, err := client.NewRequest(http.MethodGet, hookPath, nil, nil)
 	if err != nil {
 		return nil, err
 	}
 
+	var hook gitlabHook
+	resp, err := client.Do(req, &hook)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return hook.toWebHookDto(), nil
+}
+
+//Escape the id of a group or of a project like go-gitlab
+func pathEscape(id string) string {
+	return strings.Replace(url.PathEscape(id), ".", "%2E", -1)
+}
+
+func (gitlabApi *GitlabApi) deleteSystemHooks(client *gitlab.Client, webHookURL string) {
+	systemHooks, _, err := client.SystemHooks.ListHooks()
+	if err != nil {
+		log.Println("gitlab ListHooks error:", err)
+		return
+	}
+
+	for _, systemHook := range systemHooks {
+		if strings.Compare(systemHook.URL, webHookURL) != 0 {
+			continue
+		}
+
+		_, err = client.SystemHooks.DeleteHook(systemHook.ID)
+		if err != nil {
+			log.Println("gitlab DeleteHook error:", err)
+		}
+	}
+}
+
+func (gitlabApi *GitlabApi) GetRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error) {
+	client, _ := gitlabApi.getClient(gitSource, user)
+	return listGroupProjects(client, gitOrgRef, false)
+}
+
+//The projects of the descendant subgroups are named by their path relative to the group, like subgroup/project
+func (gitlabApi *GitlabApi) GetRepositoriesWithSubgroups(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error) {
+	client, _ := gitlabApi.getClient(gitSource, user)
+	return listGroupProjects(client, gitOrgRef, true)
+}
+
+func listGroupProjects(client *gitlab.Client, gitOrgRef string, includeSubgroups bool) (*[]dto.RepositoryDto, error) {
+	retVal := make([]dto.RepositoryDto, 0)
+
+	opt := &gitlab.ListGroupProjectsOptions{IncludeSubgroups: gitlab.Bool(includeSubgroups), ListOptions: gitlab.ListOptions{PerPage: config.GetGitPageSize()}}
+	for {
+		projectList, resp, err := client.Groups.ListGroupProjects(gitOrgRef, opt)
+		if err != nil {
+			return nil, wrapError(resp, err)
+		}
+
+		for _, project := range projectList {
+			name := project.Path
+			if subgroupPath := subgroupProjectPath(gitOrgRef, project.PathWithNamespace); len(subgroupPath) > 0 {
+				name = subgroupPath
+			}
+			retVal = append(retVal, dto.RepositoryDto{ID: project.ID, Name: name, FullName: project.PathWithNamespace})
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return &retVal, nil
+}
+
+//Projects of the personal namespace of the user
+func (gitlabApi *GitlabApi) GetUserRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error) {
+	client, _ := gitlabApi.getClient(gitSource, user)
+	retVal := make([]dto.RepositoryDto, 0)
+
+	opt := &gitlab.ListProjectsOptions{ListOptions: gitlab.ListOptions{PerPage: config.GetGitPageSize()}}
+	for {
+		projectList, resp, err := client.Projects.ListUserProjects(gitOrgRef, opt)
+		if err != nil {
+			return nil, wrapError(resp, err)
+		}
+
+		for _, project := range projectList {
+			retVal = append(retVal, dto.RepositoryDto{ID: project.ID, Name: project.Path, FullName: project.PathWithNamespace})
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return &retVal, nil
+}
+
+//Path of the project relative to the group, empty if the project isn't in a descendant subgroup
+func subgroupProjectPath(gitOrgRef string, pathWithNamespace string) string {
+	prefix := gitOrgRef + "/"
+	if len(pathWithNamespace) <= len(prefix) || !strings.EqualFold(pathWithNamespace[:len(prefix)], prefix) {
+		return ""
+	}
+
+	relativePath := pathWithNamespace[len(prefix):]
+	if !strings.Contains(relativePath, "/") {
+		return ""
+	}
+
+	return relativePath
+}
+
+func (gitlabApi *GitlabApi) GetEmailsRepositoryUsersOwner(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (*[]string, error) {
+	client, _ := gitlabApi.getClient(gitSource, user)
 	retVal := make([]string, 0)
 
-	for _, user := range users {
-		if user.AccessLevel == gitlab.OwnerPermissions || user.AccessLevel == gitlab.MaintainerPermissions {
-			retVal = append(retVal, user.Email)
+	opt := &gitlab.ListProjectMembersOptions{ListOptions: gitlab.ListOptions{PerPage: config.GetGitPageSize()}}
+	for {
+		users, resp, err := client.ProjectMembers.ListAllProjectMembers(gitOrgRef+"/"+repositoryRef, opt)
+		if err != nil {
+			return nil, wrapError(resp, err)
+		}
+
+		for _, user := range users {
+			if user.AccessLevel == gitlab.OwnerPermissions || user.AccessLevel == gitlab.MaintainerPermissions {
+				retVal = append(retVal, user.Email)
+			}
 		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
 	}
 
 	return &retVal, nil
@@ -99,21 +389,58 @@ func (gitlabApi *GitlabApi) GetEmailsRepositoryUsersOwner(gitSource *model.GitSo
 
 func (gitlabApi *GitlabApi) GetOrganizationMembers(gitSource *model.GitSource, user *model.User, organizationName string) (*[]GitlabUser, error) {
 	client, _ := gitlabApi.getClient(gitSource, user)
-	members, _, err := client.Groups.ListAllGroupMembers(organizationName, nil)
+	retVal := make([]GitlabUser, 0)
+
+	opt := &gitlab.ListGroupMembersOptions{ListOptions: gitlab.ListOptions{PerPage: config.GetGitPageSize()}}
+	for {
+		members, resp, err := client.Groups.ListAllGroupMembers(organizationName, opt)
+		if err != nil {
+			return &retVal, wrapError(resp, err)
+		}
+
+		for _, member := range members {
+			user := GitlabUser{
+				ID:          member.ID,
+				Username:    member.Username,
+				AccessLevel: member.AccessLevel,
+			}
+
+			retVal = append(retVal, user)
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return &retVal, nil
+}
+
+//The members inherited from the ancestor groups are included
+func (gitlabApi *GitlabApi) GetRepositoryCollaborators(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (*[]dto.UserTeamResponseDto, error) {
+	client, err := gitlabApi.getClient(gitSource, user)
 	if err != nil {
 		return nil, err
 	}
 
-	retVal := make([]GitlabUser, 0)
+	retVal := make([]dto.UserTeamResponseDto, 0)
+
+	opt := &gitlab.ListProjectMembersOptions{ListOptions: gitlab.ListOptions{PerPage: config.GetGitPageSize()}}
+	for {
+		members, resp, err := client.ProjectMembers.ListAllProjectMembers(gitOrgRef+"/"+repositoryRef, opt)
+		if err != nil {
+			return nil, wrapError(resp, err)
+		}
 
-	for _, member := range members {
-		user := GitlabUser{
-			ID:          member.ID,
-			Username:    member.Username,
-			AccessLevel: member.AccessLevel,
+		for _, member := range members {
+			retVal = append(retVal, dto.UserTeamResponseDto{ID: int64(member.ID), Username: member.Username, Email: member.Email})
 		}
 
-		retVal = append(retVal, user)
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
 	}
 
 	return &retVal, nil
@@ -125,7 +452,7 @@ func (gitlabApi *GitlabApi) GetBranches(gitSource *model.GitSource, user *model.
 		return nil, err
 	}
 
-	branches, _, err := client.Branches.ListBranches(gitOrgRef+"/"+repositoryRef, nil)
+	branches, err := listBranches(client, gitOrgRef+"/"+repositoryRef)
 	if err != nil {
 		return nil, err
 	}
@@ -139,35 +466,104 @@ func (gitlabApi *GitlabApi) GetBranches(gitSource *model.GitSource, user *model.
 	return retVal, nil
 }
 
-func (gitlabApi *GitlabApi) CheckRepositoryAgolaConfExists(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (bool, error) {
+func listBranches(client *gitlab.Client, projectRef string) ([]*gitlab.Branch, error) {
+	retVal := make([]*gitlab.Branch, 0)
+
+	opt := &gitlab.ListBranchesOptions{ListOptions: gitlab.ListOptions{PerPage: config.GetGitPageSize()}}
+	for {
+		branches, resp, err := client.Branches.ListBranches(projectRef, opt)
+		if err != nil {
+			return nil, wrapError(resp, err)
+		}
+		retVal = append(retVal, branches...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return retVal, nil
+}
+
+func (gitlabApi *GitlabApi) GetAgolaConfBranches(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string) (map[string]bool, error) {
 	client, _ := gitlabApi.getClient(gitSource, user)
-	branchList, _, err := client.Branches.ListBranches(gitOrgRef+"/"+repositoryRef, nil)
+	branchList, err := listBranches(client, gitOrgRef+"/"+repositoryRef)
 
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
+	retVal := make(map[string]bool)
 	for _, branch := range branchList {
+		agolaConfExists, err := gitlabApi.CheckBranchAgolaConfExists(gitSource, user, gitOrgRef, repositoryRef, branch.Name)
 		if err != nil {
-			return false, err
+			return nil, err
 		}
+		if agolaConfExists {
+			retVal[branch.Name] = true
+		}
+	}
+
+	return retVal, nil
+}
 
-		options := gitlab.ListTreeOptions{Ref: &branch.Name, Path: gitlab.String(".agola")}
-		tree, _, err := client.Repositories.ListTree(gitOrgRef+"/"+repositoryRef, &options)
+func (gitlabApi *GitlabApi) CheckBranchAgolaConfExists(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, branchName string) (bool, error) {
+	client, _ := gitlabApi.getClient(gitSource, user)
+
+	options := gitlab.ListTreeOptions{Ref: &branchName, Path: gitlab.String(".agola"), ListOptions: gitlab.ListOptions{PerPage: config.GetGitPageSize()}}
+	tree := make([]*gitlab.TreeNode, 0)
+	for {
+		page, resp, err := client.Repositories.ListTree(gitOrgRef+"/"+repositoryRef, &options)
 		if err != nil {
+			err = wrapError(resp, err)
+			if errors.Is(err, transport.ErrNotFound) {
+				return false, nil
+			}
 			return false, err
 		}
+		tree = append(tree, page...)
 
-		for _, file := range tree {
-			if strings.Compare(file.Type, "blob") == 0 && (strings.Compare(file.Path, ".agola/config.jsonnet") == 0 || strings.Compare(file.Path, ".agola/config.yml") == 0 || strings.Compare(file.Path, ".agola/config.json") == 0) {
-				return true, nil
-			}
+		if resp.NextPage == 0 {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+
+	for _, file := range tree {
+		if strings.Compare(file.Type, "blob") == 0 && dto.IsAgolaConfFile(file.Name) {
+			return true, nil
 		}
 	}
 
 	return false, nil
 }
 
+func (gitlabApi *GitlabApi) GetPullRequest(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, pullRequestNumber int) (*dto.PullRequestDto, error) {
+	client, _ := gitlabApi.getClient(gitSource, user)
+	mergeRequest, _, err := client.MergeRequests.GetMergeRequest(gitOrgRef+"/"+repositoryRef, pullRequestNumber, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	retVal := dto.PullRequestDto{Number: pullRequestNumber, Title: mergeRequest.Title, SourceBranch: mergeRequest.SourceBranch}
+	if mergeRequest.Author != nil {
+		retVal.AuthorLogin = mergeRequest.Author.Username
+
+		//the email is returned only if public or to administrators
+		author, _, err := client.Users.GetUser(mergeRequest.Author.ID, gitlab.GetUsersOptions{})
+		if err == nil {
+			if len(author.PublicEmail) > 0 {
+				retVal.AuthorEmail = author.PublicEmail
+			} else {
+				retVal.AuthorEmail = author.Email
+			}
+		}
+	}
+
+	return &retVal, nil
+}
+
 func (gitlabApi *GitlabApi) GetCommitMetadata(gitSource *model.GitSource, user *model.User, gitOrgRef string, repositoryRef string, commitSha string) (*dto.CommitMetadataDto, error) {
 	client, _ := gitlabApi.getClient(gitSource, user)
 	commit, _, err := client.Commits.GetCommit(gitOrgRef+"/"+repositoryRef, commitSha)
@@ -192,28 +588,36 @@ func (gitlabApi *GitlabApi) GetOrganization(gitSource *model.GitSource, user *mo
 	org, resp, err := client.Groups.GetGroup(gitOrgRef)
 
 	if err != nil {
-		return nil, err
+		return nil, wrapError(resp, err)
 	}
 	if resp.StatusCode == 404 {
 		return nil, nil
 	}
 
-	response := &dto.OrganizationDto{Name: org.Name, Path: org.Path, ID: int64(org.ID), AvatarURL: org.AvatarURL}
+	response := &dto.OrganizationDto{Name: org.Name, Path: org.FullPath, ID: int64(org.ID), AvatarURL: org.AvatarURL}
 	return response, nil
 }
 
 func (gitlabApi *GitlabApi) GetOrganizations(gitSource *model.GitSource, user *model.User) (*[]dto.OrganizationDto, error) {
 	client, _ := gitlabApi.getClient(gitSource, user)
-	organizations, _, err := client.Groups.ListGroups(&gitlab.ListGroupsOptions{MinAccessLevel: gitlab.AccessLevel(gitlab.OwnerPermissions)})
+	retVal := make([]dto.OrganizationDto, 0)
 
-	if err != nil {
-		return nil, err
-	}
+	opt := &gitlab.ListGroupsOptions{MinAccessLevel: gitlab.AccessLevel(gitlab.OwnerPermissions), ListOptions: gitlab.ListOptions{PerPage: config.GetGitPageSize()}}
+	for {
+		organizations, resp, err := client.Groups.ListGroups(opt)
+		if err != nil {
+			return nil, err
+		}
 
-	retVal := make([]dto.OrganizationDto, 0)
-	for _, org := range organizations {
-		orgDto := dto.OrganizationDto{Name: org.Name, Path: org.Path, ID: int64(org.ID), AvatarURL: org.AvatarURL}
-		retVal = append(retVal, orgDto)
+		for _, org := range organizations {
+			orgDto := dto.OrganizationDto{Name: org.Name, Path: org.FullPath, ID: int64(org.ID), AvatarURL: org.AvatarURL}
+			retVal = append(retVal, orgDto)
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
 	}
 
 	return &retVal, nil
@@ -282,7 +686,7 @@ func (gitlabApi *GitlabApi) GetUserByLogin(gitSource *model.GitSource, id int) (
 
 func (gitlabApi *GitlabApi) getClient(gitSource *model.GitSource, user *model.User) (*gitlab.Client, error) {
 	if user == nil {
-		return gitlab.NewClient("")
+		return gitlab.NewClient("", gitlab.WithHTTPClient(transport.NewHTTPClient()), gitlab.WithoutRetries())
 	}
 
 	if common.IsAccessTokenExpired(user.Oauth2AccessTokenExpiresAt) {
@@ -306,7 +710,14 @@ func (gitlabApi *GitlabApi) getClient(gitSource *model.GitSource, user *model.Us
 		}
 	}
 
-	return gitlab.NewOAuthClient(user.Oauth2AccessToken)
+	return gitlab.NewOAuthClient(user.Oauth2AccessToken, gitlab.WithHTTPClient(transport.NewHTTPClient()), gitlab.WithoutRetries())
+}
+
+func wrapError(resp *gitlab.Response, err error) error {
+	if resp == nil {
+		return transport.WrapError(nil, err)
+	}
+	return transport.WrapError(resp.Response, err)
 }
 
 const oauth2AuthorizePath string = "https://gitlab.com/oauth/authorize?client_id=%s&redirect_uri=%s&response_type=code&state=%s&scope=%s"
@@ -314,10 +725,38 @@ const oauth2AuthorizePath string = "https://gitlab.com/oauth/authorize?client_id
 const oauth2AccessTokenPath string = "https://gitlab.com/oauth/token?client_id=%s&client_secret=%s&code=%s&grant_type=authorization_code&redirect_uri=%s"
 const oauth2RefreshTokenPath string = "https://gitlab.com/oauth/token?client_id=%s&client_secret=%s&grant_type=refresh_token&refresh_token=%s"
 
+const webHookTokenHeader string = "X-Gitlab-Token"
+const webHookEventHeader string = "X-Gitlab-Event"
+
 func GetOauth2AuthorizeUrl(gitClientId string, redirectUrl string, state string) string {
 	return fmt.Sprintf(oauth2AuthorizePath, gitClientId, url.QueryEscape(redirectUrl), state, "api%20read_repository%20read_api")
 }
 
+//Gitlab doesn't sign the payload, it sends back the secret token configured in the hook
+func ValidateWebHookSignature(header http.Header, webHookSecret string) bool {
+	token := header.Get(webHookTokenHeader)
+	return subtle.ConstantTimeCompare([]byte(token), []byte(webHookSecret)) == 1
+}
+
+//Events enabled on the system hook, the projects events are always sent and the pushes come from the group hooks
+func GetSystemHookEvents() []string {
+	return []string{}
+}
+
+//Events sent by the group webhook
+func GetWebHookEvents() []string {
+	return []string{"push"}
+}
+
+//Unique id of the delivery, empty if not sent
+func GetWebHookDeliveryID(header http.Header) string {
+	return header.Get("X-Gitlab-Event-UUID")
+}
+
+func GetWebHookEventType(header http.Header) string {
+	return header.Get(webHookEventHeader)
+}
+
 func (gitlabApi *GitlabApi) GetOauth2AccessToken(gitSource *model.GitSource, code string) (*common.Token, error) {
 	client := &http.Client{}
 
@@ -14,3 +14,17 @@ type GitlabUser struct {
 func (user *GitlabUser) HasOwnerPermission() bool {
 	return user.AccessLevel == gitlab.OwnerPermissions
 }
+
+var accessLevelNames = map[gitlab.AccessLevelValue]string{
+	gitlab.OwnerPermissions:         "owner",
+	gitlab.MaintainerPermissions:    "maintainer",
+	gitlab.DeveloperPermissions:     "developer",
+	gitlab.ReporterPermissions:      "reporter",
+	gitlab.GuestPermissions:         "guest",
+	gitlab.MinimalAccessPermissions: "minimal",
+}
+
+//Name of the access level, used by the members mapping
+func (user *GitlabUser) GetRoleName() string {
+	return accessLevelNames[user.AccessLevel]
+}
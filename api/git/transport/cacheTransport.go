@@ -0,0 +1,298 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"wecode.sorint.it/opensource/papagaio-api/config"
+)
+
+//Kind of git api endpoint, every kind has its own TTL
+type EndpointKind string
+
+const (
+	EndpointRepositories  EndpointKind = "repositories"
+	EndpointBranches      EndpointKind = "branches"
+	EndpointMembers       EndpointKind = "members"
+	EndpointContents      EndpointKind = "contents"
+	EndpointOrganizations EndpointKind = "organizations"
+)
+
+//Path fragments of the endpoints of all the providers, the first match is used
+var endpointPatterns = []struct {
+	kind      EndpointKind
+	fragments []string
+}{
+	{EndpointContents, []string{"/contents", "/git/trees/", "/repository/tree", "/files/"}},
+	{EndpointBranches, []string{"/branches"}},
+	{EndpointMembers, []string{"/members", "/teams", "/memberships", "/collaborators", "/permissions"}},
+	{EndpointRepositories, []string{"/repos", "/projects", "/repositories"}},
+	{EndpointOrganizations, []string{"/orgs", "/groups", "/workspaces"}},
+}
+
+//Query parameters of the paginated list calls of all the providers
+var paginationParams = []string{"page", "per_page", "start", "limit"}
+
+type cacheEntry struct {
+	path         string
+	statusCode   int
+	header       http.Header
+	body         []byte
+	etag         string
+	lastModified string
+	validatedAt  time.Time
+	ttl          time.Duration
+}
+
+func (entry *cacheEntry) isFresh() bool {
+	return time.Since(entry.validatedAt) < entry.ttl
+}
+
+func (entry *cacheEntry) makeResponse(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        http.StatusText(entry.statusCode),
+		StatusCode:    entry.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        entry.header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(entry.body)),
+		ContentLength: int64(len(entry.body)),
+		Request:       req,
+	}
+}
+
+//Responses of the GET requests shared by all the git api clients
+type ResponseCache struct {
+	MaxEntries int
+	TTLs       map[EndpointKind]time.Duration
+
+	mutex   sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+func NewResponseCache() *ResponseCache {
+	return &ResponseCache{
+		MaxEntries: config.GetGitCacheMaxEntries(),
+		TTLs: map[EndpointKind]time.Duration{
+			EndpointRepositories:  config.GetGitCacheRepositoriesTTL(),
+			EndpointBranches:      config.GetGitCacheBranchesTTL(),
+			EndpointMembers:       config.GetGitCacheMembersTTL(),
+			EndpointContents:      config.GetGitCacheContentsTTL(),
+			EndpointOrganizations: config.GetGitCacheOrganizationsTTL(),
+		},
+		entries: make(map[string]*cacheEntry),
+	}
+}
+
+var responseCache *ResponseCache
+var responseCacheOnce sync.Once
+
+//The cache is created at the first use, after the configuration is loaded
+func getResponseCache() *ResponseCache {
+	responseCacheOnce.Do(func() {
+		responseCache = NewResponseCache()
+	})
+	return responseCache
+}
+
+//Remove the cached responses of an organization, called when a webhook notifies a change
+func InvalidateCache(gitOrgRef string) {
+	if !config.IsGitCacheEnabled() {
+		return
+	}
+	getResponseCache().Invalidate(gitOrgRef)
+}
+
+//Remove the responses with the organization in the path
+func (cache *ResponseCache) Invalidate(gitOrgRef string) {
+	if len(gitOrgRef) == 0 {
+		return
+	}
+	segment := "/" + strings.ToLower(gitOrgRef)
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	for key, entry := range cache.entries {
+		if strings.HasSuffix(entry.path, segment) || strings.Contains(entry.path, segment+"/") {
+			delete(cache.entries, key)
+		}
+	}
+}
+
+func (cache *ResponseCache) get(key string) *cacheEntry {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	return cache.entries[key]
+}
+
+func (cache *ResponseCache) put(key string, entry *cacheEntry) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if _, ok := cache.entries[key]; !ok && len(cache.entries) >= cache.MaxEntries {
+		cache.evict()
+	}
+	cache.entries[key] = entry
+}
+
+func (cache *ResponseCache) remove(key string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	delete(cache.entries, key)
+}
+
+//Remove the expired responses, the oldest one when none is expired
+func (cache *ResponseCache) evict() {
+	var oldestKey string
+	var oldest *cacheEntry
+	for key, entry := range cache.entries {
+		if !entry.isFresh() {
+			delete(cache.entries, key)
+			continue
+		}
+		if oldest == nil || entry.validatedAt.Before(oldest.validatedAt) {
+			oldestKey = key
+			oldest = entry
+		}
+	}
+
+	if len(cache.entries) >= cache.MaxEntries && oldest != nil {
+		delete(cache.entries, oldestKey)
+	}
+}
+
+/*
+Transport serving the GET requests from the response cache.
+A response is used without asking the git server until its TTL expires, then it's revalidated with a conditional request:
+the 304 responses don't count in the rate limits of the git servers.
+The responses are cached by user, the endpoints without a TTL are never cached.
+The pages of a listing are not cached: a page could be stale compared to the others
+*/
+type CacheTransport struct {
+	Base  http.RoundTripper
+	Cache *ResponseCache
+}
+
+func (cacheTransport *CacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := cacheTransport.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if req.Method != http.MethodGet || isPaginated(req.URL) {
+		return base.RoundTrip(req)
+	}
+	ttl, ok := cacheTransport.Cache.TTLs[getEndpointKind(req.URL)]
+	if !ok || ttl <= 0 {
+		return base.RoundTrip(req)
+	}
+
+	key := cacheKey(req)
+	entry := cacheTransport.Cache.get(key)
+	if entry != nil && entry.isFresh() {
+		return entry.makeResponse(req), nil
+	}
+
+	conditionalReq := req
+	if entry != nil && (len(entry.etag) > 0 || len(entry.lastModified) > 0) {
+		conditionalReq = req.Clone(req.Context())
+		if len(entry.etag) > 0 {
+			conditionalReq.Header.Set("If-None-Match", entry.etag)
+		}
+		if len(entry.lastModified) > 0 {
+			conditionalReq.Header.Set("If-Modified-Since", entry.lastModified)
+		}
+	}
+
+	resp, err := base.RoundTrip(conditionalReq)
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		resp.Body.Close()
+
+		revalidated := *entry
+		revalidated.validatedAt = time.Now()
+		revalidated.ttl = ttl
+		cacheTransport.Cache.put(key, &revalidated)
+
+		return revalidated.makeResponse(req), nil
+	}
+
+	if resp.StatusCode != http.StatusOK || len(resp.Header.Get("Link")) > 0 {
+		cacheTransport.Cache.remove(key)
+		return resp, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	cacheTransport.Cache.put(key, &cacheEntry{
+		path:         strings.ToLower(unescapedPath(req.URL)),
+		statusCode:   resp.StatusCode,
+		header:       resp.Header.Clone(),
+		body:         body,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		validatedAt:  time.Now(),
+		ttl:          ttl,
+	})
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+//Empty if the endpoint must not be cached
+func getEndpointKind(requestURL *url.URL) EndpointKind {
+	path := strings.ToLower(unescapedPath(requestURL))
+	for _, pattern := range endpointPatterns {
+		for _, fragment := range pattern.fragments {
+			if strings.Contains(path, fragment) {
+				return pattern.kind
+			}
+		}
+	}
+
+	return ""
+}
+
+func isPaginated(requestURL *url.URL) bool {
+	query := requestURL.Query()
+	for _, param := range paginationParams {
+		if _, ok := query[param]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+//GitLab escapes the slashes of the group and project paths
+func unescapedPath(requestURL *url.URL) string {
+	path, err := url.PathUnescape(requestURL.EscapedPath())
+	if err != nil {
+		return requestURL.Path
+	}
+	return path
+}
+
+//The responses depend on the permissions of the user, the credentials are part of the key
+func cacheKey(req *http.Request) string {
+	credentials := sha256.Sum256([]byte(req.Header.Get("Authorization") + "\n" + req.Header.Get("Private-Token")))
+	return req.URL.String() + "\n" + req.Header.Get("Accept") + "\n" + hex.EncodeToString(credentials[:])
+}
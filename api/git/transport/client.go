@@ -0,0 +1,18 @@
+package transport
+
+import (
+	"net/http"
+
+	apitransport "wecode.sorint.it/opensource/papagaio-api/api/transport"
+	"wecode.sorint.it/opensource/papagaio-api/config"
+)
+
+//Client for the git api calls, the GET responses are served from the cache when enabled
+func NewHTTPClient() *http.Client {
+	var clientTransport http.RoundTripper = apitransport.NewRetryTransport(http.DefaultTransport, config.GetGitMaxRetries(), config.GetGitRetryMaxDelay())
+	if config.IsGitCacheEnabled() {
+		clientTransport = &CacheTransport{Base: clientTransport, Cache: getResponseCache()}
+	}
+
+	return &http.Client{Transport: clientTransport}
+}
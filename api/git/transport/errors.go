@@ -0,0 +1,59 @@
+package transport
+
+import (
+	"errors"
+	"net/http"
+
+	apitransport "wecode.sorint.it/opensource/papagaio-api/api/transport"
+)
+
+//The resource doesn't exist on the git server
+var ErrNotFound = errors.New("git resource not found")
+
+//The request failed for network errors, 5xx or rate limits, it can succeed later
+var ErrTransient = errors.New("git server temporarily unavailable")
+
+//Keep the original error, errors.Is matches also the kind
+type classifiedError struct {
+	kind error
+	err  error
+}
+
+func (e *classifiedError) Error() string {
+	return e.kind.Error() + ": " + e.err.Error()
+}
+
+func (e *classifiedError) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e *classifiedError) Unwrap() error {
+	return e.err
+}
+
+//Classify the error of a git api call by the response, a missing response is a network error
+func WrapError(resp *http.Response, err error) error {
+	if err == nil || errors.Is(err, ErrNotFound) || errors.Is(err, ErrTransient) {
+		return err
+	}
+
+	if resp == nil {
+		return &classifiedError{kind: ErrTransient, err: err}
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return &classifiedError{kind: ErrNotFound, err: err}
+	}
+	if apitransport.IsTransientStatus(resp) {
+		return &classifiedError{kind: ErrTransient, err: err}
+	}
+
+	return err
+}
+
+//Error of a failed connection to the git server
+func TransientError(err error) error {
+	if err == nil || errors.Is(err, ErrTransient) {
+		return err
+	}
+	return &classifiedError{kind: ErrTransient, err: err}
+}
@@ -0,0 +1,203 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const defaultBaseDelay = time.Second
+
+/*
+Transport shared by the git and Agola api clients.
+The idempotent requests failed for network errors, 5xx or rate limits are sent again:
+the wait honours Retry-After and the rate limit reset headers, otherwise is an exponential backoff with jitter
+*/
+type RetryTransport struct {
+	Base       http.RoundTripper
+	MaxRetries int
+	//A longer wait requested by the server is not done, the failed response is returned
+	MaxDelay  time.Duration
+	BaseDelay time.Duration
+}
+
+func NewRetryTransport(base http.RoundTripper, maxRetries int, maxDelay time.Duration) *RetryTransport {
+	return &RetryTransport{
+		Base:       base,
+		MaxRetries: maxRetries,
+		MaxDelay:   maxDelay,
+		BaseDelay:  defaultBaseDelay,
+	}
+}
+
+func (retryTransport *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := retryTransport.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if !isRetryableRequest(req) {
+		return base.RoundTrip(req)
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := base.RoundTrip(req)
+		if attempt >= retryTransport.MaxRetries || req.Context().Err() != nil || !isRetryableResponse(resp, err) {
+			return resp, err
+		}
+
+		delay, ok := retryTransport.retryDelay(resp, attempt)
+		if !ok {
+			log.Println("api", req.Method, req.URL.Path, "rate limited beyond the max retry delay")
+			return resp, err
+		}
+
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		log.Println("api", req.Method, req.URL.Path, "failed, retry", attempt+1, "in", delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		if req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+type noRetryKey struct{}
+
+//The requests with the returned context are never sent again, for the calls that aren't idempotent even if their method is
+func WithoutRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetryKey{}, true)
+}
+
+//Only the idempotent requests are sent again, the body must be readable more times
+func isRetryableRequest(req *http.Request) bool {
+	if noRetry, _ := req.Context().Value(noRetryKey{}).(bool); noRetry {
+		return false
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+func isRetryableResponse(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	return IsTransientStatus(resp)
+}
+
+//The status of a failure that can succeed later: 5xx or rate limits
+func IsTransientStatus(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	case http.StatusForbidden:
+		return isRateLimited(resp)
+	}
+
+	return false
+}
+
+//GitHub answers 403 for both the primary and the secondary rate limits
+func isRateLimited(resp *http.Response) bool {
+	if len(resp.Header.Get("Retry-After")) > 0 {
+		return true
+	}
+
+	return rateLimitRemaining(resp) == "0"
+}
+
+//GitHub and Gitea send the X-RateLimit headers, GitLab the RateLimit ones
+func rateLimitRemaining(resp *http.Response) string {
+	if remaining := resp.Header.Get("X-RateLimit-Remaining"); len(remaining) > 0 {
+		return remaining
+	}
+	return resp.Header.Get("RateLimit-Remaining")
+}
+
+func rateLimitReset(resp *http.Response) string {
+	if reset := resp.Header.Get("X-RateLimit-Reset"); len(reset) > 0 {
+		return reset
+	}
+	return resp.Header.Get("RateLimit-Reset")
+}
+
+//Return false if the server asks to wait more than the max delay
+func (retryTransport *RetryTransport) retryDelay(resp *http.Response, attempt int) (time.Duration, bool) {
+	if resp != nil {
+		if delay, found := serverDelay(resp); found {
+			if delay > retryTransport.MaxDelay {
+				return 0, false
+			}
+			return delay + jitter(retryTransport.BaseDelay), true
+		}
+	}
+
+	backoff := retryTransport.BaseDelay << uint(attempt)
+	if backoff <= 0 || backoff > retryTransport.MaxDelay {
+		backoff = retryTransport.MaxDelay
+	}
+
+	return backoff/2 + jitter(backoff/2), true
+}
+
+//Wait requested by the Retry-After header or by the reset of the exhausted rate limit
+func serverDelay(resp *http.Response) (time.Duration, bool) {
+	if retryAfter := resp.Header.Get("Retry-After"); len(retryAfter) > 0 {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if date, err := http.ParseTime(retryAfter); err == nil {
+			return nonNegative(time.Until(date)), true
+		}
+	}
+
+	if rateLimitRemaining(resp) == "0" {
+		if reset, err := strconv.ParseInt(rateLimitReset(resp), 10, 64); err == nil {
+			return nonNegative(time.Until(time.Unix(reset, 0))), true
+		}
+	}
+
+	return 0, false
+}
+
+func nonNegative(delay time.Duration) time.Duration {
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"wecode.sorint.it/opensource/papagaio-api/api"
+	"wecode.sorint.it/opensource/papagaio-api/config"
+	"wecode.sorint.it/opensource/papagaio-api/dto"
+)
+
+var agolaVariablesCmd = &cobra.Command{
+	Use: "agolavariables",
+}
+
+var listAgolaVariablesCmd = &cobra.Command{
+	Use: "list",
+	Run: listAgolaVariables,
+}
+
+var setAgolaSecretCmd = &cobra.Command{
+	Use: "set-secret",
+	Run: setAgolaSecret,
+}
+
+var removeAgolaSecretCmd = &cobra.Command{
+	Use: "remove-secret",
+	Run: removeAgolaSecret,
+}
+
+var setAgolaVariableCmd = &cobra.Command{
+	Use: "set-variable",
+	Run: setAgolaVariable,
+}
+
+var removeAgolaVariableCmd = &cobra.Command{
+	Use: "remove-variable",
+	Run: removeAgolaVariable,
+}
+
+var cfgAgolaVariables configAgolaVariables
+
+type configAgolaVariables struct {
+	CommonConfig
+
+	organizationRef string
+	name            string
+	data            map[string]string
+	values          []string
+}
+
+func init() {
+	config.SetupConfig()
+
+	rootCmd.AddCommand(agolaVariablesCmd)
+	agolaVariablesCmd.AddCommand(listAgolaVariablesCmd)
+	agolaVariablesCmd.AddCommand(setAgolaSecretCmd)
+	agolaVariablesCmd.AddCommand(removeAgolaSecretCmd)
+	agolaVariablesCmd.AddCommand(setAgolaVariableCmd)
+	agolaVariablesCmd.AddCommand(removeAgolaVariableCmd)
+
+	AddCommonFlags(agolaVariablesCmd, &cfgAgolaVariables.CommonConfig)
+
+	agolaVariablesCmd.PersistentFlags().StringVar(&cfgAgolaVariables.organizationRef, "organization-ref", "", "agola organization ref")
+	agolaVariablesCmd.PersistentFlags().StringVar(&cfgAgolaVariables.name, "name", "", "secret or variable name")
+	agolaVariablesCmd.PersistentFlags().StringToStringVar(&cfgAgolaVariables.data, "data", nil, "secret data as key=value, can be repeated")
+	agolaVariablesCmd.PersistentFlags().StringArrayVar(&cfgAgolaVariables.values, "value", nil, "variable value as secretname:secretvar, can be repeated")
+}
+
+func (cfg configAgolaVariables) isValid(nameRequired bool) error {
+	if len(cfg.organizationRef) == 0 {
+		return errors.New("organization-ref is required")
+	}
+	if nameRequired && len(cfg.name) == 0 {
+		return errors.New("name is required")
+	}
+
+	return nil
+}
+
+func listAgolaVariables(cmd *cobra.Command, args []string) {
+	checkAgolaVariablesConfig(cmd, false)
+
+	sendAgolaVariablesRequest(cmd, "GET", "", nil)
+}
+
+func setAgolaSecret(cmd *cobra.Command, args []string) {
+	checkAgolaVariablesConfig(cmd, true)
+
+	request := dto.SaveAgolaSecretRequestDto{Data: cfgAgolaVariables.data}
+	if err := request.IsValid(); err != nil {
+		cmd.PrintErrln(err)
+		os.Exit(1)
+	}
+
+	sendAgolaVariablesRequest(cmd, "PUT", "/secrets/"+url.PathEscape(cfgAgolaVariables.name), request)
+}
+
+func removeAgolaSecret(cmd *cobra.Command, args []string) {
+	checkAgolaVariablesConfig(cmd, true)
+
+	sendAgolaVariablesRequest(cmd, "DELETE", "/secrets/"+url.PathEscape(cfgAgolaVariables.name), nil)
+}
+
+func setAgolaVariable(cmd *cobra.Command, args []string) {
+	checkAgolaVariablesConfig(cmd, true)
+
+	request := dto.SaveAgolaVariableRequestDto{Values: make([]dto.AgolaVariableValueDto, 0)}
+	for _, value := range cfgAgolaVariables.values {
+		secretName, secretVar := value, ""
+		if i := strings.Index(value, ":"); i >= 0 {
+			secretName, secretVar = value[:i], value[i+1:]
+		}
+		request.Values = append(request.Values, dto.AgolaVariableValueDto{SecretName: secretName, SecretVar: secretVar})
+	}
+	if err := request.IsValid(); err != nil {
+		cmd.PrintErrln(err)
+		os.Exit(1)
+	}
+
+	sendAgolaVariablesRequest(cmd, "PUT", "/variables/"+url.PathEscape(cfgAgolaVariables.name), request)
+}
+
+func removeAgolaVariable(cmd *cobra.Command, args []string) {
+	checkAgolaVariablesConfig(cmd, true)
+
+	sendAgolaVariablesRequest(cmd, "DELETE", "/variables/"+url.PathEscape(cfgAgolaVariables.name), nil)
+}
+
+func checkAgolaVariablesConfig(cmd *cobra.Command, nameRequired bool) {
+	if err := cfgAgolaVariables.IsAdminUser(); err != nil {
+		cmd.PrintErrln(err.Error())
+		os.Exit(1)
+	}
+
+	if err := cfgAgolaVariables.isValid(nameRequired); err != nil {
+		cmd.PrintErrln(err.Error())
+		os.Exit(1)
+	}
+}
+
+//Send the request and print the secrets and variables of the organization returned by papagaio
+func sendAgolaVariablesRequest(cmd *cobra.Command, method string, path string, request interface{}) {
+	var reqBody io.Reader
+	if request != nil {
+		data, _ := json.Marshal(request)
+		reqBody = strings.NewReader(string(data))
+	}
+
+	client := &http.Client{}
+	URLApi := cfgAgolaVariables.gatewayURL + "/api/agolavariables/" + url.PathEscape(cfgAgolaVariables.organizationRef) + path
+	req, _ := http.NewRequest(method, URLApi, reqBody)
+	req.Header.Add("Authorization", "token "+cfgAgolaVariables.token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		cmd.Println("Error:", err.Error())
+	} else {
+		if !api.IsResponseOK(resp.StatusCode) {
+			body, _ := ioutil.ReadAll(resp.Body)
+			cmd.PrintErrln("Something was wrong! " + string(body))
+			os.Exit(1)
+		}
+
+		var agolaVariables dto.AgolaVariablesDto
+		err = json.NewDecoder(resp.Body).Decode(&agolaVariables)
+		if err != nil {
+			cmd.PrintErrln("Error decoding response:", err.Error())
+			os.Exit(1)
+		}
+
+		data, _ := json.MarshalIndent(agolaVariables, "", "  ")
+		cmd.Println(string(data))
+	}
+}
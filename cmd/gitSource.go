@@ -47,6 +47,9 @@ type configGitSourceCmd struct {
 	agolaClientID         string
 	agolaClientSecret     string
 
+	githubAppID             int64
+	githubAppPrivateKeyPath string
+
 	deleteRemoteSource bool
 }
 
@@ -61,10 +64,12 @@ func init() {
 	AddCommonFlags(gitSourceCmd, &cfgGitSource.CommonConfig)
 
 	gitSourceCmd.PersistentFlags().StringVar(&cfgGitSource.name, "name", "", "gitSource name")
-	gitSourceCmd.PersistentFlags().StringVar(&cfgGitSource.gitType, "type", "", "git type(gitea, github, gitlab)")
+	gitSourceCmd.PersistentFlags().StringVar(&cfgGitSource.gitType, "type", "", "git type(gitea, github, gitlab, bitbucket)")
 	gitSourceCmd.PersistentFlags().StringVar(&cfgGitSource.gitAPIURL, "git-api-url", "", "api url")
 	gitSourceCmd.PersistentFlags().StringVar(&cfgGitSource.gitClientID, "git-client-id", "", "git oauth2 client id")
 	gitSourceCmd.PersistentFlags().StringVar(&cfgGitSource.gitClientSecret, "git-client-secret", "", "git oauth2 client secret")
+	gitSourceCmd.PersistentFlags().Int64Var(&cfgGitSource.githubAppID, "github-app-id", 0, "github app id, the organizations are managed by the app(optional)")
+	gitSourceCmd.PersistentFlags().StringVar(&cfgGitSource.githubAppPrivateKeyPath, "github-app-private-key", "", "path of the github app private key on the papagaio server")
 	gitSourceCmd.PersistentFlags().StringVar(&cfgGitSource.agolaRemoteSourceName, "agola-remotesource", "", "agola remotesource name")
 	gitSourceCmd.PersistentFlags().StringVar(&cfgGitSource.agolaClientID, "agola-client-id", "", "agola oauth2 client id")
 	gitSourceCmd.PersistentFlags().StringVar(&cfgGitSource.agolaClientSecret, "agola-client-secret", "", "agola oauth2 client secret")
@@ -88,6 +93,10 @@ func addGitSource(cmd *cobra.Command, args []string) {
 		AgolaClientID:         &cfgGitSource.agolaClientID,
 		AgolaClientSecret:     &cfgGitSource.agolaClientSecret,
 	}
+	if cfgGitSource.githubAppID > 0 || len(cfgGitSource.githubAppPrivateKeyPath) > 0 {
+		gitSourceRequest.GithubAppID = &cfgGitSource.githubAppID
+		gitSourceRequest.GithubAppPrivateKeyPath = &cfgGitSource.githubAppPrivateKeyPath
+	}
 
 	err := gitSourceRequest.IsValid()
 	if err != nil {
@@ -181,6 +190,12 @@ func updateGitSource(cmd *cobra.Command, args []string) {
 	if len(cfgGitSource.gitClientSecret) != 0 {
 		requestDto.GitClientSecret = &cfgGitSource.gitClientSecret
 	}
+	if cmd.Flags().Changed("github-app-id") {
+		requestDto.GithubAppID = &cfgGitSource.githubAppID
+	}
+	if len(cfgGitSource.githubAppPrivateKeyPath) != 0 {
+		requestDto.GithubAppPrivateKeyPath = &cfgGitSource.githubAppPrivateKeyPath
+	}
 
 	data, _ := json.Marshal(requestDto)
 
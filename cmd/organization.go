@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"wecode.sorint.it/opensource/papagaio-api/api"
+	"wecode.sorint.it/opensource/papagaio-api/config"
+	"wecode.sorint.it/opensource/papagaio-api/dto"
+	"wecode.sorint.it/opensource/papagaio-api/types"
+)
+
+var organizationCmd = &cobra.Command{
+	Use: "organization",
+}
+
+var setCollaboratorsPolicyCmd = &cobra.Command{
+	Use: "set-collaborators-policy",
+	Run: setCollaboratorsPolicy,
+}
+
+var cfgOrganization configOrganization
+
+//The token is the papagaio token of the user, who must be an owner of the git organization
+type configOrganization struct {
+	CommonConfig
+
+	organizationRef     string
+	collaboratorsPolicy string
+}
+
+func init() {
+	config.SetupConfig()
+
+	rootCmd.AddCommand(organizationCmd)
+	organizationCmd.AddCommand(setCollaboratorsPolicyCmd)
+
+	AddCommonFlags(organizationCmd, &cfgOrganization.CommonConfig)
+
+	organizationCmd.PersistentFlags().StringVar(&cfgOrganization.organizationRef, "organization-ref", "", "agola organization ref")
+	setCollaboratorsPolicyCmd.Flags().StringVar(&cfgOrganization.collaboratorsPolicy, "policy", "", "collaborators policy(ignore, member, project)")
+}
+
+func (cfg configOrganization) isValid() error {
+	if len(cfg.token) == 0 {
+		return errors.New("token is required")
+	}
+	if len(cfg.organizationRef) == 0 {
+		return errors.New("organization-ref is required")
+	}
+	if len(cfg.collaboratorsPolicy) == 0 {
+		return errors.New("policy is required")
+	}
+
+	return nil
+}
+
+func setCollaboratorsPolicy(cmd *cobra.Command, args []string) {
+	if err := cfgOrganization.isValid(); err != nil {
+		cmd.PrintErrln(err.Error())
+		os.Exit(1)
+	}
+
+	request := dto.CollaboratorsPolicyDto{CollaboratorsPolicy: types.CollaboratorsPolicy(cfgOrganization.collaboratorsPolicy)}
+	if err := request.IsValid(); err != nil {
+		cmd.PrintErrln(err)
+		os.Exit(1)
+	}
+
+	data, _ := json.Marshal(request)
+	client := &http.Client{}
+	URLApi := cfgOrganization.gatewayURL + "/api/collaboratorspolicy/" + url.PathEscape(cfgOrganization.organizationRef)
+	req, _ := http.NewRequest("PUT", URLApi, strings.NewReader(string(data)))
+	req.Header.Add("Authorization", "Bearer "+cfgOrganization.token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		cmd.PrintErrln("Error:", err.Error())
+		os.Exit(1)
+	}
+
+	if !api.IsResponseOK(resp.StatusCode) {
+		body, _ := ioutil.ReadAll(resp.Body)
+		cmd.PrintErrln("Something was wrong! " + string(body))
+		os.Exit(1)
+	}
+
+	var response dto.CollaboratorsPolicyResponseDto
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		cmd.PrintErrln("Error decoding response:", err.Error())
+		os.Exit(1)
+	}
+	if response.ErrorCode != dto.NoError {
+		cmd.PrintErrln("collaborators policy not changed:", response.ErrorCode)
+		os.Exit(1)
+	}
+
+	cmd.Println("collaborators policy of", cfgOrganization.organizationRef, "set to", response.CollaboratorsPolicy)
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"wecode.sorint.it/opensource/papagaio-api/api"
+	"wecode.sorint.it/opensource/papagaio-api/config"
+	"wecode.sorint.it/opensource/papagaio-api/dto"
+)
+
+var runCmd = &cobra.Command{
+	Use: "run",
+}
+
+var restartRunCmd = &cobra.Command{
+	Use: "restart",
+	Run: restartRun,
+}
+
+var stopRunCmd = &cobra.Command{
+	Use: "stop",
+	Run: stopRun,
+}
+
+var cancelRunCmd = &cobra.Command{
+	Use: "cancel",
+	Run: cancelRun,
+}
+
+var approveTaskCmd = &cobra.Command{
+	Use: "approve",
+	Run: approveTask,
+}
+
+var cfgRun configRun
+
+//The token is the papagaio token of the user, the actions are done with the Agola permissions of the user
+type configRun struct {
+	CommonConfig
+
+	projectRef  string
+	runNumber   uint64
+	fromScratch bool
+	taskID      string
+}
+
+func init() {
+	config.SetupConfig()
+
+	rootCmd.AddCommand(runCmd)
+	runCmd.AddCommand(restartRunCmd)
+	runCmd.AddCommand(stopRunCmd)
+	runCmd.AddCommand(cancelRunCmd)
+	runCmd.AddCommand(approveTaskCmd)
+
+	AddCommonFlags(runCmd, &cfgRun.CommonConfig)
+
+	runCmd.PersistentFlags().StringVar(&cfgRun.projectRef, "project-ref", "", "agola project ID")
+	runCmd.PersistentFlags().Uint64Var(&cfgRun.runNumber, "run-number", 0, "run number")
+	restartRunCmd.Flags().BoolVar(&cfgRun.fromScratch, "from-scratch", false, "restart all the tasks, by default only the failed tasks are restarted")
+	approveTaskCmd.Flags().StringVar(&cfgRun.taskID, "task-id", "", "ID of the task to approve")
+}
+
+func (cfg configRun) isValid(taskRequired bool) error {
+	if len(cfg.token) == 0 {
+		return errors.New("token is required")
+	}
+	if len(cfg.projectRef) == 0 {
+		return errors.New("project-ref is required")
+	}
+	if cfg.runNumber == 0 {
+		return errors.New("run-number is required")
+	}
+	if taskRequired && len(cfg.taskID) == 0 {
+		return errors.New("task-id is required")
+	}
+
+	return nil
+}
+
+func restartRun(cmd *cobra.Command, args []string) {
+	sendRunAction(cmd, dto.RunActionRequestDto{ActionType: dto.RunActionRestart, FromScratch: cfgRun.fromScratch})
+}
+
+func stopRun(cmd *cobra.Command, args []string) {
+	sendRunAction(cmd, dto.RunActionRequestDto{ActionType: dto.RunActionStop})
+}
+
+func cancelRun(cmd *cobra.Command, args []string) {
+	sendRunAction(cmd, dto.RunActionRequestDto{ActionType: dto.RunActionCancel})
+}
+
+func approveTask(cmd *cobra.Command, args []string) {
+	checkRunConfig(cmd, true)
+
+	sendRunRequest(cmd, "/tasks/"+url.PathEscape(cfgRun.taskID)+"/approve", nil)
+	cmd.Println("task approved")
+}
+
+func sendRunAction(cmd *cobra.Command, request dto.RunActionRequestDto) {
+	checkRunConfig(cmd, false)
+
+	body := sendRunRequest(cmd, "/actions", request)
+	cmd.Println(string(body))
+}
+
+func checkRunConfig(cmd *cobra.Command, taskRequired bool) {
+	if err := cfgRun.isValid(taskRequired); err != nil {
+		cmd.PrintErrln(err.Error())
+		os.Exit(1)
+	}
+}
+
+//Send the request for the run and return the response body
+func sendRunRequest(cmd *cobra.Command, path string, request interface{}) []byte {
+	var reqBody io.Reader
+	if request != nil {
+		data, _ := json.Marshal(request)
+		reqBody = strings.NewReader(string(data))
+	}
+
+	client := &http.Client{}
+	URLApi := cfgRun.gatewayURL + "/api/runs/" + url.PathEscape(cfgRun.projectRef) + "/" + strconv.FormatUint(cfgRun.runNumber, 10) + path
+	req, _ := http.NewRequest("POST", URLApi, reqBody)
+	req.Header.Add("Authorization", "Bearer "+cfgRun.token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		cmd.PrintErrln("Error:", err.Error())
+		os.Exit(1)
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if !api.IsResponseOK(resp.StatusCode) {
+		cmd.PrintErrln("Something was wrong! " + string(body))
+		os.Exit(1)
+	}
+
+	return body
+}
@@ -1,9 +1,15 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
@@ -11,11 +17,13 @@ import (
 	"github.com/spf13/cobra"
 	"wecode.sorint.it/opensource/papagaio-api/api/agola"
 	"wecode.sorint.it/opensource/papagaio-api/api/git"
+	"wecode.sorint.it/opensource/papagaio-api/api/git/bitbucket"
 	"wecode.sorint.it/opensource/papagaio-api/api/git/gitea"
 	"wecode.sorint.it/opensource/papagaio-api/api/git/github"
 	"wecode.sorint.it/opensource/papagaio-api/api/git/gitlab"
 	"wecode.sorint.it/opensource/papagaio-api/config"
 	"wecode.sorint.it/opensource/papagaio-api/controller"
+	"wecode.sorint.it/opensource/papagaio-api/manager/webHookManager"
 	"wecode.sorint.it/opensource/papagaio-api/repository"
 	"wecode.sorint.it/opensource/papagaio-api/service"
 	"wecode.sorint.it/opensource/papagaio-api/trigger"
@@ -37,6 +45,10 @@ func init() {
 }
 
 func serve(cmd *cobra.Command, args []string) {
+	if len(config.Config.SecretsEncryptionKey) == 0 {
+		log.Fatal("SecretsEncryptionKey is missing in the configuration, it's required to encrypt the secrets stored in the database")
+	}
+
 	if _, err := os.Stat(config.Config.Database.DbPath); os.IsNotExist(err) {
 		err := os.Mkdir(config.Config.Database.DbPath, os.ModeDir)
 		if err != nil {
@@ -45,16 +57,23 @@ func serve(cmd *cobra.Command, args []string) {
 	}
 
 	db := repository.NewAppDb(config.Config)
+	webHookManager.EncryptLegacyWebHookSecrets(&db)
+
 	tr := utils.ConfigUtils{Db: &db}
 	agolaApi := agola.AgolaApi{Db: &db}
 	gitGateway := git.GitGateway{
-		GiteaApi:  &gitea.GiteaApi{Db: &db},
-		GithubApi: &github.GithubApi{Db: &db},
-		GitlabApi: &gitlab.GitlabApi{Db: &db},
+		GiteaApi:     &gitea.GiteaApi{Db: &db},
+		GithubApi:    &github.GithubApi{Db: &db},
+		GitlabApi:    &gitlab.GitlabApi{Db: &db},
+		BitbucketApi: &bitbucket.BitbucketApi{Db: &db},
 	}
 
 	commonMutex := utils.NewEventMutex()
 
+	//canceled on shutdown: stops the triggers, the webhook queue and the running Agola calls
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	ctrlOrganization := service.OrganizationService{
 		Db:          &db,
 		CommonMutex: &commonMutex,
@@ -74,6 +93,8 @@ func serve(cmd *cobra.Command, args []string) {
 		AgolaApi:    &agolaApi,
 		GitGateway:  &gitGateway,
 	}
+	ctrlWebHook.DeliveryDedup = utils.NewPersistentDeliveryDedupStore(&db, config.Config.WebHookQueue.DeliveryDedupSize, time.Duration(config.Config.WebHookQueue.DeliveryDedupTTL)*time.Minute)
+	ctrlWebHook.WebHookQueue = trigger.StartWebHookQueue(ctx, &db, ctrlWebHook.ProcessWebHookEvent, config.Config.WebHookQueue.Workers, config.Config.WebHookQueue.MaxAttempts, time.Duration(config.Config.WebHookQueue.RetryDelay)*time.Second, config.Config.WebHookQueue.DeliveryHistorySize)
 
 	ctrlTrigger := service.TriggersService{
 		Db:          &db,
@@ -81,6 +102,7 @@ func serve(cmd *cobra.Command, args []string) {
 		CommonMutex: &commonMutex,
 		AgolaApi:    &agolaApi,
 		GitGateway:  &gitGateway,
+		Ctx:         ctx,
 	}
 
 	sd, err := config.InitTokenSigninData(&config.Config.TokenSigning)
@@ -98,13 +120,19 @@ func serve(cmd *cobra.Command, args []string) {
 		AgolaApi: &agolaApi,
 	}
 
+	ctrlRun := service.RunService{
+		Db:         &db,
+		AgolaApi:   &agolaApi,
+		GitGateway: &gitGateway,
+	}
+
 	if config.Config.TriggersConfig.StartOrganizationsTrigger {
 		rtDtoOrganizationSynk := &triggerDto.TriggerRunTimeDto{
 			Chan: make(chan triggerDto.TriggerMessage, 1),
 		}
 
 		ctrlTrigger.RtDtoOrganizationSynk = rtDtoOrganizationSynk
-		trigger.StartOrganizationSync(&db, tr, &commonMutex, &agolaApi, &gitGateway, &ctrlTrigger.RtDtoOrganizationSynk)
+		trigger.StartOrganizationSync(ctx, &db, tr, &commonMutex, &agolaApi, &gitGateway, &ctrlTrigger.RtDtoOrganizationSynk)
 	}
 	if config.Config.TriggersConfig.StartRunFailedTrigger {
 		rtDtoDiscoveryRunFails := &triggerDto.TriggerRunTimeDto{
@@ -112,7 +140,7 @@ func serve(cmd *cobra.Command, args []string) {
 		}
 
 		ctrlTrigger.RtDtoDiscoveryRunFails = rtDtoDiscoveryRunFails
-		trigger.StartRunFailsDiscovery(&db, tr, &commonMutex, &agolaApi, &gitGateway, &ctrlTrigger.RtDtoDiscoveryRunFails)
+		trigger.StartRunFailsDiscovery(ctx, &db, tr, &commonMutex, &agolaApi, &gitGateway, &ctrlTrigger.RtDtoDiscoveryRunFails)
 	}
 	if config.Config.TriggersConfig.StartUsersTrigger {
 		rtDtoUserSynk := &triggerDto.TriggerRunTimeDto{
@@ -120,12 +148,12 @@ func serve(cmd *cobra.Command, args []string) {
 		}
 
 		ctrlTrigger.RtDtoUserSynk = rtDtoUserSynk
-		trigger.StartSynkUsers(&db, tr, &commonMutex, &agolaApi, &gitGateway, &ctrlTrigger.RtDtoUserSynk)
+		trigger.StartSynkUsers(ctx, &db, tr, &commonMutex, &agolaApi, &gitGateway, &ctrlTrigger.RtDtoUserSynk)
 	}
 
 	router := mux.NewRouter()
 
-	controller.SetupRouter(sd, &db, router, &ctrlOrganization, &ctrlGitSource, &ctrlWebHook, &ctrlTrigger, &ctrlOauth2, &ctrlUser)
+	controller.SetupRouter(sd, &db, router, &ctrlOrganization, &ctrlGitSource, &ctrlWebHook, &ctrlTrigger, &ctrlOauth2, &ctrlUser, &ctrlRun)
 
 	log.Println("Papagaio Server Starting on port ", config.Config.Server.Port)
 
@@ -136,9 +164,37 @@ func serve(cmd *cobra.Command, args []string) {
 		logRouter = router
 	}
 
-	if e := http.ListenAndServe(":"+config.Config.Server.Port, cors.AllowAll().Handler(logRouter)); e != nil {
+	server := &http.Server{
+		Addr:    ":" + config.Config.Server.Port,
+		Handler: cors.AllowAll().Handler(logRouter),
+		//the requests are canceled on shutdown
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
+	go waitShutdown(server, cancel)
+
+	if e := server.ListenAndServe(); e != nil && !errors.Is(e, http.ErrServerClosed) {
 		log.Println("http server error:", e)
 	}
 
 	defer db.DB.Close()
 }
+
+const shutdownTimeout = 10 * time.Second
+
+//Wait for SIGINT or SIGTERM, then stop the background work and the http server
+func waitShutdown(server *http.Server, cancel context.CancelFunc) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+
+	log.Println("Papagaio Server shutting down")
+	cancel()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer shutdownCancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("http server shutdown error:", err)
+	}
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/spf13/cobra"
+	"wecode.sorint.it/opensource/papagaio-api/api"
+	"wecode.sorint.it/opensource/papagaio-api/config"
+	"wecode.sorint.it/opensource/papagaio-api/dto"
+)
+
+var webHookCmd = &cobra.Command{
+	Use: "webhook",
+}
+
+var replayWebHookCmd = &cobra.Command{
+	Use: "replay",
+	Run: replayWebHook,
+}
+
+var cfgWebHook configWebHook
+
+type configWebHook struct {
+	CommonConfig
+
+	organizationRef string
+	deliveryID      string
+}
+
+func init() {
+	config.SetupConfig()
+
+	rootCmd.AddCommand(webHookCmd)
+	webHookCmd.AddCommand(replayWebHookCmd)
+
+	AddCommonFlags(webHookCmd, &cfgWebHook.CommonConfig)
+
+	webHookCmd.PersistentFlags().StringVar(&cfgWebHook.organizationRef, "organization-ref", "", "agola organization ref")
+	webHookCmd.PersistentFlags().StringVar(&cfgWebHook.deliveryID, "delivery-id", "", "webhook delivery id")
+}
+
+func (cfg configWebHook) isReplayValid() error {
+	if len(cfg.organizationRef) == 0 {
+		return errors.New("organization-ref is required")
+	}
+	if len(cfg.deliveryID) == 0 {
+		return errors.New("delivery-id is required")
+	}
+
+	return nil
+}
+
+func replayWebHook(cmd *cobra.Command, args []string) {
+	if err := cfgWebHook.IsAdminUser(); err != nil {
+		cmd.PrintErrln(err.Error())
+		os.Exit(1)
+	}
+
+	if err := cfgWebHook.isReplayValid(); err != nil {
+		cmd.PrintErrln(err.Error())
+		os.Exit(1)
+	}
+
+	client := &http.Client{}
+	URLApi := cfgWebHook.gatewayURL + "/api/webhookdeliveries/" + url.PathEscape(cfgWebHook.organizationRef) + "/" + url.PathEscape(cfgWebHook.deliveryID) + "/replay"
+	req, _ := http.NewRequest("POST", URLApi, nil)
+	req.Header.Add("Authorization", "token "+cfgWebHook.token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		cmd.Println("Error:", err.Error())
+	} else {
+		if !api.IsResponseOK(resp.StatusCode) {
+			body, _ := ioutil.ReadAll(resp.Body)
+			cmd.PrintErrln("Something was wrong! " + string(body))
+			os.Exit(1)
+		}
+
+		var delivery dto.WebHookDeliveryDto
+		err = json.NewDecoder(resp.Body).Decode(&delivery)
+		if err != nil {
+			cmd.PrintErrln("Error decoding response:", err.Error())
+			os.Exit(1)
+		}
+
+		cmd.Println("webhook delivery", cfgWebHook.deliveryID, "queued again with id", delivery.ID)
+	}
+}
@@ -0,0 +1,66 @@
+package common
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+var ErrMissingEncryptionKey = errors.New("secrets encryption key not configured")
+
+func newSecretCipher(encryptionKey string) (cipher.AEAD, error) {
+	if len(encryptionKey) == 0 {
+		return nil, ErrMissingEncryptionKey
+	}
+
+	key := sha256.Sum256([]byte(encryptionKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+//Encrypt the value with AES-GCM using a key derived from encryptionKey, the result is the base64 of the nonce and the ciphertext
+func EncryptSecretValue(encryptionKey string, value string) (string, error) {
+	gcm, err := newSecretCipher(encryptionKey)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	encrypted := gcm.Seal(nonce, nonce, []byte(value), nil)
+
+	return base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+func DecryptSecretValue(encryptionKey string, encryptedValue string) (string, error) {
+	gcm, err := newSecretCipher(encryptionKey)
+	if err != nil {
+		return "", err
+	}
+
+	encrypted, err := base64.StdEncoding.DecodeString(encryptedValue)
+	if err != nil {
+		return "", err
+	}
+	if len(encrypted) < gcm.NonceSize() {
+		return "", errors.New("encrypted value too short")
+	}
+
+	nonce := encrypted[:gcm.NonceSize()]
+	value, err := gcm.Open(nil, nonce, encrypted[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(value), nil
+}
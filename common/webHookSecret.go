@@ -0,0 +1,33 @@
+package common
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+const webHookSecretSize int = 32
+
+//Secret shared with the git server, used to sign the webhook deliveries
+func GenerateWebHookSecret() (string, error) {
+	secret := make([]byte, webHookSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(secret), nil
+}
+
+//Compare the hex encoded HMAC-SHA256 of the payload with the signature sent by the git server
+func IsHmacSha256SignatureValid(secret string, payload []byte, signature string) bool {
+	expectedSignature, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return hmac.Equal(mac.Sum(nil), expectedSignature)
+}
@@ -23,11 +23,17 @@ type Configuration struct {
 	Agola AgolaConfig
 	//Papagaio admin token
 	AdminToken string
+	//Key of the encryption of the Agola secrets and webhook secrets stored in the database, required by serve
+	SecretsEncryptionKey string
 
 	//Cmd conficuration
 	CmdConfig CmdConfig
 	//Timers
 	TriggersConfig TriggersConfig
+	//Webhook processing queue
+	WebHookQueue WebHookQueueConfig
+	//Git providers api
+	Git GitConfig
 	// Email configuration
 	Email *EmailConfig
 
@@ -41,11 +47,64 @@ type TriggersConfig struct {
 	StartOrganizationsTrigger       bool
 	StartRunFailedTrigger           bool
 	StartUsersTrigger               bool
+	//Update the runs from the Agola run events stream, the runs polling is done only while the stream is disconnected and after a reconnect
+	RunEvents bool
+}
+
+type WebHookQueueConfig struct {
+	//Number of workers, the events of an organization are always processed by the same worker
+	Workers uint
+	//Attempts before an event is moved to the dead letter state
+	MaxAttempts uint
+	//Delay in seconds before the first retry, doubled at every failed attempt
+	RetryDelay uint
+	//Number of processed deliveries kept in the history of every organization
+	DeliveryHistorySize uint
+	//Max number of delivery ids remembered to discard the deliveries sent again
+	DeliveryDedupSize uint
+	//Minutes a delivery id is remembered
+	DeliveryDedupTTL uint
+	//Max KB of a webhook request body, the bigger deliveries are refused
+	MaxPayloadSize uint
+}
+
+type GitConfig struct {
+	//Number of items requested for every page of the git list calls, all the pages are always read
+	PageSize uint
+	//Retries of the idempotent git api requests failed for network errors, 5xx or rate limits
+	MaxRetries uint
+	//Max seconds waited before a retry, a rate limit reset further in time fails the request
+	RetryMaxDelay uint
+	//Cache of the git api responses
+	Cache GitCacheConfig
+}
+
+//The cached responses are revalidated with ETag or Last-Modified when older than the TTL of their endpoint,
+//the webhooks of an organization remove its responses from the cache. The pages of the list calls are never cached
+type GitCacheConfig struct {
+	//The cache is used only when enabled
+	Enabled bool
+	//Max number of responses kept in the cache
+	MaxEntries uint
+	//Seconds a response is used without asking the git server
+	RepositoriesTTL  uint
+	BranchesTTL      uint
+	MembersTTL       uint
+	ContentsTTL      uint
+	OrganizationsTTL uint
 }
 
 type AgolaConfig struct {
 	AgolaAddr  string
 	AdminToken string
+	//Address of the Agola runservice, the run events stream is an internal runservice api not exposed by the gateway
+	RunserviceAddr string
+	//Max seconds of an Agola api call, retries included
+	Timeout uint
+	//Retries of the idempotent Agola api requests failed for network errors or 5xx
+	MaxRetries uint
+	//Max seconds waited before a retry
+	RetryMaxDelay uint
 }
 
 type DbConfig struct {
@@ -100,6 +159,25 @@ var Config Configuration
 const DefaultOrganizationsDefaultTriggerTime = 5
 const DefaultRunFailedDefaultTriggerTime = 5
 const DefaultUsersDefaultTriggerTime = 1440
+const DefaultWebHookQueueWorkers = 4
+const DefaultWebHookQueueMaxAttempts = 8
+const DefaultWebHookQueueRetryDelay = 5
+const DefaultWebHookQueueDeliveryHistorySize = 100
+const DefaultWebHookQueueDeliveryDedupSize = 10000
+const DefaultWebHookQueueDeliveryDedupTTL = 60
+const DefaultWebHookMaxPayloadSize = 25 * 1024
+const DefaultAgolaTimeout = 30
+const DefaultAgolaMaxRetries = 3
+const DefaultAgolaRetryMaxDelay = 10
+const DefaultGitPageSize = 50
+const DefaultGitMaxRetries = 3
+const DefaultGitRetryMaxDelay = 60
+const DefaultGitCacheMaxEntries = 10000
+const DefaultGitCacheRepositoriesTTL = 300
+const DefaultGitCacheBranchesTTL = 120
+const DefaultGitCacheMembersTTL = 600
+const DefaultGitCacheContentsTTL = 300
+const DefaultGitCacheOrganizationsTTL = 600
 
 func readConfig() {
 	var raw []byte
@@ -136,6 +214,155 @@ func SetupConfig() {
 		log.Println("UsersDefaultTriggerTime non setted correctly..set default value:", DefaultUsersDefaultTriggerTime)
 		Config.TriggersConfig.UsersDefaultTriggerTime = DefaultUsersDefaultTriggerTime
 	}
+
+	if Config.WebHookQueue.Workers <= 0 {
+		log.Println("WebHookQueue.Workers non setted correctly..set default value:", DefaultWebHookQueueWorkers)
+		Config.WebHookQueue.Workers = DefaultWebHookQueueWorkers
+	}
+
+	if Config.WebHookQueue.MaxAttempts <= 0 {
+		log.Println("WebHookQueue.MaxAttempts non setted correctly..set default value:", DefaultWebHookQueueMaxAttempts)
+		Config.WebHookQueue.MaxAttempts = DefaultWebHookQueueMaxAttempts
+	}
+
+	if Config.WebHookQueue.RetryDelay <= 0 {
+		log.Println("WebHookQueue.RetryDelay non setted correctly..set default value:", DefaultWebHookQueueRetryDelay)
+		Config.WebHookQueue.RetryDelay = DefaultWebHookQueueRetryDelay
+	}
+
+	if Config.WebHookQueue.DeliveryHistorySize <= 0 {
+		log.Println("WebHookQueue.DeliveryHistorySize non setted correctly..set default value:", DefaultWebHookQueueDeliveryHistorySize)
+		Config.WebHookQueue.DeliveryHistorySize = DefaultWebHookQueueDeliveryHistorySize
+	}
+
+	if Config.WebHookQueue.DeliveryDedupSize <= 0 {
+		log.Println("WebHookQueue.DeliveryDedupSize non setted correctly..set default value:", DefaultWebHookQueueDeliveryDedupSize)
+		Config.WebHookQueue.DeliveryDedupSize = DefaultWebHookQueueDeliveryDedupSize
+	}
+
+	if Config.WebHookQueue.DeliveryDedupTTL <= 0 {
+		log.Println("WebHookQueue.DeliveryDedupTTL non setted correctly..set default value:", DefaultWebHookQueueDeliveryDedupTTL)
+		Config.WebHookQueue.DeliveryDedupTTL = DefaultWebHookQueueDeliveryDedupTTL
+	}
+
+	if Config.Agola.Timeout <= 0 {
+		log.Println("Agola.Timeout non setted correctly..set default value:", DefaultAgolaTimeout)
+		Config.Agola.Timeout = DefaultAgolaTimeout
+	}
+
+	if Config.Agola.MaxRetries <= 0 {
+		log.Println("Agola.MaxRetries non setted correctly..set default value:", DefaultAgolaMaxRetries)
+		Config.Agola.MaxRetries = DefaultAgolaMaxRetries
+	}
+
+	if Config.Agola.RetryMaxDelay <= 0 {
+		log.Println("Agola.RetryMaxDelay non setted correctly..set default value:", DefaultAgolaRetryMaxDelay)
+		Config.Agola.RetryMaxDelay = DefaultAgolaRetryMaxDelay
+	}
+
+	if Config.Git.PageSize <= 0 {
+		log.Println("Git.PageSize non setted correctly..set default value:", DefaultGitPageSize)
+		Config.Git.PageSize = DefaultGitPageSize
+	}
+
+	if Config.Git.MaxRetries <= 0 {
+		log.Println("Git.MaxRetries non setted correctly..set default value:", DefaultGitMaxRetries)
+		Config.Git.MaxRetries = DefaultGitMaxRetries
+	}
+
+	if Config.Git.RetryMaxDelay <= 0 {
+		log.Println("Git.RetryMaxDelay non setted correctly..set default value:", DefaultGitRetryMaxDelay)
+		Config.Git.RetryMaxDelay = DefaultGitRetryMaxDelay
+	}
+}
+
+//Max bytes of a webhook request body
+func GetWebHookMaxPayloadSize() int64 {
+	if Config.WebHookQueue.MaxPayloadSize <= 0 {
+		return DefaultWebHookMaxPayloadSize * 1024
+	}
+	return int64(Config.WebHookQueue.MaxPayloadSize) * 1024
+}
+
+func GetAgolaTimeout() time.Duration {
+	if Config.Agola.Timeout <= 0 {
+		return DefaultAgolaTimeout * time.Second
+	}
+	return time.Duration(Config.Agola.Timeout) * time.Second
+}
+
+func GetAgolaMaxRetries() int {
+	if Config.Agola.MaxRetries <= 0 {
+		return DefaultAgolaMaxRetries
+	}
+	return int(Config.Agola.MaxRetries)
+}
+
+func GetAgolaRetryMaxDelay() time.Duration {
+	if Config.Agola.RetryMaxDelay <= 0 {
+		return DefaultAgolaRetryMaxDelay * time.Second
+	}
+	return time.Duration(Config.Agola.RetryMaxDelay) * time.Second
+}
+
+//Page size of the git list calls, the default is used when the configuration is not loaded
+func GetGitPageSize() int {
+	if Config.Git.PageSize <= 0 {
+		return DefaultGitPageSize
+	}
+	return int(Config.Git.PageSize)
+}
+
+func GetGitMaxRetries() int {
+	if Config.Git.MaxRetries <= 0 {
+		return DefaultGitMaxRetries
+	}
+	return int(Config.Git.MaxRetries)
+}
+
+func GetGitRetryMaxDelay() time.Duration {
+	if Config.Git.RetryMaxDelay <= 0 {
+		return DefaultGitRetryMaxDelay * time.Second
+	}
+	return time.Duration(Config.Git.RetryMaxDelay) * time.Second
+}
+
+func IsGitCacheEnabled() bool {
+	return Config.Git.Cache.Enabled
+}
+
+func GetGitCacheMaxEntries() int {
+	if Config.Git.Cache.MaxEntries <= 0 {
+		return DefaultGitCacheMaxEntries
+	}
+	return int(Config.Git.Cache.MaxEntries)
+}
+
+func GetGitCacheRepositoriesTTL() time.Duration {
+	return cacheTTL(Config.Git.Cache.RepositoriesTTL, DefaultGitCacheRepositoriesTTL)
+}
+
+func GetGitCacheBranchesTTL() time.Duration {
+	return cacheTTL(Config.Git.Cache.BranchesTTL, DefaultGitCacheBranchesTTL)
+}
+
+func GetGitCacheMembersTTL() time.Duration {
+	return cacheTTL(Config.Git.Cache.MembersTTL, DefaultGitCacheMembersTTL)
+}
+
+func GetGitCacheContentsTTL() time.Duration {
+	return cacheTTL(Config.Git.Cache.ContentsTTL, DefaultGitCacheContentsTTL)
+}
+
+func GetGitCacheOrganizationsTTL() time.Duration {
+	return cacheTTL(Config.Git.Cache.OrganizationsTTL, DefaultGitCacheOrganizationsTTL)
+}
+
+func cacheTTL(seconds uint, defaultSeconds uint) time.Duration {
+	if seconds <= 0 {
+		return time.Duration(defaultSeconds) * time.Second
+	}
+	return time.Duration(seconds) * time.Second
 }
 
 func InitTokenSigninData(tokenSigning *TokenSigning) (*common.TokenSigningData, error) {
@@ -11,6 +11,15 @@ type OrganizationController interface {
 	AddExternalUser(w http.ResponseWriter, r *http.Request)
 	GetExternalUsers(w http.ResponseWriter, r *http.Request)
 	RemoveExternalUser(w http.ResponseWriter, r *http.Request)
+	GetMembersMapping(w http.ResponseWriter, r *http.Request)
+	SaveMembersMapping(w http.ResponseWriter, r *http.Request)
+	GetMembersPlan(w http.ResponseWriter, r *http.Request)
+	SaveCollaboratorsPolicy(w http.ResponseWriter, r *http.Request)
+	GetAgolaVariables(w http.ResponseWriter, r *http.Request)
+	SaveAgolaSecret(w http.ResponseWriter, r *http.Request)
+	RemoveAgolaSecret(w http.ResponseWriter, r *http.Request)
+	SaveAgolaVariable(w http.ResponseWriter, r *http.Request)
+	RemoveAgolaVariable(w http.ResponseWriter, r *http.Request)
 	GetReport(w http.ResponseWriter, r *http.Request)
 	GetOrganizationReport(w http.ResponseWriter, r *http.Request)
 	GetProjectReport(w http.ResponseWriter, r *http.Request)
@@ -19,6 +28,10 @@ type OrganizationController interface {
 
 type WebHookController interface {
 	WebHookOrganization(w http.ResponseWriter, r *http.Request)
+	WebHookGitSource(w http.ResponseWriter, r *http.Request)
+	GetWebHookDeliveries(w http.ResponseWriter, r *http.Request)
+	ReplayWebHookDelivery(w http.ResponseWriter, r *http.Request)
+	GetWebHookDedupStats(w http.ResponseWriter, r *http.Request)
 }
 
 type GitSourceController interface {
@@ -47,3 +60,9 @@ type UserController interface {
 	ChangeUserRole(w http.ResponseWriter, r *http.Request)
 	GetAllAgolaRunningRuns(w http.ResponseWriter, r *http.Request)
 }
+
+type RunController interface {
+	RunAction(w http.ResponseWriter, r *http.Request)
+	ApproveTask(w http.ResponseWriter, r *http.Request)
+	StreamLogs(w http.ResponseWriter, r *http.Request)
+}
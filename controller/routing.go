@@ -40,7 +40,17 @@ func GetWebHookPath() string {
 	return apiPath + WebHookPath
 }
 
-func SetupRouter(signingData *common.TokenSigningData, database repository.Database, router *mux.Router, ctrlOrganization OrganizationController, ctrlGitSource GitSourceController, ctrlWebHook WebHookController, ctrlTrigger TriggersController, ctrlOauth2 Oauth2Controller, ctrlUser UserController) {
+//Url called by the git provider for the organization events
+func GetWebHookURL(organizationRef string) string {
+	return config.Config.Server.ApiExposedURL + GetWebHookPath() + "/" + organizationRef
+}
+
+//Endpoint of the hooks that send the events of all the organizations of the git source
+func GetGitSourceWebHookURL(gitSourceName string) string {
+	return config.Config.Server.ApiExposedURL + GetWebHookPath() + "/gitsource/" + gitSourceName
+}
+
+func SetupRouter(signingData *common.TokenSigningData, database repository.Database, router *mux.Router, ctrlOrganization OrganizationController, ctrlGitSource GitSourceController, ctrlWebHook WebHookController, ctrlTrigger TriggersController, ctrlOauth2 Oauth2Controller, ctrlUser UserController, ctrlRun RunController) {
 	db = database
 	sd = signingData
 
@@ -57,6 +67,11 @@ func SetupRouter(signingData *common.TokenSigningData, database repository.Datab
 	setupAddOrganizationExternalUserEndpoint(apirouter.PathPrefix("/addexternaluser").Subrouter(), ctrlOrganization)
 	setupGetOrganizationExternalUsersEndpoint(apirouter.PathPrefix("/getexternalusers").Subrouter(), ctrlOrganization)
 	setupDeleteOrganizationExternalUserEndpoint(apirouter.PathPrefix("/deleteexternaluser").Subrouter(), ctrlOrganization)
+	setupGetMembersMappingEndpoint(apirouter.PathPrefix("/membersmapping").Subrouter(), ctrlOrganization)
+	setupSaveMembersMappingEndpoint(apirouter.PathPrefix("/membersmapping").Subrouter(), ctrlOrganization)
+	setupGetMembersPlanEndpoint(apirouter.PathPrefix("/membersplan").Subrouter(), ctrlOrganization)
+	setupSaveCollaboratorsPolicyEndpoint(apirouter.PathPrefix("/collaboratorspolicy").Subrouter(), ctrlOrganization)
+	setupAgolaVariablesEndpoints(apirouter.PathPrefix("/agolavariables").Subrouter(), ctrlOrganization)
 	setupReportEndpoint(apirouter.PathPrefix("/report").Subrouter(), ctrlOrganization)
 	setupOrganizationReportEndpoint(apirouter.PathPrefix("/report").Subrouter(), ctrlOrganization)
 	setupProjectReportEndpoint(apirouter.PathPrefix("/report").Subrouter(), ctrlOrganization)
@@ -69,6 +84,9 @@ func SetupRouter(signingData *common.TokenSigningData, database repository.Datab
 	setupGetGitOrganizations(apirouter.PathPrefix("/gitorganizations").Subrouter(), ctrlGitSource)
 
 	setupWebHookEndpoint(apirouter.PathPrefix(WebHookPath).Subrouter(), ctrlWebHook)
+	setupGetWebHookDeliveriesEndpoint(apirouter.PathPrefix("/webhookdeliveries").Subrouter(), ctrlWebHook)
+	setupReplayWebHookDeliveryEndpoint(apirouter.PathPrefix("/webhookdeliveries").Subrouter(), ctrlWebHook)
+	setupGetWebHookDedupStatsEndpoint(apirouter.PathPrefix("/webhookdedup").Subrouter(), ctrlWebHook)
 
 	setupGetTriggersConfigEndpoint(apirouter.PathPrefix("/gettriggersconfig").Subrouter(), ctrlTrigger)
 	setupSaveTriggersConfigEndpoint(apirouter.PathPrefix("/savetriggersconfig").Subrouter(), ctrlTrigger)
@@ -83,6 +101,8 @@ func SetupRouter(signingData *common.TokenSigningData, database repository.Datab
 	setupChangeUserRole(apirouter.PathPrefix("/changeuserrole").Subrouter(), ctrlUser)
 	setupGetAllRunningRuns(apirouter.PathPrefix("/agolarunningruns").Subrouter(), ctrlUser)
 
+	setupRunEndpoints(apirouter.PathPrefix("/runs").Subrouter(), ctrlRun)
+
 	router.PathPrefix("/").HandlerFunc(NewWebBundleHandlerFunc(config.Config.Server.ApiExposedURL + config.Config.Server.ApiBasePath))
 }
 
@@ -127,6 +147,35 @@ func setupDeleteOrganizationExternalUserEndpoint(router *mux.Router, ctrl Organi
 	router.HandleFunc("/{organizationRef}", ctrl.RemoveExternalUser).Methods("DELETE")
 }
 
+func setupGetMembersMappingEndpoint(router *mux.Router, ctrl OrganizationController) {
+	router.Use(handleLoggedUserRoutes)
+	router.HandleFunc("/{organizationRef}", ctrl.GetMembersMapping).Methods("GET")
+}
+
+func setupSaveMembersMappingEndpoint(router *mux.Router, ctrl OrganizationController) {
+	router.Use(handleLoggedUserRoutes)
+	router.HandleFunc("/{organizationRef}", ctrl.SaveMembersMapping).Methods("PUT")
+}
+
+func setupGetMembersPlanEndpoint(router *mux.Router, ctrl OrganizationController) {
+	router.Use(handleLoggedUserRoutes)
+	router.HandleFunc("/{organizationRef}", ctrl.GetMembersPlan).Methods("GET")
+}
+
+func setupSaveCollaboratorsPolicyEndpoint(router *mux.Router, ctrl OrganizationController) {
+	router.Use(handleLoggedUserRoutes)
+	router.HandleFunc("/{organizationRef}", ctrl.SaveCollaboratorsPolicy).Methods("PUT")
+}
+
+func setupAgolaVariablesEndpoints(router *mux.Router, ctrl OrganizationController) {
+	router.Use(handleRestrictedAdminRoutes)
+	router.HandleFunc("/{organizationRef}", ctrl.GetAgolaVariables).Methods("GET")
+	router.HandleFunc("/{organizationRef}/secrets/{secretName}", ctrl.SaveAgolaSecret).Methods("PUT")
+	router.HandleFunc("/{organizationRef}/secrets/{secretName}", ctrl.RemoveAgolaSecret).Methods("DELETE")
+	router.HandleFunc("/{organizationRef}/variables/{variableName}", ctrl.SaveAgolaVariable).Methods("PUT")
+	router.HandleFunc("/{organizationRef}/variables/{variableName}", ctrl.RemoveAgolaVariable).Methods("DELETE")
+}
+
 func setupReportEndpoint(router *mux.Router, ctrl OrganizationController) {
 	router.Use(handleLoggedUserRoutes)
 	router.HandleFunc("", ctrl.GetReport).Methods("GET")
@@ -168,6 +217,22 @@ func setupGetGitOrganizations(router *mux.Router, ctrl GitSourceController) {
 
 func setupWebHookEndpoint(router *mux.Router, ctrl WebHookController) {
 	router.HandleFunc("/{organizationRef}", ctrl.WebHookOrganization).Methods("POST")
+	router.HandleFunc("/gitsource/{gitSourceName}", ctrl.WebHookGitSource).Methods("POST")
+}
+
+func setupGetWebHookDeliveriesEndpoint(router *mux.Router, ctrl WebHookController) {
+	router.Use(handleRestrictedAdminRoutes)
+	router.HandleFunc("/{organizationRef}", ctrl.GetWebHookDeliveries).Methods("GET")
+}
+
+func setupReplayWebHookDeliveryEndpoint(router *mux.Router, ctrl WebHookController) {
+	router.Use(handleRestrictedAdminRoutes)
+	router.HandleFunc("/{organizationRef}/{deliveryId}/replay", ctrl.ReplayWebHookDelivery).Methods("POST")
+}
+
+func setupGetWebHookDedupStatsEndpoint(router *mux.Router, ctrl WebHookController) {
+	router.Use(handleRestrictedAdminRoutes)
+	router.HandleFunc("", ctrl.GetWebHookDedupStats).Methods("GET")
 }
 
 func setupGetTriggersConfigEndpoint(router *mux.Router, ctrl TriggersController) {
@@ -223,6 +288,13 @@ func setupGetAllRunningRuns(router *mux.Router, ctrl UserController) {
 	router.HandleFunc("", ctrl.GetAllAgolaRunningRuns).Methods("GET")
 }
 
+func setupRunEndpoints(router *mux.Router, ctrl RunController) {
+	router.Use(handleLoggedUserRoutes)
+	router.HandleFunc("/{projectRef}/{runNumber}/actions", ctrl.RunAction).Methods("POST")
+	router.HandleFunc("/{projectRef}/{runNumber}/tasks/{taskId}/approve", ctrl.ApproveTask).Methods("POST")
+	router.HandleFunc("/{projectRef}/{runNumber}/tasks/{taskId}/steps/{step}/logs", ctrl.StreamLogs).Methods("GET")
+}
+
 func handleLoggedUserWithAdminRoleRoutes(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer r.Body.Close()
@@ -98,6 +98,291 @@ var doc = `{
                 }
             }
         },
+        "/agolavariables/{organizationRef}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyToken": []
+                    }
+                ],
+                "description": "Return the secrets and variables added to every Agola project of the organization, the values of the secrets are not returned",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organization"
+                ],
+                "summary": "Get the Agola secrets and variables",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization Name",
+                        "name": "organizationRef",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/dto.AgolaVariablesDto"
+                        }
+                    },
+                    "404": {
+                        "description": "not found"
+                    }
+                }
+            }
+        },
+        "/agolavariables/{organizationRef}/secrets/{secretName}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyToken": []
+                    }
+                ],
+                "description": "Add or replace a secret of the Agola projects of the organization, the values are stored encrypted and applied by the organizations synk",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organization"
+                ],
+                "summary": "Save an Agola secret",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization Name",
+                        "name": "organizationRef",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Secret Name",
+                        "name": "secretName",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Secret data",
+                        "name": "secret",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SaveAgolaSecretRequestDto"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/dto.AgolaVariablesDto"
+                        }
+                    },
+                    "404": {
+                        "description": "not found"
+                    },
+                    "422": {
+                        "description": "invalid secret"
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyToken": []
+                    }
+                ],
+                "description": "Remove the secret from the organization, it is deleted from the Agola projects by the organizations synk. A secret used by a variable can't be removed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organization"
+                ],
+                "summary": "Remove an Agola secret",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization Name",
+                        "name": "organizationRef",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Secret Name",
+                        "name": "secretName",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/dto.AgolaVariablesDto"
+                        }
+                    },
+                    "404": {
+                        "description": "not found"
+                    },
+                    "422": {
+                        "description": "secret used"
+                    }
+                }
+            }
+        },
+        "/agolavariables/{organizationRef}/variables/{variableName}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyToken": []
+                    }
+                ],
+                "description": "Add or replace a variable of the Agola projects of the organization, applied by the organizations synk",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organization"
+                ],
+                "summary": "Save an Agola variable",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization Name",
+                        "name": "organizationRef",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Variable Name",
+                        "name": "variableName",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Variable values",
+                        "name": "variable",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SaveAgolaVariableRequestDto"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/dto.AgolaVariablesDto"
+                        }
+                    },
+                    "404": {
+                        "description": "not found"
+                    },
+                    "422": {
+                        "description": "invalid variable"
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyToken": []
+                    }
+                ],
+                "description": "Remove the variable from the organization, it is deleted from the Agola projects by the organizations synk",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organization"
+                ],
+                "summary": "Remove an Agola variable",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization Name",
+                        "name": "organizationRef",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Variable Name",
+                        "name": "variableName",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/dto.AgolaVariablesDto"
+                        }
+                    },
+                    "404": {
+                        "description": "not found"
+                    }
+                }
+            }
+        },
+        "/collaboratorspolicy/{organizationRef}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyToken": []
+                    }
+                ],
+                "description": "Set how the repository collaborators are synchronized: ignore, member (Agola organization members) or project (access to the runs of the projects of their repositories). Applied by the next members synk, an Agola owner is never downgraded",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organization"
+                ],
+                "summary": "Save the collaborators policy",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization Name",
+                        "name": "organizationRef",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Collaborators policy",
+                        "name": "collaboratorsPolicy",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CollaboratorsPolicyDto"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CollaboratorsPolicyResponseDto"
+                        }
+                    },
+                    "404": {
+                        "description": "not found"
+                    },
+                    "422": {
+                        "description": "invalid policy"
+                    }
+                }
+            }
+        },
         "/createorganization": {
             "post": {
                 "security": [
@@ -461,27 +746,27 @@ var doc = `{
                 }
             }
         },
-        "/report": {
+        "/membersmapping/{organizationRef}": {
             "get": {
                 "security": [
                     {
                         "ApiKeyToken": []
                     }
                 ],
-                "description": "Obtain a full report of all organizations. If the \"onlyowner\" query parameter is specified, only the organizations the user owns will be listed.",
+                "description": "Return the Agola roles of the git teams and roles and the Agola users ignored by the members synk",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Organization"
                 ],
-                "summary": "Get Report",
+                "summary": "Get the members mapping",
                 "parameters": [
                     {
-                        "type": "boolean",
-                        "description": "?onlyowner",
-                        "name": "onlyowner",
-                        "in": "query",
+                        "type": "string",
+                        "description": "Organization Name",
+                        "name": "organizationRef",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -489,9 +774,130 @@ var doc = `{
                     "200": {
                         "description": "ok",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/dto.OrganizationDto"
+                            "$ref": "#/definitions/dto.MembersMappingResponseDto"
+                        }
+                    },
+                    "404": {
+                        "description": "not found"
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyToken": []
+                    }
+                ],
+                "description": "Set the Agola roles (owner, member or none) of the git teams and, separately, of the git roles and the Agola users ignored by the members synk. The members without a mapped team or git role keep the default rule",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organization"
+                ],
+                "summary": "Save the members mapping",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization Name",
+                        "name": "organizationRef",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Members mapping",
+                        "name": "membersMapping",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.MembersMappingDto"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MembersMappingResponseDto"
+                        }
+                    },
+                    "404": {
+                        "description": "not found"
+                    },
+                    "422": {
+                        "description": "invalid mapping"
+                    }
+                }
+            }
+        },
+        "/membersplan/{organizationRef}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyToken": []
+                    }
+                ],
+                "description": "Return the Agola members the synk would add, update and remove, nothing is applied",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organization"
+                ],
+                "summary": "Members synk dry-run",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization Name",
+                        "name": "organizationRef",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MembersPlanResponseDto"
+                        }
+                    },
+                    "404": {
+                        "description": "not found"
+                    }
+                }
+            }
+        },
+        "/report": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyToken": []
+                    }
+                ],
+                "description": "Obtain a full report of all organizations. If the \"onlyowner\" query parameter is specified, only the organizations the user owns will be listed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organization"
+                ],
+                "summary": "Get Report",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "?onlyowner",
+                        "name": "onlyowner",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.OrganizationDto"
                             }
                         }
                     }
@@ -627,6 +1033,180 @@ var doc = `{
                 }
             }
         },
+        "/runs/{projectRef}/{runNumber}/actions": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyToken": []
+                    }
+                ],
+                "description": "Send the action to Agola with the token of the user, the Agola permissions of the user apply. The restart returns the new run",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Runs"
+                ],
+                "summary": "Restart, stop or cancel a run",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Agola project ID",
+                        "name": "projectRef",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Run number",
+                        "name": "runNumber",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Run action",
+                        "name": "action",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RunActionRequestDto"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/agola.RunDto"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden"
+                    },
+                    "404": {
+                        "description": "not found"
+                    },
+                    "422": {
+                        "description": "invalid action"
+                    }
+                }
+            }
+        },
+        "/runs/{projectRef}/{runNumber}/tasks/{taskId}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyToken": []
+                    }
+                ],
+                "description": "Approve a task waiting for approval with the token of the user, the Agola permissions of the user apply",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Runs"
+                ],
+                "summary": "Approve a task",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Agola project ID",
+                        "name": "projectRef",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Run number",
+                        "name": "runNumber",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Task ID",
+                        "name": "taskId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok"
+                    },
+                    "403": {
+                        "description": "forbidden"
+                    },
+                    "404": {
+                        "description": "not found"
+                    }
+                }
+            }
+        },
+        "/runs/{projectRef}/{runNumber}/tasks/{taskId}/steps/{step}/logs": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyToken": []
+                    }
+                ],
+                "description": "Server-sent events with the log lines of the step, followed while the step runs. The event id is the line number: on reconnect the lines up to the Last-Event-ID header are skipped. The end event is sent when the step is finished",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Runs"
+                ],
+                "summary": "Stream the logs of a step",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Agola project ID",
+                        "name": "projectRef",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Run number",
+                        "name": "runNumber",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Task ID",
+                        "name": "taskId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Step number or setup",
+                        "name": "step",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Last line received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok"
+                    },
+                    "403": {
+                        "description": "forbidden"
+                    },
+                    "404": {
+                        "description": "not found"
+                    }
+                }
+            }
+        },
         "/savetriggersconfig": {
             "post": {
                 "security": [
@@ -777,25 +1357,365 @@ var doc = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "dto.BranchDto": {
-            "type": "object",
-            "properties": {
-                "lastFailedRunDate": {
-                    "type": "string"
-                },
-                "lastFailedRunURL": {
-                    "type": "string"
-                },
-                "lastRunDuration": {
-                    "type": "integer"
-                },
-                "lastSuccessRunDate": {
-                    "type": "string"
-                },
-                "lastSuccessRunURL": {
+        },
+        "/webhookdedup": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyToken": []
+                    }
+                ],
+                "description": "Return the number of deliveries received and of the duplicated deliveries discarded since the start",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "WebHook"
+                ],
+                "summary": "Return the webhook deliveries deduplication counters",
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/dto.WebHookDedupStatsDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhookdeliveries/{organizationRef}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyToken": []
+                    }
+                ],
+                "description": "Return the webhook deliveries received for the organization, from the oldest to the newest",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "WebHook"
+                ],
+                "summary": "Return the webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization Name",
+                        "name": "organizationRef",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.WebHookDeliveryDto"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "not found"
+                    }
+                }
+            }
+        },
+        "/webhookdeliveries/{organizationRef}/{deliveryId}/replay": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyToken": []
+                    }
+                ],
+                "description": "Queue again the payload of a webhook delivery, it is processed as a new delivery",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "WebHook"
+                ],
+                "summary": "Replay a webhook delivery",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization Name",
+                        "name": "organizationRef",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delivery ID",
+                        "name": "deliveryId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "accepted",
+                        "schema": {
+                            "$ref": "#/definitions/dto.WebHookDeliveryDto"
+                        }
+                    },
+                    "404": {
+                        "description": "not found"
+                    },
+                    "422": {
+                        "description": "delivery pending"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "agola.RunDto": {
+            "type": "object",
+            "properties": {
+                "annotations": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "can_restart_from_failed_tasks": {
+                    "type": "boolean"
+                },
+                "can_restart_from_scratch": {
+                    "type": "boolean"
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "enqueue_time": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "integer"
+                },
+                "phase": {
+                    "type": "string"
+                },
+                "result": {
+                    "type": "string"
+                },
+                "setup_errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "stopping": {
+                    "type": "boolean"
+                },
+                "tasks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/agola.TaskDto"
+                    }
+                },
+                "tasks_waiting_approval": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "agola.RunTaskResponseContainer": {
+            "type": "object",
+            "properties": {
+                "image": {
+                    "type": "string"
+                }
+            }
+        },
+        "agola.RunTaskResponseSetupStep": {
+            "type": "object",
+            "properties": {
+                "end_time": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phase": {
+                    "type": "string"
+                },
+                "start_time": {
+                    "type": "string"
+                }
+            }
+        },
+        "agola.RunTaskResponseStep": {
+            "type": "object",
+            "properties": {
+                "command": {
+                    "type": "string"
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "exit_status": {
+                    "type": "integer"
+                },
+                "log_archived": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phase": {
+                    "type": "string"
+                },
+                "shell": {
+                    "type": "string"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "agola.TaskDto": {
+            "type": "object",
+            "properties": {
+                "approval_annotations": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "approved": {
+                    "type": "boolean"
+                },
+                "containers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/agola.RunTaskResponseContainer"
+                    }
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "setup_step": {
+                    "$ref": "#/definitions/agola.RunTaskResponseSetupStep"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/agola.RunTaskResponseStep"
+                    }
+                },
+                "task_timeout_interval": {
+                    "type": "integer"
+                },
+                "timedout": {
+                    "type": "boolean"
+                },
+                "waiting_approval": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.AgolaSecretDto": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.AgolaVariableDto": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "values": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.AgolaVariableValueDto"
+                    }
+                }
+            }
+        },
+        "dto.AgolaVariableValueDto": {
+            "type": "object",
+            "properties": {
+                "secretName": {
+                    "type": "string"
+                },
+                "secretVar": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.AgolaVariablesDto": {
+            "type": "object",
+            "properties": {
+                "revision": {
+                    "type": "integer"
+                },
+                "secrets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.AgolaSecretDto"
+                    }
+                },
+                "variables": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.AgolaVariableDto"
+                    }
+                }
+            }
+        },
+        "dto.BranchDto": {
+            "type": "object",
+            "properties": {
+                "lastFailedRunDate": {
+                    "type": "string"
+                },
+                "lastFailedRunURL": {
+                    "type": "string"
+                },
+                "lastRunDuration": {
+                    "type": "integer"
+                },
+                "lastSuccessRunDate": {
+                    "type": "string"
+                },
+                "lastSuccessRunURL": {
                     "type": "string"
                 },
                 "name": {
@@ -810,6 +1730,26 @@ var doc = `{
                 }
             }
         },
+        "dto.CollaboratorsPolicyDto": {
+            "type": "object",
+            "properties": {
+                "collaboratorsPolicy": {
+                    "type": "string",
+                    "example": "member"
+                }
+            }
+        },
+        "dto.CollaboratorsPolicyResponseDto": {
+            "type": "object",
+            "properties": {
+                "collaboratorsPolicy": {
+                    "type": "string"
+                },
+                "errorCode": {
+                    "type": "string"
+                }
+            }
+        },
         "dto.ConfigTriggersDto": {
             "type": "object",
             "properties": {
@@ -848,6 +1788,13 @@ var doc = `{
                 "gitType": {
                     "type": "string"
                 },
+                "githubAppId": {
+                    "description": "Optional, the organizations of a github gitSource are managed by the GitHub App",
+                    "type": "integer"
+                },
+                "githubAppPrivateKeyPath": {
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
                 }
@@ -868,9 +1815,22 @@ var doc = `{
                 "behaviourType": {
                     "type": "string"
                 },
+                "collaboratorsPolicy": {
+                    "description": "How the repository collaborators are synchronized: ignore, member or project, ignore by default",
+                    "type": "string",
+                    "example": "ignore"
+                },
                 "gitPath": {
                     "type": "string"
                 },
+                "includeSubgroups": {
+                    "description": "Add also the repositories of the descendant subgroups, only for the git providers with nested groups",
+                    "type": "boolean"
+                },
+                "userNamespace": {
+                    "description": "The gitPath is the login of the user, the repositories of the personal namespace are added",
+                    "type": "boolean"
+                },
                 "visibility": {
                     "type": "string"
                 }
@@ -920,6 +1880,94 @@ var doc = `{
                 }
             }
         },
+        "dto.MemberChangeDto": {
+            "type": "object",
+            "properties": {
+                "agolaUserRef": {
+                    "type": "string"
+                },
+                "currentRole": {
+                    "type": "string"
+                },
+                "gitUsername": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.MembersMappingDto": {
+            "type": "object",
+            "properties": {
+                "gitRoles": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "ignoredUsers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "teams": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.MembersMappingResponseDto": {
+            "type": "object",
+            "properties": {
+                "errorCode": {
+                    "type": "string"
+                },
+                "membersMapping": {
+                    "$ref": "#/definitions/dto.MembersMappingDto"
+                }
+            }
+        },
+        "dto.MembersPlanDto": {
+            "type": "object",
+            "properties": {
+                "add": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.MemberChangeDto"
+                    }
+                },
+                "remove": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.MemberChangeDto"
+                    }
+                },
+                "removeSkipped": {
+                    "type": "boolean"
+                },
+                "update": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.MemberChangeDto"
+                    }
+                }
+            }
+        },
+        "dto.MembersPlanResponseDto": {
+            "type": "object",
+            "properties": {
+                "errorCode": {
+                    "type": "string"
+                },
+                "plan": {
+                    "$ref": "#/definitions/dto.MembersPlanDto"
+                }
+            }
+        },
         "dto.OrganizationDto": {
             "type": "object",
             "properties": {
@@ -929,6 +1977,10 @@ var doc = `{
                 "avatarUrl": {
                     "type": "string"
                 },
+                "collaboratorsPolicy": {
+                    "description": "how the repository collaborators are synchronized, as Agola members or project collaborators",
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
@@ -959,9 +2011,19 @@ var doc = `{
                         "$ref": "#/definitions/dto.ProjectDto"
                     }
                 },
+                "userNamespace": {
+                    "description": "the organization is the personal namespace of a git user",
+                    "type": "boolean"
+                },
                 "visibility": {
                     "type": "string"
                 },
+                "webHookCheckDate": {
+                    "type": "string"
+                },
+                "webHookStatus": {
+                    "type": "string"
+                },
                 "worstReport": {
                     "$ref": "#/definitions/dto.ReportDto"
                 }
@@ -982,11 +2044,59 @@ var doc = `{
                 "projectURL": {
                     "type": "string"
                 },
+                "pullRequests": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.PullRequestDto"
+                    }
+                },
                 "worstReport": {
                     "$ref": "#/definitions/dto.ReportDto"
                 }
             }
         },
+        "dto.PullRequestDto": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "lastFailedRunDate": {
+                    "type": "string"
+                },
+                "lastFailedRunURL": {
+                    "type": "string"
+                },
+                "lastRunDuration": {
+                    "type": "integer"
+                },
+                "lastSuccessRunDate": {
+                    "type": "string"
+                },
+                "lastSuccessRunURL": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "integer"
+                },
+                "report": {
+                    "$ref": "#/definitions/dto.ReportDto"
+                },
+                "sourceBranch": {
+                    "type": "string"
+                },
+                "state": {
+                    "description": "state of last run",
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
         "dto.ReportDto": {
             "type": "object",
             "properties": {
@@ -1010,6 +2120,39 @@ var doc = `{
                 }
             }
         },
+        "dto.RunActionRequestDto": {
+            "type": "object",
+            "properties": {
+                "actionType": {
+                    "type": "string"
+                },
+                "fromScratch": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.SaveAgolaSecretRequestDto": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.SaveAgolaVariableRequestDto": {
+            "type": "object",
+            "properties": {
+                "values": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.AgolaVariableValueDto"
+                    }
+                }
+            }
+        },
         "dto.UpdateGitSourceRequestDto": {
             "type": "object",
             "properties": {
@@ -1027,6 +2170,76 @@ var doc = `{
                 },
                 "gitType": {
                     "type": "string"
+                },
+                "githubAppId": {
+                    "type": "integer"
+                },
+                "githubAppPrivateKeyPath": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.WebHookDedupStatsDto": {
+            "type": "object",
+            "properties": {
+                "duplicates": {
+                    "type": "integer"
+                },
+                "evicted": {
+                    "type": "integer"
+                },
+                "expired": {
+                    "type": "integer"
+                },
+                "maxSize": {
+                    "type": "integer"
+                },
+                "received": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.WebHookDeliveryDto": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "deliveryId": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "header": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "string"
+                },
+                "processedAt": {
+                    "type": "string"
+                },
+                "receivedAt": {
+                    "type": "string"
+                },
+                "replayOf": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
                 }
             }
         }
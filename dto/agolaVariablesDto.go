@@ -0,0 +1,88 @@
+package dto
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"wecode.sorint.it/opensource/papagaio-api/model"
+)
+
+//Secrets and variables of the organization, the values of the secrets are never returned
+type AgolaVariablesDto struct {
+	Secrets   []AgolaSecretDto   `json:"secrets"`
+	Variables []AgolaVariableDto `json:"variables"`
+	Revision  uint64             `json:"revision"`
+}
+
+type AgolaSecretDto struct {
+	Name string   `json:"name"`
+	Keys []string `json:"keys"`
+}
+
+type AgolaVariableDto struct {
+	Name   string                  `json:"name"`
+	Values []AgolaVariableValueDto `json:"values"`
+}
+
+type AgolaVariableValueDto struct {
+	SecretName string `json:"secretName"`
+	SecretVar  string `json:"secretVar"`
+}
+
+func NewAgolaVariablesDto(agolaVariables *model.AgolaVariables) AgolaVariablesDto {
+	retVal := AgolaVariablesDto{Secrets: make([]AgolaSecretDto, 0), Variables: make([]AgolaVariableDto, 0), Revision: agolaVariables.Revision}
+
+	for _, secret := range agolaVariables.Secrets {
+		keys := make([]string, 0, len(secret.Data))
+		for key := range secret.Data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		retVal.Secrets = append(retVal.Secrets, AgolaSecretDto{Name: secret.Name, Keys: keys})
+	}
+
+	for _, variable := range agolaVariables.Variables {
+		values := make([]AgolaVariableValueDto, 0, len(variable.Values))
+		for _, value := range variable.Values {
+			values = append(values, AgolaVariableValueDto{SecretName: value.SecretName, SecretVar: value.SecretVar})
+		}
+		retVal.Variables = append(retVal.Variables, AgolaVariableDto{Name: variable.Name, Values: values})
+	}
+
+	return retVal
+}
+
+type SaveAgolaSecretRequestDto struct {
+	Data map[string]string `json:"data"`
+}
+
+func (request *SaveAgolaSecretRequestDto) IsValid() error {
+	if len(request.Data) == 0 {
+		return errors.New("data is empty")
+	}
+	for key := range request.Data {
+		if len(strings.TrimSpace(key)) == 0 {
+			return errors.New("empty key in data")
+		}
+	}
+
+	return nil
+}
+
+type SaveAgolaVariableRequestDto struct {
+	Values []AgolaVariableValueDto `json:"values"`
+}
+
+func (request *SaveAgolaVariableRequestDto) IsValid() error {
+	if len(request.Values) == 0 {
+		return errors.New("values are empty")
+	}
+	for _, value := range request.Values {
+		if len(strings.TrimSpace(value.SecretName)) == 0 || len(strings.TrimSpace(value.SecretVar)) == 0 {
+			return errors.New("secretName and secretVar are required")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,18 @@
+package dto
+
+import (
+	"wecode.sorint.it/opensource/papagaio-api/types"
+)
+
+type CollaboratorsPolicyDto struct {
+	CollaboratorsPolicy types.CollaboratorsPolicy `json:"collaboratorsPolicy" example:"member"`
+}
+
+func (policy *CollaboratorsPolicyDto) IsValid() error {
+	return policy.CollaboratorsPolicy.IsValid()
+}
+
+type CollaboratorsPolicyResponseDto struct {
+	ErrorCode           OrganizationResponseStatusCode `json:"errorCode"`
+	CollaboratorsPolicy types.CollaboratorsPolicy      `json:"collaboratorsPolicy,omitempty"`
+}
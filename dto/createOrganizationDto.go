@@ -4,6 +4,7 @@ import (
 	"errors"
 	"path/filepath"
 	"regexp"
+	"strings"
 
 	"wecode.sorint.it/opensource/papagaio-api/types"
 )
@@ -16,6 +17,13 @@ type CreateOrganizationRequestDto struct {
 	BehaviourInclude string              `json:"behaviourInclude"`
 	BehaviourExclude string              `json:"behaviourExclude"`
 	BehaviourType    types.BehaviourType `json:"behaviourType"`
+
+	//Add also the repositories of the descendant subgroups, only for the git providers with nested groups
+	IncludeSubgroups bool `json:"includeSubgroups"`
+	//The gitPath is the login of the user, the repositories of the personal namespace are added
+	UserNamespace bool `json:"userNamespace"`
+	//How the repository collaborators are synchronized: ignore, member or project, ignore by default
+	CollaboratorsPolicy types.CollaboratorsPolicy `json:"collaboratorsPolicy" example:"ignore"`
 }
 
 var organizationRegexp = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9]*([-]?[a-zA-Z0-9]+)+$`)
@@ -24,8 +32,23 @@ func (org *CreateOrganizationRequestDto) IsAgolaRefValid() bool {
 	return organizationRegexp.MatchString(org.AgolaRef)
 }
 
+//The gitPath can be the path of a subgroup, like group/subgroup, only for the git providers with nested groups
+func (org *CreateOrganizationRequestDto) IsGitPathValid() bool {
+	for _, segment := range strings.Split(org.GitPath, "/") {
+		if len(segment) == 0 || segment == "." || segment == ".." {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (org *CreateOrganizationRequestDto) IsSubgroupPath() bool {
+	return strings.Contains(org.GitPath, "/")
+}
+
 func (org *CreateOrganizationRequestDto) IsValid() error {
-	if org.Visibility.IsValid() == nil && org.BehaviourType.IsValid() == nil && org.IsBehaviourValid() && len(org.GitPath) > 0 && len(org.AgolaRef) > 0 && org.IsAgolaRefValid() {
+	if org.Visibility.IsValid() == nil && org.BehaviourType.IsValid() == nil && org.CollaboratorsPolicy.IsValid() == nil && org.IsBehaviourValid() && org.IsGitPathValid() && len(org.AgolaRef) > 0 && org.IsAgolaRefValid() {
 		return nil
 	}
 	return errors.New("fields not valid")
@@ -79,6 +102,7 @@ const (
 	InvalidEmail                    OrganizationResponseStatusCode = "INVALID_EMAIL"
 	EmailAlreadyExists              OrganizationResponseStatusCode = "EMAIL_ALREADY_EXISTS"
 	EmailNotFound                   OrganizationResponseStatusCode = "EMAIL_NOT_FOUND"
+	GitSystemHookForbiddenError     OrganizationResponseStatusCode = "ORG_GIT_SYSTEM_HOOK_FORBIDDEN"
 )
 
 type DeleteOrganizationResponseDto struct {
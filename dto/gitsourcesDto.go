@@ -20,6 +20,9 @@ type UpdateGitSourceRequestDto struct {
 	GitClientID     *string        `json:"gitClientId"`
 	GitClientSecret *string        `json:"gitClientSecret"`
 
+	GithubAppID             *int64  `json:"githubAppId"`
+	GithubAppPrivateKeyPath *string `json:"githubAppPrivateKeyPath"`
+
 	AgolaRemoteSource *string `json:"agolaRemoteSource"`
 }
 
@@ -31,6 +34,10 @@ type CreateGitSourceRequestDto struct {
 	GitClientID     string `json:"gitClientId"`
 	GitClientSecret string `json:"gitClientSecret"`
 
+	//Optional, the organizations of a github gitSource are managed by the GitHub App
+	GithubAppID             *int64  `json:"githubAppId"`
+	GithubAppPrivateKeyPath *string `json:"githubAppPrivateKeyPath"`
+
 	AgolaRemoteSourceName *string `json:"agolaRemoteSourceName"`
 	AgolaClientID         *string `json:"agolaClientId"`
 	AgolaClientSecret     *string `json:"agolaClientSecret"`
@@ -52,7 +59,7 @@ func (gitSource *CreateGitSourceRequestDto) IsValid() error {
 		if err != nil {
 			return errors.New("gitApiUrl is not valid")
 		}
-	} else if gitSource.GitType == types.Gitea {
+	} else if gitSource.GitType == types.Gitea || gitSource.GitType == types.Bitbucket {
 		return errors.New("gitApiUrl is nil")
 	}
 
@@ -64,6 +71,23 @@ func (gitSource *CreateGitSourceRequestDto) IsValid() error {
 		return errors.New("gitSecret is empty")
 	}
 
+	if gitSource.GithubAppID != nil || gitSource.GithubAppPrivateKeyPath != nil {
+		if gitSource.GitType != types.Github {
+			return errors.New("githubApp is valid only for github")
+		}
+		if gitSource.GithubAppID == nil || *gitSource.GithubAppID <= 0 {
+			return errors.New("githubAppId is not valid")
+		}
+		if gitSource.GithubAppPrivateKeyPath == nil || len(*gitSource.GithubAppPrivateKeyPath) == 0 {
+			return errors.New("githubAppPrivateKeyPath is empty")
+		}
+	}
+
+	//Agola has no bitbucket remote source type, it must be created in Agola before the gitSource
+	if gitSource.GitType == types.Bitbucket && (gitSource.AgolaRemoteSourceName == nil || len(*gitSource.AgolaRemoteSourceName) == 0) {
+		return errors.New("agolaRemoteSource must be specified for bitbucket")
+	}
+
 	if gitSource.AgolaRemoteSourceName == nil {
 		if gitSource.AgolaClientID == nil || gitSource.AgolaClientSecret == nil {
 			return errors.New("agolaRemoteSource or oauth2 application must be specified")
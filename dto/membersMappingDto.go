@@ -0,0 +1,67 @@
+package dto
+
+import (
+	"errors"
+	"strings"
+
+	"wecode.sorint.it/opensource/papagaio-api/types"
+)
+
+type MembersMappingDto struct {
+	Teams        map[string]types.MemberRole `json:"teams"`
+	GitRoles     map[string]types.MemberRole `json:"gitRoles"`
+	IgnoredUsers []string                    `json:"ignoredUsers"`
+}
+
+func (mapping *MembersMappingDto) IsValid() error {
+	for team, role := range mapping.Teams {
+		if len(strings.TrimSpace(team)) == 0 {
+			return errors.New("empty team in teams")
+		}
+		if err := role.IsValid(); err != nil {
+			return err
+		}
+	}
+
+	for gitRole, role := range mapping.GitRoles {
+		if len(strings.TrimSpace(gitRole)) == 0 {
+			return errors.New("empty git role in gitRoles")
+		}
+		if err := role.IsValid(); err != nil {
+			return err
+		}
+	}
+
+	for _, agolaUserRef := range mapping.IgnoredUsers {
+		if len(strings.TrimSpace(agolaUserRef)) == 0 {
+			return errors.New("empty user in ignoredUsers")
+		}
+	}
+
+	return nil
+}
+
+type MembersMappingResponseDto struct {
+	ErrorCode      OrganizationResponseStatusCode `json:"errorCode"`
+	MembersMapping *MembersMappingDto             `json:"membersMapping,omitempty"`
+}
+
+type MemberChangeDto struct {
+	AgolaUserRef string `json:"agolaUserRef"`
+	GitUsername  string `json:"gitUsername,omitempty"`
+	Role         string `json:"role,omitempty"`
+	CurrentRole  string `json:"currentRole,omitempty"`
+}
+
+//Changes of the members synk, RemoveSkipped is true when a git listing is incomplete and the members are not removed
+type MembersPlanDto struct {
+	Add           []MemberChangeDto `json:"add"`
+	Update        []MemberChangeDto `json:"update"`
+	Remove        []MemberChangeDto `json:"remove"`
+	RemoveSkipped bool              `json:"removeSkipped"`
+}
+
+type MembersPlanResponseDto struct {
+	ErrorCode OrganizationResponseStatusCode `json:"errorCode"`
+	Plan      *MembersPlanDto                `json:"plan,omitempty"`
+}
@@ -0,0 +1,16 @@
+package dto
+
+import "wecode.sorint.it/opensource/papagaio-api/model"
+
+//Organization returned by the api: the empty fields shadow the webhook secrets and the agola secrets are returned without their values
+type OrganizationDataDto struct {
+	model.Organization
+
+	EncryptedWebHookSecret string            `json:"encryptedWebHookSecret,omitempty"`
+	LegacyWebHookSecret    string            `json:"webHookSecret,omitempty"`
+	AgolaVariables         AgolaVariablesDto `json:"agolaVariables"`
+}
+
+func NewOrganizationDataDto(organization model.Organization) OrganizationDataDto {
+	return OrganizationDataDto{Organization: organization, AgolaVariables: NewAgolaVariablesDto(&organization.AgolaVariables)}
+}
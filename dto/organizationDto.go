@@ -12,6 +12,10 @@ type OrganizationDto struct {
 	AgolaRef   string               `json:"agolaRef"`
 	Visibility types.VisibilityType `json:"visibility"`
 	AvatarURL  string               `json:"avatarUrl"`
+	//the organization is the personal namespace of a git user
+	UserNamespace bool `json:"userNamespace"`
+	//how the repository collaborators are synchronized, as Agola members or project collaborators
+	CollaboratorsPolicy types.CollaboratorsPolicy `json:"collaboratorsPolicy"`
 
 	Projects    []ProjectDto `json:"projects"`
 	WorstReport *ReportDto   `json:"worstReport"`
@@ -23,4 +27,7 @@ type OrganizationDto struct {
 	LastSuccessRunURL string `json:"lastSuccessRunURL"`
 	LastFailedRunURL  string `json:"lastFailedRunURL"`
 	OrganizationURL   string `json:"organizationURL"`
+
+	WebHookStatus    types.WebHookStatusType `json:"webHookStatus"`
+	WebHookCheckDate *time.Time              `json:"webHookCheckDate"`
 }
@@ -4,6 +4,8 @@ type ProjectDto struct {
 	Name    string      `json:"projectName"`
 	Branchs []BranchDto `json:"branchs"`
 
+	PullRequests []PullRequestDto `json:"pullRequests"`
+
 	WorstReport *ReportDto `json:"worstReport"`
 	ProjectUrl  *string    `json:"projectURL"`
 }
@@ -0,0 +1,10 @@
+package dto
+
+type PullRequestDto struct {
+	Number       int    `json:"number"`
+	Title        string `json:"title"`
+	Author       string `json:"author"`
+	SourceBranch string `json:"sourceBranch"`
+
+	BranchDto //runs of the pull request
+}
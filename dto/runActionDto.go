@@ -0,0 +1,26 @@
+package dto
+
+import "errors"
+
+type RunActionType string
+
+const (
+	RunActionRestart RunActionType = "restart"
+	RunActionStop    RunActionType = "stop"
+	RunActionCancel  RunActionType = "cancel"
+)
+
+//FromScratch is used only by the restart: true restarts all the tasks, false only the failed ones
+type RunActionRequestDto struct {
+	ActionType  RunActionType `json:"actionType"`
+	FromScratch bool          `json:"fromScratch"`
+}
+
+func (request *RunActionRequestDto) IsValid() error {
+	switch request.ActionType {
+	case RunActionRestart, RunActionStop, RunActionCancel:
+		return nil
+	}
+
+	return errors.New("actionType must be restart, stop or cancel")
+}
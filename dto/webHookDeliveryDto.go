@@ -0,0 +1,25 @@
+package dto
+
+import "time"
+
+type WebHookDeliveryDto struct {
+	ID          string              `json:"id"`
+	DeliveryID  string              `json:"deliveryId,omitempty"`
+	Header      map[string][]string `json:"header"`
+	Payload     string              `json:"payload"`
+	Status      string              `json:"status"`
+	Attempts    int                 `json:"attempts"`
+	Error       string              `json:"error,omitempty"`
+	ReceivedAt  time.Time           `json:"receivedAt"`
+	ProcessedAt *time.Time          `json:"processedAt,omitempty"`
+	ReplayOf    string              `json:"replayOf,omitempty"`
+}
+
+type WebHookDedupStatsDto struct {
+	Size       int    `json:"size"`
+	MaxSize    int    `json:"maxSize"`
+	Received   uint64 `json:"received"`
+	Duplicates uint64 `json:"duplicates"`
+	Expired    uint64 `json:"expired"`
+	Evicted    uint64 `json:"evicted"`
+}
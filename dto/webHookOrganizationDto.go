@@ -8,21 +8,105 @@ type WebHookDto struct {
 	Action     string        `json:"action"`
 	Repository RepositoryDto `json:"repository"`
 	RefType    string        `json:"ref_type"`
+	Ref        string        `json:"ref"`
+	Before     string        `json:"before"`
+	After      string        `json:"after"`
+	Deleted    bool          `json:"deleted"`
+	Changes    *ChangesDto   `json:"changes,omitempty"`
+
+	EventType         string `json:"-"` //read from the event header of the git provider
+	OldRepositoryName string `json:"-"`
+	//The repository has been transferred into the organization from another namespace
+	TransferredIn bool `json:"-"`
+	//Full path of a repository transferred out of the organization
+	TransferredTo string `json:"-"`
 }
 
+const (
+	WebHookEventCreate string = "create"
+	WebHookEventDelete string = "delete"
+
+	RepositoryCreatedAction     string = "created"
+	RepositoryDeletedAction     string = "deleted"
+	RepositoryRenamedAction     string = "renamed"
+	RepositoryTransferredAction string = "transferred"
+
+	refTypeBranch   string = "branch"
+	refTypeTag      string = "tag"
+	branchRefPrefix string = "refs/heads/"
+	tagRefPrefix    string = "refs/tags/"
+	emptyCommitSha  string = "0000000000000000000000000000000000000000"
+)
+
 func (webHookMessage *WebHookDto) IsRepositoryCreated() bool {
-	return strings.Compare(webHookMessage.Action, "created") == 0
+	return strings.Compare(webHookMessage.Action, RepositoryCreatedAction) == 0
 }
 
 func (webHookMessage *WebHookDto) IsRepositoryDeleted() bool {
-	return strings.Compare(webHookMessage.Action, "deleted") == 0
+	return strings.Compare(webHookMessage.Action, RepositoryDeletedAction) == 0
+}
+
+func (webHookMessage *WebHookDto) IsRepositoryRenamed() bool {
+	return strings.Compare(webHookMessage.Action, RepositoryRenamedAction) == 0
+}
+
+func (webHookMessage *WebHookDto) IsRepositoryTransferred() bool {
+	return strings.Compare(webHookMessage.Action, RepositoryTransferredAction) == 0
+}
+
+//Branch or tag create/delete events
+func (webHookMessage *WebHookDto) IsRefEvent() bool {
+	return strings.Compare(webHookMessage.Action, "") == 0 && len(webHookMessage.RefType) > 0
 }
 
 func (webHookMessage *WebHookDto) IsPush() bool {
-	return strings.Compare(webHookMessage.Action, "") == 0
+	return strings.Compare(webHookMessage.Action, "") == 0 && len(webHookMessage.RefType) == 0
+}
+
+func (webHookMessage *WebHookDto) IsBranchCreated() bool {
+	return webHookMessage.IsRefEvent() && webHookMessage.RefType == refTypeBranch && webHookMessage.EventType == WebHookEventCreate
+}
+
+func (webHookMessage *WebHookDto) IsBranchDeleted() bool {
+	return webHookMessage.IsRefEvent() && webHookMessage.RefType == refTypeBranch && webHookMessage.EventType == WebHookEventDelete
+}
+
+func (webHookMessage *WebHookDto) IsTagRef() bool {
+	return webHookMessage.RefType == refTypeTag || strings.HasPrefix(webHookMessage.Ref, tagRefPrefix)
+}
+
+//Return the branch of a push or of a branch event, false if the ref is not a branch
+func (webHookMessage *WebHookDto) GetBranchName() (string, bool) {
+	if webHookMessage.IsRefEvent() {
+		if webHookMessage.RefType != refTypeBranch || len(webHookMessage.Ref) == 0 {
+			return "", false
+		}
+		return strings.TrimPrefix(webHookMessage.Ref, branchRefPrefix), true
+	}
+
+	if !strings.HasPrefix(webHookMessage.Ref, branchRefPrefix) {
+		return "", false
+	}
+
+	return strings.TrimPrefix(webHookMessage.Ref, branchRefPrefix), true
+}
+
+//True when the push removed the ref
+func (webHookMessage *WebHookDto) IsRefRemovedByPush() bool {
+	return webHookMessage.Deleted || webHookMessage.After == emptyCommitSha
 }
 
 type RepositoryDto struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+}
+
+//Changes of a renamed repository
+type ChangesDto struct {
+	Repository struct {
+		Name struct {
+			From string `json:"from"`
+		} `json:"name"`
+	} `json:"repository"`
 }
@@ -1,6 +1,7 @@
 package manager
 
 import (
+	"sort"
 	"time"
 
 	"wecode.sorint.it/opensource/papagaio-api/api/git"
@@ -16,8 +17,24 @@ func GetOrganizationDto(user *model.User, organization *model.Organization, gits
 		Name:       organization.GitName,
 		AgolaRef:   organization.AgolaOrganizationRef,
 		Visibility: organization.Visibility,
+
+		UserNamespace:       organization.UserNamespace,
+		CollaboratorsPolicy: organization.CollaboratorsPolicy,
+	}
+	if len(retVal.CollaboratorsPolicy) == 0 {
+		retVal.CollaboratorsPolicy = types.CollaboratorsIgnore
+	}
+
+	retVal.WebHookStatus = organization.WebHookStatus
+	if len(retVal.WebHookStatus) == 0 {
+		retVal.WebHookStatus = types.WebHookStatusUnknown
+	}
+	if !organization.WebHookCheckDate.IsZero() {
+		webHookCheckDate := organization.WebHookCheckDate
+		retVal.WebHookCheckDate = &webHookCheckDate
 	}
-	orgDto, _ := gitGateway.GetOrganization(gitsource, user, organization.GitPath)
+
+	orgDto, _ := gitGateway.GetNamespace(gitsource, user, organization)
 	if orgDto != nil {
 		retVal.AvatarURL = orgDto.AvatarURL
 	}
@@ -91,6 +108,15 @@ func GetProjectDto(project *model.Project, organization *model.Organization) dto
 	}
 	retVal.Branchs = branchList
 
+	pullRequestList := make([]dto.PullRequestDto, 0)
+	for _, pullRequest := range project.PullRequests {
+		pullRequestList = append(pullRequestList, GetPullRequestDto(pullRequest, project, organization))
+	}
+	sort.SliceStable(pullRequestList, func(i, j int) bool {
+		return pullRequestList[i].Number > pullRequestList[j].Number
+	})
+	retVal.PullRequests = pullRequestList
+
 	var worstReport *dto.ReportDto = nil
 	if len(retVal.Branchs) > 0 {
 		worstReport = retVal.Branchs[0].Report
@@ -150,6 +176,16 @@ func GetBranchDto(branch model.Branch, project *model.Project, organization *mod
 	return retVal
 }
 
+func GetPullRequestDto(pullRequest model.PullRequest, project *model.Project, organization *model.Organization) dto.PullRequestDto {
+	return dto.PullRequestDto{
+		Number:       pullRequest.Number,
+		Title:        pullRequest.Title,
+		Author:       pullRequest.Author,
+		SourceBranch: pullRequest.SourceBranch,
+		BranchDto:    GetBranchDto(pullRequest.GetBranch(), project, organization),
+	}
+}
+
 func GetBranchReport(branch model.Branch, projectName string, organizationName string) *dto.ReportDto {
 	report := dto.ReportDto{BranchName: branch.Name, ProjectName: projectName, OrganizationName: organizationName}
 
@@ -0,0 +1,40 @@
+package membersManager
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"wecode.sorint.it/opensource/papagaio-api/api/agola"
+	"wecode.sorint.it/opensource/papagaio-api/api/git"
+	"wecode.sorint.it/opensource/papagaio-api/api/git/bitbucket"
+	"wecode.sorint.it/opensource/papagaio-api/model"
+)
+
+//Members of the bitbucket project, the key is the lowercase project permission
+func listBitbucketMembers(organization *model.Organization, gitSource *model.GitSource, gitGateway *git.GitGateway, user *model.User) ([]gitMember, bool, error) {
+	bitbucketUsers, err := gitGateway.BitbucketApi.GetOrganizationMembers(gitSource, user, organization.GitPath)
+	if bitbucketUsers == nil || (err != nil && !errors.Is(err, bitbucket.ErrGroupMembersNotListed)) {
+		log.Println("error in GetOrganizationMembers:", err)
+		if err == nil {
+			err = errors.New("bitbucket members not found")
+		}
+		return nil, false, err
+	}
+	//the users with a permission granted only by a group not listed are missing
+	listingComplete := err == nil
+	if !listingComplete {
+		log.Println("GetOrganizationMembers returned an incomplete listing, members will not be removed:", err)
+	}
+
+	gitMembers := make([]gitMember, 0, len(*bitbucketUsers))
+	for _, bitbucketUser := range *bitbucketUsers {
+		role := agola.Member
+		if bitbucketUser.HasOwnerPermission() {
+			role = agola.Owner
+		}
+		gitMembers = append(gitMembers, gitMember{ID: int64(bitbucketUser.ID), Username: bitbucketUser.Username, GitRoles: []string{strings.ToLower(bitbucketUser.Permission)}, DefaultRole: role})
+	}
+
+	return gitMembers, listingComplete, nil
+}
@@ -2,121 +2,57 @@ package membersManager
 
 import (
 	"log"
-	"strings"
 
 	"wecode.sorint.it/opensource/papagaio-api/api/agola"
 	"wecode.sorint.it/opensource/papagaio-api/api/git"
-	"wecode.sorint.it/opensource/papagaio-api/api/git/dto"
 	"wecode.sorint.it/opensource/papagaio-api/model"
-	"wecode.sorint.it/opensource/papagaio-api/utils"
 )
 
-//Sincronizzo i membri della organization tra gitea e agola
-func SyncMembersForGitea(organization *model.Organization, gitSource *model.GitSource, agolaApi agola.AgolaApiInterface, gitGateway *git.GitGateway, user *model.User) {
-	log.Println("SyncMembersForGitea start")
-
+//Members of the gitea teams, with the names of their teams and the owner or member git role given by the teams
+func listGiteaMembers(organization *model.Organization, gitSource *model.GitSource, gitGateway *git.GitGateway, user *model.User) ([]gitMember, bool, error) {
 	gitTeams, err := gitGateway.GiteaApi.GetOrganizationTeams(gitSource, user, organization.GitPath)
 	if err != nil {
 		log.Println("error in GetOrganizationTeams:", err)
-		return
+		return nil, false, err
 	}
-	gitTeamOwners := make(map[int64]dto.UserTeamResponseDto)
-	gitTeamMembers := make(map[int64]dto.UserTeamResponseDto)
 
+	membersMap := make(map[int64]*gitMember)
+	members := make([]*gitMember, 0)
+
+	listingComplete := true
 	for _, team := range *gitTeams {
 		log.Println("team", team.Name, "owner permission:", team.HasOwnerPermission(), team.Permission)
-		teamMembers, _ := gitGateway.GiteaApi.GetTeamMembers(gitSource, user, team.ID)
-
-		var teamToCheck *map[int64]dto.UserTeamResponseDto
-		if team.HasOwnerPermission() {
-			teamToCheck = &gitTeamOwners
-		} else {
-			teamToCheck = &gitTeamMembers
-		}
-
-		for _, member := range *teamMembers {
-			(*teamToCheck)[member.ID] = member
-		}
-	}
-
-	// clear gitTeamMembers
-	for userId := range gitTeamOwners {
-		_, ok := gitTeamMembers[userId]
-		if ok {
-			delete(gitTeamMembers, userId)
-		}
-	}
-
-	gitUsers := map[int64]dto.UserTeamResponseDto{}
-	for k, v := range gitTeamMembers {
-		gitUsers[k] = v
-	}
-	for k, v := range gitTeamOwners {
-		gitUsers[k] = v
-	}
-
-	agolaOrganizationMembers, _ := agolaApi.GetOrganizationMembers(organization)
-	agolaOrganizationMembersMap := toMapMembers(&agolaOrganizationMembers.Members)
-
-	agolaUsersMap := utils.GetUsersMapByRemotesource(agolaApi, gitSource.AgolaRemoteSource, gitUsers)
-
-	for _, gitMember := range gitTeamMembers {
-		agolaUserRef, usersExists := (*agolaUsersMap)[gitMember.Username]
-		if !usersExists {
+		teamMembers, err := gitGateway.GiteaApi.GetTeamMembers(gitSource, user, team.ID)
+		if err != nil || teamMembers == nil {
+			log.Println("error in GetTeamMembers of team", team.Name, "members will not be removed:", err)
+			listingComplete = false
 			continue
 		}
 
-		if agolaMember, ok := (*agolaOrganizationMembersMap)[agolaUserRef]; !ok || agolaMember.Role == agola.Owner {
-			err := agolaApi.AddOrUpdateOrganizationMember(organization, agolaUserRef, string(agola.Member))
-			if err != nil {
-				log.Println("AddOrUpdateOrganizationMember error:", err)
-			}
-		}
-	}
-
-	for _, gitMember := range gitTeamOwners {
-		agolaUserRef, usersExists := (*agolaUsersMap)[gitMember.Username]
-		if !usersExists {
-			continue
+		role := agola.Member
+		if team.HasOwnerPermission() {
+			role = agola.Owner
 		}
 
-		if agolaMember, ok := (*agolaOrganizationMembersMap)[agolaUserRef]; !ok || agolaMember.Role == agola.Member {
-			err := agolaApi.AddOrUpdateOrganizationMember(organization, agolaUserRef, string(agola.Owner))
-			if err != nil {
-				log.Println("AddOrUpdateOrganizationMember error:", err)
+		for _, teamMember := range *teamMembers {
+			member, ok := membersMap[teamMember.ID]
+			if !ok {
+				member = &gitMember{ID: teamMember.ID, Username: teamMember.Username, DefaultRole: agola.Member}
+				membersMap[teamMember.ID] = member
+				members = append(members, member)
 			}
-		}
-	}
-
-	//Verifico i membri eliminati su git
-
-	for _, agolaMember := range agolaOrganizationMembers.Members {
-		if findGiteaMemberByAgolaUserRef(gitTeamOwners, agolaUsersMap, agolaMember.User.Username) == nil && findGiteaMemberByAgolaUserRef(gitTeamMembers, agolaUsersMap, agolaMember.User.Username) == nil {
-			err := agolaApi.RemoveOrganizationMember(organization, agolaMember.User.Username)
-			if err != nil {
-				log.Println("RemoveOrganizationMember error:", err)
+			member.Teams = append(member.Teams, team.Name)
+			if role == agola.Owner {
+				member.DefaultRole = agola.Owner
 			}
 		}
 	}
 
-	log.Println("SyncMembersForGitea end")
-}
-
-func findGiteaMemberByAgolaUserRef(gitMembers map[int64]dto.UserTeamResponseDto, agolaUsersMap *map[string]string, agolaUserRef string) *dto.UserTeamResponseDto {
-	for _, gitMember := range gitMembers {
-		if strings.Compare(agolaUserRef, (*agolaUsersMap)[gitMember.Username]) == 0 {
-			return &gitMember
-		}
-
+	gitMembers := make([]gitMember, 0, len(members))
+	for _, member := range members {
+		member.GitRoles = append(member.GitRoles, string(member.DefaultRole))
+		gitMembers = append(gitMembers, *member)
 	}
 
-	return nil
-}
-
-func toMapMembers(members *[]agola.MemberDto) *map[string]agola.MemberDto {
-	membersMap := make(map[string]agola.MemberDto)
-	for _, member := range *members {
-		membersMap[member.User.Username] = member
-	}
-	return &membersMap
+	return gitMembers, listingComplete, nil
 }
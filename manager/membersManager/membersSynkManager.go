@@ -1,32 +1,355 @@
 package membersManager
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log"
+	"sort"
 
+	"wecode.sorint.it/opensource/papagaio-api/api/agola"
 	agolaApi "wecode.sorint.it/opensource/papagaio-api/api/agola"
 	"wecode.sorint.it/opensource/papagaio-api/api/git"
+	"wecode.sorint.it/opensource/papagaio-api/dto"
 	"wecode.sorint.it/opensource/papagaio-api/model"
 	"wecode.sorint.it/opensource/papagaio-api/types"
 )
 
-func SynkMembers(org *model.Organization, gitSource *model.GitSource, agolaApi agolaApi.AgolaApiInterface, gitGateway *git.GitGateway, user *model.User) error {
+//Git member with the names of its teams and its git roles, used by the members mapping
+type gitMember struct {
+	ID       int64
+	Username string
+	Teams    []string
+	GitRoles []string
+	//Role of the fixed rule, used when none of the teams and git roles is mapped
+	DefaultRole agola.RoleType
+	//Repository collaborator that isn't a git member
+	Collaborator bool
+}
+
+//Git role of the repository collaborators in the members mapping
+const collaboratorRole = "collaborator"
+
+//List the git members of the organization, return false if the listing is incomplete and the members must not be removed
+type membersListFunc func(organization *model.Organization, gitSource *model.GitSource, gitGateway *git.GitGateway, user *model.User) ([]gitMember, bool, error)
+
+//Members listing of every git type, the git types without an entry are not supported
+var membersListers = map[types.GitType]membersListFunc{
+	types.Gitea:     listGiteaMembers,
+	types.Github:    listGithubMembers,
+	types.Gitlab:    listGitlabMembers,
+	types.Bitbucket: listBitbucketMembers,
+}
+
+func getMembersLister(gitSource *model.GitSource) (membersListFunc, error) {
+	listMembers, ok := membersListers[gitSource.GitType]
+	if !ok {
+		log.Println("Warning!!! members synk not supported for git type", gitSource.GitType)
+		return nil, fmt.Errorf("%w: %s", git.ErrUnsupportedGitType, gitSource.GitType)
+	}
+
+	return listMembers, nil
+}
+
+func SynkMembers(ctx context.Context, org *model.Organization, gitSource *model.GitSource, agolaApi agolaApi.AgolaApiInterface, gitGateway *git.GitGateway, user *model.User) error {
 	log.Println("SynkMembers", org.AgolaOrganizationRef, org.GitPath, "start")
 
-	if gitSource != nil {
-		if gitSource.GitType == types.Gitea {
-			SyncMembersForGitea(org, gitSource, agolaApi, gitGateway, user)
-		} else if gitSource.GitType == types.Github {
-			SyncMembersForGithub(org, gitSource, agolaApi, gitGateway, user)
-		} else {
-			SyncMembersForGitlab(org, gitSource, agolaApi, gitGateway, user)
-		}
-	} else {
+	if gitSource == nil {
 		log.Println("Warning!!! Found gitSource null: ", org.AgolaOrganizationRef)
 		return errors.New("gitsource not found")
 	}
 
+	//the projects of a user namespace are owned by the Agola user, there are no members
+	if org.UserNamespace {
+		return nil
+	}
+
+	listMembers, err := getMembersLister(gitSource)
+	if err != nil {
+		return err
+	}
+
+	plan, projectsCollaborators, err := makeMembersPlan(ctx, org, gitSource, agolaApi, gitGateway, user, listMembers)
+	if err != nil {
+		log.Println("members synk of", org.AgolaOrganizationRef, "failed:", err)
+		return nil
+	}
+	applyMembersPlan(ctx, org, agolaApi, plan)
+	setProjectsCollaborators(org, projectsCollaborators)
+
 	log.Println("SynkMembers", org.AgolaOrganizationRef, "end")
 
 	return nil
 }
+
+//Changes SynkMembers would apply to the Agola organization, nothing is applied
+func GetMembersPlan(ctx context.Context, org *model.Organization, gitSource *model.GitSource, agolaApi agolaApi.AgolaApiInterface, gitGateway *git.GitGateway, user *model.User) (*dto.MembersPlanDto, error) {
+	if gitSource == nil {
+		return nil, errors.New("gitsource not found")
+	}
+
+	if org.UserNamespace {
+		return newMembersPlan(), nil
+	}
+
+	listMembers, err := getMembersLister(gitSource)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, _, err := makeMembersPlan(ctx, org, gitSource, agolaApi, gitGateway, user, listMembers)
+	return plan, err
+}
+
+func newMembersPlan() *dto.MembersPlanDto {
+	return &dto.MembersPlanDto{
+		Add:    []dto.MemberChangeDto{},
+		Update: []dto.MemberChangeDto{},
+		Remove: []dto.MemberChangeDto{},
+	}
+}
+
+//Return also the collaborators of every project listed, by project name
+func makeMembersPlan(ctx context.Context, organization *model.Organization, gitSource *model.GitSource, agolaApi agolaApi.AgolaApiInterface, gitGateway *git.GitGateway, user *model.User, listMembers membersListFunc) (*dto.MembersPlanDto, map[string][]int64, error) {
+	gitMembers, listingComplete, err := listMembers(organization, gitSource, gitGateway, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !listingComplete {
+		log.Println("git members listing of", organization.GitPath, "is incomplete, members will not be removed")
+	}
+
+	collaborators, projectsCollaborators, collaboratorsComplete := getRepositoryCollaborators(organization, gitSource, gitGateway, user, gitMembers)
+	gitMembers = append(gitMembers, collaborators...)
+
+	agolaMembers, err := agolaApi.GetOrganizationMembers(ctx, organization)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	agolaUsersMap, err := getAgolaUsersMap(ctx, agolaApi, gitSource.AgolaRemoteSource, gitMembers)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	agolaMembersMap := make(map[string]agola.MemberDto)
+	if agolaMembers != nil {
+		agolaMembersMap = *toMapMembers(&agolaMembers.Members)
+	}
+
+	//Agola role and git username by Agola user
+	roles := make(map[string]agola.RoleType)
+	gitUsernames := make(map[string]string)
+	for _, member := range gitMembers {
+		agolaUserRef, ok := agolaUsersMap[member.ID]
+		if !ok {
+			continue
+		}
+		role, ok := getMemberRole(&organization.MembersMapping, &member)
+		if !ok {
+			continue
+		}
+
+		//a collaborator never downgrades an Agola owner, with the project policy it isn't added to the organization
+		if member.Collaborator {
+			if agolaMember, ok := agolaMembersMap[agolaUserRef]; ok && agolaMember.Role == agola.Owner {
+				role = agola.Owner
+			} else if organization.HasProjectCollaborators() {
+				continue
+			}
+		}
+
+		if currentRole, exists := roles[agolaUserRef]; !exists || currentRole != agola.Owner {
+			roles[agolaUserRef] = role
+			gitUsernames[agolaUserRef] = member.Username
+		}
+	}
+
+	plan := newMembersPlan()
+	for agolaUserRef, role := range roles {
+		if organization.MembersMapping.IsIgnored(agolaUserRef) {
+			continue
+		}
+
+		change := dto.MemberChangeDto{AgolaUserRef: agolaUserRef, GitUsername: gitUsernames[agolaUserRef], Role: string(role)}
+		agolaMember, ok := agolaMembersMap[agolaUserRef]
+		if !ok {
+			plan.Add = append(plan.Add, change)
+		} else if agolaMember.Role != role {
+			change.CurrentRole = string(agolaMember.Role)
+			plan.Update = append(plan.Update, change)
+		}
+	}
+
+	if !listingComplete || !collaboratorsComplete || agolaMembers == nil {
+		plan.RemoveSkipped = true
+	} else {
+		for _, agolaMember := range agolaMembers.Members {
+			agolaUserRef := agolaMember.User.Username
+			if _, ok := roles[agolaUserRef]; ok || organization.MembersMapping.IsIgnored(agolaUserRef) {
+				continue
+			}
+			plan.Remove = append(plan.Remove, dto.MemberChangeDto{AgolaUserRef: agolaUserRef, CurrentRole: string(agolaMember.Role)})
+		}
+	}
+
+	sortMemberChanges(plan.Add)
+	sortMemberChanges(plan.Update)
+	sortMemberChanges(plan.Remove)
+
+	return plan, projectsCollaborators, nil
+}
+
+func applyMembersPlan(ctx context.Context, organization *model.Organization, agolaApi agolaApi.AgolaApiInterface, plan *dto.MembersPlanDto) {
+	for _, change := range append(plan.Add, plan.Update...) {
+		err := agolaApi.AddOrUpdateOrganizationMember(ctx, organization, change.AgolaUserRef, change.Role)
+		if err != nil {
+			log.Println("AddOrUpdateOrganizationMember error:", err)
+		}
+	}
+
+	for _, change := range plan.Remove {
+		err := agolaApi.RemoveOrganizationMember(ctx, organization, change.AgolaUserRef)
+		if err != nil {
+			log.Println("RemoveOrganizationMember error:", err)
+		}
+	}
+}
+
+func sortMemberChanges(changes []dto.MemberChangeDto) {
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].AgolaUserRef < changes[j].AgolaUserRef
+	})
+}
+
+/*
+Agola role of the git member: the highest role of its mapped teams and git roles, the default role when none of them is mapped.
+Return false if the member must not be in the Agola organization
+*/
+func getMemberRole(mapping *model.MembersMapping, member *gitMember) (agola.RoleType, bool) {
+	mapped := false
+	var role agola.RoleType
+	mapRole := func(mappedRole types.MemberRole) {
+		mapped = true
+
+		switch mappedRole {
+		case types.MemberRoleOwner:
+			role = agola.Owner
+		case types.MemberRoleMember:
+			if role != agola.Owner {
+				role = agola.Member
+			}
+		}
+	}
+
+	for _, team := range member.Teams {
+		if mappedRole, ok := mapping.Teams[team]; ok {
+			mapRole(mappedRole)
+		}
+	}
+	for _, gitRole := range member.GitRoles {
+		if mappedRole, ok := mapping.GitRoles[gitRole]; ok {
+			mapRole(mappedRole)
+		}
+	}
+
+	if !mapped {
+		return member.DefaultRole, true
+	}
+	return role, len(role) > 0
+}
+
+/*
+Return the Agola users of the git members by the agola remoteSource. Key is the git user ID, the members without an Agola user are not in the map.
+Any Agola error but a user not found is returned: a partial map would add or remove the wrong members
+*/
+func getAgolaUsersMap(ctx context.Context, agolaApi agola.AgolaApiInterface, agolaRemoteSource string, gitMembers []gitMember) (map[int64]string, error) {
+	remotesource, err := agolaApi.GetRemoteSource(ctx, agolaRemoteSource)
+	if err != nil {
+		return nil, fmt.Errorf("remotesource %s: %w", agolaRemoteSource, err)
+	}
+	if remotesource == nil {
+		return nil, errors.New("remotesource " + agolaRemoteSource + " not found")
+	}
+
+	usersMap := make(map[int64]string)
+	for _, member := range gitMembers {
+		if _, ok := usersMap[member.ID]; ok {
+			continue
+		}
+		user, err := agolaApi.GetUsersFilterbyRemoteUser(ctx, remotesource.ID, member.ID)
+		if err != nil && !errors.Is(err, agola.ErrNotFound) {
+			return nil, fmt.Errorf("agola user of %s: %w", member.Username, err)
+		}
+		if len(user) == 1 {
+			usersMap[member.ID] = user[0].Username
+		}
+	}
+
+	return usersMap, nil
+}
+
+/*
+Users with access to the repositories of the projects that aren't git members, only with the member or project collaborators policy.
+Return also the git user ids of the collaborators of every project listed and false if a listing failed, the members must not be removed
+*/
+func getRepositoryCollaborators(organization *model.Organization, gitSource *model.GitSource, gitGateway *git.GitGateway, user *model.User, gitMembers []gitMember) ([]gitMember, map[string][]int64, bool) {
+	collaborators := make([]gitMember, 0)
+	projectsCollaborators := make(map[string][]int64)
+	if !organization.SynkCollaborators() {
+		return collaborators, projectsCollaborators, true
+	}
+
+	members := make(map[int64]bool)
+	for _, member := range gitMembers {
+		members[member.ID] = true
+	}
+
+	found := make(map[int64]bool)
+	listingComplete := true
+	for projectName, project := range organization.Projects {
+		repositoryCollaborators, err := gitGateway.GetRepositoryCollaborators(gitSource, user, organization.GitPath, project.GitRepoPath)
+		if err != nil || repositoryCollaborators == nil {
+			log.Println("error in GetRepositoryCollaborators of", project.GitRepoPath, "members will not be removed:", err)
+			listingComplete = false
+			continue
+		}
+
+		projectsCollaborators[projectName] = make([]int64, 0)
+		for _, collaborator := range *repositoryCollaborators {
+			if members[collaborator.ID] {
+				continue
+			}
+			projectsCollaborators[projectName] = append(projectsCollaborators[projectName], collaborator.ID)
+
+			if found[collaborator.ID] {
+				continue
+			}
+			found[collaborator.ID] = true
+			collaborators = append(collaborators, gitMember{ID: collaborator.ID, Username: collaborator.Username, GitRoles: []string{collaboratorRole}, DefaultRole: agola.Member, Collaborator: true})
+		}
+	}
+
+	return collaborators, projectsCollaborators, listingComplete
+}
+
+//Store the collaborators of the projects with the project policy, the projects whose listing failed keep their collaborators.
+//With the other policies the projects have no collaborators
+func setProjectsCollaborators(organization *model.Organization, projectsCollaborators map[string][]int64) {
+	for projectName, project := range organization.Projects {
+		if !organization.HasProjectCollaborators() {
+			project.Collaborators = nil
+		} else if collaborators, ok := projectsCollaborators[projectName]; ok {
+			project.Collaborators = collaborators
+		}
+		organization.Projects[projectName] = project
+	}
+}
+
+func toMapMembers(members *[]agola.MemberDto) *map[string]agola.MemberDto {
+	membersMap := make(map[string]agola.MemberDto)
+	for _, member := range *members {
+		membersMap[member.User.Username] = member
+	}
+	return &membersMap
+}
@@ -1,6 +1,7 @@
 package manager
 
 import (
+	"context"
 	"log"
 
 	"wecode.sorint.it/opensource/papagaio-api/api/agola"
@@ -11,17 +12,17 @@ import (
 	"wecode.sorint.it/opensource/papagaio-api/repository"
 )
 
-func StartOrganizationCheckout(db repository.Database, user *model.User, organization *model.Organization, gitSource *model.GitSource, agolaApi agola.AgolaApiInterface, gitGateway *git.GitGateway) {
-	organizationCheckout(db, user, organization, gitSource, agolaApi, gitGateway)
+func StartOrganizationCheckout(ctx context.Context, db repository.Database, user *model.User, organization *model.Organization, gitSource *model.GitSource, agolaApi agola.AgolaApiInterface, gitGateway *git.GitGateway) {
+	organizationCheckout(ctx, db, user, organization, gitSource, agolaApi, gitGateway)
 }
 
-func organizationCheckout(db repository.Database, user *model.User, organization *model.Organization, gitSource *model.GitSource, agolaApi agola.AgolaApiInterface, gitGateway *git.GitGateway) {
+func organizationCheckout(ctx context.Context, db repository.Database, user *model.User, organization *model.Organization, gitSource *model.GitSource, agolaApi agola.AgolaApiInterface, gitGateway *git.GitGateway) {
 	log.Println("Start organization synk")
 
-	err := membersManager.SynkMembers(organization, gitSource, agolaApi, gitGateway, user)
+	err := membersManager.SynkMembers(ctx, organization, gitSource, agolaApi, gitGateway, user)
 	if err != nil {
 		log.Println("SynkMembers error:", err)
 	}
 
-	repositoryManager.CheckoutAllGitRepository(db, user, organization, gitSource, agolaApi, gitGateway)
+	repositoryManager.CheckoutAllGitRepository(ctx, db, user, organization, gitSource, agolaApi, gitGateway)
 }
@@ -1,45 +1,55 @@
 package repositoryManager
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"strings"
 
 	"wecode.sorint.it/opensource/papagaio-api/api/agola"
 	agolaApi "wecode.sorint.it/opensource/papagaio-api/api/agola"
 	"wecode.sorint.it/opensource/papagaio-api/api/git"
+	"wecode.sorint.it/opensource/papagaio-api/api/git/dto"
+	"wecode.sorint.it/opensource/papagaio-api/manager/variablesManager"
 	"wecode.sorint.it/opensource/papagaio-api/model"
 	"wecode.sorint.it/opensource/papagaio-api/repository"
 	"wecode.sorint.it/opensource/papagaio-api/utils"
 )
 
 //Inserisco tutti i repository di git su agola
-func CheckoutAllGitRepository(db repository.Database, user *model.User, organization *model.Organization, gitSource *model.GitSource, agolaApi agolaApi.AgolaApiInterface, gitGateway *git.GitGateway) {
+func CheckoutAllGitRepository(ctx context.Context, db repository.Database, user *model.User, organization *model.Organization, gitSource *model.GitSource, agolaApi agolaApi.AgolaApiInterface, gitGateway *git.GitGateway) {
 	log.Println("Start AddAllGitRepository")
 
-	repositoryList, _ := gitGateway.GetRepositories(gitSource, user, organization.GitPath)
+	repositoryList, _ := gitGateway.GetOrganizationRepositories(gitSource, user, organization)
 
 	if organization.Projects == nil {
 		organization.Projects = make(map[string]model.Project)
 	}
 
-	for _, repo := range *repositoryList {
+	for _, gitRepo := range *repositoryList {
+		repo := gitRepo.Name
 		if !utils.EvaluateBehaviour(organization, repo) {
 			continue
 		}
 
 		log.Println("Start add repository:", repo)
 
-		agolaConfExists, _ := gitGateway.CheckRepositoryAgolaConfExists(gitSource, user, organization.GitPath, repo)
-		project := model.Project{GitRepoPath: repo, Archivied: false, AgolaProjectRef: utils.ConvertToAgolaProjectRef(repo)}
+		agolaConfBranches, _ := gitGateway.GetAgolaConfBranches(gitSource, user, organization.GitPath, repo)
+		project := model.Project{GitRepoPath: repo, GitRepoID: gitRepo.ID, Archivied: false, AgolaProjectRef: utils.ConvertToAgolaProjectRef(repo), AgolaConfBranches: agolaConfBranches}
 
-		if agolaConfExists {
-			projectID, err := agolaApi.CreateProject(repo, project.AgolaProjectRef, organization, gitSource.AgolaRemoteSource, user)
+		if project.HasAgolaConf() {
+			projectID, err := agolaApi.CreateProject(ctx, repo, project.AgolaProjectRef, organization, gitSource.AgolaRemoteSource, user)
 			project.AgolaProjectID = projectID
 			if err != nil {
 				log.Println("Warning!!! Agola CreateProject API error:", err.Error())
+			} else {
+				synkProjectVariables(ctx, organization, &project, agolaApi)
 			}
 		}
 
+		createRepositoryWebHook(user, organization, gitSource, &project, gitGateway)
+
 		organization.Projects[repo] = project
 		err := db.SaveOrganization(organization)
 		if err != nil {
@@ -55,38 +65,42 @@ func CheckoutAllGitRepository(db repository.Database, user *model.User, organiza
 	log.Println("End CheckoutAllGitRepository")
 }
 
-func SynkGitRepositorys(db repository.Database, user *model.User, organization *model.Organization, gitSource *model.GitSource, agolaApi agola.AgolaApiInterface, gitGateway *git.GitGateway) error {
+func SynkGitRepositorys(ctx context.Context, db repository.Database, user *model.User, organization *model.Organization, gitSource *model.GitSource, agolaApi agola.AgolaApiInterface, gitGateway *git.GitGateway) error {
 	log.Println("Start SynkGitRepositorys for", organization.GitPath)
 
 	if organization.Projects == nil {
 		organization.Projects = make(map[string]model.Project)
 	}
 
-	gitRepositoryList, err := gitGateway.GetRepositories(gitSource, user, organization.GitPath)
-	if err == nil {
-		log.Println("git GetRepositories err:", err)
+	gitRepositoryList, err := gitGateway.GetOrganizationRepositories(gitSource, user, organization)
+	if err != nil {
+		log.Println("git GetOrganizationRepositories err:", err)
 	}
 
-	if gitRepositoryList != nil {
+	if gitRepositoryList != nil && err == nil {
+		synckRenamedRepositories(ctx, organization, *gitRepositoryList, agolaApi, user)
+
 		for projectName, project := range organization.Projects {
 			log.Println("SynkGitRepositorys git repository:", projectName)
 			gitRepoExists := false
 			for _, gitRepo := range *gitRepositoryList {
-				if strings.Compare(projectName, gitRepo) == 0 {
+				if strings.Compare(projectName, gitRepo.Name) == 0 {
 					gitRepoExists = true
 					break
 				}
 			}
 			if !gitRepoExists {
-				err := agolaApi.DeleteProject(organization, project.AgolaProjectRef, user)
-				if err == nil {
+				err := agolaApi.DeleteProject(ctx, organization, project.AgolaProjectRef, user)
+				if err == nil || errors.Is(err, agola.ErrNotFound) {
 					delete(organization.Projects, projectName)
 				} else {
 					log.Println("Agola DeleteProject error:", err)
 				}
 			} else {
-				agolaExists, agolaProjectID := agolaApi.CheckProjectExists(organization, project.AgolaProjectRef)
-				if !agolaExists && !project.Archivied {
+				agolaExists, agolaProjectID, err := agolaApi.CheckProjectExists(ctx, organization, project.AgolaProjectRef)
+				if err != nil {
+					log.Println("Agola CheckProjectExists of", projectName, "error, project not changed:", err)
+				} else if !agolaExists && !project.Archivied {
 					delete(organization.Projects, projectName)
 				} else {
 					project.AgolaProjectID = agolaProjectID
@@ -95,13 +109,19 @@ func SynkGitRepositorys(db repository.Database, user *model.User, organization *
 			}
 		}
 
-		for _, repo := range *gitRepositoryList {
+		for _, gitRepo := range *gitRepositoryList {
+			repo := gitRepo.Name
 			if !utils.EvaluateBehaviour(organization, repo) {
+				if project, ok := organization.Projects[repo]; ok {
+					deleteRepositoryWebHook(user, organization, gitSource, &project, gitGateway)
+				}
 				delete(organization.Projects, repo)
 
 				agolaProjectRef := utils.ConvertToAgolaProjectRef(repo)
-				if exists, _ := agolaApi.CheckProjectExists(organization, agolaProjectRef); exists {
-					err := agolaApi.DeleteProject(organization, agolaProjectRef, user)
+				if exists, _, err := agolaApi.CheckProjectExists(ctx, organization, agolaProjectRef); err != nil {
+					log.Println("Agola CheckProjectExists of", repo, "error:", err)
+				} else if exists {
+					err := agolaApi.DeleteProject(ctx, organization, agolaProjectRef, user)
 					if err != nil {
 						log.Println("Agola DeleteProject error:", err)
 					}
@@ -112,18 +132,32 @@ func SynkGitRepositorys(db repository.Database, user *model.User, organization *
 
 			var project model.Project
 			if p, ok := organization.Projects[repo]; !ok {
-				project = model.Project{GitRepoPath: repo, AgolaProjectRef: utils.ConvertToAgolaProjectRef(repo)}
+				project = model.Project{GitRepoPath: repo, GitRepoID: gitRepo.ID, AgolaProjectRef: utils.ConvertToAgolaProjectRef(repo)}
+				createRepositoryWebHook(user, organization, gitSource, &project, gitGateway)
 				organization.Projects[repo] = project
 			} else {
 				project = p
+				if project.GitRepoID != gitRepo.ID {
+					project.GitRepoID = gitRepo.ID
+					organization.Projects[repo] = project
+				}
 			}
 
 			BranchSynck(db, user, gitSource, organization, repo, gitGateway)
 
-			agolaConfExists, _ := gitGateway.CheckRepositoryAgolaConfExists(gitSource, user, organization.GitPath, repo)
-			if !agolaConfExists {
+			agolaConfBranches, err := gitGateway.GetAgolaConfBranches(gitSource, user, organization.GitPath, repo)
+			//only a repository not found has no Agola config, with the other errors the config is unknown
+			if err != nil && !errors.Is(err, git.ErrNotFound) {
+				log.Println("GetAgolaConfBranches of", repo, "failed, project not changed:", err)
+				continue
+			}
+			project = organization.Projects[repo]
+			project.AgolaConfBranches = agolaConfBranches
+			organization.Projects[repo] = project
+
+			if !project.HasAgolaConf() {
 				if project, ok := organization.Projects[repo]; ok && !project.Archivied {
-					err := agolaApi.ArchiveProject(organization, project.AgolaProjectRef)
+					err := agolaApi.ArchiveProject(ctx, organization, project.AgolaProjectRef)
 					if err == nil {
 						project.Archivied = true
 						organization.Projects[repo] = project
@@ -133,16 +167,25 @@ func SynkGitRepositorys(db repository.Database, user *model.User, organization *
 				continue
 			}
 
-			if exists, projectID := agolaApi.CheckProjectExists(organization, utils.ConvertToAgolaProjectRef(repo)); exists {
+			exists, projectID, err := agolaApi.CheckProjectExists(ctx, organization, utils.ConvertToAgolaProjectRef(repo))
+			if err != nil {
+				log.Println("Agola CheckProjectExists of", repo, "error, project not created:", err)
+				if errors.Is(err, agola.ErrUnavailable) || ctx.Err() != nil {
+					break
+				}
+				continue
+			}
+			if exists {
 				if project, ok := organization.Projects[repo]; ok {
 					project.AgolaProjectID = projectID
 					if project.Archivied {
-						err := agolaApi.UnarchiveProject(organization, utils.ConvertToAgolaProjectRef(repo))
+						err := agolaApi.UnarchiveProject(ctx, organization, utils.ConvertToAgolaProjectRef(repo))
 						if err == nil {
 							project.Archivied = false
 							organization.Projects[repo] = project
 						}
 					}
+					synkProjectVariables(ctx, organization, &project, agolaApi)
 					organization.Projects[repo] = project
 				}
 
@@ -150,12 +193,13 @@ func SynkGitRepositorys(db repository.Database, user *model.User, organization *
 			}
 
 			log.Println("Start add repository:", repo)
-			projectID, err := agolaApi.CreateProject(repo, utils.ConvertToAgolaProjectRef(repo), organization, gitSource.AgolaRemoteSource, user)
+			projectID, err = agolaApi.CreateProject(ctx, repo, utils.ConvertToAgolaProjectRef(repo), organization, gitSource.AgolaRemoteSource, user)
 			if err != nil {
 				log.Println("Warning!!! Agola CreateProject API error:", err.Error())
 				break
 			}
 			project.AgolaProjectID = projectID
+			synkProjectVariables(ctx, organization, &project, agolaApi)
 			organization.Projects[repo] = project
 			log.Println("End add repository:", repo)
 		}
@@ -171,6 +215,168 @@ func SynkGitRepositorys(db repository.Database, user *model.User, organization *
 	return nil
 }
 
+//The errors are only logged, the variables are applied again by the next synk
+func synkProjectVariables(ctx context.Context, organization *model.Organization, project *model.Project, agolaApi agola.AgolaApiInterface) {
+	err := variablesManager.SynkProjectVariables(ctx, organization, project, agolaApi)
+	if err != nil {
+		log.Println("SynkProjectVariables of", project.GitRepoPath, "error:", err)
+	}
+}
+
+//The repositories of the user namespaces have their webhook, the organizations have a single webhook
+func createRepositoryWebHook(user *model.User, organization *model.Organization, gitSource *model.GitSource, project *model.Project, gitGateway *git.GitGateway) {
+	if !organization.UserNamespace {
+		return
+	}
+
+	webHookID, err := gitGateway.CreateRepositoryWebHook(gitSource, user, organization, project.GitRepoPath)
+	if err != nil {
+		log.Println("CreateRepositoryWebHook of", project.GitRepoPath, "error:", err)
+	}
+	project.WebHookID = webHookID
+}
+
+func deleteRepositoryWebHook(user *model.User, organization *model.Organization, gitSource *model.GitSource, project *model.Project, gitGateway *git.GitGateway) {
+	if !organization.UserNamespace || project.WebHookID <= 0 {
+		return
+	}
+
+	err := gitGateway.DeleteRepositoryWebHook(gitSource, user, organization, project.GitRepoPath, project.WebHookID)
+	if err != nil {
+		log.Println("DeleteRepositoryWebHook of", project.GitRepoPath, "error:", err)
+	}
+}
+
+//Delete the webhooks of all the repositories of a user namespace
+func DeleteRepositoryWebHooks(user *model.User, organization *model.Organization, gitSource *model.GitSource, gitGateway *git.GitGateway) {
+	for _, project := range organization.Projects {
+		deleteRepositoryWebHook(user, organization, gitSource, &project, gitGateway)
+	}
+}
+
+/*
+Repositories renamed or transferred are found by their stable git ID:
+the project is moved to the new name keeping the Agola project and the branches
+*/
+func synckRenamedRepositories(ctx context.Context, organization *model.Organization, gitRepositoryList []dto.RepositoryDto, agolaApi agola.AgolaApiInterface, user *model.User) {
+	gitRepositoryNames := make(map[int]string)
+	for _, gitRepo := range gitRepositoryList {
+		gitRepositoryNames[gitRepo.ID] = gitRepo.Name
+	}
+
+	for projectName, project := range organization.Projects {
+		if project.GitRepoID == 0 {
+			continue
+		}
+
+		repositoryName, ok := gitRepositoryNames[project.GitRepoID]
+		if !ok || strings.Compare(projectName, repositoryName) == 0 {
+			continue
+		}
+
+		err := moveProject(ctx, organization, projectName, repositoryName, project.GitRepoID, agolaApi, user)
+		if err != nil {
+			log.Println("rename of project", projectName, "to", repositoryName, "error:", err)
+		}
+	}
+}
+
+//Rename the project of a renamed or transferred repository keeping the Agola project and its runs history
+func RenameProject(ctx context.Context, db repository.Database, organization *model.Organization, oldRepositoryName string, repositoryName string, gitRepoID int, agolaApi agola.AgolaApiInterface, user *model.User) error {
+	err := moveProject(ctx, organization, oldRepositoryName, repositoryName, gitRepoID, agolaApi, user)
+	if err != nil {
+		return err
+	}
+
+	return db.SaveOrganization(organization)
+}
+
+func moveProject(ctx context.Context, organization *model.Organization, oldRepositoryName string, repositoryName string, gitRepoID int, agolaApi agola.AgolaApiInterface, user *model.User) error {
+	project, ok := organization.Projects[oldRepositoryName]
+	if !ok {
+		return fmt.Errorf("project %s not found", oldRepositoryName)
+	}
+	if _, exists := organization.Projects[repositoryName]; exists {
+		return fmt.Errorf("project %s already exists", repositoryName)
+	}
+
+	log.Println("rename project", oldRepositoryName, "to", repositoryName)
+
+	agolaProjectRef := utils.ConvertToAgolaProjectRef(repositoryName)
+	if project.ExistsInAgola() && strings.Compare(project.AgolaProjectRef, agolaProjectRef) != 0 {
+		err := agolaApi.RenameProject(ctx, organization, project.AgolaProjectRef, agolaProjectRef, user)
+		if err != nil {
+			return err
+		}
+	}
+
+	project.GitRepoPath = repositoryName
+	project.AgolaProjectRef = agolaProjectRef
+	if gitRepoID != 0 {
+		project.GitRepoID = gitRepoID
+	}
+
+	delete(organization.Projects, oldRepositoryName)
+	organization.Projects[repositoryName] = project
+
+	return nil
+}
+
+/*
+Move the project of a git repository transferred from another organization, the Agola project keeps its ID and its runs.
+Return false if the repository isn't a project of fromOrganization
+*/
+func MoveProjectToOrganization(ctx context.Context, db repository.Database, fromOrganization *model.Organization, organization *model.Organization, repositoryName string, gitRepoID int, agolaApi agola.AgolaApiInterface, user *model.User) (bool, error) {
+	oldRepositoryName := GetProjectNameByGitRepoID(fromOrganization, gitRepoID)
+	project, ok := fromOrganization.Projects[oldRepositoryName]
+	if !ok {
+		return false, nil
+	}
+	if _, exists := organization.Projects[repositoryName]; exists {
+		return false, fmt.Errorf("project %s already exists", repositoryName)
+	}
+
+	log.Println("move project", oldRepositoryName, "of", fromOrganization.AgolaOrganizationRef, "to", repositoryName, "of", organization.AgolaOrganizationRef)
+
+	agolaProjectRef := utils.ConvertToAgolaProjectRef(repositoryName)
+	if project.ExistsInAgola() {
+		err := agolaApi.MoveProject(ctx, fromOrganization, project.AgolaProjectRef, organization, agolaProjectRef, user)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	project.GitRepoPath = repositoryName
+	project.AgolaProjectRef = agolaProjectRef
+
+	delete(fromOrganization.Projects, oldRepositoryName)
+	if organization.Projects == nil {
+		organization.Projects = make(map[string]model.Project)
+	}
+	organization.Projects[repositoryName] = project
+
+	if err := db.SaveOrganization(fromOrganization); err != nil {
+		return true, err
+	}
+
+	return true, db.SaveOrganization(organization)
+}
+
+//Return the name of the project of the git repository, empty if not found
+func GetProjectNameByGitRepoID(organization *model.Organization, gitRepoID int) string {
+	if gitRepoID == 0 {
+		return ""
+	}
+
+	for projectName, project := range organization.Projects {
+		if project.GitRepoID == gitRepoID {
+			return projectName
+		}
+	}
+
+	return ""
+}
+
 func BranchSynck(db repository.Database, user *model.User, gitSource *model.GitSource, organization *model.Organization, repositoryName string, gitGateway *git.GitGateway) {
 	if _, exists := organization.Projects[repositoryName]; !exists {
 		return
@@ -187,7 +393,7 @@ func BranchSynck(db repository.Database, user *model.User, gitSource *model.GitS
 		log.Println("GetBranches error:", err)
 	}
 
-	if branchList != nil {
+	if branchList != nil && err == nil {
 		for branch := range branchList {
 			if _, ok := organization.Projects[repositoryName].Branchs[branch]; !ok {
 				organization.Projects[repositoryName].Branchs[branch] = model.Branch{Name: branch}
@@ -206,3 +412,29 @@ func BranchSynck(db repository.Database, user *model.User, gitSource *model.GitS
 		log.Println("error in SaveOrganization:", err)
 	}
 }
+
+//Add or remove a single branch of the project without asking the branch list to git
+func BranchUpdate(db repository.Database, organization *model.Organization, repositoryName string, branchName string, deleted bool) error {
+	project, exists := organization.Projects[repositoryName]
+	if !exists {
+		return nil
+	}
+
+	if project.Branchs == nil {
+		project.Branchs = make(map[string]model.Branch)
+	}
+
+	_, branchExists := project.Branchs[branchName]
+	if deleted == !branchExists {
+		return nil
+	}
+
+	if deleted {
+		delete(project.Branchs, branchName)
+	} else {
+		project.Branchs[branchName] = model.Branch{Name: branchName}
+	}
+	organization.Projects[repositoryName] = project
+
+	return db.SaveOrganization(organization)
+}
@@ -0,0 +1,135 @@
+package variablesManager
+
+import (
+	"context"
+	"log"
+
+	"wecode.sorint.it/opensource/papagaio-api/api/agola"
+	"wecode.sorint.it/opensource/papagaio-api/common"
+	"wecode.sorint.it/opensource/papagaio-api/config"
+	"wecode.sorint.it/opensource/papagaio-api/model"
+)
+
+/*
+Apply the secrets and variables of the organization to the Agola project when their revision changed since the last apply:
+the missing ones are created, the existing ones updated and the ones removed from the organization deleted.
+The project isn't saved, on error it is applied again by the next synk
+*/
+func SynkProjectVariables(ctx context.Context, organization *model.Organization, project *model.Project, agolaApi agola.AgolaApiInterface) error {
+	if !project.ExistsInAgola() || project.IsAgolaVariablesApplied(&organization.AgolaVariables) {
+		return nil
+	}
+
+	log.Println("SynkProjectVariables", project.AgolaProjectRef, "revision", organization.AgolaVariables.Revision)
+
+	agolaSecrets, err := agolaApi.GetProjectSecrets(ctx, organization, project.AgolaProjectRef)
+	if err != nil {
+		return err
+	}
+	agolaVariables, err := agolaApi.GetProjectVariables(ctx, organization, project.AgolaProjectRef)
+	if err != nil {
+		return err
+	}
+
+	existingSecrets := make(map[string]bool)
+	for _, secret := range agolaSecrets {
+		existingSecrets[secret.Name] = true
+	}
+	existingVariables := make(map[string]bool)
+	for _, variable := range agolaVariables {
+		existingVariables[variable.Name] = true
+	}
+
+	applied := &model.AppliedAgolaVariables{Revision: organization.AgolaVariables.Revision, Secrets: []string{}, Variables: []string{}}
+
+	for _, secret := range organization.AgolaVariables.Secrets {
+		data, err := decryptSecretData(secret.Data)
+		if err != nil {
+			return err
+		}
+
+		if existingSecrets[secret.Name] {
+			err = agolaApi.UpdateProjectSecret(ctx, organization, project.AgolaProjectRef, secret.Name, data)
+		} else {
+			err = agolaApi.CreateProjectSecret(ctx, organization, project.AgolaProjectRef, secret.Name, data)
+		}
+		if err != nil {
+			return err
+		}
+		applied.Secrets = append(applied.Secrets, secret.Name)
+	}
+
+	for _, variable := range organization.AgolaVariables.Variables {
+		values := make([]agola.VariableValueDto, 0, len(variable.Values))
+		for _, value := range variable.Values {
+			values = append(values, agola.VariableValueDto{SecretName: value.SecretName, SecretVar: value.SecretVar})
+		}
+
+		if existingVariables[variable.Name] {
+			err = agolaApi.UpdateProjectVariable(ctx, organization, project.AgolaProjectRef, variable.Name, values)
+		} else {
+			err = agolaApi.CreateProjectVariable(ctx, organization, project.AgolaProjectRef, variable.Name, values)
+		}
+		if err != nil {
+			return err
+		}
+		applied.Variables = append(applied.Variables, variable.Name)
+	}
+
+	//the variables are deleted before the secrets they use
+	if project.AgolaVariables != nil {
+		for _, variableName := range removedNames(project.AgolaVariables.Variables, applied.Variables) {
+			if !existingVariables[variableName] {
+				continue
+			}
+			err = agolaApi.DeleteProjectVariable(ctx, organization, project.AgolaProjectRef, variableName)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, secretName := range removedNames(project.AgolaVariables.Secrets, applied.Secrets) {
+			if !existingSecrets[secretName] {
+				continue
+			}
+			err = agolaApi.DeleteProjectSecret(ctx, organization, project.AgolaProjectRef, secretName)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	project.AgolaVariables = applied
+
+	return nil
+}
+
+func decryptSecretData(encryptedData map[string]string) (map[string]string, error) {
+	data := make(map[string]string)
+	for key, encryptedValue := range encryptedData {
+		value, err := common.DecryptSecretValue(config.Config.SecretsEncryptionKey, encryptedValue)
+		if err != nil {
+			return nil, err
+		}
+		data[key] = value
+	}
+
+	return data, nil
+}
+
+//Names applied before that aren't in the organization anymore
+func removedNames(appliedNames []string, names []string) []string {
+	found := make(map[string]bool)
+	for _, name := range names {
+		found[name] = true
+	}
+
+	removed := make([]string, 0)
+	for _, name := range appliedNames {
+		if !found[name] {
+			removed = append(removed, name)
+		}
+	}
+
+	return removed
+}
@@ -0,0 +1,286 @@
+package webHookManager
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"wecode.sorint.it/opensource/papagaio-api/api/git"
+	"wecode.sorint.it/opensource/papagaio-api/api/git/dto"
+	"wecode.sorint.it/opensource/papagaio-api/controller"
+	"wecode.sorint.it/opensource/papagaio-api/model"
+	"wecode.sorint.it/opensource/papagaio-api/repository"
+	"wecode.sorint.it/opensource/papagaio-api/types"
+)
+
+//Encrypt the plain webhook secrets stored by the previous versions
+func EncryptLegacyWebHookSecrets(db repository.Database) {
+	organizations, err := db.GetOrganizations()
+	if err != nil || organizations == nil {
+		log.Println("EncryptLegacyWebHookSecrets GetOrganizations error:", err)
+		return
+	}
+
+	for i := range *organizations {
+		organization := &(*organizations)[i]
+
+		encrypted, err := organization.EncryptLegacyWebHookSecret()
+		if err != nil {
+			log.Println("failed to encrypt the webhook secret of", organization.AgolaOrganizationRef, ":", err)
+			continue
+		}
+		if !encrypted {
+			continue
+		}
+
+		if err := db.SaveOrganization(organization); err != nil {
+			log.Println("EncryptLegacyWebHookSecrets SaveOrganization error:", err)
+		}
+	}
+}
+
+//The organizations of a git source can be created at the same time, only one of them creates the system hook
+var systemHookMutex sync.Mutex
+
+/*
+Create the system hook of the git source if it doesn't exist yet, only for the providers with the systemHooks capability.
+It is created once for all the organizations of the git source, the user must be an administrator of the git server
+*/
+func CreateSystemHook(db repository.Database, user *model.User, gitSource *model.GitSource, gitGateway *git.GitGateway) error {
+	if !gitGateway.HasCapability(gitSource, git.CapabilitySystemHooks) {
+		return nil
+	}
+
+	systemHookMutex.Lock()
+	defer systemHookMutex.Unlock()
+
+	current, err := db.GetGitSourceByName(gitSource.Name)
+	if err != nil {
+		return err
+	}
+	if current != nil {
+		*gitSource = *current
+	}
+	if gitSource.HasSystemHook() {
+		return nil
+	}
+
+	systemHookID, systemHookSecret, err := gitGateway.CreateSystemHook(gitSource, user, controller.GetGitSourceWebHookURL(gitSource.Name))
+	if err != nil {
+		return err
+	}
+
+	if err := gitSource.SetSystemHook(systemHookID, systemHookSecret); err != nil {
+		return err
+	}
+
+	log.Println("system hook", systemHookID, "created for git source", gitSource.Name)
+
+	return db.SaveGitSource(gitSource)
+}
+
+/*
+Check that the organization webhook exists, is active, calls the papagaio url and sends exactly the expected events.
+Otherwise the webhook is recreated with a new secret.
+The system hook of the git source is checked too, the status saved is the worst of them
+*/
+func SynkWebHook(db repository.Database, user *model.User, organization *model.Organization, gitSource *model.GitSource, gitGateway *git.GitGateway) error {
+	log.Println("Start SynkWebHook for", organization.GitPath)
+
+	var status types.WebHookStatusType
+	if organization.UserNamespace {
+		status = synkRepositoryWebHooks(user, organization, gitSource, gitGateway)
+	} else {
+		status = synkOrganizationWebHook(user, organization, gitSource, gitGateway)
+	}
+
+	if gitGateway.HasCapability(gitSource, git.CapabilitySystemHooks) {
+		status = worstWebHookStatus(status, synkSystemHook(db, user, gitSource, gitGateway))
+	}
+
+	log.Println("End SynkWebHook for", organization.GitPath)
+
+	return saveWebHookStatus(db, organization, status)
+}
+
+func synkOrganizationWebHook(user *model.User, organization *model.Organization, gitSource *model.GitSource, gitGateway *git.GitGateway) types.WebHookStatusType {
+	webHook, err := gitGateway.GetWebHook(gitSource, user, organization.GitPath, organization.WebHookID)
+	if err != nil {
+		log.Println("GetWebHook error:", err)
+		return types.WebHookStatusUnknown
+	}
+
+	problem := checkWebHook(webHook, controller.GetWebHookURL(organization.AgolaOrganizationRef), organization.HasWebHookSecret(), gitGateway.GetWebHookEvents(gitSource))
+	if len(problem) == 0 {
+		return types.WebHookStatusOk
+	}
+
+	log.Println("webhook of organization", organization.AgolaOrganizationRef, "must be repaired:", problem)
+
+	if webHook != nil {
+		err := gitGateway.DeleteWebHook(gitSource, user, organization.GitPath, organization.WebHookID)
+		if err != nil {
+			log.Println("DeleteWebHook error:", err)
+		}
+	}
+
+	webHookID, webHookSecret, err := gitGateway.CreateWebHook(gitSource, user, organization.GitPath, organization.AgolaOrganizationRef)
+	if err != nil {
+		log.Println("CreateWebHook error:", err)
+		return types.WebHookStatusBroken
+	}
+
+	organization.WebHookID = webHookID
+	if err := organization.SetWebHookSecret(webHookSecret); err != nil {
+		log.Println("SetWebHookSecret error:", err)
+		return types.WebHookStatusBroken
+	}
+
+	return types.WebHookStatusRepaired
+}
+
+//The user namespaces have a webhook for every repository, the status of the organization is the worst of them
+func synkRepositoryWebHooks(user *model.User, organization *model.Organization, gitSource *model.GitSource, gitGateway *git.GitGateway) types.WebHookStatusType {
+	status := types.WebHookStatusOk
+	webHookURL := controller.GetWebHookURL(organization.AgolaOrganizationRef)
+	expectedEvents := gitGateway.GetWebHookEvents(gitSource)
+
+	for projectName, project := range organization.Projects {
+		webHook, err := gitGateway.GetRepositoryWebHook(gitSource, user, organization, project.GitRepoPath, project.WebHookID)
+		if err != nil {
+			log.Println("GetRepositoryWebHook of", projectName, "error:", err)
+			status = worstWebHookStatus(status, types.WebHookStatusUnknown)
+			continue
+		}
+
+		problem := checkWebHook(webHook, webHookURL, organization.HasWebHookSecret(), expectedEvents)
+		if len(problem) == 0 {
+			continue
+		}
+
+		log.Println("webhook of repository", projectName, "must be repaired:", problem)
+
+		if webHook != nil {
+			err := gitGateway.DeleteRepositoryWebHook(gitSource, user, organization, project.GitRepoPath, project.WebHookID)
+			if err != nil {
+				log.Println("DeleteRepositoryWebHook error:", err)
+			}
+		}
+
+		project.WebHookID, err = gitGateway.CreateRepositoryWebHook(gitSource, user, organization, project.GitRepoPath)
+		organization.Projects[projectName] = project
+		if err != nil {
+			log.Println("CreateRepositoryWebHook error:", err)
+			status = worstWebHookStatus(status, types.WebHookStatusBroken)
+			continue
+		}
+
+		status = worstWebHookStatus(status, types.WebHookStatusRepaired)
+	}
+
+	return status
+}
+
+//Check the system hook shared by the organizations of the git source and recreate it when it isn't valid
+func synkSystemHook(db repository.Database, user *model.User, gitSource *model.GitSource, gitGateway *git.GitGateway) types.WebHookStatusType {
+	systemHookMutex.Lock()
+	defer systemHookMutex.Unlock()
+
+	current, err := db.GetGitSourceByName(gitSource.Name)
+	if err != nil || current == nil {
+		log.Println("GetGitSourceByName error:", err)
+		return types.WebHookStatusUnknown
+	}
+	*gitSource = *current
+
+	var systemHook *dto.WebHookDto
+	if gitSource.HasSystemHook() {
+		systemHook, err = gitGateway.GetSystemHook(gitSource, user, gitSource.SystemHookID)
+		if err != nil {
+			log.Println("GetSystemHook error:", err)
+			return types.WebHookStatusUnknown
+		}
+	}
+
+	problem := checkWebHook(systemHook, controller.GetGitSourceWebHookURL(gitSource.Name), gitSource.HasSystemHook(), gitGateway.GetSystemHookEvents(gitSource))
+	if len(problem) == 0 {
+		return types.WebHookStatusOk
+	}
+
+	log.Println("system hook of git source", gitSource.Name, "must be repaired:", problem)
+
+	//the system hooks with the same url are deleted when the new one is created
+	systemHookID, systemHookSecret, err := gitGateway.CreateSystemHook(gitSource, user, controller.GetGitSourceWebHookURL(gitSource.Name))
+	if err != nil {
+		log.Println("CreateSystemHook error:", err)
+		return types.WebHookStatusBroken
+	}
+
+	if err := gitSource.SetSystemHook(systemHookID, systemHookSecret); err != nil {
+		log.Println("SetSystemHook error:", err)
+		return types.WebHookStatusBroken
+	}
+
+	if err := db.SaveGitSource(gitSource); err != nil {
+		log.Println("SaveGitSource error:", err)
+		return types.WebHookStatusBroken
+	}
+
+	return types.WebHookStatusRepaired
+}
+
+var webHookStatusSeverity = map[types.WebHookStatusType]int{
+	types.WebHookStatusOk:       0,
+	types.WebHookStatusRepaired: 1,
+	types.WebHookStatusUnknown:  2,
+	types.WebHookStatusBroken:   3,
+}
+
+func worstWebHookStatus(status types.WebHookStatusType, other types.WebHookStatusType) types.WebHookStatusType {
+	if webHookStatusSeverity[other] > webHookStatusSeverity[status] {
+		return other
+	}
+	return status
+}
+
+//Return the reason why the webhook must be repaired, empty if the webhook is ok
+func checkWebHook(webHook *dto.WebHookDto, webHookURL string, hasSecret bool, expectedEvents []string) string {
+	if webHook == nil {
+		return "webhook not found"
+	}
+	if !webHook.Active {
+		return "webhook not active"
+	}
+	if strings.Compare(webHook.URL, webHookURL) != 0 {
+		return "webhook url " + webHook.URL + " not valid"
+	}
+	if !hasSecret {
+		return "webhook without secret"
+	}
+
+	events := make(map[string]bool)
+	for _, event := range webHook.Events {
+		events[event] = true
+	}
+
+	for _, expectedEvent := range expectedEvents {
+		if !events[expectedEvent] {
+			return "webhook event " + expectedEvent + " not enabled"
+		}
+		delete(events, expectedEvent)
+	}
+
+	for event := range events {
+		return "webhook event " + event + " not expected"
+	}
+
+	return ""
+}
+
+func saveWebHookStatus(db repository.Database, organization *model.Organization, status types.WebHookStatusType) error {
+	organization.WebHookStatus = status
+	organization.WebHookCheckDate = time.Now()
+
+	return db.SaveOrganization(organization)
+}
@@ -0,0 +1,106 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrAgolaVariableNotFound = errors.New("not found")
+var ErrAgolaSecretInUse = errors.New("the secret is used by the variable")
+
+/*
+Templates of the Agola secrets and variables added to every project of the organization.
+The values of the secrets are encrypted, Revision changes at every update so the synk applies them again
+*/
+type AgolaVariables struct {
+	Secrets   []AgolaSecret   `json:"secrets,omitempty"`
+	Variables []AgolaVariable `json:"variables,omitempty"`
+	Revision  uint64          `json:"revision"`
+}
+
+type AgolaSecret struct {
+	Name string            `json:"name"`
+	Data map[string]string `json:"data"` //encrypted values
+}
+
+type AgolaVariable struct {
+	Name   string               `json:"name"`
+	Values []AgolaVariableValue `json:"values"`
+}
+
+type AgolaVariableValue struct {
+	SecretName string `json:"secretName"`
+	SecretVar  string `json:"secretVar"`
+}
+
+//Secrets and variables applied to the Agola project, used to remove the ones deleted from the organization
+type AppliedAgolaVariables struct {
+	Revision  uint64   `json:"revision"`
+	Secrets   []string `json:"secrets"`
+	Variables []string `json:"variables"`
+}
+
+func (agolaVariables *AgolaVariables) IsEmpty() bool {
+	return len(agolaVariables.Secrets) == 0 && len(agolaVariables.Variables) == 0
+}
+
+//Add the secret or replace the one with the same name
+func (agolaVariables *AgolaVariables) SetSecret(secret AgolaSecret) {
+	for i := range agolaVariables.Secrets {
+		if agolaVariables.Secrets[i].Name == secret.Name {
+			agolaVariables.Secrets[i] = secret
+			agolaVariables.Revision++
+			return
+		}
+	}
+
+	agolaVariables.Secrets = append(agolaVariables.Secrets, secret)
+	agolaVariables.Revision++
+}
+
+//A secret used by a variable can't be removed, the variable must be changed or removed before
+func (agolaVariables *AgolaVariables) RemoveSecret(name string) error {
+	for _, variable := range agolaVariables.Variables {
+		for _, value := range variable.Values {
+			if value.SecretName == name {
+				return fmt.Errorf("%w %s", ErrAgolaSecretInUse, variable.Name)
+			}
+		}
+	}
+
+	for i := range agolaVariables.Secrets {
+		if agolaVariables.Secrets[i].Name == name {
+			agolaVariables.Secrets = append(agolaVariables.Secrets[:i], agolaVariables.Secrets[i+1:]...)
+			agolaVariables.Revision++
+			return nil
+		}
+	}
+
+	return ErrAgolaVariableNotFound
+}
+
+//Add the variable or replace the one with the same name
+func (agolaVariables *AgolaVariables) SetVariable(variable AgolaVariable) {
+	for i := range agolaVariables.Variables {
+		if agolaVariables.Variables[i].Name == variable.Name {
+			agolaVariables.Variables[i] = variable
+			agolaVariables.Revision++
+			return
+		}
+	}
+
+	agolaVariables.Variables = append(agolaVariables.Variables, variable)
+	agolaVariables.Revision++
+}
+
+func (agolaVariables *AgolaVariables) RemoveVariable(name string) error {
+	for i := range agolaVariables.Variables {
+		if agolaVariables.Variables[i].Name == name {
+			agolaVariables.Variables = append(agolaVariables.Variables[:i], agolaVariables.Variables[i+1:]...)
+			agolaVariables.Revision++
+			return nil
+		}
+	}
+
+	return ErrAgolaVariableNotFound
+}
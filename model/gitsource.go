@@ -1,6 +1,10 @@
 package model
 
-import "wecode.sorint.it/opensource/papagaio-api/types"
+import (
+	"wecode.sorint.it/opensource/papagaio-api/common"
+	"wecode.sorint.it/opensource/papagaio-api/config"
+	"wecode.sorint.it/opensource/papagaio-api/types"
+)
 
 type GitSource struct {
 	ID                string        `json:"id"`
@@ -10,4 +14,36 @@ type GitSource struct {
 	GitClientID       string        `json:"gitClientId"`
 	GitSecret         string        `json:"gitSecret"`
 	AgolaRemoteSource string        `json:"agolaRemoteSource"`
+
+	//GitHub App used for the organization calls, the user oauth2 is used only for the login
+	GithubAppID             int64  `json:"githubAppId,omitempty"`
+	GithubAppPrivateKeyPath string `json:"githubAppPrivateKeyPath,omitempty"`
+
+	//GitLab system hook shared by the organizations of the git source, the secret is encrypted like the webhook secrets
+	SystemHookID              int64  `json:"systemHookId,omitempty"`
+	EncryptedSystemHookSecret string `json:"encryptedSystemHookSecret,omitempty"`
+}
+
+func (gitSource *GitSource) IsGithubApp() bool {
+	return gitSource.GitType == types.Github && gitSource.GithubAppID > 0
+}
+
+func (gitSource *GitSource) HasSystemHook() bool {
+	return gitSource.SystemHookID > 0 && len(gitSource.EncryptedSystemHookSecret) > 0
+}
+
+func (gitSource *GitSource) GetSystemHookSecret() (string, error) {
+	return common.DecryptSecretValue(config.Config.SecretsEncryptionKey, gitSource.EncryptedSystemHookSecret)
+}
+
+func (gitSource *GitSource) SetSystemHook(systemHookID int64, systemHookSecret string) error {
+	encryptedSystemHookSecret, err := common.EncryptSecretValue(config.Config.SecretsEncryptionKey, systemHookSecret)
+	if err != nil {
+		return err
+	}
+
+	gitSource.SystemHookID = systemHookID
+	gitSource.EncryptedSystemHookSecret = encryptedSystemHookSecret
+
+	return nil
 }
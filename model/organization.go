@@ -1,6 +1,10 @@
 package model
 
 import (
+	"time"
+
+	"wecode.sorint.it/opensource/papagaio-api/common"
+	"wecode.sorint.it/opensource/papagaio-api/config"
 	"wecode.sorint.it/opensource/papagaio-api/types"
 )
 
@@ -17,10 +21,102 @@ type Organization struct {
 	WebHookID         int64  `json:"webHookId"`
 	GitOrganizationID int64  `json:"gitOrganizationId"`
 
+	//Webhook secret encrypted with the secrets encryption key, use GetWebHookSecret and SetWebHookSecret
+	EncryptedWebHookSecret string `json:"encryptedWebHookSecret,omitempty"`
+	//Plain webhook secret stored by the previous versions, encrypted at startup by EncryptLegacyWebHookSecret
+	LegacyWebHookSecret string `json:"webHookSecret,omitempty"`
+
+	WebHookStatus    types.WebHookStatusType `json:"webHookStatus"`
+	WebHookCheckDate time.Time               `json:"webHookCheckDate"`
+
 	BehaviourInclude string              `json:"behaviourInclude"`
 	BehaviourExclude string              `json:"behaviourExclude"`
 	BehaviourType    types.BehaviourType `json:"behaviourType" example:"none"`
 
+	//The projects of the subgroups are named by their path relative to GitPath
+	IncludeSubgroups bool `json:"includeSubgroups"`
+
+	//Personal namespace of a git user: the projects are created in the projectgroup of the Agola user
+	//and every repository has its webhook
+	UserNamespace bool   `json:"userNamespace"`
+	AgolaUserRef  string `json:"agolaUserRef,omitempty"`
+
+	CollaboratorsPolicy types.CollaboratorsPolicy `json:"collaboratorsPolicy,omitempty"`
+	MembersMapping      MembersMapping            `json:"membersMapping"`
+
+	AgolaVariables AgolaVariables `json:"agolaVariables"`
+
 	Projects      map[string]Project `json:"projects"`
 	ExternalUsers map[string]bool    `json:"externalUsers"`
 }
+
+func (organization *Organization) HasWebHookSecret() bool {
+	return len(organization.EncryptedWebHookSecret) > 0
+}
+
+//The plain webhook secret, shared with the git server
+func (organization *Organization) GetWebHookSecret() (string, error) {
+	return common.DecryptSecretValue(config.Config.SecretsEncryptionKey, organization.EncryptedWebHookSecret)
+}
+
+func (organization *Organization) SetWebHookSecret(webHookSecret string) error {
+	encryptedWebHookSecret, err := common.EncryptSecretValue(config.Config.SecretsEncryptionKey, webHookSecret)
+	if err != nil {
+		return err
+	}
+
+	organization.EncryptedWebHookSecret = encryptedWebHookSecret
+	organization.LegacyWebHookSecret = ""
+
+	return nil
+}
+
+//Return true if a plain webhook secret has been encrypted
+func (organization *Organization) EncryptLegacyWebHookSecret() (bool, error) {
+	if len(organization.LegacyWebHookSecret) == 0 {
+		return false, nil
+	}
+
+	return true, organization.SetWebHookSecret(organization.LegacyWebHookSecret)
+}
+
+//The collaborators of the repositories are synchronized, as Agola members or as project collaborators
+func (organization *Organization) SynkCollaborators() bool {
+	return organization.CollaboratorsPolicy == types.CollaboratorsMember || organization.CollaboratorsPolicy == types.CollaboratorsProject
+}
+
+//The collaborators of the repositories can see the runs of their projects without being Agola members
+func (organization *Organization) HasProjectCollaborators() bool {
+	return organization.CollaboratorsPolicy == types.CollaboratorsProject
+}
+
+/*
+Agola roles of the git members used by the members synk.
+The keys of Teams are git team names, the keys of GitRoles are git roles (gitea owner/member, github owner/member,
+gitlab owner/maintainer/developer/reporter/guest, bitbucket project_admin/project_write/project_read, collaborator):
+a member gets the highest role of its mapped teams and git roles and the default role when none of them is mapped.
+The ignored Agola users are never added, updated or removed
+*/
+type MembersMapping struct {
+	Teams        map[string]types.MemberRole `json:"teams,omitempty"`
+	GitRoles     map[string]types.MemberRole `json:"gitRoles,omitempty"`
+	IgnoredUsers []string                    `json:"ignoredUsers,omitempty"`
+}
+
+func (mapping *MembersMapping) IsIgnored(agolaUserRef string) bool {
+	for _, ignoredUser := range mapping.IgnoredUsers {
+		if ignoredUser == agolaUserRef {
+			return true
+		}
+	}
+	return false
+}
+
+//Ref of the Agola projectgroup of the projects, used also by the Agola web urls
+func (organization *Organization) AgolaParentRef() string {
+	if organization.UserNamespace {
+		return "user/" + organization.AgolaUserRef
+	}
+
+	return "org/" + organization.AgolaOrganizationRef
+}
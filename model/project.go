@@ -2,17 +2,50 @@ package model
 
 type Project struct {
 	GitRepoPath     string `json:"gitRepoPath"`
+	GitRepoID       int    `json:"gitRepoId"` //stable ID of the git repository, used to detect renames
 	AgolaProjectRef string `json:"agolaProjectRef"`
 	AgolaProjectID  string `json:"agolaProjectID"`
 	Archivied       bool   `json:"archivied"`
+	WebHookID       int64  `json:"webHookId,omitempty"` //repository webhook, only for the user namespaces
 
-	Branchs map[string]Branch `json:"branchs"` //use branch name as key
+	AgolaConfBranches map[string]bool `json:"agolaConfBranches"` //branches with the Agola config, nil until the branches are checked
+
+	AgolaVariables *AppliedAgolaVariables `json:"agolaVariables,omitempty"` //organization secrets and variables applied to the Agola project
+
+	Collaborators []int64 `json:"collaborators,omitempty"` //git user ids of the repository collaborators that aren't git members, only with the project collaborators policy
+
+	Branchs      map[string]Branch   `json:"branchs"`      //use branch name as key
+	PullRequests map[int]PullRequest `json:"pullRequests"` //use pull request number as key
 }
 
+const lastPullRequestsSize int = 20
+
 func (project *Project) ExistsInAgola() bool {
 	return len(project.AgolaProjectID) > 0
 }
 
+//The organization secrets and variables of the revision are already in the Agola project
+func (project *Project) IsAgolaVariablesApplied(agolaVariables *AgolaVariables) bool {
+	if project.AgolaVariables == nil {
+		return agolaVariables.IsEmpty()
+	}
+	return project.AgolaVariables.Revision == agolaVariables.Revision
+}
+
+func (project *Project) IsCollaborator(gitUserID uint64) bool {
+	for _, collaboratorID := range project.Collaborators {
+		if uint64(collaboratorID) == gitUserID {
+			return true
+		}
+	}
+	return false
+}
+
+//The project is active in Agola when at least a branch has the Agola config
+func (project *Project) HasAgolaConf() bool {
+	return len(project.AgolaConfBranches) > 0
+}
+
 func (project *Project) GetLastRun() RunInfo {
 	var lastRun RunInfo
 
@@ -27,6 +60,13 @@ func (project *Project) GetLastRun() RunInfo {
 		}
 	}
 
+	for _, pullRequest := range project.PullRequests {
+		pullRequestLastRun := pullRequest.getLastRun()
+		if pullRequestLastRun.RunStartDate.After(lastRun.RunStartDate) {
+			lastRun = pullRequestLastRun
+		}
+	}
+
 	return lastRun
 }
 
@@ -42,3 +82,24 @@ func (project *Project) PushNewRun(runInfo RunInfo) {
 	branch.PushNewRun(runInfo)
 	project.Branchs[runInfo.Branch] = branch
 }
+
+//Store the pull request with its new run, only the pull requests with the most recent runs are kept
+func (project *Project) PushPullRequest(pullRequest PullRequest) {
+	if project.PullRequests == nil {
+		project.PullRequests = make(map[int]PullRequest)
+	}
+	project.PullRequests[pullRequest.Number] = pullRequest
+
+	for len(project.PullRequests) > lastPullRequestsSize {
+		oldestNumber := pullRequest.Number
+		oldestRun := pullRequest.getLastRun()
+		for number, pr := range project.PullRequests {
+			prLastRun := pr.getLastRun()
+			if prLastRun.RunStartDate.Before(oldestRun.RunStartDate) {
+				oldestNumber = number
+				oldestRun = prLastRun
+			}
+		}
+		delete(project.PullRequests, oldestNumber)
+	}
+}
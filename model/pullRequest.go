@@ -0,0 +1,45 @@
+package model
+
+type PullRequest struct {
+	Number       int    `json:"number"`
+	Title        string `json:"title"`
+	Author       string `json:"author"`
+	AuthorEmail  string `json:"authorEmail"`
+	SourceBranch string `json:"sourceBranch"`
+
+	LastSuccessRun RunInfo   `json:"lastSuccessRun"`
+	LastFailedRun  RunInfo   `json:"lastFailedRun"`
+	LastRuns       []RunInfo `json:"lastRuns"`
+}
+
+//True when title, author and source branch have been read from the git provider
+func (pullRequest *PullRequest) IsEnriched() bool {
+	return len(pullRequest.Title) > 0
+}
+
+//Return the runs of the pull request as the runs of its source branch
+func (pullRequest *PullRequest) GetBranch() Branch {
+	return Branch{
+		Name:           pullRequest.SourceBranch,
+		LastSuccessRun: pullRequest.LastSuccessRun,
+		LastFailedRun:  pullRequest.LastFailedRun,
+		LastRuns:       pullRequest.LastRuns,
+	}
+}
+
+func (pullRequest *PullRequest) PushNewRun(runInfo RunInfo) {
+	branch := pullRequest.GetBranch()
+	branch.PushNewRun(runInfo)
+
+	pullRequest.LastSuccessRun = branch.LastSuccessRun
+	pullRequest.LastFailedRun = branch.LastFailedRun
+	pullRequest.LastRuns = branch.LastRuns
+}
+
+func (pullRequest *PullRequest) getLastRun() RunInfo {
+	if len(pullRequest.LastRuns) == 0 {
+		return RunInfo{}
+	}
+
+	return pullRequest.LastRuns[len(pullRequest.LastRuns)-1]
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"net/http"
+	"time"
+)
+
+type WebHookEvent struct {
+	ID              string                 `json:"id"`
+	OrganizationRef string                 `json:"organizationRef"`
+	DeliveryID      string                 `json:"deliveryId,omitempty"` //id assigned by the git provider
+	Header          http.Header            `json:"header"`
+	Payload         []byte                 `json:"payload"`
+	Status          WebHookEventStatusType `json:"status"`
+	Attempts        int                    `json:"attempts"`
+	LastError       string                 `json:"lastError"`
+	NextAttempt     time.Time              `json:"nextAttempt"`
+	ReceivedAt      time.Time              `json:"receivedAt"`
+	ProcessedAt     time.Time              `json:"processedAt"`
+	ReplayOf        string                 `json:"replayOf,omitempty"`
+}
+
+type WebHookEventStatusType string
+
+const (
+	WebHookEventPending    WebHookEventStatusType = "pending"
+	WebHookEventProcessed  WebHookEventStatusType = "processed"
+	WebHookEventDeadLetter WebHookEventStatusType = "deadLetter"
+	WebHookEventRejected   WebHookEventStatusType = "rejected"
+)
+
+func (event *WebHookEvent) IsPending() bool {
+	return event.Status == WebHookEventPending
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"encoding/base64"
 	"log"
+	"time"
 
 	badger "github.com/dgraph-io/badger/v3"
 	"github.com/google/uuid"
@@ -38,6 +39,15 @@ type Database interface {
 	GetUserByGitSourceNameAndID(gitSourceName string, id uint64) (*model.User, error)
 	SaveUser(user *model.User) error
 	DeleteUser(userId uint64) error
+
+	SaveWebHookEvent(event *model.WebHookEvent) error
+	GetWebHookEvents(organizationRef string) (*[]model.WebHookEvent, error)
+	GetWebHookEvent(organizationRef string, eventID string) (*model.WebHookEvent, error)
+	DeleteWebHookEvent(organizationRef string, eventID string) error
+
+	SaveWebHookDelivery(deliveryKey string, receivedAt time.Time, ttl time.Duration) error
+	GetWebHookDeliveries() (map[string]time.Time, error)
+	DeleteWebHookDelivery(deliveryKey string) error
 }
 
 type AppDb struct {
@@ -152,6 +152,11 @@ func (db *AppDb) GetOrganizationByAgolaRef(agolaOrganizationRef string) (*model.
 }
 
 func (db *AppDb) DeleteOrganization(organizationName string) error {
+	err := db.DB.DropPrefix([]byte(getWebHookEventsPrefix(organizationName)))
+	if err != nil {
+		return err
+	}
+
 	return db.DB.DropPrefix([]byte("org/" + organizationName))
 }
 
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v3"
+	"wecode.sorint.it/opensource/papagaio-api/model"
+)
+
+const webHookDeliveriesPrefix string = "webhookdelivery/"
+
+func getWebHookEventsPrefix(organizationRef string) string {
+	return "webhookevent/" + organizationRef + "/"
+}
+
+//The event ID starts with the receive time so that the events of an organization are iterated in arrival order
+func (db *AppDb) SaveWebHookEvent(event *model.WebHookEvent) error {
+	if len(event.ID) == 0 {
+		event.ID = fmt.Sprintf("%020d", event.ReceivedAt.UnixNano()) + "-" + getNewUid()
+	}
+
+	key := getWebHookEventsPrefix(event.OrganizationRef) + event.ID
+	value, err := json.Marshal(event)
+	if err != nil {
+		log.Println("SaveWebHookEvent error in json marshal", err)
+		return err
+	}
+
+	err = db.DB.Update(func(txn *badger.Txn) error {
+		e := badger.NewEntry([]byte(key), value)
+		err := txn.SetEntry(e)
+
+		return err
+	})
+
+	return err
+}
+
+func (db *AppDb) GetWebHookEvents(organizationRef string) (*[]model.WebHookEvent, error) {
+	var retVal []model.WebHookEvent = make([]model.WebHookEvent, 0)
+
+	err := db.DB.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = []byte(getWebHookEventsPrefix(organizationRef))
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			dst := make([]byte, 0)
+			value, err := item.ValueCopy(dst)
+			if err != nil {
+				return err
+			}
+
+			var event model.WebHookEvent
+			err = json.Unmarshal(value, &event)
+			if err != nil {
+				return err
+			}
+
+			retVal = append(retVal, event)
+		}
+		return nil
+	})
+
+	return &retVal, err
+}
+
+func (db *AppDb) GetWebHookEvent(organizationRef string, eventID string) (*model.WebHookEvent, error) {
+	var event *model.WebHookEvent = nil
+
+	err := db.DB.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(getWebHookEventsPrefix(organizationRef) + eventID))
+		if err != nil {
+			if err == badger.ErrKeyNotFound {
+				return nil
+			}
+			return err
+		}
+
+		dst := make([]byte, 0)
+		value, err := item.ValueCopy(dst)
+		if err != nil {
+			return err
+		}
+
+		event = &model.WebHookEvent{}
+		return json.Unmarshal(value, event)
+	})
+
+	return event, err
+}
+
+func (db *AppDb) DeleteWebHookEvent(organizationRef string, eventID string) error {
+	return db.DB.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(getWebHookEventsPrefix(organizationRef) + eventID))
+	})
+}
+
+//The delivery is removed by badger when the ttl expires
+func (db *AppDb) SaveWebHookDelivery(deliveryKey string, receivedAt time.Time, ttl time.Duration) error {
+	return db.DB.Update(func(txn *badger.Txn) error {
+		byteVal := []byte(strconv.FormatInt(receivedAt.UnixNano(), 10))
+		e := badger.NewEntry([]byte(webHookDeliveriesPrefix+deliveryKey), byteVal).WithTTL(ttl)
+		err := txn.SetEntry(e)
+
+		return err
+	})
+}
+
+//Return the receive time of the deliveries not expired
+func (db *AppDb) GetWebHookDeliveries() (map[string]time.Time, error) {
+	retVal := make(map[string]time.Time)
+
+	err := db.DB.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(webHookDeliveriesPrefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			dst := make([]byte, 0)
+			value, err := item.ValueCopy(dst)
+			if err != nil {
+				return err
+			}
+
+			receivedAt, err := strconv.ParseInt(string(value), 10, 64)
+			if err != nil {
+				return err
+			}
+
+			retVal[strings.TrimPrefix(string(item.Key()), webHookDeliveriesPrefix)] = time.Unix(0, receivedAt)
+		}
+		return nil
+	})
+
+	return retVal, err
+}
+
+func (db *AppDb) DeleteWebHookDelivery(deliveryKey string) error {
+	return db.DB.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(webHookDeliveriesPrefix + deliveryKey))
+	})
+}
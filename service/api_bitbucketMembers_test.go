@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"gotest.tools/assert"
+	"wecode.sorint.it/opensource/papagaio-api/api/agola"
+	"wecode.sorint.it/opensource/papagaio-api/api/git"
+	"wecode.sorint.it/opensource/papagaio-api/api/git/bitbucket"
+	"wecode.sorint.it/opensource/papagaio-api/manager/membersManager"
+	"wecode.sorint.it/opensource/papagaio-api/test"
+	"wecode.sorint.it/opensource/papagaio-api/test/mock/mock_agola"
+	"wecode.sorint.it/opensource/papagaio-api/test/mock/mock_bitbucket"
+)
+
+//Bitbucket project PRJ with user1 admin and the group developers with write permission, the group members are listed only if groupsListed
+func setupBitbucketMembersServer(groupsListed bool) *httptest.Server {
+	pages := map[string]string{
+		"/rest/api/1.0/projects/PRJ/permissions/users":                    `[{"user": {"id": 1, "name": "user1"}, "permission": "PROJECT_ADMIN"}]`,
+		"/rest/api/1.0/projects/PRJ/permissions/groups":                   `[{"group": {"name": "developers"}, "permission": "PROJECT_WRITE"}]`,
+		"/rest/api/1.0/projects/PRJ/repos":                                `[{"id": 1, "slug": "repository1", "project": {"key": "PRJ"}}]`,
+		"/rest/api/1.0/projects/PRJ/repos/repository1/permissions/users":  `[]`,
+		"/rest/api/1.0/projects/PRJ/repos/repository1/permissions/groups": `[{"group": {"name": "developers"}, "permission": "REPO_READ"}]`,
+		"/rest/api/1.0/admin/groups/more-members":                         `[{"id": 1, "name": "user1"}, {"id": 2, "name": "user2"}]`,
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Path == "/rest/api/1.0/admin/groups/more-members" && (!groupsListed || r.URL.Query().Get("context") != "developers") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"values": ` + page + `, "isLastPage": true}`))
+	}))
+}
+
+func TestBitbucketOrganizationMembersWithGroupMember(t *testing.T) {
+	ts := setupBitbucketMembersServer(true)
+	defer ts.Close()
+
+	gitSource := (*test.MakeGitSourceMap())["bitbucket"]
+	gitSource.GitAPIURL = ts.URL
+	bitbucketApi := bitbucket.BitbucketApi{}
+
+	members, err := bitbucketApi.GetOrganizationMembers(&gitSource, test.MakeUser(), "PRJ")
+
+	assert.Equal(t, err, nil)
+	permissions := make(map[string]string)
+	for _, member := range *members {
+		permissions[member.Username] = member.Permission
+	}
+	assert.DeepEqual(t, permissions, map[string]string{"user1": "PROJECT_ADMIN", "user2": "PROJECT_WRITE"})
+}
+
+func TestBitbucketOrganizationMembersGroupNotListed(t *testing.T) {
+	ts := setupBitbucketMembersServer(false)
+	defer ts.Close()
+
+	gitSource := (*test.MakeGitSourceMap())["bitbucket"]
+	gitSource.GitAPIURL = ts.URL
+	bitbucketApi := bitbucket.BitbucketApi{}
+
+	members, err := bitbucketApi.GetOrganizationMembers(&gitSource, test.MakeUser(), "PRJ")
+
+	assert.Assert(t, errors.Is(err, bitbucket.ErrGroupMembersNotListed))
+	assert.Equal(t, len(*members), 1)
+	assert.Equal(t, (*members)[0].Username, "user1")
+}
+
+func TestSynkMembersBitbucketGroupMemberNotRemoved(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	bitbucketApi := mock_bitbucket.NewMockBitbucketInterface(ctl)
+
+	gitSource := (*test.MakeGitSourceMap())["bitbucket"]
+	organization := (*test.MakeOrganizationMap())["Organization1"]
+	organization.GitSourceName = gitSource.Name
+	user := test.MakeUser()
+
+	bitbucketUsers := []bitbucket.BitbucketUser{{ID: 1, Username: "user1", Permission: "PROJECT_ADMIN"}}
+	agolaMembers := agola.OrganizationMembersResponseDto{
+		Members: []agola.MemberDto{
+			{User: agola.UserDto{Username: "user1"}, Role: agola.Owner},
+			{User: agola.UserDto{Username: "user2"}, Role: agola.Member},
+		},
+	}
+	remotesource := agola.RemoteSourceDto{ID: "remotesource_test", Name: gitSource.AgolaRemoteSource}
+
+	bitbucketApi.EXPECT().GetOrganizationMembers(gomock.Any(), gomock.Any(), organization.GitPath).Return(&bitbucketUsers, bitbucket.ErrGroupMembersNotListed)
+	agolaApi.EXPECT().GetOrganizationMembers(gomock.Any(), gomock.Any()).Return(&agolaMembers, nil)
+	agolaApi.EXPECT().GetRemoteSource(gomock.Any(), gitSource.AgolaRemoteSource).Return(&remotesource, nil)
+	agolaApi.EXPECT().GetUsersFilterbyRemoteUser(gomock.Any(), remotesource.ID, int64(1)).Return([]*agola.UserDto{{Username: "user1"}}, nil)
+
+	err := membersManager.SynkMembers(context.Background(), &organization, &gitSource, agolaApi, &git.GitGateway{BitbucketApi: bitbucketApi}, user)
+
+	assert.Equal(t, err, nil)
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	gittransport "wecode.sorint.it/opensource/papagaio-api/api/git/transport"
+)
+
+//Server answering with an ETag, the requests with a matching If-None-Match get a 304
+func setupCacheServer(requests *int32, notModified *int32, header http.Header) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		for key, values := range header {
+			w.Header()[key] = values
+		}
+		w.Header().Set("ETag", "\"v1\"")
+		if r.Header.Get("If-None-Match") == "\"v1\"" {
+			atomic.AddInt32(notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("[\"repository1\"]"))
+	}))
+}
+
+func makeCacheTransport(ttl time.Duration) *gittransport.CacheTransport {
+	cache := gittransport.NewResponseCache()
+	cache.TTLs = map[gittransport.EndpointKind]time.Duration{gittransport.EndpointRepositories: ttl}
+	return &gittransport.CacheTransport{Base: http.DefaultTransport, Cache: cache}
+}
+
+func doCachedRequest(t *testing.T, cacheTransport *gittransport.CacheTransport, url string, authorization string) string {
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if len(authorization) > 0 {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := cacheTransport.RoundTrip(req)
+	assert.Equal(t, err, nil)
+	defer resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, err, nil)
+	return string(body)
+}
+
+func TestCacheTransportFreshResponse(t *testing.T) {
+	var requests, notModified int32
+	ts := setupCacheServer(&requests, &notModified, nil)
+	defer ts.Close()
+
+	cacheTransport := makeCacheTransport(time.Minute)
+	assert.Equal(t, doCachedRequest(t, cacheTransport, ts.URL+"/api/v1/orgs/org1/repos", "token1"), "[\"repository1\"]")
+	assert.Equal(t, doCachedRequest(t, cacheTransport, ts.URL+"/api/v1/orgs/org1/repos", "token1"), "[\"repository1\"]")
+
+	assert.Equal(t, atomic.LoadInt32(&requests), int32(1))
+}
+
+func TestCacheTransportRevalidatedWithNotModified(t *testing.T) {
+	var requests, notModified int32
+	ts := setupCacheServer(&requests, &notModified, nil)
+	defer ts.Close()
+
+	cacheTransport := makeCacheTransport(time.Nanosecond)
+	assert.Equal(t, doCachedRequest(t, cacheTransport, ts.URL+"/api/v1/orgs/org1/repos", "token1"), "[\"repository1\"]")
+	assert.Equal(t, doCachedRequest(t, cacheTransport, ts.URL+"/api/v1/orgs/org1/repos", "token1"), "[\"repository1\"]")
+
+	assert.Equal(t, atomic.LoadInt32(&requests), int32(2))
+	assert.Equal(t, atomic.LoadInt32(&notModified), int32(1))
+}
+
+func TestCacheTransportResponsesByCredentials(t *testing.T) {
+	var requests, notModified int32
+	ts := setupCacheServer(&requests, &notModified, nil)
+	defer ts.Close()
+
+	cacheTransport := makeCacheTransport(time.Minute)
+	doCachedRequest(t, cacheTransport, ts.URL+"/api/v1/orgs/org1/repos", "token1")
+	doCachedRequest(t, cacheTransport, ts.URL+"/api/v1/orgs/org1/repos", "token2")
+	doCachedRequest(t, cacheTransport, ts.URL+"/api/v1/orgs/org1/repos", "")
+
+	assert.Equal(t, atomic.LoadInt32(&requests), int32(3))
+	assert.Equal(t, atomic.LoadInt32(&notModified), int32(0))
+
+	doCachedRequest(t, cacheTransport, ts.URL+"/api/v1/orgs/org1/repos", "token2")
+	assert.Equal(t, atomic.LoadInt32(&requests), int32(3))
+}
+
+func TestCacheTransportInvalidate(t *testing.T) {
+	var requests, notModified int32
+	ts := setupCacheServer(&requests, &notModified, nil)
+	defer ts.Close()
+
+	cacheTransport := makeCacheTransport(time.Minute)
+	doCachedRequest(t, cacheTransport, ts.URL+"/api/v1/orgs/org1/repos", "token1")
+	doCachedRequest(t, cacheTransport, ts.URL+"/api/v1/orgs/org2/repos", "token1")
+
+	cacheTransport.Cache.Invalidate("org1")
+
+	doCachedRequest(t, cacheTransport, ts.URL+"/api/v1/orgs/org1/repos", "token1")
+	doCachedRequest(t, cacheTransport, ts.URL+"/api/v1/orgs/org2/repos", "token1")
+	assert.Equal(t, atomic.LoadInt32(&requests), int32(3))
+}
+
+func TestCacheTransportPaginatedNotCached(t *testing.T) {
+	var requests, notModified int32
+	ts := setupCacheServer(&requests, &notModified, nil)
+	defer ts.Close()
+
+	cacheTransport := makeCacheTransport(time.Minute)
+	for _, query := range []string{"?page=1&limit=50", "?per_page=50", "?start=0&limit=50"} {
+		doCachedRequest(t, cacheTransport, ts.URL+"/api/v1/orgs/org1/repos"+query, "token1")
+		doCachedRequest(t, cacheTransport, ts.URL+"/api/v1/orgs/org1/repos"+query, "token1")
+	}
+
+	assert.Equal(t, atomic.LoadInt32(&requests), int32(6))
+	assert.Equal(t, atomic.LoadInt32(&notModified), int32(0))
+}
+
+func TestCacheTransportLinkHeaderNotCached(t *testing.T) {
+	var requests, notModified int32
+	ts := setupCacheServer(&requests, &notModified, http.Header{"Link": {"<https://api.github.com/orgs/org1/repos?page=2>; rel=\"next\""}})
+	defer ts.Close()
+
+	cacheTransport := makeCacheTransport(time.Minute)
+	doCachedRequest(t, cacheTransport, ts.URL+"/orgs/org1/repos", "token1")
+	doCachedRequest(t, cacheTransport, ts.URL+"/orgs/org1/repos", "token1")
+
+	assert.Equal(t, atomic.LoadInt32(&requests), int32(2))
+	assert.Equal(t, atomic.LoadInt32(&notModified), int32(0))
+}
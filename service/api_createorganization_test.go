@@ -6,6 +6,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
 
@@ -15,17 +16,26 @@ import (
 	"wecode.sorint.it/opensource/papagaio-api/api/agola"
 	"wecode.sorint.it/opensource/papagaio-api/api/git"
 	gitDto "wecode.sorint.it/opensource/papagaio-api/api/git/dto"
+	"wecode.sorint.it/opensource/papagaio-api/config"
 	"wecode.sorint.it/opensource/papagaio-api/controller"
 	"wecode.sorint.it/opensource/papagaio-api/dto"
 	"wecode.sorint.it/opensource/papagaio-api/model"
 	"wecode.sorint.it/opensource/papagaio-api/test"
 	"wecode.sorint.it/opensource/papagaio-api/test/mock/mock_agola"
 	"wecode.sorint.it/opensource/papagaio-api/test/mock/mock_gitea"
+	"wecode.sorint.it/opensource/papagaio-api/test/mock/mock_gitlab"
 	"wecode.sorint.it/opensource/papagaio-api/test/mock/mock_repository"
 	"wecode.sorint.it/opensource/papagaio-api/types"
 	"wecode.sorint.it/opensource/papagaio-api/utils"
 )
 
+//The webhook secrets and the Agola secrets are encrypted with the configured key
+func TestMain(m *testing.M) {
+	config.Config.SecretsEncryptionKey = "testencryptionkey"
+
+	os.Exit(m.Run())
+}
+
 var organizationReqDto dto.CreateOrganizationRequestDto
 var commonMutex utils.CommonMutex
 var giteaApi *mock_gitea.MockGiteaInterface
@@ -99,9 +109,9 @@ func TestCreateOrganizationOK(t *testing.T) {
 	giteaApi.EXPECT().GetOrganization(gomock.Any(), gomock.Any(), organizationReqDto.GitPath).Return(&gitDto.OrganizationDto{ID: 1, Name: organizationReqDto.GitPath}, nil)
 	giteaApi.EXPECT().IsUserOwner(gomock.Any(), gomock.Any(), organizationReqDto.GitPath).Return(true, nil)
 	db.EXPECT().GetOrganizationByAgolaRef(organizationReqDto.AgolaRef).Return(nil, nil)
-	giteaApi.EXPECT().CreateWebHook(gomock.Any(), gomock.Any(), organizationReqDto.GitPath, organizationReqDto.AgolaRef).Return(int64(1), nil)
-	agolaApiInt.EXPECT().CheckOrganizationExists(gomock.Any()).Return(false, "", nil)
-	agolaApiInt.EXPECT().CreateOrganization(gomock.Any(), organizationReqDto.Visibility).Return("123456", nil)
+	giteaApi.EXPECT().CreateWebHook(gomock.Any(), gomock.Any(), organizationReqDto.GitPath, organizationReqDto.AgolaRef, gomock.Any()).Return(int64(1), nil)
+	agolaApiInt.EXPECT().CheckOrganizationExists(gomock.Any(), gomock.Any()).Return(false, "", nil)
+	agolaApiInt.EXPECT().CreateOrganization(gomock.Any(), gomock.Any(), organizationReqDto.Visibility).Return("123456", nil)
 	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
 
 	setupSynkMembersUserTestMocks(agolaApiInt, giteaApi, organizationReqDto.GitPath, gitSource.AgolaRemoteSource)
@@ -125,6 +135,158 @@ func TestCreateOrganizationOK(t *testing.T) {
 	assert.Check(t, strings.Contains(responseDto.OrganizationURL, "/org/"+organizationReqDto.AgolaRef), "OrganizationURL is not correct")
 }
 
+func TestCreateOrganizationGitlabSystemHookForbidden(t *testing.T) {
+	setupMock(t)
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	user := test.MakeUser()
+	user.GitSourceName = "gitlab"
+	gitSource = (*test.MakeGitSourceMap())[user.GitSourceName]
+
+	gitlabApi := mock_gitlab.NewMockGitlabInterface(ctl)
+	serviceOrganization.GitGateway = &git.GitGateway{GitlabApi: gitlabApi}
+
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	db.EXPECT().GetOrganizationsByGitSource(user.GitSourceName).Return(&organizationList, nil)
+	db.EXPECT().GetGitSourceByName(gomock.Eq(user.GitSourceName)).Return(&gitSource, nil).Times(2)
+	gitlabApi.EXPECT().GetOrganization(gomock.Any(), gomock.Any(), organizationReqDto.GitPath).Return(&gitDto.OrganizationDto{ID: 1, Name: organizationReqDto.GitPath}, nil)
+	gitlabApi.EXPECT().IsUserOwner(gomock.Any(), gomock.Any(), organizationReqDto.GitPath).Return(true, nil)
+	db.EXPECT().GetOrganizationByAgolaRef(organizationReqDto.AgolaRef).Return(nil, nil)
+	gitlabApi.EXPECT().CreateSystemHook(gomock.Any(), gomock.Any(), controller.GetGitSourceWebHookURL(gitSource.Name), gomock.Any()).Return(int64(-1), git.ErrSystemHookForbidden)
+
+	ts := httptest.NewServer(setupRouter(user))
+
+	client := ts.Client()
+
+	data, _ := json.Marshal(organizationReqDto)
+	requestBody := strings.NewReader(string(data))
+	resp, err := client.Post(ts.URL+"/", "application/json", requestBody)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusOK, "http StatusCode is not OK")
+
+	var responseDto dto.CreateOrganizationResponseDto
+	test.ParseBody(resp, &responseDto)
+
+	assert.Equal(t, responseDto.ErrorCode, dto.GitSystemHookForbiddenError, "ErrorCode is not correct")
+}
+
+func TestCreateOrganizationGitlabSystemHookCreatedOnce(t *testing.T) {
+	setupMock(t)
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	user := test.MakeUser()
+	user.GitSourceName = "gitlab"
+	gitSource = (*test.MakeGitSourceMap())[user.GitSourceName]
+	gitSource.SetSystemHook(1, "systemHookSecret")
+
+	gitlabApi := mock_gitlab.NewMockGitlabInterface(ctl)
+	serviceOrganization.GitGateway = &git.GitGateway{GitlabApi: gitlabApi}
+
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	db.EXPECT().GetOrganizationsByGitSource(user.GitSourceName).Return(&organizationList, nil)
+	db.EXPECT().GetGitSourceByName(gomock.Eq(user.GitSourceName)).Return(&gitSource, nil).Times(2)
+	gitlabApi.EXPECT().GetOrganization(gomock.Any(), gomock.Any(), organizationReqDto.GitPath).Return(&gitDto.OrganizationDto{ID: 1, Name: organizationReqDto.GitPath}, nil)
+	gitlabApi.EXPECT().IsUserOwner(gomock.Any(), gomock.Any(), organizationReqDto.GitPath).Return(true, nil)
+	db.EXPECT().GetOrganizationByAgolaRef(organizationReqDto.AgolaRef).Return(nil, nil)
+	gitlabApi.EXPECT().CreateWebHook(gomock.Any(), gomock.Any(), organizationReqDto.GitPath, organizationReqDto.AgolaRef, gomock.Any()).Return(int64(1), nil)
+	agolaApiInt.EXPECT().CheckOrganizationExists(gomock.Any(), gomock.Any()).Return(true, "123456", nil)
+	gitlabApi.EXPECT().DeleteWebHook(gomock.Any(), gomock.Any(), organizationReqDto.GitPath, int64(1)).Return(nil)
+
+	ts := httptest.NewServer(setupRouter(user))
+
+	client := ts.Client()
+
+	data, _ := json.Marshal(organizationReqDto)
+	requestBody := strings.NewReader(string(data))
+	resp, err := client.Post(ts.URL+"/", "application/json", requestBody)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusOK, "http StatusCode is not OK")
+
+	var responseDto dto.CreateOrganizationResponseDto
+	test.ParseBody(resp, &responseDto)
+
+	assert.Equal(t, responseDto.ErrorCode, dto.AgolaOrganizationExistsError, "ErrorCode is not correct")
+}
+
+func TestCreateOrganizationUserNamespaceOK(t *testing.T) {
+	setupMock(t)
+
+	user := test.MakeUser()
+	organizationReqDto.GitPath = user.Login
+	organizationReqDto.UserNamespace = true
+
+	var savedOrganization *model.Organization
+	repositoryList := make([]gitDto.RepositoryDto, 0)
+
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	db.EXPECT().GetOrganizationsByGitSource(user.GitSourceName).Return(&organizationList, nil)
+	db.EXPECT().GetGitSourceByName(gomock.Eq(user.GitSourceName)).Return(&gitSource, nil)
+	giteaApi.EXPECT().GetUserInfo(gomock.Any(), gomock.Any()).Return(&gitDto.UserInfoDto{ID: int64(user.ID), Login: user.Login, FullName: "Nome Cognome"}, nil)
+	db.EXPECT().GetOrganizationByAgolaRef(organizationReqDto.AgolaRef).Return(nil, nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).DoAndReturn(func(organization *model.Organization) error {
+		savedOrganization = organization
+		return nil
+	})
+	giteaApi.EXPECT().GetUserRepositories(gomock.Any(), gomock.Any(), user.Login).Return(&repositoryList, nil)
+
+	ts := httptest.NewServer(setupRouter(user))
+
+	client := ts.Client()
+
+	data, _ := json.Marshal(organizationReqDto)
+	requestBody := strings.NewReader(string(data))
+	resp, err := client.Post(ts.URL+"/", "application/json", requestBody)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusOK, "http StatusCode is not OK")
+
+	var responseDto dto.CreateOrganizationResponseDto
+	test.ParseBody(resp, &responseDto)
+
+	assert.Equal(t, responseDto.ErrorCode, dto.NoError, "ErrorCode is not correct")
+	assert.Check(t, strings.Contains(responseDto.OrganizationURL, "/user/"+*user.AgolaUserRef), "OrganizationURL is not correct")
+	assert.Check(t, savedOrganization.UserNamespace)
+	assert.Equal(t, savedOrganization.AgolaUserRef, *user.AgolaUserRef)
+	assert.Equal(t, savedOrganization.GitName, "Nome Cognome")
+	webHookSecret, err := savedOrganization.GetWebHookSecret()
+	assert.Equal(t, err, nil)
+	assert.Check(t, len(webHookSecret) > 0)
+}
+
+func TestCreateOrganizationUserNamespaceOfOtherUser(t *testing.T) {
+	setupMock(t)
+
+	user := test.MakeUser()
+	organizationReqDto.GitPath = "otherUser"
+	organizationReqDto.UserNamespace = true
+
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	db.EXPECT().GetGitSourceByName(gomock.Eq(user.GitSourceName)).Return(&gitSource, nil)
+	giteaApi.EXPECT().GetUserInfo(gomock.Any(), gomock.Any()).Return(&gitDto.UserInfoDto{ID: int64(user.ID), Login: user.Login}, nil)
+
+	ts := httptest.NewServer(setupRouter(user))
+
+	client := ts.Client()
+
+	data, _ := json.Marshal(organizationReqDto)
+	requestBody := strings.NewReader(string(data))
+	resp, err := client.Post(ts.URL+"/", "application/json", requestBody)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusOK, "http StatusCode is not OK")
+
+	var responseDto dto.CreateOrganizationResponseDto
+	test.ParseBody(resp, &responseDto)
+
+	assert.Equal(t, responseDto.ErrorCode, dto.GitOrganizationNotFoundError, "ErrorCode is not correct")
+}
+
 func TestCreateOrganizationUserNotOwner(t *testing.T) {
 	setupMock(t)
 	user := test.MakeUser()
@@ -192,8 +354,8 @@ func TestCreateOrganizationUserAgolaNotFound(t *testing.T) {
 	db.EXPECT().GetGitSourceByName(gomock.Eq(user.GitSourceName)).Return(&gitSource, nil)
 	giteaApi.EXPECT().GetOrganization(gomock.Any(), gomock.Any(), organizationReqDto.GitPath).Return(&gitDto.OrganizationDto{ID: 1, Name: organizationReqDto.GitPath}, nil)
 	giteaApi.EXPECT().IsUserOwner(gomock.Any(), gomock.Any(), organizationReqDto.GitPath).Return(true, nil)
-	agolaApiInt.EXPECT().GetRemoteSource(gitSource.AgolaRemoteSource).Return(&remotesource, nil)
-	agolaApiInt.EXPECT().GetUsersFilterbyRemoteUser(remotesource.ID, gomock.Any()).Return(nil, nil)
+	agolaApiInt.EXPECT().GetRemoteSource(gomock.Any(), gitSource.AgolaRemoteSource).Return(&remotesource, nil)
+	agolaApiInt.EXPECT().GetUsersFilterbyRemoteUser(gomock.Any(), remotesource.ID, gomock.Any()).Return(nil, nil)
 
 	ts := httptest.NewServer(setupRouter(user))
 
@@ -230,14 +392,14 @@ func TestCreateOrganizationUserAgolaCreateToken(t *testing.T) {
 	db.EXPECT().GetGitSourceByName(gomock.Eq(user.GitSourceName)).Return(&gitSource, nil)
 	giteaApi.EXPECT().GetOrganization(gomock.Any(), gomock.Any(), organizationReqDto.GitPath).Return(&gitDto.OrganizationDto{ID: 1, Name: organizationReqDto.GitPath}, nil)
 	giteaApi.EXPECT().IsUserOwner(gomock.Any(), gomock.Any(), organizationReqDto.GitPath).Return(true, nil)
-	agolaApiInt.EXPECT().GetRemoteSource(gitSource.AgolaRemoteSource).Return(&remotesource, nil)
-	agolaApiInt.EXPECT().GetUsersFilterbyRemoteUser(remotesource.ID, gomock.Any()).Return(users, nil)
-	agolaApiInt.EXPECT().CreateUserToken(user).Return(nil)
+	agolaApiInt.EXPECT().GetRemoteSource(gomock.Any(), gitSource.AgolaRemoteSource).Return(&remotesource, nil)
+	agolaApiInt.EXPECT().GetUsersFilterbyRemoteUser(gomock.Any(), remotesource.ID, gomock.Any()).Return(users, nil)
+	agolaApiInt.EXPECT().CreateUserToken(gomock.Any(), user).Return(nil)
 	db.EXPECT().SaveUser(user).Return(nil)
 	db.EXPECT().GetOrganizationByAgolaRef(organizationReqDto.AgolaRef).Return(nil, nil)
-	giteaApi.EXPECT().CreateWebHook(gomock.Any(), gomock.Any(), organizationReqDto.GitPath, organizationReqDto.AgolaRef).Return(int64(1), nil)
-	agolaApiInt.EXPECT().CheckOrganizationExists(gomock.Any()).Return(false, "", nil)
-	agolaApiInt.EXPECT().CreateOrganization(gomock.Any(), organizationReqDto.Visibility).Return("123456", nil)
+	giteaApi.EXPECT().CreateWebHook(gomock.Any(), gomock.Any(), organizationReqDto.GitPath, organizationReqDto.AgolaRef, gomock.Any()).Return(int64(1), nil)
+	agolaApiInt.EXPECT().CheckOrganizationExists(gomock.Any(), gomock.Any()).Return(false, "", nil)
+	agolaApiInt.EXPECT().CreateOrganization(gomock.Any(), gomock.Any(), organizationReqDto.Visibility).Return("123456", nil)
 	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
 
 	setupSynkMembersUserTestMocks(agolaApiInt, giteaApi, organizationReqDto.GitPath, gitSource.AgolaRemoteSource)
@@ -302,8 +464,8 @@ func TestCreateOrganizationJustExistsInAgola(t *testing.T) {
 	giteaApi.EXPECT().GetOrganization(gomock.Any(), gomock.Any(), organizationReqDto.GitPath).Return(&gitDto.OrganizationDto{ID: 1, Name: organizationReqDto.GitPath}, nil)
 	giteaApi.EXPECT().IsUserOwner(gomock.Any(), gomock.Any(), organizationReqDto.GitPath).Return(true, nil)
 	db.EXPECT().GetOrganizationByAgolaRef(organizationReqDto.AgolaRef).Return(nil, nil)
-	giteaApi.EXPECT().CreateWebHook(gomock.Any(), gomock.Any(), organizationReqDto.GitPath, organizationReqDto.AgolaRef).Return(int64(1), nil)
-	agolaApiInt.EXPECT().CheckOrganizationExists(gomock.Any()).Return(true, "test123456", nil)
+	giteaApi.EXPECT().CreateWebHook(gomock.Any(), gomock.Any(), organizationReqDto.GitPath, organizationReqDto.AgolaRef, gomock.Any()).Return(int64(1), nil)
+	agolaApiInt.EXPECT().CheckOrganizationExists(gomock.Any(), gomock.Any()).Return(true, "test123456", nil)
 	giteaApi.EXPECT().DeleteWebHook(gomock.Any(), gomock.Any(), organizationReqDto.GitPath, int64(1)).Return(nil)
 
 	ts := httptest.NewServer(setupRouter(user))
@@ -360,8 +522,8 @@ func TestCreateOrganizationJustExistsInAgolaForce(t *testing.T) {
 	giteaApi.EXPECT().GetOrganization(gomock.Any(), gomock.Any(), organizationReqDto.GitPath).Return(&gitDto.OrganizationDto{ID: 1, Name: organizationReqDto.GitPath}, nil)
 	giteaApi.EXPECT().IsUserOwner(gomock.Any(), gomock.Any(), organizationReqDto.GitPath).Return(true, nil)
 	db.EXPECT().GetOrganizationByAgolaRef(organizationReqDto.AgolaRef).Return(nil, nil)
-	giteaApi.EXPECT().CreateWebHook(gomock.Any(), gomock.Any(), organizationReqDto.GitPath, organizationReqDto.AgolaRef).Return(int64(1), nil)
-	agolaApiInt.EXPECT().CheckOrganizationExists(gomock.Any()).Return(true, "test123456", nil)
+	giteaApi.EXPECT().CreateWebHook(gomock.Any(), gomock.Any(), organizationReqDto.GitPath, organizationReqDto.AgolaRef, gomock.Any()).Return(int64(1), nil)
+	agolaApiInt.EXPECT().CheckOrganizationExists(gomock.Any(), gomock.Any()).Return(true, "test123456", nil)
 	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
 
 	setupSynkMembersUserTestMocks(agolaApiInt, giteaApi, organizationReqDto.GitPath, gitSource.AgolaRemoteSource)
@@ -459,6 +621,43 @@ func TestCreateOrganizationParametersBehaviourTypeInvalid(t *testing.T) {
 	assert.Equal(t, resp.StatusCode, http.StatusUnprocessableEntity, "http StatusCode is not OK")
 }
 
+func TestCreateOrganizationGitPathWithParentSegment(t *testing.T) {
+	setupMock(t)
+
+	user := test.MakeUser()
+
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+
+	ts := httptest.NewServer(setupRouter(user))
+	client := ts.Client()
+	organizationReqDto.GitPath = "group/../other"
+	data, _ := json.Marshal(organizationReqDto)
+	requestBody := strings.NewReader(string(data))
+	resp, err := client.Post(ts.URL+"/", "application/json", requestBody)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusUnprocessableEntity, "http StatusCode is not OK")
+}
+
+func TestCreateOrganizationSubgroupPathNotSupported(t *testing.T) {
+	setupMock(t)
+
+	user := test.MakeUser()
+
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	db.EXPECT().GetGitSourceByName(user.GitSourceName).Return(&gitSource, nil)
+
+	ts := httptest.NewServer(setupRouter(user))
+	client := ts.Client()
+	organizationReqDto.GitPath = "group/subgroup"
+	data, _ := json.Marshal(organizationReqDto)
+	requestBody := strings.NewReader(string(data))
+	resp, err := client.Post(ts.URL+"/", "application/json", requestBody)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusUnprocessableEntity, "http StatusCode is not OK")
+}
+
 func TestCreateOrganizationGitSourceInvalid(t *testing.T) {
 	setupMock(t)
 
@@ -513,7 +712,7 @@ func TestCreateOrganizationWhenCreateWebhookFailed(t *testing.T) {
 	giteaApi.EXPECT().GetOrganization(gomock.Any(), gomock.Any(), organizationReqDto.GitPath).Return(&gitDto.OrganizationDto{ID: 1, Name: organizationReqDto.GitPath}, nil)
 	giteaApi.EXPECT().IsUserOwner(gomock.Any(), gomock.Any(), organizationReqDto.GitPath).Return(true, nil)
 	db.EXPECT().GetOrganizationByAgolaRef(organizationReqDto.AgolaRef).Return(nil, nil)
-	giteaApi.EXPECT().CreateWebHook(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), errors.New(string("someError")))
+	giteaApi.EXPECT().CreateWebHook(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), errors.New(string("someError")))
 
 	data, _ := json.Marshal(organizationReqDto)
 	requestBody := strings.NewReader(string(data))
@@ -536,9 +735,9 @@ func TestCreateOrganizationWhenFailsToCreateInAgola(t *testing.T) {
 	giteaApi.EXPECT().GetOrganization(gomock.Any(), gomock.Any(), organizationReqDto.GitPath).Return(&gitDto.OrganizationDto{ID: 1, Name: organizationReqDto.GitPath}, nil)
 	giteaApi.EXPECT().IsUserOwner(gomock.Any(), gomock.Any(), organizationReqDto.GitPath).Return(true, nil)
 	db.EXPECT().GetOrganizationByAgolaRef(organizationReqDto.AgolaRef).Return(nil, nil)
-	giteaApi.EXPECT().CreateWebHook(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
-	agolaApiInt.EXPECT().CheckOrganizationExists(gomock.Any()).Return(false, "", nil)
-	agolaApiInt.EXPECT().CreateOrganization(gomock.Any(), organizationReqDto.Visibility).Return("123456", errors.New(string("someError")))
+	giteaApi.EXPECT().CreateWebHook(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+	agolaApiInt.EXPECT().CheckOrganizationExists(gomock.Any(), gomock.Any()).Return(false, "", nil)
+	agolaApiInt.EXPECT().CreateOrganization(gomock.Any(), gomock.Any(), organizationReqDto.Visibility).Return("123456", errors.New(string("someError")))
 	giteaApi.EXPECT().DeleteWebHook(gomock.Any(), gomock.Any(), organizationReqDto.GitPath, int64(1)).Return(nil)
 
 	data, _ := json.Marshal(organizationReqDto)
@@ -567,10 +766,10 @@ func TestCreateOrganizationWhenFailedToSaveOrgInDB(t *testing.T) {
 	giteaApi.EXPECT().IsUserOwner(gomock.Any(), gomock.Any(), organizationReqDto.GitPath).Return(true, nil)
 	db.EXPECT().GetOrganizationByAgolaRef(organizationReqDto.AgolaRef).Return(nil, nil)
 	db.EXPECT().GetOrganizationsByGitSource(user.GitSourceName).Return(&organizationList, nil)
-	giteaApi.EXPECT().CreateWebHook(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
-	agolaApiInt.EXPECT().CheckOrganizationExists(gomock.Any()).Return(false, "", nil)
+	giteaApi.EXPECT().CreateWebHook(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+	agolaApiInt.EXPECT().CheckOrganizationExists(gomock.Any(), gomock.Any()).Return(false, "", nil)
 	db.EXPECT().SaveOrganization(gomock.Any()).Return(errors.New(string("someError")))
-	agolaApiInt.EXPECT().CreateOrganization(gomock.Any(), organizationReqDto.Visibility).Return("123456", nil)
+	agolaApiInt.EXPECT().CreateOrganization(gomock.Any(), gomock.Any(), organizationReqDto.Visibility).Return("123456", nil)
 
 	data, _ := json.Marshal(organizationReqDto)
 	requestBody := strings.NewReader(string(data))
@@ -599,10 +798,10 @@ func TestCreateOrganizationWhenOrgNameAlreadyExistsInPapagaio(t *testing.T) {
 	giteaApi.EXPECT().IsUserOwner(gomock.Any(), gomock.Any(), organizationReqDto.GitPath).Return(true, nil)
 	db.EXPECT().GetOrganizationByAgolaRef(organizationReqDto.AgolaRef).Return(nil, nil)
 	db.EXPECT().GetOrganizationsByGitSource(user.GitSourceName).Return(&organizationList, nil)
-	giteaApi.EXPECT().CreateWebHook(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
-	agolaApiInt.EXPECT().CheckOrganizationExists(gomock.Any()).Return(false, "", nil)
+	giteaApi.EXPECT().CreateWebHook(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+	agolaApiInt.EXPECT().CheckOrganizationExists(gomock.Any(), gomock.Any()).Return(false, "", nil)
 	db.EXPECT().SaveOrganization(gomock.Any()).Return(errors.New(string("someError")))
-	agolaApiInt.EXPECT().CreateOrganization(gomock.Any(), organizationReqDto.Visibility).Return("123456", nil)
+	agolaApiInt.EXPECT().CreateOrganization(gomock.Any(), gomock.Any(), organizationReqDto.Visibility).Return("123456", nil)
 
 	data, _ := json.Marshal(organizationReqDto)
 	requestBody := strings.NewReader(string(data))
@@ -635,7 +834,7 @@ func setupSynkMembersUserTestMocks(agolaApiInt *mock_agola.MockAgolaApiInterface
 	giteaApi.EXPECT().GetTeamMembers(gomock.Any(), gomock.Any(), int64(1)).Return(&gitTeamMembers, nil)
 
 	remoteSourceDto := agola.RemoteSourceDto{ID: "123456"}
-	agolaApiInt.EXPECT().GetRemoteSource("gitea").Return(&remoteSourceDto, nil)
+	agolaApiInt.EXPECT().GetRemoteSource(gomock.Any(), "gitea").Return(&remoteSourceDto, nil)
 
 	users := []*agola.UserDto{
 		{
@@ -643,13 +842,13 @@ func setupSynkMembersUserTestMocks(agolaApiInt *mock_agola.MockAgolaApiInterface
 		},
 	}
 
-	agolaApiInt.EXPECT().GetUsersFilterbyRemoteUser(remoteSourceDto.ID, gomock.Any()).AnyTimes().Return(users, nil)
+	agolaApiInt.EXPECT().GetUsersFilterbyRemoteUser(gomock.Any(), remoteSourceDto.ID, gomock.Any()).AnyTimes().Return(users, nil)
 
-	agolaApiInt.EXPECT().GetOrganizationMembers(gomock.Any()).Return(&agola.OrganizationMembersResponseDto{}, nil)
-	agolaApiInt.EXPECT().AddOrUpdateOrganizationMember(gomock.Any(), "usertest", "owner")
+	agolaApiInt.EXPECT().GetOrganizationMembers(gomock.Any(), gomock.Any()).Return(&agola.OrganizationMembersResponseDto{}, nil)
+	agolaApiInt.EXPECT().AddOrUpdateOrganizationMember(gomock.Any(), gomock.Any(), "usertest", "owner")
 }
 
 func setupCheckoutAllGitRepositoryEmptyMocks(giteaApi *mock_gitea.MockGiteaInterface, organizationName string) {
-	repositoryList := make([]string, 0)
+	repositoryList := make([]gitDto.RepositoryDto, 0)
 	giteaApi.EXPECT().GetRepositories(gomock.Any(), gomock.Any(), organizationName).Return(&repositoryList, nil)
 }
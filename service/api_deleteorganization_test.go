@@ -37,7 +37,7 @@ func TestDeleteOrganizationOK(t *testing.T) {
 	db.EXPECT().GetGitSourceByName(gomock.Eq(organization.GitSourceName)).Return(&gitSource, nil)
 	giteaApi.EXPECT().IsUserOwner(gomock.Any(), gomock.Any(), organization.GitPath).Return(true, nil)
 	db.EXPECT().GetUserByUserId(organization.UserIDConnected).Return(user, nil)
-	agolaApi.EXPECT().DeleteOrganization(gomock.Any(), gomock.Any()).Return(nil)
+	agolaApi.EXPECT().DeleteOrganization(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 	giteaApi.EXPECT().DeleteWebHook(gomock.Any(), gomock.Any(), gomock.Eq(organization.GitPath), gomock.Eq(organization.WebHookID)).Return(nil)
 	db.EXPECT().DeleteOrganization(gomock.Eq(organization.AgolaOrganizationRef)).Return(nil)
 
@@ -275,7 +275,7 @@ func TestDeleteOrganizationWhenDeletingOrganizationInAgola(t *testing.T) {
 	db.EXPECT().GetGitSourceByName(gomock.Any()).Return(&gitSource, nil)
 	giteaApi.EXPECT().IsUserOwner(gomock.Any(), gomock.Any(), organization.GitPath).Return(true, nil)
 	db.EXPECT().GetUserByUserId(organization.UserIDConnected).Return(user, nil)
-	agolaApi.EXPECT().DeleteOrganization(gomock.Any(), gomock.Any()).Return(errors.New(string("someError")))
+	agolaApi.EXPECT().DeleteOrganization(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New(string("someError")))
 
 	serviceOrganization := OrganizationService{
 		Db:          db,
@@ -314,7 +314,7 @@ func TestDeleteOrganizationWhenInternalOnlyDeletingOrganizationError(t *testing.
 	db.EXPECT().GetGitSourceByName(gomock.Eq(organization.GitSourceName)).Return(&gitSource, nil)
 	giteaApi.EXPECT().IsUserOwner(gomock.Any(), gomock.Any(), organization.GitPath).Return(true, nil)
 	db.EXPECT().GetUserByUserId(organization.UserIDConnected).Return(user, nil)
-	agolaApi.EXPECT().DeleteOrganization(gomock.Any(), gomock.Any()).Return(nil)
+	agolaApi.EXPECT().DeleteOrganization(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 	giteaApi.EXPECT().DeleteWebHook(gomock.Any(), gomock.Any(), gomock.Eq(organization.GitPath), gomock.Eq(organization.WebHookID)).Return(nil)
 	db.EXPECT().DeleteOrganization(gomock.Eq(organization.AgolaOrganizationRef)).Return(errors.New(string("someError")))
 
@@ -27,6 +27,7 @@ func TestGetReportOK(t *testing.T) {
 
 	organization := (*test.MakeOrganizationList())[0]
 	insertRunsData(&organization)
+	organization.WebHookStatus = types.WebHookStatusRepaired
 	organizationList := make([]model.Organization, 0)
 	organizationList = append(organizationList, organization)
 
@@ -60,6 +61,7 @@ func TestGetReportOK(t *testing.T) {
 
 	assert.Check(t, len(organizationsDto) == 1)
 	assertOrganizationDto(t, &organization, &organizationsDto[0])
+	assert.Equal(t, organizationsDto[0].WebHookStatus, types.WebHookStatusRepaired)
 }
 func TestGetReportOnlyownerWhenIsNotOwnerButOk(t *testing.T) {
 	ctl := gomock.NewController(t)
@@ -159,6 +161,68 @@ func TestGetProjectReportOK(t *testing.T) {
 	assertProjectDto(t, &projectDto)
 }
 
+func TestGetProjectReportWithPullRequests(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	db := mock_repository.NewMockDatabase(ctl)
+	giteaApi := mock_gitea.NewMockGiteaInterface(ctl)
+
+	organization := (*test.MakeOrganizationList())[0]
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+	insertRunsData(&organization)
+
+	now := time.Now()
+	project := organization.Projects["test1"]
+	for _, pullRequestNumber := range []int{3, 7} {
+		pullRequest := model.PullRequest{Number: pullRequestNumber, Title: "fix", Author: "usertest", SourceBranch: "feature"}
+		pullRequest.PushNewRun(model.RunInfo{
+			Number:       uint64(10 + pullRequestNumber),
+			PullRequest:  pullRequestNumber,
+			Phase:        types.RunPhaseFinished,
+			Result:       types.RunResultFailed,
+			RunStartDate: now.Add(time.Duration(pullRequestNumber) * time.Minute),
+			RunEndDate:   now.Add(time.Hour),
+		})
+		project.PushPullRequest(pullRequest)
+	}
+	organization.Projects["test1"] = project
+
+	assert.Equal(t, project.GetLastRun().Number, uint64(17))
+
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	db.EXPECT().GetGitSourceByName(gomock.Eq(user.GitSourceName)).Return(&gitSource, nil)
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+
+	serviceOrganization := OrganizationService{
+		Db:         db,
+		GitGateway: &git.GitGateway{GiteaApi: giteaApi},
+	}
+
+	router := test.SetupBaseRouter(user)
+	router.HandleFunc("/{organizationRef}/{projectName}", serviceOrganization.GetProjectReport)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client := ts.Client()
+	resp, err := client.Get(ts.URL + "/" + organization.AgolaOrganizationRef + "/test1")
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusOK, "http StatusCode is not OK")
+
+	var projectDto dto.ProjectDto
+	test.ParseBody(resp, &projectDto)
+
+	assertProjectDto(t, &projectDto)
+	assert.Equal(t, len(projectDto.PullRequests), 2)
+	assert.Equal(t, projectDto.PullRequests[0].Number, 7)
+	assert.Equal(t, projectDto.PullRequests[0].Author, "usertest")
+	assert.Equal(t, projectDto.PullRequests[0].SourceBranch, "feature")
+	assert.Equal(t, projectDto.PullRequests[0].State, types.RunStateFailed)
+	assert.Check(t, len(projectDto.PullRequests[0].LastFailedRunURL) > 0)
+}
+
 func TestGetProjectReportNotFound(t *testing.T) {
 	ctl := gomock.NewController(t)
 	defer ctl.Finish()
@@ -1,8 +1,13 @@
 package service
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -108,8 +113,8 @@ func TestAddGitsourcesWithCreateRemotesourceOK(t *testing.T) {
 	remoteSources = append(remoteSources, agolaDto.RemoteSourceDto{Name: reqDto.Name})
 
 	db.EXPECT().GetGitSourceByName(reqDto.Name).Return(nil, nil)
-	agolaApiInt.EXPECT().GetRemoteSources().Return(&remoteSources, nil)
-	agolaApiInt.EXPECT().CreateRemoteSource(reqDto.Name+"0", string(reqDto.GitType), "https://api.github.com", *reqDto.AgolaClientID, *reqDto.AgolaClientSecret).Return(nil)
+	agolaApiInt.EXPECT().GetRemoteSources(gomock.Any()).Return(&remoteSources, nil)
+	agolaApiInt.EXPECT().CreateRemoteSource(gomock.Any(), reqDto.Name+"0", string(reqDto.GitType), "https://api.github.com", *reqDto.AgolaClientID, *reqDto.AgolaClientSecret).Return(nil)
 	db.EXPECT().SaveGitSource(gomock.Any()).Return(nil)
 
 	data, _ := json.Marshal(reqDto)
@@ -155,6 +160,124 @@ func TestAddGitsourcesNotValid(t *testing.T) {
 	assert.Equal(t, resp.StatusCode, http.StatusUnprocessableEntity, "http StatusCode is not correct")
 }
 
+func TestAddGitsourcesBitbucketWithoutRemoteSource(t *testing.T) {
+	setupGitsourceMock(t)
+
+	reqDto := dto.CreateGitSourceRequestDto{
+		Name:              "test",
+		GitType:           "bitbucket",
+		GitAPIURL:         utils.NewString("https://bitbucket.example.com"),
+		GitClientID:       "test",
+		GitClientSecret:   "test",
+		AgolaClientID:     utils.NewString("test"),
+		AgolaClientSecret: utils.NewString("test"),
+	}
+
+	db.EXPECT().GetGitSourceByName(reqDto.Name).Return(nil, nil)
+
+	data, _ := json.Marshal(reqDto)
+	requestBody := strings.NewReader(string(data))
+
+	router := test.SetupBaseRouter(nil)
+	router.HandleFunc("/gitsource", serviceGitsource.AddGitSource)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client := ts.Client()
+	resp, err := client.Post(ts.URL+"/gitsource", "application/json", requestBody)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusUnprocessableEntity, "http StatusCode is not correct")
+}
+
+func TestAddGitsourcesGithubAppOK(t *testing.T) {
+	setupGitsourceMock(t)
+
+	privateKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	privateKeyPath := t.TempDir() + "/app.pem"
+	err := ioutil.WriteFile(privateKeyPath, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}), 0600)
+	assert.Equal(t, err, nil)
+
+	appID := int64(1234)
+	reqDto := dto.CreateGitSourceRequestDto{
+		Name:                    "test",
+		GitType:                 "github",
+		GitClientID:             "test",
+		GitClientSecret:         "test",
+		GithubAppID:             &appID,
+		GithubAppPrivateKeyPath: &privateKeyPath,
+		AgolaRemoteSourceName:   utils.NewString("test"),
+	}
+
+	var savedGitSource model.GitSource
+	db.EXPECT().GetGitSourceByName(reqDto.Name).Return(nil, nil)
+	db.EXPECT().SaveGitSource(gomock.Any()).DoAndReturn(func(gitSource *model.GitSource) error {
+		savedGitSource = *gitSource
+		return nil
+	})
+
+	data, _ := json.Marshal(reqDto)
+	requestBody := strings.NewReader(string(data))
+
+	router := test.SetupBaseRouter(nil)
+	router.HandleFunc("/gitsource", serviceGitsource.AddGitSource)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client := ts.Client()
+	resp, err := client.Post(ts.URL+"/gitsource", "application/json", requestBody)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusOK, "http StatusCode is not OK")
+	assert.Check(t, savedGitSource.IsGithubApp())
+	assert.Equal(t, savedGitSource.GithubAppPrivateKeyPath, privateKeyPath)
+}
+
+func TestAddGitsourcesGithubAppNotValid(t *testing.T) {
+	setupGitsourceMock(t)
+
+	appID := int64(1234)
+	privateKeyPath := t.TempDir() + "/notexists.pem"
+	reqDto := dto.CreateGitSourceRequestDto{
+		Name:                    "test",
+		GitType:                 "github",
+		GitClientID:             "test",
+		GitClientSecret:         "test",
+		GithubAppID:             &appID,
+		GithubAppPrivateKeyPath: &privateKeyPath,
+		AgolaRemoteSourceName:   utils.NewString("test"),
+	}
+
+	router := test.SetupBaseRouter(nil)
+	router.HandleFunc("/gitsource", serviceGitsource.AddGitSource)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client := ts.Client()
+
+	// private key not found
+
+	db.EXPECT().GetGitSourceByName(reqDto.Name).Return(nil, nil)
+
+	data, _ := json.Marshal(reqDto)
+	resp, err := client.Post(ts.URL+"/gitsource", "application/json", strings.NewReader(string(data)))
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusUnprocessableEntity, "http StatusCode is not correct")
+
+	// github app for a gitea gitSource
+
+	reqDto.GitType = "gitea"
+	reqDto.GitAPIURL = utils.NewString("https://gitea.test")
+	db.EXPECT().GetGitSourceByName(reqDto.Name).Return(nil, nil)
+
+	data, _ = json.Marshal(reqDto)
+	resp, err = client.Post(ts.URL+"/gitsource", "application/json", strings.NewReader(string(data)))
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusUnprocessableEntity, "http StatusCode is not correct")
+}
+
 func TestRemoveGitsourcesOK(t *testing.T) {
 	setupGitsourceMock(t)
 
@@ -186,7 +309,7 @@ func TestRemoveGitsourcesWithDeleteRemotesource(t *testing.T) {
 	db.EXPECT().GetOrganizationsByGitSource(gitSource.Name).Return(nil, nil)
 	db.EXPECT().GetUsersIDByGitSourceName(gitSource.Name).Return([]uint64{1}, nil)
 	db.EXPECT().DeleteUser(uint64(1)).Return(nil)
-	agolaApiInt.EXPECT().DeleteRemotesource(gitSource.AgolaRemoteSource).Return(nil)
+	agolaApiInt.EXPECT().DeleteRemotesource(gomock.Any(), gitSource.AgolaRemoteSource).Return(nil)
 	db.EXPECT().DeleteGitSource(gitSource.Name).Return(nil)
 
 	router := test.SetupBaseRouter(nil)
@@ -311,6 +434,18 @@ func TestGetGitOrganizationsWithErrors(t *testing.T) {
 
 	assert.Equal(t, err, nil)
 	assert.Equal(t, resp.StatusCode, http.StatusInternalServerError, "http StatusCode is not correct")
+
+	// git type without provider
+
+	unsupportedGitSource := gitSource
+	unsupportedGitSource.GitType = "unsupported"
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	db.EXPECT().GetGitSourceByName(user.GitSourceName).Return(&unsupportedGitSource, nil)
+
+	resp, err = client.Get(ts.URL + "/gitorganizations")
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusUnprocessableEntity, "http StatusCode is not correct")
 }
 
 func TestRemoveGitsourceWithErrors(t *testing.T) {
@@ -348,7 +483,7 @@ func TestRemoveGitsourceWithErrors(t *testing.T) {
 	db.EXPECT().GetGitSourceByName(gitSource.Name).Return(&gitSource, nil)
 	db.EXPECT().GetOrganizationsByGitSource(gitSource.Name).Return(nil, nil)
 	db.EXPECT().GetUsersIDByGitSourceName(gitSource.Name).Return(make([]uint64, 0), nil)
-	agolaApiInt.EXPECT().DeleteRemotesource(gitSource.Name).Return(errors.New("test"))
+	agolaApiInt.EXPECT().DeleteRemotesource(gomock.Any(), gitSource.Name).Return(errors.New("test"))
 
 	resp, err = client.Get(ts.URL + "/gitsource/" + gitSource.Name + "?deleteremotesource")
 
@@ -408,7 +543,7 @@ func TestAddGitsourceWithErrors(t *testing.T) {
 	//GetRemoteSources error
 
 	db.EXPECT().GetGitSourceByName(reqDto.Name).Return(nil, nil)
-	agolaApiInt.EXPECT().GetRemoteSources().Return(nil, errors.New("test"))
+	agolaApiInt.EXPECT().GetRemoteSources(gomock.Any()).Return(nil, errors.New("test"))
 
 	resp, err = client.Post(ts.URL+"/gitsource", "application/json", requestBody)
 
@@ -431,8 +566,8 @@ func TestAddGitsourceWithErrors(t *testing.T) {
 	requestBody = strings.NewReader(string(data))
 
 	db.EXPECT().GetGitSourceByName(reqDto.Name).Return(nil, nil)
-	agolaApiInt.EXPECT().GetRemoteSources().Return(nil, nil)
-	agolaApiInt.EXPECT().CreateRemoteSource(reqDto.Name, reqDto.GitType, *reqDto.GitAPIURL, *reqDto.AgolaClientID, reqDto.AgolaClientSecret).Return(errors.New("test"))
+	agolaApiInt.EXPECT().GetRemoteSources(gomock.Any()).Return(nil, nil)
+	agolaApiInt.EXPECT().CreateRemoteSource(gomock.Any(), reqDto.Name, reqDto.GitType, *reqDto.GitAPIURL, *reqDto.AgolaClientID, reqDto.AgolaClientSecret).Return(errors.New("test"))
 
 	resp, err = client.Post(ts.URL+"/gitsource", "application/json", requestBody)
 
@@ -442,8 +577,8 @@ func TestAddGitsourceWithErrors(t *testing.T) {
 	//SaveGitSource error
 
 	db.EXPECT().GetGitSourceByName(reqDto.Name).Return(nil, nil)
-	agolaApiInt.EXPECT().GetRemoteSources().Return(nil, nil)
-	agolaApiInt.EXPECT().CreateRemoteSource(reqDto.Name, reqDto.GitType, *reqDto.GitAPIURL, *reqDto.AgolaClientID, reqDto.AgolaClientSecret).Return(nil)
+	agolaApiInt.EXPECT().GetRemoteSources(gomock.Any()).Return(nil, nil)
+	agolaApiInt.EXPECT().CreateRemoteSource(gomock.Any(), reqDto.Name, reqDto.GitType, *reqDto.GitAPIURL, *reqDto.AgolaClientID, reqDto.AgolaClientSecret).Return(nil)
 	db.EXPECT().SaveGitSource(gomock.Any()).Return(errors.New("test"))
 
 	resp, err = client.Post(ts.URL+"/gitsource", "application/json", requestBody)
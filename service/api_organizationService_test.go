@@ -1,24 +1,35 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
 	"gotest.tools/assert"
 	"wecode.sorint.it/opensource/papagaio-api/api/agola"
 	"wecode.sorint.it/opensource/papagaio-api/api/git"
+	gitDto "wecode.sorint.it/opensource/papagaio-api/api/git/dto"
+	"wecode.sorint.it/opensource/papagaio-api/api/git/github"
+	"wecode.sorint.it/opensource/papagaio-api/common"
+	"wecode.sorint.it/opensource/papagaio-api/config"
 	"wecode.sorint.it/opensource/papagaio-api/dto"
+	"wecode.sorint.it/opensource/papagaio-api/manager/membersManager"
+	"wecode.sorint.it/opensource/papagaio-api/manager/variablesManager"
 	"wecode.sorint.it/opensource/papagaio-api/model"
 	"wecode.sorint.it/opensource/papagaio-api/test"
 	"wecode.sorint.it/opensource/papagaio-api/test/mock/mock_agola"
 	"wecode.sorint.it/opensource/papagaio-api/test/mock/mock_gitea"
+	"wecode.sorint.it/opensource/papagaio-api/test/mock/mock_github"
 	"wecode.sorint.it/opensource/papagaio-api/test/mock/mock_repository"
+	"wecode.sorint.it/opensource/papagaio-api/types"
 	"wecode.sorint.it/opensource/papagaio-api/utils"
 )
 
@@ -27,6 +38,9 @@ func TestGetOrganizationsOK(t *testing.T) {
 	defer ctl.Finish()
 
 	organizationsMock := test.MakeOrganizationList()
+	(*organizationsMock)[0].SetWebHookSecret("webHookSecret")
+	(*organizationsMock)[1].LegacyWebHookSecret = "legacyWebHookSecret"
+	(*organizationsMock)[0].AgolaVariables.SetSecret(model.AgolaSecret{Name: "deploy", Data: map[string]string{"token": "encryptedSecretValue"}})
 
 	db := mock_repository.NewMockDatabase(ctl)
 	db.EXPECT().GetOrganizations().Return(organizationsMock, nil)
@@ -43,8 +57,23 @@ func TestGetOrganizationsOK(t *testing.T) {
 
 	assert.Equal(t, err, nil)
 
-	var organizations *[]model.Organization
-	test.ParseBody(resp, organizations)
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Check(t, !strings.Contains(string(body), "ebHookSecret"), "webhook secret returned")
+	assert.Check(t, !strings.Contains(string(body), "encryptedSecretValue"), "agola secret value returned")
+
+	var response []map[string]json.RawMessage
+	err = json.Unmarshal(body, &response)
+	assert.Equal(t, err, nil)
+	found := false
+	for _, organization := range response {
+		var agolaVariables dto.AgolaVariablesDto
+		json.Unmarshal(organization["agolaVariables"], &agolaVariables)
+		if len(agolaVariables.Secrets) > 0 {
+			found = true
+			assert.DeepEqual(t, agolaVariables.Secrets, []dto.AgolaSecretDto{{Name: "deploy", Keys: []string{"token"}}})
+		}
+	}
+	assert.Check(t, found, "agola secret names not returned")
 }
 
 func TestAddExternalUser(t *testing.T) {
@@ -601,7 +630,7 @@ func TestGetAgolaOrganizationsOK(t *testing.T) {
 	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
 	db := mock_repository.NewMockDatabase(ctl)
 
-	agolaApi.EXPECT().GetOrganizations().Return(agolaOrganizations, nil)
+	agolaApi.EXPECT().GetOrganizations(gomock.Any()).Return(agolaOrganizations, nil)
 	db.EXPECT().GetOrganizationByAgolaRef(agolaOrganizations[0].Name).Return(nil, nil)
 
 	serviceOrganization := OrganizationService{
@@ -797,3 +826,722 @@ func TestGetExternalUserWhenIsnotOwner(t *testing.T) {
 	assert.Equal(t, resp.StatusCode, http.StatusOK, "http StatusCode not correct")
 	assert.Equal(t, dtoResponse.ErrorCode, dto.UserNotOwnerError)
 }
+
+func TestSynkMembersIncompleteListingKeepsMembers(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	githubApi := mock_github.NewMockGithubInterface(ctl)
+
+	gitSource := (*test.MakeGitSourceMap())["github"]
+	organization := (*test.MakeOrganizationMap())["Organization1"]
+	organization.GitSourceName = gitSource.Name
+	user := test.MakeUser()
+
+	githubUsers := []github.GitHubUser{{ID: 1, Username: "user1", Role: "owner"}}
+	agolaMembers := agola.OrganizationMembersResponseDto{
+		Members: []agola.MemberDto{
+			{User: agola.UserDto{Username: "user1"}, Role: agola.Member},
+			{User: agola.UserDto{Username: "user2"}, Role: agola.Member},
+		},
+	}
+	remotesource := agola.RemoteSourceDto{ID: "remotesource_test", Name: gitSource.AgolaRemoteSource}
+
+	githubApi.EXPECT().GetOrganizationMembers(gomock.Any(), gomock.Any(), organization.GitPath).Return(&githubUsers, errors.New("page 2 not available"))
+	agolaApi.EXPECT().GetOrganizationMembers(gomock.Any(), gomock.Any()).Return(&agolaMembers, nil)
+	agolaApi.EXPECT().GetRemoteSource(gomock.Any(), gitSource.AgolaRemoteSource).Return(&remotesource, nil)
+	agolaApi.EXPECT().GetUsersFilterbyRemoteUser(gomock.Any(), remotesource.ID, int64(1)).Return([]*agola.UserDto{{Username: "user1"}}, nil)
+	agolaApi.EXPECT().AddOrUpdateOrganizationMember(gomock.Any(), gomock.Any(), "user1", "owner").Return(nil)
+
+	err := membersManager.SynkMembers(context.Background(), &organization, &gitSource, agolaApi, &git.GitGateway{GithubApi: githubApi}, user)
+
+	assert.Equal(t, err, nil)
+}
+
+func TestSynkMembersRepositoryCollaborators(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	githubApi := mock_github.NewMockGithubInterface(ctl)
+
+	gitSource := (*test.MakeGitSourceMap())["github"]
+	organization := (*test.MakeOrganizationMap())["Organization1"]
+	organization.GitSourceName = gitSource.Name
+	organization.CollaboratorsPolicy = types.CollaboratorsMember
+	organization.Projects = map[string]model.Project{"repository1": {GitRepoPath: "repository1"}}
+	user := test.MakeUser()
+
+	githubUsers := []github.GitHubUser{{ID: 1, Username: "user1", Role: "owner"}}
+	collaborators := []gitDto.UserTeamResponseDto{{ID: 1, Username: "user1"}, {ID: 3, Username: "user3"}, {ID: 4, Username: "user4"}}
+	agolaMembers := agola.OrganizationMembersResponseDto{
+		Members: []agola.MemberDto{
+			{User: agola.UserDto{Username: "user1"}, Role: agola.Owner},
+			{User: agola.UserDto{Username: "user2"}, Role: agola.Member},
+			{User: agola.UserDto{Username: "user3"}, Role: agola.Owner},
+		},
+	}
+	remotesource := agola.RemoteSourceDto{ID: "remotesource_test", Name: gitSource.AgolaRemoteSource}
+
+	githubApi.EXPECT().GetOrganizationMembers(gomock.Any(), gomock.Any(), organization.GitPath).Return(&githubUsers, nil)
+	githubApi.EXPECT().GetRepositoryCollaborators(gomock.Any(), gomock.Any(), organization.GitPath, "repository1").Return(&collaborators, nil)
+	agolaApi.EXPECT().GetOrganizationMembers(gomock.Any(), gomock.Any()).Return(&agolaMembers, nil)
+	agolaApi.EXPECT().GetRemoteSource(gomock.Any(), gitSource.AgolaRemoteSource).Return(&remotesource, nil)
+	agolaApi.EXPECT().GetUsersFilterbyRemoteUser(gomock.Any(), remotesource.ID, int64(1)).Return([]*agola.UserDto{{Username: "user1"}}, nil)
+	agolaApi.EXPECT().GetUsersFilterbyRemoteUser(gomock.Any(), remotesource.ID, int64(3)).Return([]*agola.UserDto{{Username: "user3"}}, nil)
+	agolaApi.EXPECT().GetUsersFilterbyRemoteUser(gomock.Any(), remotesource.ID, int64(4)).Return([]*agola.UserDto{{Username: "user4"}}, nil)
+	agolaApi.EXPECT().AddOrUpdateOrganizationMember(gomock.Any(), gomock.Any(), "user4", "member").Return(nil)
+	agolaApi.EXPECT().RemoveOrganizationMember(gomock.Any(), gomock.Any(), "user2").Return(nil)
+
+	err := membersManager.SynkMembers(context.Background(), &organization, &gitSource, agolaApi, &git.GitGateway{GithubApi: githubApi}, user)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(organization.Projects["repository1"].Collaborators), 0)
+}
+
+func TestSynkMembersProjectCollaborators(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	githubApi := mock_github.NewMockGithubInterface(ctl)
+
+	gitSource := (*test.MakeGitSourceMap())["github"]
+	organization := (*test.MakeOrganizationMap())["Organization1"]
+	organization.GitSourceName = gitSource.Name
+	organization.CollaboratorsPolicy = types.CollaboratorsProject
+	organization.Projects = map[string]model.Project{
+		"repository1": {GitRepoPath: "repository1"},
+		"repository2": {GitRepoPath: "repository2", Collaborators: []int64{5}},
+	}
+	user := test.MakeUser()
+
+	githubUsers := []github.GitHubUser{{ID: 1, Username: "user1", Role: "owner"}}
+	collaborators := []gitDto.UserTeamResponseDto{{ID: 1, Username: "user1"}, {ID: 3, Username: "user3"}, {ID: 4, Username: "user4"}}
+	agolaMembers := agola.OrganizationMembersResponseDto{
+		Members: []agola.MemberDto{
+			{User: agola.UserDto{Username: "user1"}, Role: agola.Owner},
+			{User: agola.UserDto{Username: "user3"}, Role: agola.Owner},
+		},
+	}
+	remotesource := agola.RemoteSourceDto{ID: "remotesource_test", Name: gitSource.AgolaRemoteSource}
+
+	githubApi.EXPECT().GetOrganizationMembers(gomock.Any(), gomock.Any(), organization.GitPath).Return(&githubUsers, nil)
+	githubApi.EXPECT().GetRepositoryCollaborators(gomock.Any(), gomock.Any(), organization.GitPath, "repository1").Return(&collaborators, nil)
+	githubApi.EXPECT().GetRepositoryCollaborators(gomock.Any(), gomock.Any(), organization.GitPath, "repository2").Return(nil, errors.New("not available"))
+	agolaApi.EXPECT().GetOrganizationMembers(gomock.Any(), gomock.Any()).Return(&agolaMembers, nil)
+	agolaApi.EXPECT().GetRemoteSource(gomock.Any(), gitSource.AgolaRemoteSource).Return(&remotesource, nil)
+	agolaApi.EXPECT().GetUsersFilterbyRemoteUser(gomock.Any(), remotesource.ID, int64(1)).Return([]*agola.UserDto{{Username: "user1"}}, nil)
+	agolaApi.EXPECT().GetUsersFilterbyRemoteUser(gomock.Any(), remotesource.ID, int64(3)).Return([]*agola.UserDto{{Username: "user3"}}, nil)
+	agolaApi.EXPECT().GetUsersFilterbyRemoteUser(gomock.Any(), remotesource.ID, int64(4)).Return([]*agola.UserDto{{Username: "user4"}}, nil)
+
+	err := membersManager.SynkMembers(context.Background(), &organization, &gitSource, agolaApi, &git.GitGateway{GithubApi: githubApi}, user)
+
+	assert.Equal(t, err, nil)
+	assert.DeepEqual(t, organization.Projects["repository1"].Collaborators, []int64{3, 4})
+	assert.DeepEqual(t, organization.Projects["repository2"].Collaborators, []int64{5})
+}
+
+func TestGetMembersPlanWithMembersMapping(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	db := mock_repository.NewMockDatabase(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	giteaApi := mock_gitea.NewMockGiteaInterface(ctl)
+
+	serviceOrganization := OrganizationService{
+		Db:         db,
+		AgolaApi:   agolaApi,
+		GitGateway: &git.GitGateway{GiteaApi: giteaApi},
+	}
+	user := test.MakeUser()
+
+	org := (*test.MakeOrganizationList())[0]
+	org.MembersMapping = model.MembersMapping{
+		Teams:        map[string]types.MemberRole{"developers": types.MemberRoleOwner, "readers": types.MemberRoleNone},
+		IgnoredUsers: []string{"user5"},
+	}
+	gitSource := (*test.MakeGitSourceMap())[org.GitSourceName]
+
+	teams := []gitDto.TeamResponseDto{
+		{ID: 1, Name: "Owners", Permission: "owner"},
+		{ID: 2, Name: "developers", Permission: "write"},
+		{ID: 3, Name: "readers", Permission: "read"},
+	}
+	agolaMembers := agola.OrganizationMembersResponseDto{
+		Members: []agola.MemberDto{
+			{User: agola.UserDto{Username: "user1"}, Role: agola.Member},
+			{User: agola.UserDto{Username: "user2"}, Role: agola.Member},
+			{User: agola.UserDto{Username: "user5"}, Role: agola.Owner},
+		},
+	}
+	remotesource := agola.RemoteSourceDto{ID: "remotesource_test", Name: gitSource.AgolaRemoteSource}
+
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	db.EXPECT().GetOrganizationByAgolaRef(gomock.Any()).Return(&org, nil)
+	db.EXPECT().GetGitSourceByName(gomock.Eq(org.GitSourceName)).Return(&gitSource, nil)
+	giteaApi.EXPECT().IsUserOwner(gomock.Any(), gomock.Any(), org.GitPath).Return(true, nil)
+	giteaApi.EXPECT().GetOrganizationTeams(gomock.Any(), gomock.Any(), org.GitPath).Return(&teams, nil)
+	giteaApi.EXPECT().GetTeamMembers(gomock.Any(), gomock.Any(), int64(1)).Return(&[]gitDto.UserTeamResponseDto{{ID: 1, Username: "user1"}}, nil)
+	giteaApi.EXPECT().GetTeamMembers(gomock.Any(), gomock.Any(), int64(2)).Return(&[]gitDto.UserTeamResponseDto{{ID: 3, Username: "user3"}}, nil)
+	giteaApi.EXPECT().GetTeamMembers(gomock.Any(), gomock.Any(), int64(3)).Return(&[]gitDto.UserTeamResponseDto{{ID: 2, Username: "user2"}}, nil)
+	agolaApi.EXPECT().GetOrganizationMembers(gomock.Any(), gomock.Any()).Return(&agolaMembers, nil)
+	agolaApi.EXPECT().GetRemoteSource(gomock.Any(), gitSource.AgolaRemoteSource).Return(&remotesource, nil)
+	agolaApi.EXPECT().GetUsersFilterbyRemoteUser(gomock.Any(), remotesource.ID, int64(1)).Return([]*agola.UserDto{{Username: "user1"}}, nil)
+	agolaApi.EXPECT().GetUsersFilterbyRemoteUser(gomock.Any(), remotesource.ID, int64(2)).Return([]*agola.UserDto{{Username: "user2"}}, nil)
+	agolaApi.EXPECT().GetUsersFilterbyRemoteUser(gomock.Any(), remotesource.ID, int64(3)).Return([]*agola.UserDto{{Username: "user3"}}, nil)
+
+	router := test.SetupBaseRouter(user)
+
+	router.HandleFunc("/{organizationRef}", serviceOrganization.GetMembersPlan)
+	ts := httptest.NewServer(router)
+
+	client := ts.Client()
+
+	resp, err := client.Get(ts.URL + "/" + org.AgolaOrganizationRef)
+
+	var dtoResponse = dto.MembersPlanResponseDto{}
+	test.ParseBody(resp, &dtoResponse)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusOK, "http StatusCode is not OK")
+	assert.Equal(t, dtoResponse.ErrorCode, dto.NoError)
+	assert.DeepEqual(t, dtoResponse.Plan.Add, []dto.MemberChangeDto{{AgolaUserRef: "user3", GitUsername: "user3", Role: "owner"}})
+	assert.DeepEqual(t, dtoResponse.Plan.Update, []dto.MemberChangeDto{{AgolaUserRef: "user1", GitUsername: "user1", Role: "owner", CurrentRole: "member"}})
+	assert.DeepEqual(t, dtoResponse.Plan.Remove, []dto.MemberChangeDto{{AgolaUserRef: "user2", CurrentRole: "member"}})
+	assert.Equal(t, dtoResponse.Plan.RemoveSkipped, false)
+}
+
+func TestGetMembersPlanTeamNamedAsGitRole(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	db := mock_repository.NewMockDatabase(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	giteaApi := mock_gitea.NewMockGiteaInterface(ctl)
+
+	serviceOrganization := OrganizationService{
+		Db:         db,
+		AgolaApi:   agolaApi,
+		GitGateway: &git.GitGateway{GiteaApi: giteaApi},
+	}
+	user := test.MakeUser()
+
+	org := (*test.MakeOrganizationList())[0]
+	org.MembersMapping = model.MembersMapping{
+		Teams: map[string]types.MemberRole{"owner": types.MemberRoleNone},
+	}
+	gitSource := (*test.MakeGitSourceMap())[org.GitSourceName]
+
+	teams := []gitDto.TeamResponseDto{
+		{ID: 1, Name: "Owners", Permission: "owner"},
+		{ID: 2, Name: "owner", Permission: "write"},
+	}
+	agolaMembers := agola.OrganizationMembersResponseDto{Members: []agola.MemberDto{}}
+	remotesource := agola.RemoteSourceDto{ID: "remotesource_test", Name: gitSource.AgolaRemoteSource}
+
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	db.EXPECT().GetOrganizationByAgolaRef(gomock.Any()).Return(&org, nil)
+	db.EXPECT().GetGitSourceByName(gomock.Eq(org.GitSourceName)).Return(&gitSource, nil)
+	giteaApi.EXPECT().IsUserOwner(gomock.Any(), gomock.Any(), org.GitPath).Return(true, nil)
+	giteaApi.EXPECT().GetOrganizationTeams(gomock.Any(), gomock.Any(), org.GitPath).Return(&teams, nil)
+	giteaApi.EXPECT().GetTeamMembers(gomock.Any(), gomock.Any(), int64(1)).Return(&[]gitDto.UserTeamResponseDto{{ID: 1, Username: "user1"}}, nil)
+	giteaApi.EXPECT().GetTeamMembers(gomock.Any(), gomock.Any(), int64(2)).Return(&[]gitDto.UserTeamResponseDto{{ID: 2, Username: "user2"}}, nil)
+	agolaApi.EXPECT().GetOrganizationMembers(gomock.Any(), gomock.Any()).Return(&agolaMembers, nil)
+	agolaApi.EXPECT().GetRemoteSource(gomock.Any(), gitSource.AgolaRemoteSource).Return(&remotesource, nil)
+	agolaApi.EXPECT().GetUsersFilterbyRemoteUser(gomock.Any(), remotesource.ID, int64(1)).Return([]*agola.UserDto{{Username: "user1"}}, nil)
+	agolaApi.EXPECT().GetUsersFilterbyRemoteUser(gomock.Any(), remotesource.ID, int64(2)).Return([]*agola.UserDto{{Username: "user2"}}, nil)
+
+	router := test.SetupBaseRouter(user)
+
+	router.HandleFunc("/{organizationRef}", serviceOrganization.GetMembersPlan)
+	ts := httptest.NewServer(router)
+
+	client := ts.Client()
+
+	resp, err := client.Get(ts.URL + "/" + org.AgolaOrganizationRef)
+
+	var dtoResponse = dto.MembersPlanResponseDto{}
+	test.ParseBody(resp, &dtoResponse)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusOK, "http StatusCode is not OK")
+	assert.DeepEqual(t, dtoResponse.Plan.Add, []dto.MemberChangeDto{{AgolaUserRef: "user1", GitUsername: "user1", Role: "owner"}})
+}
+
+func TestGetMembersPlanAgolaMembersError(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	db := mock_repository.NewMockDatabase(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	giteaApi := mock_gitea.NewMockGiteaInterface(ctl)
+
+	serviceOrganization := OrganizationService{
+		Db:         db,
+		AgolaApi:   agolaApi,
+		GitGateway: &git.GitGateway{GiteaApi: giteaApi},
+	}
+	user := test.MakeUser()
+
+	org := (*test.MakeOrganizationList())[0]
+	gitSource := (*test.MakeGitSourceMap())[org.GitSourceName]
+
+	teams := []gitDto.TeamResponseDto{{ID: 1, Name: "Owners", Permission: "owner"}}
+
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	db.EXPECT().GetOrganizationByAgolaRef(gomock.Any()).Return(&org, nil)
+	db.EXPECT().GetGitSourceByName(gomock.Eq(org.GitSourceName)).Return(&gitSource, nil)
+	giteaApi.EXPECT().IsUserOwner(gomock.Any(), gomock.Any(), org.GitPath).Return(true, nil)
+	giteaApi.EXPECT().GetOrganizationTeams(gomock.Any(), gomock.Any(), org.GitPath).Return(&teams, nil)
+	giteaApi.EXPECT().GetTeamMembers(gomock.Any(), gomock.Any(), int64(1)).Return(&[]gitDto.UserTeamResponseDto{{ID: 1, Username: "user1"}}, nil)
+	agolaApi.EXPECT().GetOrganizationMembers(gomock.Any(), gomock.Any()).Return(nil, errors.New("agola not available"))
+
+	router := test.SetupBaseRouter(user)
+
+	router.HandleFunc("/{organizationRef}", serviceOrganization.GetMembersPlan)
+	ts := httptest.NewServer(router)
+
+	client := ts.Client()
+
+	resp, err := client.Get(ts.URL + "/" + org.AgolaOrganizationRef)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusInternalServerError, "http StatusCode is not correct")
+}
+
+func TestSynkMembersAgolaUserUnavailableNothingRemoved(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	githubApi := mock_github.NewMockGithubInterface(ctl)
+
+	gitSource := (*test.MakeGitSourceMap())["github"]
+	organization := (*test.MakeOrganizationMap())["Organization1"]
+	organization.GitSourceName = gitSource.Name
+	user := test.MakeUser()
+
+	githubUsers := []github.GitHubUser{{ID: 1, Username: "user1", Role: "owner"}, {ID: 2, Username: "user2", Role: "member"}}
+	agolaMembers := agola.OrganizationMembersResponseDto{
+		Members: []agola.MemberDto{
+			{User: agola.UserDto{Username: "user1"}, Role: agola.Owner},
+			{User: agola.UserDto{Username: "user2"}, Role: agola.Member},
+		},
+	}
+	remotesource := agola.RemoteSourceDto{ID: "remotesource_test", Name: gitSource.AgolaRemoteSource}
+
+	githubApi.EXPECT().GetOrganizationMembers(gomock.Any(), gomock.Any(), organization.GitPath).Return(&githubUsers, nil)
+	agolaApi.EXPECT().GetOrganizationMembers(gomock.Any(), gomock.Any()).Return(&agolaMembers, nil)
+	agolaApi.EXPECT().GetRemoteSource(gomock.Any(), gitSource.AgolaRemoteSource).Return(&remotesource, nil)
+	agolaApi.EXPECT().GetUsersFilterbyRemoteUser(gomock.Any(), remotesource.ID, gomock.Any()).DoAndReturn(func(ctx context.Context, remoteSourceID string, remoteUserID int64) ([]*agola.UserDto, error) {
+		if remoteUserID == 1 {
+			return []*agola.UserDto{{Username: "user1"}}, nil
+		}
+		return nil, agola.ErrUnavailable
+	}).MaxTimes(2)
+
+	err := membersManager.SynkMembers(context.Background(), &organization, &gitSource, agolaApi, &git.GitGateway{GithubApi: githubApi}, user)
+
+	assert.Equal(t, err, nil)
+}
+
+func TestGetMembersPlanRemoteSourceUnavailable(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	db := mock_repository.NewMockDatabase(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	giteaApi := mock_gitea.NewMockGiteaInterface(ctl)
+
+	serviceOrganization := OrganizationService{
+		Db:         db,
+		AgolaApi:   agolaApi,
+		GitGateway: &git.GitGateway{GiteaApi: giteaApi},
+	}
+	user := test.MakeUser()
+
+	org := (*test.MakeOrganizationList())[0]
+	gitSource := (*test.MakeGitSourceMap())[org.GitSourceName]
+
+	teams := []gitDto.TeamResponseDto{{ID: 1, Name: "Owners", Permission: "owner"}}
+	agolaMembers := agola.OrganizationMembersResponseDto{Members: []agola.MemberDto{{User: agola.UserDto{Username: "user2"}, Role: agola.Member}}}
+
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	db.EXPECT().GetOrganizationByAgolaRef(gomock.Any()).Return(&org, nil)
+	db.EXPECT().GetGitSourceByName(gomock.Eq(org.GitSourceName)).Return(&gitSource, nil)
+	giteaApi.EXPECT().IsUserOwner(gomock.Any(), gomock.Any(), org.GitPath).Return(true, nil)
+	giteaApi.EXPECT().GetOrganizationTeams(gomock.Any(), gomock.Any(), org.GitPath).Return(&teams, nil)
+	giteaApi.EXPECT().GetTeamMembers(gomock.Any(), gomock.Any(), int64(1)).Return(&[]gitDto.UserTeamResponseDto{{ID: 1, Username: "user1"}}, nil)
+	agolaApi.EXPECT().GetOrganizationMembers(gomock.Any(), gomock.Any()).Return(&agolaMembers, nil)
+	agolaApi.EXPECT().GetRemoteSource(gomock.Any(), gitSource.AgolaRemoteSource).Return(nil, agola.ErrUnavailable)
+
+	router := test.SetupBaseRouter(user)
+
+	router.HandleFunc("/{organizationRef}", serviceOrganization.GetMembersPlan)
+	ts := httptest.NewServer(router)
+
+	client := ts.Client()
+
+	resp, err := client.Get(ts.URL + "/" + org.AgolaOrganizationRef)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusInternalServerError, "http StatusCode is not correct")
+}
+
+func TestSaveMembersMappingOK(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	commonMutex := utils.NewEventMutex()
+	db := mock_repository.NewMockDatabase(ctl)
+	giteaApi := mock_gitea.NewMockGiteaInterface(ctl)
+
+	serviceOrganization := OrganizationService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{GiteaApi: giteaApi},
+		CommonMutex: &commonMutex,
+	}
+	user := test.MakeUser()
+
+	org := (*test.MakeOrganizationList())[0]
+	gitSource := (*test.MakeGitSourceMap())[org.GitSourceName]
+	membersMapping := dto.MembersMappingDto{
+		Teams:        map[string]types.MemberRole{"developers": types.MemberRoleOwner},
+		GitRoles:     map[string]types.MemberRole{"member": types.MemberRoleNone},
+		IgnoredUsers: []string{"user5"},
+	}
+
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	db.EXPECT().GetOrganizationByAgolaRef(gomock.Any()).Return(&org, nil)
+	db.EXPECT().GetGitSourceByName(gomock.Eq(org.GitSourceName)).Return(&gitSource, nil)
+	giteaApi.EXPECT().IsUserOwner(gomock.Any(), gomock.Any(), org.GitPath).Return(true, nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).DoAndReturn(func(organization *model.Organization) error {
+		assert.Equal(t, organization.MembersMapping.Teams["developers"], types.MemberRoleOwner)
+		assert.Equal(t, organization.MembersMapping.GitRoles["member"], types.MemberRoleNone)
+		assert.Equal(t, organization.MembersMapping.IsIgnored("user5"), true)
+		return nil
+	})
+
+	router := test.SetupBaseRouter(user)
+
+	router.HandleFunc("/{organizationRef}", serviceOrganization.SaveMembersMapping)
+	ts := httptest.NewServer(router)
+
+	client := ts.Client()
+
+	data, _ := json.Marshal(membersMapping)
+	req, _ := http.NewRequest(http.MethodPut, ts.URL+"/"+org.AgolaOrganizationRef, strings.NewReader(string(data)))
+	resp, err := client.Do(req)
+
+	var dtoResponse = dto.MembersMappingResponseDto{}
+	test.ParseBody(resp, &dtoResponse)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusOK, "http StatusCode is not OK")
+	assert.Equal(t, dtoResponse.ErrorCode, dto.NoError)
+}
+
+func TestSaveMembersMappingInvalidRole(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	db := mock_repository.NewMockDatabase(ctl)
+
+	serviceOrganization := OrganizationService{
+		Db: db,
+	}
+	user := test.MakeUser()
+	org := (*test.MakeOrganizationList())[0]
+
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+
+	router := test.SetupBaseRouter(user)
+
+	router.HandleFunc("/{organizationRef}", serviceOrganization.SaveMembersMapping)
+	ts := httptest.NewServer(router)
+
+	client := ts.Client()
+
+	data, _ := json.Marshal(dto.MembersMappingDto{GitRoles: map[string]types.MemberRole{"member": "admin"}})
+	req, _ := http.NewRequest(http.MethodPut, ts.URL+"/"+org.AgolaOrganizationRef, strings.NewReader(string(data)))
+	resp, err := client.Do(req)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusUnprocessableEntity, "http StatusCode is not correct")
+}
+
+func TestSaveCollaboratorsPolicyOK(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	commonMutex := utils.NewEventMutex()
+	db := mock_repository.NewMockDatabase(ctl)
+	giteaApi := mock_gitea.NewMockGiteaInterface(ctl)
+
+	serviceOrganization := OrganizationService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{GiteaApi: giteaApi},
+		CommonMutex: &commonMutex,
+	}
+	user := test.MakeUser()
+
+	org := (*test.MakeOrganizationList())[0]
+	org.GitSourceName = "gitea"
+	gitSource := (*test.MakeGitSourceMap())[org.GitSourceName]
+
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	db.EXPECT().GetOrganizationByAgolaRef(gomock.Any()).Return(&org, nil)
+	db.EXPECT().GetGitSourceByName(gomock.Eq(org.GitSourceName)).Return(&gitSource, nil)
+	giteaApi.EXPECT().IsUserOwner(gomock.Any(), gomock.Any(), org.GitPath).Return(true, nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).DoAndReturn(func(organization *model.Organization) error {
+		assert.Equal(t, organization.CollaboratorsPolicy, types.CollaboratorsProject)
+		return nil
+	})
+
+	router := test.SetupBaseRouter(user)
+
+	router.HandleFunc("/{organizationRef}", serviceOrganization.SaveCollaboratorsPolicy)
+	ts := httptest.NewServer(router)
+
+	client := ts.Client()
+
+	data, _ := json.Marshal(dto.CollaboratorsPolicyDto{CollaboratorsPolicy: types.CollaboratorsProject})
+	req, _ := http.NewRequest(http.MethodPut, ts.URL+"/"+org.AgolaOrganizationRef, strings.NewReader(string(data)))
+	resp, err := client.Do(req)
+
+	var dtoResponse = dto.CollaboratorsPolicyResponseDto{}
+	test.ParseBody(resp, &dtoResponse)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusOK, "http StatusCode is not OK")
+	assert.Equal(t, dtoResponse.ErrorCode, dto.NoError)
+	assert.Equal(t, dtoResponse.CollaboratorsPolicy, types.CollaboratorsProject)
+}
+
+func TestSaveCollaboratorsPolicyNotOwner(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	commonMutex := utils.NewEventMutex()
+	db := mock_repository.NewMockDatabase(ctl)
+	giteaApi := mock_gitea.NewMockGiteaInterface(ctl)
+
+	serviceOrganization := OrganizationService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{GiteaApi: giteaApi},
+		CommonMutex: &commonMutex,
+	}
+	user := test.MakeUser()
+
+	org := (*test.MakeOrganizationList())[0]
+	org.GitSourceName = "gitea"
+	gitSource := (*test.MakeGitSourceMap())[org.GitSourceName]
+
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	db.EXPECT().GetOrganizationByAgolaRef(gomock.Any()).Return(&org, nil)
+	db.EXPECT().GetGitSourceByName(gomock.Eq(org.GitSourceName)).Return(&gitSource, nil)
+	giteaApi.EXPECT().IsUserOwner(gomock.Any(), gomock.Any(), org.GitPath).Return(false, nil)
+
+	router := test.SetupBaseRouter(user)
+
+	router.HandleFunc("/{organizationRef}", serviceOrganization.SaveCollaboratorsPolicy)
+	ts := httptest.NewServer(router)
+
+	client := ts.Client()
+
+	data, _ := json.Marshal(dto.CollaboratorsPolicyDto{CollaboratorsPolicy: types.CollaboratorsMember})
+	req, _ := http.NewRequest(http.MethodPut, ts.URL+"/"+org.AgolaOrganizationRef, strings.NewReader(string(data)))
+	resp, err := client.Do(req)
+
+	var dtoResponse = dto.CollaboratorsPolicyResponseDto{}
+	test.ParseBody(resp, &dtoResponse)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusOK, "http StatusCode is not OK")
+	assert.Equal(t, dtoResponse.ErrorCode, dto.UserNotOwnerError)
+}
+
+func TestSaveCollaboratorsPolicyInvalid(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	db := mock_repository.NewMockDatabase(ctl)
+
+	serviceOrganization := OrganizationService{
+		Db: db,
+	}
+	user := test.MakeUser()
+	org := (*test.MakeOrganizationList())[0]
+
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+
+	router := test.SetupBaseRouter(user)
+
+	router.HandleFunc("/{organizationRef}", serviceOrganization.SaveCollaboratorsPolicy)
+	ts := httptest.NewServer(router)
+
+	client := ts.Client()
+
+	data, _ := json.Marshal(dto.CollaboratorsPolicyDto{CollaboratorsPolicy: "team"})
+	req, _ := http.NewRequest(http.MethodPut, ts.URL+"/"+org.AgolaOrganizationRef, strings.NewReader(string(data)))
+	resp, err := client.Do(req)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusUnprocessableEntity, "http StatusCode is not correct")
+}
+
+func TestSaveAgolaSecretEncrypted(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	commonMutex := utils.NewEventMutex()
+	db := mock_repository.NewMockDatabase(ctl)
+
+	serviceOrganization := OrganizationService{
+		Db:          db,
+		CommonMutex: &commonMutex,
+	}
+
+	org := (*test.MakeOrganizationList())[0]
+
+	db.EXPECT().GetOrganizationByAgolaRef(org.AgolaOrganizationRef).Return(&org, nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).DoAndReturn(func(organization *model.Organization) error {
+		assert.Equal(t, len(organization.AgolaVariables.Secrets), 1)
+		encryptedValue := organization.AgolaVariables.Secrets[0].Data["password"]
+		assert.Check(t, encryptedValue != "secretpassword")
+
+		value, err := common.DecryptSecretValue(config.Config.SecretsEncryptionKey, encryptedValue)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, value, "secretpassword")
+		return nil
+	})
+
+	router := mux.NewRouter()
+	router.HandleFunc("/{organizationRef}/secrets/{secretName}", serviceOrganization.SaveAgolaSecret)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client := ts.Client()
+
+	data, _ := json.Marshal(dto.SaveAgolaSecretRequestDto{Data: map[string]string{"password": "secretpassword"}})
+	req, _ := http.NewRequest(http.MethodPut, ts.URL+"/"+org.AgolaOrganizationRef+"/secrets/registry", strings.NewReader(string(data)))
+	resp, err := client.Do(req)
+
+	var dtoResponse = dto.AgolaVariablesDto{}
+	test.ParseBody(resp, &dtoResponse)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusOK, "http StatusCode is not OK")
+	assert.Equal(t, dtoResponse.Revision, uint64(1))
+	assert.Equal(t, len(dtoResponse.Secrets), 1)
+	assert.Equal(t, dtoResponse.Secrets[0].Name, "registry")
+	assert.DeepEqual(t, dtoResponse.Secrets[0].Keys, []string{"password"})
+}
+
+func TestRemoveAgolaSecretUsedByVariable(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	commonMutex := utils.NewEventMutex()
+	db := mock_repository.NewMockDatabase(ctl)
+
+	serviceOrganization := OrganizationService{
+		Db:          db,
+		CommonMutex: &commonMutex,
+	}
+
+	org := (*test.MakeOrganizationList())[0]
+	org.AgolaVariables = model.AgolaVariables{
+		Secrets:   []model.AgolaSecret{{Name: "registry", Data: map[string]string{"password": "encrypted"}}},
+		Variables: []model.AgolaVariable{{Name: "registrypassword", Values: []model.AgolaVariableValue{{SecretName: "registry", SecretVar: "password"}}}},
+		Revision:  2,
+	}
+
+	db.EXPECT().GetOrganizationByAgolaRef(org.AgolaOrganizationRef).Return(&org, nil)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/{organizationRef}/secrets/{secretName}", serviceOrganization.RemoveAgolaSecret)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client := ts.Client()
+
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL+"/"+org.AgolaOrganizationRef+"/secrets/registry", nil)
+	resp, err := client.Do(req)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusUnprocessableEntity, "http StatusCode is not correct")
+	assert.Equal(t, len(org.AgolaVariables.Secrets), 1)
+	assert.Equal(t, org.AgolaVariables.Revision, uint64(2))
+}
+
+func TestRemoveAgolaSecretNotFound(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	commonMutex := utils.NewEventMutex()
+	db := mock_repository.NewMockDatabase(ctl)
+
+	serviceOrganization := OrganizationService{
+		Db:          db,
+		CommonMutex: &commonMutex,
+	}
+
+	org := (*test.MakeOrganizationList())[0]
+
+	db.EXPECT().GetOrganizationByAgolaRef(org.AgolaOrganizationRef).Return(&org, nil)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/{organizationRef}/secrets/{secretName}", serviceOrganization.RemoveAgolaSecret)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client := ts.Client()
+
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL+"/"+org.AgolaOrganizationRef+"/secrets/registry", nil)
+	resp, err := client.Do(req)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusNotFound, "http StatusCode is not correct")
+}
+
+func TestSynkProjectVariables(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+
+	encryptedPassword, _ := common.EncryptSecretValue(config.Config.SecretsEncryptionKey, "secretpassword")
+	organization := (*test.MakeOrganizationList())[0]
+	organization.AgolaVariables = model.AgolaVariables{
+		Secrets:   []model.AgolaSecret{{Name: "registry", Data: map[string]string{"password": encryptedPassword}}},
+		Variables: []model.AgolaVariable{{Name: "registrypassword", Values: []model.AgolaVariableValue{{SecretName: "registry", SecretVar: "password"}}}},
+		Revision:  2,
+	}
+	project := model.Project{
+		GitRepoPath:     "repo",
+		AgolaProjectRef: "repo",
+		AgolaProjectID:  "projectID",
+		AgolaVariables:  &model.AppliedAgolaVariables{Revision: 1, Secrets: []string{"registry", "oldsecret"}, Variables: []string{"oldvariable"}},
+	}
+
+	agolaApi.EXPECT().GetProjectSecrets(gomock.Any(), gomock.Any(), "repo").Return([]*agola.SecretDto{{Name: "registry"}, {Name: "oldsecret"}}, nil)
+	agolaApi.EXPECT().GetProjectVariables(gomock.Any(), gomock.Any(), "repo").Return([]*agola.VariableDto{{Name: "oldvariable"}}, nil)
+	agolaApi.EXPECT().UpdateProjectSecret(gomock.Any(), gomock.Any(), "repo", "registry", map[string]string{"password": "secretpassword"}).Return(nil)
+	agolaApi.EXPECT().CreateProjectVariable(gomock.Any(), gomock.Any(), "repo", "registrypassword", []agola.VariableValueDto{{SecretName: "registry", SecretVar: "password"}}).Return(nil)
+	agolaApi.EXPECT().DeleteProjectVariable(gomock.Any(), gomock.Any(), "repo", "oldvariable").Return(nil)
+	agolaApi.EXPECT().DeleteProjectSecret(gomock.Any(), gomock.Any(), "repo", "oldsecret").Return(nil)
+
+	err := variablesManager.SynkProjectVariables(context.Background(), &organization, &project, agolaApi)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, project.AgolaVariables.Revision, uint64(2))
+	assert.DeepEqual(t, project.AgolaVariables.Secrets, []string{"registry"})
+	assert.DeepEqual(t, project.AgolaVariables.Variables, []string{"registrypassword"})
+
+	//the revision is already applied, no Agola call
+	err = variablesManager.SynkProjectVariables(context.Background(), &organization, &project, agolaApi)
+	assert.Equal(t, err, nil)
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	"wecode.sorint.it/opensource/papagaio-api/api/transport"
+)
+
+//Server answering with the given status codes in sequence, then always with the last one
+func setupRetryServer(statusCodes []int, header http.Header, requests *int32, bodies *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := int(atomic.AddInt32(requests, 1)) - 1
+		if bodies != nil {
+			body, _ := ioutil.ReadAll(r.Body)
+			*bodies = append(*bodies, string(body))
+		}
+
+		statusCode := statusCodes[len(statusCodes)-1]
+		if request < len(statusCodes) {
+			statusCode = statusCodes[request]
+		}
+		if statusCode != http.StatusOK {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+		}
+		w.WriteHeader(statusCode)
+	}))
+}
+
+func makeRetryTransport(maxRetries int, maxDelay time.Duration) *transport.RetryTransport {
+	retryTransport := transport.NewRetryTransport(http.DefaultTransport, maxRetries, maxDelay)
+	retryTransport.BaseDelay = time.Millisecond
+	return retryTransport
+}
+
+func TestRetryTransportRetryableRequests(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		body          string
+		ctx           context.Context
+		statusCodes   []int
+		header        http.Header
+		expectedCalls int32
+		expectedCode  int
+	}{
+		{"GET 500 is retried", http.MethodGet, "", context.Background(), []int{500, 200}, nil, 2, 200},
+		{"GET 502 and 503 are retried", http.MethodGet, "", context.Background(), []int{502, 503, 200}, nil, 3, 200},
+		{"PUT with body is retried", http.MethodPut, "data", context.Background(), []int{504, 200}, nil, 2, 200},
+		{"DELETE is retried", http.MethodDelete, "", context.Background(), []int{500, 200}, nil, 2, 200},
+		{"POST is not retried", http.MethodPost, "data", context.Background(), []int{500, 200}, nil, 1, 500},
+		{"PATCH is not retried", http.MethodPatch, "data", context.Background(), []int{500, 200}, nil, 1, 500},
+		{"request without retry", http.MethodGet, "", transport.WithoutRetry(context.Background()), []int{500, 200}, nil, 1, 500},
+		{"404 is not retried", http.MethodGet, "", context.Background(), []int{404, 200}, nil, 1, 404},
+		{"403 without rate limit is not retried", http.MethodGet, "", context.Background(), []int{403, 200}, nil, 1, 403},
+		{"403 with exhausted rate limit is retried", http.MethodGet, "", context.Background(), []int{403, 200}, http.Header{"X-Ratelimit-Remaining": {"0"}}, 2, 200},
+		{"429 is retried", http.MethodGet, "", context.Background(), []int{429, 200}, nil, 2, 200},
+		{"max retries reached", http.MethodGet, "", context.Background(), []int{503}, nil, 3, 503},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			bodies := make([]string, 0)
+			ts := setupRetryServer(tt.statusCodes, tt.header, &requests, &bodies)
+			defer ts.Close()
+
+			var body io.Reader
+			if len(tt.body) > 0 {
+				body = strings.NewReader(tt.body)
+			}
+			req, _ := http.NewRequestWithContext(tt.ctx, tt.method, ts.URL+"/api/v1/repos", body)
+
+			resp, err := makeRetryTransport(2, 10*time.Millisecond).RoundTrip(req)
+			assert.Equal(t, err, nil)
+			resp.Body.Close()
+
+			assert.Equal(t, resp.StatusCode, tt.expectedCode)
+			assert.Equal(t, atomic.LoadInt32(&requests), tt.expectedCalls)
+			for _, sentBody := range bodies {
+				assert.Equal(t, sentBody, tt.body)
+			}
+		})
+	}
+}
+
+func TestRetryTransportRetryAfterDelay(t *testing.T) {
+	var requests int32
+	ts := setupRetryServer([]int{429, 200}, http.Header{"Retry-After": {"1"}}, &requests, nil)
+	defer ts.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/v1/repos", nil)
+	start := time.Now()
+	resp, err := makeRetryTransport(2, 5*time.Second).RoundTrip(req)
+	elapsed := time.Since(start)
+
+	assert.Equal(t, err, nil)
+	resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+	assert.Equal(t, atomic.LoadInt32(&requests), int32(2))
+	assert.Check(t, elapsed >= time.Second, "retried after %s", elapsed)
+}
+
+func TestRetryTransportRateLimitResetDelay(t *testing.T) {
+	var requests int32
+	reset := time.Now().Add(2 * time.Second).Unix()
+	ts := setupRetryServer([]int{429, 200}, http.Header{"Ratelimit-Remaining": {"0"}, "Ratelimit-Reset": {strconv.FormatInt(reset, 10)}}, &requests, nil)
+	defer ts.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/v4/projects", nil)
+	start := time.Now()
+	resp, err := makeRetryTransport(2, 5*time.Second).RoundTrip(req)
+
+	assert.Equal(t, err, nil)
+	resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+	assert.Equal(t, atomic.LoadInt32(&requests), int32(2))
+	assert.Check(t, !time.Now().Before(time.Unix(reset, 0)), "retried before the reset, after %s", time.Since(start))
+}
+
+func TestRetryTransportDelayOverMaxDelayNotRetried(t *testing.T) {
+	var requests int32
+	ts := setupRetryServer([]int{429, 200}, http.Header{"Retry-After": {"120"}}, &requests, nil)
+	defer ts.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/v1/repos", nil)
+	start := time.Now()
+	resp, err := makeRetryTransport(2, time.Second).RoundTrip(req)
+
+	assert.Equal(t, err, nil)
+	resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusTooManyRequests)
+	assert.Equal(t, atomic.LoadInt32(&requests), int32(1))
+	assert.Check(t, time.Since(start) < time.Second)
+}
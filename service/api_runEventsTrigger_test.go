@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"gotest.tools/assert"
+	"wecode.sorint.it/opensource/papagaio-api/model"
+	"wecode.sorint.it/opensource/papagaio-api/trigger"
+	"wecode.sorint.it/opensource/papagaio-api/types"
+	"wecode.sorint.it/opensource/papagaio-api/utils"
+)
+
+func waitRunEventsChanged(t *testing.T, listener *trigger.RunEventsListener) {
+	select {
+	case <-listener.Changed():
+	case <-time.After(5 * time.Second):
+		t.Fatal("runEvents connection not changed")
+	}
+}
+
+func TestReadRunEventsMultiLineData(t *testing.T) {
+	setupRunMock(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	connectedStream, connectedStreamWriter := io.Pipe()
+	defer func() {
+		cancel()
+		connectedStreamWriter.Close()
+	}()
+
+	commonMutex := utils.NewEventMutex()
+	listener := trigger.NewRunEventsListener(db, &commonMutex, agolaApiInt, nil)
+
+	updated := make(chan struct{}, 2)
+	agolaApiInt.EXPECT().GetRunEventsStream(gomock.Any()).Return(connectedStream, nil).AnyTimes()
+	db.EXPECT().GetOrganizations().DoAndReturn(func() (*[]model.Organization, error) {
+		updated <- struct{}{}
+		return makeRunOrganizationList(types.Public), nil
+	})
+
+	stream := strings.NewReader(": keepalive\n\n" +
+		"event: run\n" +
+		"data: {\"group\": \"/project/otherProjectID/branch/master\",\n" +
+		"data: \"phase\": \"running\"}\n\n" +
+		"data: not valid\n\n" +
+		"event: run\r\n" +
+		"data: {\"group\": \"/project/otherProjectID/branch/master\",\r\n" +
+		"data:\"phase\": \"finished\", \"result\": \"success\"}\r\n\r\n")
+
+	listener.Start(ctx)
+	err := listener.ReadEvents(ctx, stream)
+	assert.Equal(t, err, io.ErrUnexpectedEOF)
+
+	select {
+	case <-updated:
+	case <-time.After(5 * time.Second):
+		t.Fatal("runs of the project not updated")
+	}
+}
+
+func TestRunEventsUnknownProjectIndexedOnce(t *testing.T) {
+	setupRunMock(t)
+
+	commonMutex := utils.NewEventMutex()
+	listener := trigger.NewRunEventsListener(db, &commonMutex, agolaApiInt, nil)
+
+	db.EXPECT().GetOrganizations().Return(makeRunOrganizationList(types.Public), nil).Times(1)
+
+	listener.UpdateProjectRuns(context.Background(), "otherProjectID")
+	listener.UpdateProjectRuns(context.Background(), "otherProjectID")
+}
+
+func TestRunEventsMovedProjectIndexedAgain(t *testing.T) {
+	setupRunMock(t)
+
+	commonMutex := utils.NewEventMutex()
+	listener := trigger.NewRunEventsListener(db, &commonMutex, agolaApiInt, nil)
+
+	organizations := makeRunOrganizationList(types.Public)
+	organization := (*organizations)[0]
+	movedOrganizations := append([]model.Organization{}, *organizations...)
+	movedOrganizations[0].Projects = map[string]model.Project{}
+	movedOrganizations[1].Projects = organization.Projects
+
+	gomock.InOrder(
+		db.EXPECT().GetOrganizations().Return(organizations, nil),
+		db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(nil, nil),
+		db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&movedOrganizations[0], nil),
+		db.EXPECT().GetOrganizations().Return(&movedOrganizations, nil),
+		db.EXPECT().GetOrganizationByAgolaRef(movedOrganizations[1].AgolaOrganizationRef).Return(nil, nil),
+	)
+
+	listener.UpdateProjectRuns(context.Background(), "projectID")
+	listener.UpdateProjectRuns(context.Background(), "projectID")
+}
+
+func TestReadRunEventsEOF(t *testing.T) {
+	setupRunMock(t)
+
+	commonMutex := utils.NewEventMutex()
+	listener := trigger.NewRunEventsListener(db, &commonMutex, agolaApiInt, nil)
+
+	err := listener.ReadEvents(context.Background(), strings.NewReader("event: run\ndata: {\"phase\": \"finished\""))
+	assert.Equal(t, err, io.ErrUnexpectedEOF)
+
+	err = listener.ReadEvents(context.Background(), strings.NewReader(""))
+	assert.Equal(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestRunEventsNeedsPollingAfterReconnect(t *testing.T) {
+	setupRunMock(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	firstStream, firstStreamWriter := io.Pipe()
+	secondStream, secondStreamWriter := io.Pipe()
+	defer func() {
+		cancel()
+		secondStreamWriter.Close()
+	}()
+
+	gomock.InOrder(
+		agolaApiInt.EXPECT().GetRunEventsStream(gomock.Any()).Return(firstStream, nil),
+		agolaApiInt.EXPECT().GetRunEventsStream(gomock.Any()).Return(secondStream, nil),
+	)
+
+	commonMutex := utils.NewEventMutex()
+	listener := trigger.NewRunEventsListener(db, &commonMutex, agolaApiInt, nil)
+	listener.MinReconnectDelay = 200 * time.Millisecond
+
+	assert.Equal(t, listener.NeedsPolling(), true, "polling not done before the first connect")
+
+	listener.Start(ctx)
+	waitRunEventsChanged(t, listener)
+	assert.Equal(t, listener.NeedsPolling(), true, "polling not done after the connect")
+	assert.Equal(t, listener.NeedsPolling(), false, "polling done while connected")
+
+	firstStreamWriter.Close()
+	waitRunEventsChanged(t, listener)
+	assert.Equal(t, listener.NeedsPolling(), true, "polling not done while disconnected")
+	assert.Equal(t, listener.NeedsPolling(), true, "polling not done while disconnected")
+
+	waitRunEventsChanged(t, listener)
+	assert.Equal(t, listener.NeedsPolling(), true, "polling not done after the reconnect")
+	assert.Equal(t, listener.NeedsPolling(), false, "polling done while connected")
+}
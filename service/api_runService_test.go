@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"gotest.tools/assert"
+	"wecode.sorint.it/opensource/papagaio-api/api/agola"
+	"wecode.sorint.it/opensource/papagaio-api/api/git"
+	"wecode.sorint.it/opensource/papagaio-api/dto"
+	"wecode.sorint.it/opensource/papagaio-api/model"
+	"wecode.sorint.it/opensource/papagaio-api/test"
+	"wecode.sorint.it/opensource/papagaio-api/test/mock/mock_agola"
+	"wecode.sorint.it/opensource/papagaio-api/test/mock/mock_gitea"
+	"wecode.sorint.it/opensource/papagaio-api/test/mock/mock_repository"
+	"wecode.sorint.it/opensource/papagaio-api/trigger"
+	"wecode.sorint.it/opensource/papagaio-api/types"
+	"wecode.sorint.it/opensource/papagaio-api/utils"
+)
+
+var serviceRun RunService
+
+func setupRunMock(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	db = mock_repository.NewMockDatabase(ctl)
+	agolaApiInt = mock_agola.NewMockAgolaApiInterface(ctl)
+	giteaApi = mock_gitea.NewMockGiteaInterface(ctl)
+
+	serviceRun = RunService{
+		Db:         db,
+		AgolaApi:   agolaApiInt,
+		GitGateway: &git.GitGateway{GiteaApi: giteaApi},
+	}
+}
+
+func postRunAction(t *testing.T, user *model.User, request dto.RunActionRequestDto) *http.Response {
+	data, _ := json.Marshal(request)
+	requestBody := strings.NewReader(string(data))
+
+	router := test.SetupBaseRouter(user)
+	router.HandleFunc("/runs/{projectRef}/{runNumber}/actions", serviceRun.RunAction)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client := ts.Client()
+	resp, err := client.Post(ts.URL+"/runs/projectID/3/actions", "application/json", requestBody)
+	assert.Equal(t, err, nil)
+
+	return resp
+}
+
+func TestRestartRunOK(t *testing.T) {
+	setupRunMock(t)
+
+	user := test.MakeUser()
+	run := agola.RunDto{Number: 4}
+
+	db.EXPECT().GetUserByUserId(user.ID).Return(user, nil)
+	agolaApiInt.EXPECT().RestartRun(gomock.Any(), user, "projectID", uint64(3), true).Return(&run, nil)
+
+	resp := postRunAction(t, user, dto.RunActionRequestDto{ActionType: dto.RunActionRestart, FromScratch: true})
+	assert.Equal(t, resp.StatusCode, http.StatusOK, "http StatusCode is not OK")
+
+	var response agola.RunDto
+	test.ParseBody(resp, &response)
+	assert.Equal(t, response.Number, uint64(4))
+}
+
+func TestStopRunForbidden(t *testing.T) {
+	setupRunMock(t)
+
+	user := test.MakeUser()
+
+	db.EXPECT().GetUserByUserId(user.ID).Return(user, nil)
+	agolaApiInt.EXPECT().StopRun(gomock.Any(), user, "projectID", uint64(3)).Return(nil, &agola.ResponseError{StatusCode: http.StatusForbidden, Message: "forbidden"})
+
+	resp := postRunAction(t, user, dto.RunActionRequestDto{ActionType: dto.RunActionStop})
+	assert.Equal(t, resp.StatusCode, http.StatusForbidden, "http StatusCode is not Forbidden")
+}
+
+func TestCancelRunUserNotInAgola(t *testing.T) {
+	setupRunMock(t)
+
+	user := test.MakeUser()
+	user.AgolaUserRef = nil
+
+	db.EXPECT().GetUserByUserId(user.ID).Return(user, nil)
+
+	resp := postRunAction(t, user, dto.RunActionRequestDto{ActionType: dto.RunActionCancel})
+	assert.Equal(t, resp.StatusCode, http.StatusUnprocessableEntity, "http StatusCode is not UnprocessableEntity")
+}
+
+func TestRunActionNotValid(t *testing.T) {
+	setupRunMock(t)
+
+	resp := postRunAction(t, test.MakeUser(), dto.RunActionRequestDto{ActionType: "delete"})
+	assert.Equal(t, resp.StatusCode, http.StatusUnprocessableEntity, "http StatusCode is not UnprocessableEntity")
+}
+
+func makeRunOrganizationList(visibility types.VisibilityType) *[]model.Organization {
+	organizations := test.MakeOrganizationList()
+	(*organizations)[0].Visibility = visibility
+	(*organizations)[0].Projects = map[string]model.Project{"project": {GitRepoPath: "project", AgolaProjectID: "projectID"}}
+
+	return organizations
+}
+
+func getLogs(t *testing.T, user *model.User, lastEventID string) *http.Response {
+	router := test.SetupBaseRouter(user)
+	router.HandleFunc("/runs/{projectRef}/{runNumber}/tasks/{taskId}/steps/{step}/logs", serviceRun.StreamLogs)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	req, _ := http.NewRequest("GET", ts.URL+"/runs/projectID/3/tasks/taskID/steps/1/logs", nil)
+	if len(lastEventID) > 0 {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	client := ts.Client()
+	resp, err := client.Do(req)
+	assert.Equal(t, err, nil)
+
+	return resp
+}
+
+func TestStreamLogsResume(t *testing.T) {
+	setupRunMock(t)
+
+	user := test.MakeUser()
+
+	db.EXPECT().GetUserByUserId(user.ID).Return(user, nil)
+	db.EXPECT().GetOrganizationsByGitSource(user.GitSourceName).Return(makeRunOrganizationList(types.Public), nil)
+	agolaApiInt.EXPECT().GetLogsStream(gomock.Any(), "projectID", uint64(3), "taskID", 1).Return(ioutil.NopCloser(strings.NewReader("line1\nline2\nline3")), nil)
+
+	resp := getLogs(t, user, "1")
+	assert.Equal(t, resp.StatusCode, http.StatusOK, "http StatusCode is not OK")
+	assert.Equal(t, resp.Header.Get("Content-Type"), "text/event-stream")
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, string(body), "id: 2\ndata: line2\n\nid: 3\ndata: line3\n\nevent: end\ndata:\n\n")
+}
+
+func TestStreamLogsPrivateOrganizationForbidden(t *testing.T) {
+	setupRunMock(t)
+
+	user := test.MakeUser()
+	gitSource := (*test.MakeGitSourceMap())[user.GitSourceName]
+
+	db.EXPECT().GetUserByUserId(user.ID).Return(user, nil)
+	db.EXPECT().GetOrganizationsByGitSource(user.GitSourceName).Return(makeRunOrganizationList(types.Private), nil)
+	db.EXPECT().GetGitSourceByName(user.GitSourceName).Return(&gitSource, nil)
+	giteaApi.EXPECT().GetOrganization(gomock.Any(), user, gomock.Any()).Return(nil, nil)
+
+	resp := getLogs(t, user, "")
+	assert.Equal(t, resp.StatusCode, http.StatusForbidden, "http StatusCode is not Forbidden")
+}
+
+func TestStreamLogsPrivateOrganizationProjectCollaborator(t *testing.T) {
+	setupRunMock(t)
+
+	user := test.MakeUser()
+	organizations := makeRunOrganizationList(types.Private)
+	(*organizations)[0].CollaboratorsPolicy = types.CollaboratorsProject
+	(*organizations)[0].Projects = map[string]model.Project{"project": {GitRepoPath: "project", AgolaProjectID: "projectID", Collaborators: []int64{int64(user.ID)}}}
+
+	db.EXPECT().GetUserByUserId(user.ID).Return(user, nil)
+	db.EXPECT().GetOrganizationsByGitSource(user.GitSourceName).Return(organizations, nil)
+	agolaApiInt.EXPECT().GetLogsStream(gomock.Any(), "projectID", uint64(3), "taskID", 1).Return(ioutil.NopCloser(strings.NewReader("line1")), nil)
+
+	resp := getLogs(t, user, "")
+	assert.Equal(t, resp.StatusCode, http.StatusOK, "http StatusCode is not OK")
+}
+
+func TestRunEventFinishedUpdatesProject(t *testing.T) {
+	setupRunMock(t)
+
+	user := test.MakeUser()
+	gitSource := (*test.MakeGitSourceMap())[user.GitSourceName]
+	organizations := makeRunOrganizationList(types.Public)
+	organization := (*organizations)[0]
+	commonMutex := utils.NewEventMutex()
+
+	listener := trigger.NewRunEventsListener(db, &commonMutex, agolaApiInt, &git.GitGateway{GiteaApi: giteaApi})
+
+	annotations := map[string]string{"ref_type": "branch", "branch": "master", "run_creation_trigger": "webhook"}
+	runs := []*agola.RunsDto{{Number: 1, Phase: agola.RunPhaseFinished, Result: agola.RunResultSuccess, Annotations: annotations}}
+
+	db.EXPECT().GetOrganizations().Return(organizations, nil)
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(user.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(organization.UserIDConnected).Return(user, nil)
+	agolaApiInt.EXPECT().GetRuns(gomock.Any(), "projectID", true, "finished", nil, uint(1), false).Return(runs, nil)
+	agolaApiInt.EXPECT().GetRuns(gomock.Any(), "projectID", false, "finished", gomock.Any(), uint(0), true).Return(runs, nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).DoAndReturn(func(org *model.Organization) error {
+		branch, ok := org.Projects["project"].Branchs["master"]
+		assert.Assert(t, ok, "branch master not stored")
+		assert.Equal(t, branch.LastRuns[0].Number, uint64(1))
+		return nil
+	})
+
+	listener.UpdateProjectRuns(context.Background(), "projectID")
+}
+
+func TestRunEventNotFinishedIgnored(t *testing.T) {
+	setupRunMock(t)
+
+	commonMutex := utils.NewEventMutex()
+	listener := trigger.NewRunEventsListener(db, &commonMutex, agolaApiInt, nil)
+
+	listener.ProcessRunEvent(context.Background(), &agola.RunEventDto{Group: "/project/projectID/branch/master", Phase: agola.RunPhaseRunning})
+}
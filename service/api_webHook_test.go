@@ -1,25 +1,38 @@
 package service
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/gorilla/mux"
 	"github.com/xanzy/go-gitlab"
 	"gotest.tools/assert"
+	"wecode.sorint.it/opensource/papagaio-api/api/agola"
 	"wecode.sorint.it/opensource/papagaio-api/api/git"
+	gitDto "wecode.sorint.it/opensource/papagaio-api/api/git/dto"
+	"wecode.sorint.it/opensource/papagaio-api/config"
+	"wecode.sorint.it/opensource/papagaio-api/controller"
 	"wecode.sorint.it/opensource/papagaio-api/dto"
+	"wecode.sorint.it/opensource/papagaio-api/manager/webHookManager"
 	"wecode.sorint.it/opensource/papagaio-api/model"
 	"wecode.sorint.it/opensource/papagaio-api/test"
 	"wecode.sorint.it/opensource/papagaio-api/test/mock/mock_agola"
+	"wecode.sorint.it/opensource/papagaio-api/test/mock/mock_bitbucket"
 	"wecode.sorint.it/opensource/papagaio-api/test/mock/mock_gitea"
 	"wecode.sorint.it/opensource/papagaio-api/test/mock/mock_gitlab"
 	"wecode.sorint.it/opensource/papagaio-api/test/mock/mock_repository"
+	"wecode.sorint.it/opensource/papagaio-api/trigger"
 	"wecode.sorint.it/opensource/papagaio-api/types"
 	"wecode.sorint.it/opensource/papagaio-api/utils"
 )
@@ -48,8 +61,8 @@ func TestRepositoryCreatedWithAgolaConfigOK(t *testing.T) {
 	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
 	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
 	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
-	giteaApi.EXPECT().CheckRepositoryAgolaConfExists(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(true, nil)
-	agolaApi.EXPECT().CreateProject(webHookMessage.Repository.Name, utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name), gomock.Any(), gitSource.AgolaRemoteSource, gomock.Any()).Return("projectTestID", nil)
+	giteaApi.EXPECT().GetAgolaConfBranches(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(map[string]bool{"master": true}, nil)
+	agolaApi.EXPECT().CreateProject(gomock.Any(), webHookMessage.Repository.Name, utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name), gomock.Any(), gitSource.AgolaRemoteSource, gomock.Any()).Return("projectTestID", nil)
 	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
 
 	serviceWebHook := WebHookService{
@@ -59,18 +72,9 @@ func TestRepositoryCreatedWithAgolaConfigOK(t *testing.T) {
 		CommonMutex: &commonMutex,
 	}
 
-	router := mux.NewRouter()
-	router.HandleFunc("/{organizationRef}", serviceWebHook.WebHookOrganization)
-	ts := httptest.NewServer(router)
-	defer ts.Close()
-
-	client := ts.Client()
 	data, _ := json.Marshal(webHookMessage)
-	requestBody := strings.NewReader(string(data))
-	resp, err := client.Post(ts.URL+"/"+organization.AgolaOrganizationRef, "application/json", requestBody)
-
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
 	assert.Equal(t, err, nil)
-	assert.Equal(t, resp.StatusCode, http.StatusOK)
 
 	project, exists := organization.Projects[repositoryRef]
 	assert.Check(t, exists)
@@ -102,7 +106,7 @@ func TestRepositoryCreatedWithoutAgolaConfigOK(t *testing.T) {
 	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
 	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
 	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
-	giteaApi.EXPECT().CheckRepositoryAgolaConfExists(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(false, nil)
+	giteaApi.EXPECT().GetAgolaConfBranches(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(map[string]bool{}, nil)
 	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
 
 	serviceWebHook := WebHookService{
@@ -112,18 +116,9 @@ func TestRepositoryCreatedWithoutAgolaConfigOK(t *testing.T) {
 		CommonMutex: &commonMutex,
 	}
 
-	router := mux.NewRouter()
-	router.HandleFunc("/{organizationRef}", serviceWebHook.WebHookOrganization)
-	ts := httptest.NewServer(router)
-	defer ts.Close()
-
-	client := ts.Client()
 	data, _ := json.Marshal(webHookMessage)
-	requestBody := strings.NewReader(string(data))
-	resp, err := client.Post(ts.URL+"/"+organization.AgolaOrganizationRef, "application/json", requestBody)
-
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
 	assert.Equal(t, err, nil)
-	assert.Equal(t, resp.StatusCode, http.StatusOK)
 
 	project, exists := organization.Projects[repositoryRef]
 	assert.Check(t, exists)
@@ -156,7 +151,7 @@ func TestRepositoryDeletedOK(t *testing.T) {
 	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
 	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
 	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
-	agolaApi.EXPECT().DeleteProject(gomock.Any(), utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name), gomock.Any()).Return(nil)
+	agolaApi.EXPECT().DeleteProject(gomock.Any(), gomock.Any(), utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name), gomock.Any()).Return(nil)
 	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
 
 	serviceWebHook := WebHookService{
@@ -165,20 +160,64 @@ func TestRepositoryDeletedOK(t *testing.T) {
 		CommonMutex: &commonMutex,
 	}
 
-	router := mux.NewRouter()
-	router.HandleFunc("/{organizationRef}", serviceWebHook.WebHookOrganization)
-	ts := httptest.NewServer(router)
-	defer ts.Close()
+	data, _ := json.Marshal(webHookMessage)
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Equal(t, err, nil)
+
+	_, exists := organization.Projects[repositoryRef]
+	assert.Check(t, !exists)
+}
+
+func TestRepositoryDeletedAgolaErrors(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+
+	repositoryRef := "repositoryTest"
+	organization.Projects = make(map[string]model.Project)
+	organization.Projects[repositoryRef] = model.Project{AgolaProjectRef: repositoryRef}
+
+	webHookMessage := dto.WebHookDto{
+		Repository: dto.RepositoryDto{ID: 1, Name: repositoryRef},
+		Action:     "deleted",
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		AgolaApi:    agolaApi,
+		CommonMutex: &commonMutex,
+	}
 
-	client := ts.Client()
 	data, _ := json.Marshal(webHookMessage)
-	requestBody := strings.NewReader(string(data))
-	resp, err := client.Post(ts.URL+"/"+organization.AgolaOrganizationRef, "application/json", requestBody)
 
-	assert.Equal(t, err, nil)
-	assert.Equal(t, resp.StatusCode, http.StatusOK)
+	// Agola unavailable: the project is kept and the event is retried
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	agolaApi.EXPECT().DeleteProject(gomock.Any(), gomock.Any(), utils.ConvertToAgolaProjectRef(repositoryRef), gomock.Any()).Return(&agola.ResponseError{StatusCode: http.StatusServiceUnavailable})
 
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Check(t, errors.Is(err, agola.ErrUnavailable))
 	_, exists := organization.Projects[repositoryRef]
+	assert.Check(t, exists)
+
+	// project already deleted in Agola
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	agolaApi.EXPECT().DeleteProject(gomock.Any(), gomock.Any(), utils.ConvertToAgolaProjectRef(repositoryRef), gomock.Any()).Return(&agola.ResponseError{StatusCode: http.StatusNotFound})
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
+
+	err = serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Equal(t, err, nil)
+	_, exists = organization.Projects[repositoryRef]
 	assert.Check(t, !exists)
 }
 
@@ -205,8 +244,8 @@ func TestRepositoryPushWithAgolaConfAndProjectNotExists(t *testing.T) {
 	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
 	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
 	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
-	giteaApi.EXPECT().CheckRepositoryAgolaConfExists(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(true, nil)
-	agolaApi.EXPECT().CreateProject(webHookMessage.Repository.Name, utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name), gomock.Any(), gitSource.AgolaRemoteSource, gomock.Any()).Return("projectTestID", nil)
+	giteaApi.EXPECT().GetAgolaConfBranches(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(map[string]bool{"master": true}, nil)
+	agolaApi.EXPECT().CreateProject(gomock.Any(), webHookMessage.Repository.Name, utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name), gomock.Any(), gitSource.AgolaRemoteSource, gomock.Any()).Return("projectTestID", nil)
 	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
 
 	setupBranchSynckMock(db, giteaApi, organization.GitPath, repositoryRef)
@@ -218,18 +257,9 @@ func TestRepositoryPushWithAgolaConfAndProjectNotExists(t *testing.T) {
 		CommonMutex: &commonMutex,
 	}
 
-	router := mux.NewRouter()
-	router.HandleFunc("/{organizationRef}", serviceWebHook.WebHookOrganization)
-	ts := httptest.NewServer(router)
-	defer ts.Close()
-
-	client := ts.Client()
 	data, _ := json.Marshal(webHookMessage)
-	requestBody := strings.NewReader(string(data))
-	resp, err := client.Post(ts.URL+"/"+organization.AgolaOrganizationRef, "application/json", requestBody)
-
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
 	assert.Equal(t, err, nil)
-	assert.Equal(t, resp.StatusCode, http.StatusOK)
 
 	project, exists := organization.Projects[repositoryRef]
 	assert.Check(t, exists)
@@ -266,12 +296,6 @@ func TestRepositoryPushWithAgolaConfAndProjectNotExistsWithErrors(t *testing.T)
 		CommonMutex: &commonMutex,
 	}
 
-	router := mux.NewRouter()
-	router.HandleFunc("/{organizationRef}", serviceWebHook.WebHookOrganization)
-	ts := httptest.NewServer(router)
-	defer ts.Close()
-
-	client := ts.Client()
 	data, _ := json.Marshal(webHookMessage)
 
 	// agola CreateProject error
@@ -279,29 +303,23 @@ func TestRepositoryPushWithAgolaConfAndProjectNotExistsWithErrors(t *testing.T)
 	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
 	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
 	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
-	giteaApi.EXPECT().CheckRepositoryAgolaConfExists(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(true, nil)
-	agolaApi.EXPECT().CreateProject(webHookMessage.Repository.Name, utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name), gomock.Any(), gitSource.AgolaRemoteSource, gomock.Any()).Return("", errors.New("test error"))
+	giteaApi.EXPECT().GetAgolaConfBranches(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(map[string]bool{"master": true}, nil)
+	agolaApi.EXPECT().CreateProject(gomock.Any(), webHookMessage.Repository.Name, utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name), gomock.Any(), gitSource.AgolaRemoteSource, gomock.Any()).Return("", errors.New("test error"))
 
-	requestBody := strings.NewReader(string(data))
-	resp, err := client.Post(ts.URL+"/"+organization.AgolaOrganizationRef, "application/json", requestBody)
-
-	assert.Equal(t, err, nil)
-	assert.Equal(t, resp.StatusCode, http.StatusInternalServerError)
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Check(t, err != nil)
 
 	// SaveOrganization errpr
 
 	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
 	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
 	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
-	giteaApi.EXPECT().CheckRepositoryAgolaConfExists(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(true, nil)
-	agolaApi.EXPECT().CreateProject(webHookMessage.Repository.Name, utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name), gomock.Any(), gitSource.AgolaRemoteSource, gomock.Any()).Return("projectTestID", nil)
+	giteaApi.EXPECT().GetAgolaConfBranches(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(map[string]bool{"master": true}, nil)
+	agolaApi.EXPECT().CreateProject(gomock.Any(), webHookMessage.Repository.Name, utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name), gomock.Any(), gitSource.AgolaRemoteSource, gomock.Any()).Return("projectTestID", nil)
 	db.EXPECT().SaveOrganization(gomock.Any()).Return(errors.New("test error"))
 
-	requestBody = strings.NewReader(string(data))
-	resp, err = client.Post(ts.URL+"/"+organization.AgolaOrganizationRef, "application/json", requestBody)
-
-	assert.Equal(t, err, nil)
-	assert.Equal(t, resp.StatusCode, http.StatusInternalServerError)
+	err = serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Check(t, err != nil)
 }
 
 func TestRepositoryPushWithAgolaConfAndProjectArchivied(t *testing.T) {
@@ -329,8 +347,8 @@ func TestRepositoryPushWithAgolaConfAndProjectArchivied(t *testing.T) {
 	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
 	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
 	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
-	giteaApi.EXPECT().CheckRepositoryAgolaConfExists(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(true, nil)
-	agolaApi.EXPECT().UnarchiveProject(gomock.Any(), utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name)).Return(nil)
+	giteaApi.EXPECT().GetAgolaConfBranches(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(map[string]bool{"master": true}, nil)
+	agolaApi.EXPECT().UnarchiveProject(gomock.Any(), gomock.Any(), utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name)).Return(nil)
 	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
 
 	setupBranchSynckMock(db, giteaApi, organization.GitPath, repositoryRef)
@@ -342,18 +360,9 @@ func TestRepositoryPushWithAgolaConfAndProjectArchivied(t *testing.T) {
 		CommonMutex: &commonMutex,
 	}
 
-	router := mux.NewRouter()
-	router.HandleFunc("/{organizationRef}", serviceWebHook.WebHookOrganization)
-	ts := httptest.NewServer(router)
-	defer ts.Close()
-
-	client := ts.Client()
 	data, _ := json.Marshal(webHookMessage)
-	requestBody := strings.NewReader(string(data))
-	resp, err := client.Post(ts.URL+"/"+organization.AgolaOrganizationRef, "application/json", requestBody)
-
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
 	assert.Equal(t, err, nil)
-	assert.Equal(t, resp.StatusCode, http.StatusOK)
 
 	project, exists := organization.Projects[repositoryRef]
 	assert.Check(t, exists)
@@ -392,12 +401,6 @@ func TestRepositoryPushWithAgolaConfAndProjectArchiviedWithErrors(t *testing.T)
 		CommonMutex: &commonMutex,
 	}
 
-	router := mux.NewRouter()
-	router.HandleFunc("/{organizationRef}", serviceWebHook.WebHookOrganization)
-	ts := httptest.NewServer(router)
-	defer ts.Close()
-
-	client := ts.Client()
 	data, _ := json.Marshal(webHookMessage)
 
 	// agola UnarchiveProject error
@@ -405,29 +408,22 @@ func TestRepositoryPushWithAgolaConfAndProjectArchiviedWithErrors(t *testing.T)
 	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
 	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
 	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
-	giteaApi.EXPECT().CheckRepositoryAgolaConfExists(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(true, nil)
-	agolaApi.EXPECT().UnarchiveProject(gomock.Any(), utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name)).Return(errors.New("test error"))
-
-	requestBody := strings.NewReader(string(data))
-	resp, err := client.Post(ts.URL+"/"+organization.AgolaOrganizationRef, "application/json", requestBody)
+	giteaApi.EXPECT().GetAgolaConfBranches(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(map[string]bool{"master": true}, nil)
+	agolaApi.EXPECT().UnarchiveProject(gomock.Any(), gomock.Any(), utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name)).Return(errors.New("test error"))
 
-	assert.Equal(t, err, nil)
-	assert.Equal(t, resp.StatusCode, http.StatusInternalServerError)
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Check(t, err != nil)
 
 	// SaveOrganization error
 
 	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
 	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
 	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
-	giteaApi.EXPECT().CheckRepositoryAgolaConfExists(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(true, nil)
-	agolaApi.EXPECT().UnarchiveProject(gomock.Any(), utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name)).Return(nil)
+	agolaApi.EXPECT().UnarchiveProject(gomock.Any(), gomock.Any(), utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name)).Return(nil)
 	db.EXPECT().SaveOrganization(gomock.Any()).Return(errors.New("test error"))
 
-	requestBody = strings.NewReader(string(data))
-	resp, err = client.Post(ts.URL+"/"+organization.AgolaOrganizationRef, "application/json", requestBody)
-
-	assert.Equal(t, err, nil)
-	assert.Equal(t, resp.StatusCode, http.StatusInternalServerError)
+	err = serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Check(t, err != nil)
 }
 
 func TestRepositoryPushWithAgolaConfAndProjectNotArchivied(t *testing.T) {
@@ -455,7 +451,7 @@ func TestRepositoryPushWithAgolaConfAndProjectNotArchivied(t *testing.T) {
 	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
 	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
 	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
-	giteaApi.EXPECT().CheckRepositoryAgolaConfExists(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(true, nil)
+	giteaApi.EXPECT().GetAgolaConfBranches(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(map[string]bool{"master": true}, nil)
 
 	setupBranchSynckMock(db, giteaApi, organization.GitPath, repositoryRef)
 
@@ -466,18 +462,9 @@ func TestRepositoryPushWithAgolaConfAndProjectNotArchivied(t *testing.T) {
 		CommonMutex: &commonMutex,
 	}
 
-	router := mux.NewRouter()
-	router.HandleFunc("/{organizationRef}", serviceWebHook.WebHookOrganization)
-	ts := httptest.NewServer(router)
-	defer ts.Close()
-
-	client := ts.Client()
 	data, _ := json.Marshal(webHookMessage)
-	requestBody := strings.NewReader(string(data))
-	resp, err := client.Post(ts.URL+"/"+organization.AgolaOrganizationRef, "application/json", requestBody)
-
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
 	assert.Equal(t, err, nil)
-	assert.Equal(t, resp.StatusCode, http.StatusOK)
 
 	project, exists := organization.Projects[repositoryRef]
 	assert.Check(t, exists)
@@ -512,7 +499,7 @@ func TestRepositoryPushWithoutAgolaConfAndProjectArchivied(t *testing.T) {
 	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
 	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
 	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
-	giteaApi.EXPECT().CheckRepositoryAgolaConfExists(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(false, nil)
+	giteaApi.EXPECT().GetAgolaConfBranches(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(map[string]bool{}, nil)
 
 	setupBranchSynckMock(db, giteaApi, organization.GitPath, repositoryRef)
 
@@ -523,18 +510,9 @@ func TestRepositoryPushWithoutAgolaConfAndProjectArchivied(t *testing.T) {
 		CommonMutex: &commonMutex,
 	}
 
-	router := mux.NewRouter()
-	router.HandleFunc("/{organizationRef}", serviceWebHook.WebHookOrganization)
-	ts := httptest.NewServer(router)
-	defer ts.Close()
-
-	client := ts.Client()
 	data, _ := json.Marshal(webHookMessage)
-	requestBody := strings.NewReader(string(data))
-	resp, err := client.Post(ts.URL+"/"+organization.AgolaOrganizationRef, "application/json", requestBody)
-
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
 	assert.Equal(t, err, nil)
-	assert.Equal(t, resp.StatusCode, http.StatusOK)
 
 	project, exists := organization.Projects[repositoryRef]
 	assert.Check(t, exists)
@@ -569,8 +547,8 @@ func TestRepositoryPushWithoutAgolaConfAndProjectNotArchivied(t *testing.T) {
 	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
 	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
 	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
-	giteaApi.EXPECT().CheckRepositoryAgolaConfExists(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(false, nil)
-	agolaApi.EXPECT().ArchiveProject(gomock.Any(), utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name)).Return(nil)
+	giteaApi.EXPECT().GetAgolaConfBranches(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(map[string]bool{}, nil)
+	agolaApi.EXPECT().ArchiveProject(gomock.Any(), gomock.Any(), utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name)).Return(nil)
 	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
 
 	setupBranchSynckMock(db, giteaApi, organization.GitPath, repositoryRef)
@@ -582,18 +560,9 @@ func TestRepositoryPushWithoutAgolaConfAndProjectNotArchivied(t *testing.T) {
 		CommonMutex: &commonMutex,
 	}
 
-	router := mux.NewRouter()
-	router.HandleFunc("/{organizationRef}", serviceWebHook.WebHookOrganization)
-	ts := httptest.NewServer(router)
-	defer ts.Close()
-
-	client := ts.Client()
 	data, _ := json.Marshal(webHookMessage)
-	requestBody := strings.NewReader(string(data))
-	resp, err := client.Post(ts.URL+"/"+organization.AgolaOrganizationRef, "application/json", requestBody)
-
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
 	assert.Equal(t, err, nil)
-	assert.Equal(t, resp.StatusCode, http.StatusOK)
 
 	project, exists := organization.Projects[repositoryRef]
 	assert.Check(t, exists)
@@ -632,12 +601,6 @@ func TestRepositoryPushWithoutAgolaConfAndProjectNotArchiviedWithErrors(t *testi
 		CommonMutex: &commonMutex,
 	}
 
-	router := mux.NewRouter()
-	router.HandleFunc("/{organizationRef}", serviceWebHook.WebHookOrganization)
-	ts := httptest.NewServer(router)
-	defer ts.Close()
-
-	client := ts.Client()
 	data, _ := json.Marshal(webHookMessage)
 
 	// agola ArchiveProject error
@@ -645,29 +608,106 @@ func TestRepositoryPushWithoutAgolaConfAndProjectNotArchiviedWithErrors(t *testi
 	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
 	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
 	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
-	giteaApi.EXPECT().CheckRepositoryAgolaConfExists(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(false, nil)
-	agolaApi.EXPECT().ArchiveProject(gomock.Any(), utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name)).Return(errors.New("test error"))
+	giteaApi.EXPECT().GetAgolaConfBranches(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(map[string]bool{}, nil)
+	agolaApi.EXPECT().ArchiveProject(gomock.Any(), gomock.Any(), utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name)).Return(errors.New("test error"))
 
-	requestBody := strings.NewReader(string(data))
-	resp, err := client.Post(ts.URL+"/"+organization.AgolaOrganizationRef, "application/json", requestBody)
-
-	assert.Equal(t, err, nil)
-	assert.Equal(t, resp.StatusCode, http.StatusInternalServerError)
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Check(t, err != nil)
 
 	// SaveOrganization error
 
 	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
 	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
 	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
-	giteaApi.EXPECT().CheckRepositoryAgolaConfExists(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(false, nil)
-	agolaApi.EXPECT().ArchiveProject(gomock.Any(), utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name)).Return(nil)
+	agolaApi.EXPECT().ArchiveProject(gomock.Any(), gomock.Any(), utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name)).Return(nil)
 	db.EXPECT().SaveOrganization(gomock.Any()).Return(errors.New("test error"))
 
-	requestBody = strings.NewReader(string(data))
-	resp, err = client.Post(ts.URL+"/"+organization.AgolaOrganizationRef, "application/json", requestBody)
+	err = serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Check(t, err != nil)
+}
 
-	assert.Equal(t, err, nil)
-	assert.Equal(t, resp.StatusCode, http.StatusInternalServerError)
+func TestRepositoryPushWithGitTransientErrorNotArchivied(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+
+	repositoryRef := "repositoryTest"
+	organization.Projects = make(map[string]model.Project)
+	organization.Projects[repositoryRef] = model.Project{AgolaProjectRef: repositoryRef, Archivied: false}
+
+	webHookMessage := dto.WebHookDto{
+		Repository: dto.RepositoryDto{ID: 1, Name: repositoryRef},
+		Action:     "",
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	giteaApi := mock_gitea.NewMockGiteaInterface(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	giteaApi.EXPECT().GetAgolaConfBranches(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(nil, fmt.Errorf("%w: test error", git.ErrTransient))
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{GiteaApi: giteaApi},
+		AgolaApi:    agolaApi,
+		CommonMutex: &commonMutex,
+	}
+
+	data, _ := json.Marshal(webHookMessage)
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Check(t, errors.Is(err, git.ErrTransient))
+
+	project := organization.Projects[repositoryRef]
+	assert.Check(t, !project.Archivied)
+}
+
+func TestRepositoryPushWithGitPermissionErrorNotArchivied(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+
+	repositoryRef := "repositoryTest"
+	organization.Projects = make(map[string]model.Project)
+	organization.Projects[repositoryRef] = model.Project{AgolaProjectRef: repositoryRef, Archivied: false}
+
+	webHookMessage := dto.WebHookDto{
+		Repository: dto.RepositoryDto{ID: 1, Name: repositoryRef},
+		Action:     "",
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	giteaApi := mock_gitea.NewMockGiteaInterface(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	giteaApi.EXPECT().GetAgolaConfBranches(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(nil, errors.New("403 Forbidden"))
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{GiteaApi: giteaApi},
+		AgolaApi:    agolaApi,
+		CommonMutex: &commonMutex,
+	}
+
+	data, _ := json.Marshal(webHookMessage)
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Check(t, err != nil)
+
+	project := organization.Projects[repositoryRef]
+	assert.Check(t, !project.Archivied)
 }
 
 func TestRepositoryGitlabPushWithAgolaConfAndProjectNotExists(t *testing.T) {
@@ -696,8 +736,8 @@ func TestRepositoryGitlabPushWithAgolaConfAndProjectNotExists(t *testing.T) {
 	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
 	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
 	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
-	gitlabApi.EXPECT().CheckRepositoryAgolaConfExists(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(true, nil)
-	agolaApi.EXPECT().CreateProject(webHookMessage.Repository.Name, utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name), gomock.Any(), gitSource.AgolaRemoteSource, gomock.Any()).Return("projectTestID", nil)
+	gitlabApi.EXPECT().GetAgolaConfBranches(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(map[string]bool{"master": true}, nil)
+	agolaApi.EXPECT().CreateProject(gomock.Any(), webHookMessage.Repository.Name, utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name), gomock.Any(), gitSource.AgolaRemoteSource, gomock.Any()).Return("projectTestID", nil)
 	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
 
 	setupBranchSynckGitlabMock(db, gitlabApi, organization.GitPath, repositoryRef)
@@ -709,18 +749,9 @@ func TestRepositoryGitlabPushWithAgolaConfAndProjectNotExists(t *testing.T) {
 		CommonMutex: &commonMutex,
 	}
 
-	router := mux.NewRouter()
-	router.HandleFunc("/{organizationRef}", serviceWebHook.WebHookOrganization)
-	ts := httptest.NewServer(router)
-	defer ts.Close()
-
-	client := ts.Client()
 	data, _ := json.Marshal(webHookMessage)
-	requestBody := strings.NewReader(string(data))
-	resp, err := client.Post(ts.URL+"/"+organization.AgolaOrganizationRef, "application/json", requestBody)
-
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
 	assert.Equal(t, err, nil)
-	assert.Equal(t, resp.StatusCode, http.StatusOK)
 
 	project, exists := organization.Projects[repositoryRef]
 	assert.Check(t, exists)
@@ -793,13 +824,16 @@ func TestRepositoryCreatedWithAgolaConfigWithErrors(t *testing.T) {
 	organizationTest := organization
 	organizationTest.BehaviourType = types.Regex
 	organizationTest.BehaviourExclude = repositoryRef
+	organizationTest.SetWebHookSecret("webHookSecret")
 
 	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organizationTest, nil)
 	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().SaveWebHookEvent(gomock.Any()).Return(nil)
 
 	data, _ = json.Marshal(webHookMessage)
-	requestBody = strings.NewReader(string(data))
-	resp, err = client.Post(ts.URL+"/"+organization.AgolaOrganizationRef, "application/json", requestBody)
+	req, _ := http.NewRequest("POST", ts.URL+"/"+organization.AgolaOrganizationRef, strings.NewReader(string(data)))
+	req.Header.Set("X-Gitea-Signature", makeWebHookSignature("webHookSecret", data))
+	resp, err = client.Do(req)
 
 	assert.Equal(t, err, nil)
 	assert.Equal(t, resp.StatusCode, http.StatusUnprocessableEntity)
@@ -811,42 +845,33 @@ func TestRepositoryCreatedWithAgolaConfigWithErrors(t *testing.T) {
 	db.EXPECT().GetUserByUserId(organization.UserIDConnected).Return(nil, nil)
 
 	data, _ = json.Marshal(webHookMessage)
-	requestBody = strings.NewReader(string(data))
-	resp, err = client.Post(ts.URL+"/"+organization.AgolaOrganizationRef, "application/json", requestBody)
-
-	assert.Equal(t, err, nil)
-	assert.Equal(t, resp.StatusCode, http.StatusInternalServerError)
+	err = serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Check(t, err != nil)
 
 	// agola CreateProject error
 
 	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
 	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
 	db.EXPECT().GetUserByUserId(organization.UserIDConnected).Return(user, nil)
-	giteaApi.EXPECT().CheckRepositoryAgolaConfExists(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(true, nil)
-	agolaApi.EXPECT().CreateProject(webHookMessage.Repository.Name, utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name), gomock.Any(), gitSource.AgolaRemoteSource, gomock.Any()).Return("", errors.New("error test"))
+	giteaApi.EXPECT().GetAgolaConfBranches(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(map[string]bool{"master": true}, nil)
+	agolaApi.EXPECT().CreateProject(gomock.Any(), webHookMessage.Repository.Name, utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name), gomock.Any(), gitSource.AgolaRemoteSource, gomock.Any()).Return("", errors.New("error test"))
 
 	data, _ = json.Marshal(webHookMessage)
-	requestBody = strings.NewReader(string(data))
-	resp, err = client.Post(ts.URL+"/"+organization.AgolaOrganizationRef, "application/json", requestBody)
-
-	assert.Equal(t, err, nil)
-	assert.Equal(t, resp.StatusCode, http.StatusInternalServerError)
+	err = serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Check(t, err != nil)
 
 	// SaveOrganization error
 
 	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
 	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
 	db.EXPECT().GetUserByUserId(organization.UserIDConnected).Return(user, nil)
-	giteaApi.EXPECT().CheckRepositoryAgolaConfExists(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(true, nil)
-	agolaApi.EXPECT().CreateProject(webHookMessage.Repository.Name, utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name), gomock.Any(), gitSource.AgolaRemoteSource, gomock.Any()).Return("projectTestID", nil)
+	giteaApi.EXPECT().GetAgolaConfBranches(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(map[string]bool{"master": true}, nil)
+	agolaApi.EXPECT().CreateProject(gomock.Any(), webHookMessage.Repository.Name, utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name), gomock.Any(), gitSource.AgolaRemoteSource, gomock.Any()).Return("projectTestID", nil)
 	db.EXPECT().SaveOrganization(gomock.Any()).Return(errors.New("test error"))
 
 	data, _ = json.Marshal(webHookMessage)
-	requestBody = strings.NewReader(string(data))
-	resp, err = client.Post(ts.URL+"/"+organization.AgolaOrganizationRef, "application/json", requestBody)
-
-	assert.Equal(t, err, nil)
-	assert.Equal(t, resp.StatusCode, http.StatusInternalServerError)
+	err = serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Check(t, err != nil)
 }
 
 func TestRepositoryDeletedWithErrors(t *testing.T) {
@@ -876,12 +901,6 @@ func TestRepositoryDeletedWithErrors(t *testing.T) {
 		CommonMutex: &commonMutex,
 	}
 
-	router := mux.NewRouter()
-	router.HandleFunc("/{organizationRef}", serviceWebHook.WebHookOrganization)
-	ts := httptest.NewServer(router)
-	defer ts.Close()
-
-	client := ts.Client()
 	data, _ := json.Marshal(webHookMessage)
 
 	// repository not found
@@ -893,38 +912,1799 @@ func TestRepositoryDeletedWithErrors(t *testing.T) {
 	db.EXPECT().GetGitSourceByName(organizationTest.GitSourceName).Return(&gitSource, nil)
 	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
 
-	requestBody := strings.NewReader(string(data))
-	resp, err := client.Post(ts.URL+"/"+organizationTest.AgolaOrganizationRef, "application/json", requestBody)
-
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organizationTest.AgolaOrganizationRef, data))
 	assert.Equal(t, err, nil)
-	assert.Equal(t, resp.StatusCode, http.StatusUnprocessableEntity)
 
 	// agola DeleteProject error
 
 	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
 	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
 	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
-	agolaApi.EXPECT().DeleteProject(gomock.Any(), utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name), gomock.Any()).Return(errors.New("test error"))
-
-	requestBody = strings.NewReader(string(data))
-	resp, err = client.Post(ts.URL+"/"+organization.AgolaOrganizationRef, "application/json", requestBody)
+	agolaApi.EXPECT().DeleteProject(gomock.Any(), gomock.Any(), utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name), gomock.Any()).Return(errors.New("test error"))
 
-	assert.Equal(t, err, nil)
-	assert.Equal(t, resp.StatusCode, http.StatusInternalServerError)
+	err = serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Check(t, err != nil)
 
 	// SaveOrganization error
 
 	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
 	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
 	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
-	agolaApi.EXPECT().DeleteProject(gomock.Any(), utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name), gomock.Any()).Return(nil)
+	agolaApi.EXPECT().DeleteProject(gomock.Any(), gomock.Any(), utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name), gomock.Any()).Return(nil)
 	db.EXPECT().SaveOrganization(gomock.Any()).Return(errors.New("test error"))
 
-	requestBody = strings.NewReader(string(data))
-	resp, err = client.Post(ts.URL+"/"+organization.AgolaOrganizationRef, "application/json", requestBody)
+	err = serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Check(t, err != nil)
+}
+
+func TestRepositoryPushWithBranchRef(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+
+	repositoryRef := "repositoryTest"
+	organization.Projects = make(map[string]model.Project)
+	organization.Projects[repositoryRef] = model.Project{AgolaProjectRef: repositoryRef, Archivied: false, AgolaProjectID: "test", Branchs: map[string]model.Branch{"master": {Name: "master"}}}
+
+	webHookMessage := dto.WebHookDto{
+		Repository: dto.RepositoryDto{ID: 1, Name: repositoryRef},
+		Ref:        "refs/heads/develop",
+		After:      "a1b2c3",
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	giteaApi := mock_gitea.NewMockGiteaInterface(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{GiteaApi: giteaApi},
+		AgolaApi:    agolaApi,
+		CommonMutex: &commonMutex,
+	}
 
+	// push on a new branch
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	giteaApi.EXPECT().GetAgolaConfBranches(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(map[string]bool{"master": true}, nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
+
+	data, _ := json.Marshal(webHookMessage)
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
 	assert.Equal(t, err, nil)
-	assert.Equal(t, resp.StatusCode, http.StatusInternalServerError)
+
+	assert.Equal(t, len(organization.Projects[repositoryRef].Branchs), 2)
+	assert.Equal(t, organization.Projects[repositoryRef].Branchs["develop"].Name, "develop")
+
+	// push on a known branch
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	giteaApi.EXPECT().CheckBranchAgolaConfExists(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name, "develop").Return(false, nil)
+
+	err = serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Equal(t, err, nil)
+
+	assert.Equal(t, len(organization.Projects[repositoryRef].Branchs), 2)
+
+	// push on a tag
+
+	webHookMessage.Ref = "refs/tags/v1.0.0"
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+
+	data, _ = json.Marshal(webHookMessage)
+	err = serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Equal(t, err, nil)
+
+	assert.Equal(t, len(organization.Projects[repositoryRef].Branchs), 2)
+}
+
+func TestRepositoryPushOnBranchWithNewAgolaConf(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+
+	repositoryRef := "repositoryTest"
+	organization.Projects = make(map[string]model.Project)
+	organization.Projects[repositoryRef] = model.Project{AgolaProjectRef: repositoryRef, Archivied: true, AgolaProjectID: "test", AgolaConfBranches: map[string]bool{}, Branchs: map[string]model.Branch{"master": {Name: "master"}}}
+
+	webHookMessage := dto.WebHookDto{
+		Repository: dto.RepositoryDto{ID: 1, Name: repositoryRef},
+		Ref:        "refs/heads/feature",
+		After:      "a1b2c3",
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	giteaApi := mock_gitea.NewMockGiteaInterface(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{GiteaApi: giteaApi},
+		AgolaApi:    agolaApi,
+		CommonMutex: &commonMutex,
+	}
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	giteaApi.EXPECT().CheckBranchAgolaConfExists(gomock.Any(), gomock.Any(), organization.GitPath, repositoryRef, "feature").Return(true, nil)
+	agolaApi.EXPECT().UnarchiveProject(gomock.Any(), gomock.Any(), repositoryRef).Return(nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil).Times(2)
+
+	data, _ := json.Marshal(webHookMessage)
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Equal(t, err, nil)
+
+	assert.Equal(t, organization.Projects[repositoryRef].Archivied, false)
+	assert.DeepEqual(t, organization.Projects[repositoryRef].AgolaConfBranches, map[string]bool{"feature": true})
+	assert.Equal(t, len(organization.Projects[repositoryRef].Branchs), 2)
+}
+
+func TestRepositoryPushOnBranchWithoutAgolaConf(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+
+	repositoryRef := "repositoryTest"
+	organization.Projects = make(map[string]model.Project)
+	organization.Projects[repositoryRef] = model.Project{AgolaProjectRef: repositoryRef, Archivied: false, AgolaProjectID: "test", AgolaConfBranches: map[string]bool{"master": true, "feature": true}, Branchs: map[string]model.Branch{"master": {Name: "master"}, "feature": {Name: "feature"}}}
+
+	webHookMessage := dto.WebHookDto{
+		Repository: dto.RepositoryDto{ID: 1, Name: repositoryRef},
+		Ref:        "refs/heads/feature",
+		After:      "a1b2c3",
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	giteaApi := mock_gitea.NewMockGiteaInterface(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{GiteaApi: giteaApi},
+		AgolaApi:    agolaApi,
+		CommonMutex: &commonMutex,
+	}
+
+	// the config is removed from a branch, the project stays active
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	giteaApi.EXPECT().CheckBranchAgolaConfExists(gomock.Any(), gomock.Any(), organization.GitPath, repositoryRef, "feature").Return(false, nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
+
+	data, _ := json.Marshal(webHookMessage)
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Equal(t, err, nil)
+
+	assert.Equal(t, organization.Projects[repositoryRef].Archivied, false)
+	assert.DeepEqual(t, organization.Projects[repositoryRef].AgolaConfBranches, map[string]bool{"master": true})
+
+	// the last branch with the config is deleted, the project is archived
+
+	webHookMessage.Ref = "refs/heads/master"
+	webHookMessage.After = "0000000000000000000000000000000000000000"
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	agolaApi.EXPECT().ArchiveProject(gomock.Any(), gomock.Any(), repositoryRef).Return(nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil).Times(2)
+
+	data, _ = json.Marshal(webHookMessage)
+	err = serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Equal(t, err, nil)
+
+	assert.Equal(t, organization.Projects[repositoryRef].Archivied, true)
+	assert.Equal(t, len(organization.Projects[repositoryRef].AgolaConfBranches), 0)
+	assert.Equal(t, len(organization.Projects[repositoryRef].Branchs), 1)
+}
+
+func TestBranchCreatedAndDeleted(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+
+	repositoryRef := "repositoryTest"
+	organization.Projects = make(map[string]model.Project)
+	organization.Projects[repositoryRef] = model.Project{AgolaProjectRef: repositoryRef, AgolaProjectID: "test", Branchs: map[string]model.Branch{"master": {Name: "master"}}}
+
+	webHookMessage := dto.WebHookDto{
+		Repository: dto.RepositoryDto{ID: 1, Name: repositoryRef},
+		Ref:        "develop",
+		RefType:    "branch",
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	giteaApi := mock_gitea.NewMockGiteaInterface(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{GiteaApi: giteaApi},
+		AgolaApi:    agolaApi,
+		CommonMutex: &commonMutex,
+	}
+
+	data, _ := json.Marshal(webHookMessage)
+
+	// branch created
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
+
+	event := makeWebHookEvent(organization.AgolaOrganizationRef, data)
+	event.Header = http.Header{"X-Gitea-Event": []string{"create"}}
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), event)
+	assert.Equal(t, err, nil)
+
+	_, exists := organization.Projects[repositoryRef].Branchs["develop"]
+	assert.Check(t, exists)
+
+	// branch deleted
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
+
+	event = makeWebHookEvent(organization.AgolaOrganizationRef, data)
+	event.Header = http.Header{"X-Gitea-Event": []string{"delete"}}
+	err = serviceWebHook.ProcessWebHookEvent(context.Background(), event)
+	assert.Equal(t, err, nil)
+
+	_, exists = organization.Projects[repositoryRef].Branchs["develop"]
+	assert.Check(t, !exists)
+
+	// event without header, full branch synchronization
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	setupBranchSynckMock(db, giteaApi, organization.GitPath, repositoryRef)
+
+	err = serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Equal(t, err, nil)
+
+	// tag created
+
+	webHookMessage.RefType = "tag"
+	data, _ = json.Marshal(webHookMessage)
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+
+	event = makeWebHookEvent(organization.AgolaOrganizationRef, data)
+	event.Header = http.Header{"X-Gitea-Event": []string{"create"}}
+	err = serviceWebHook.ProcessWebHookEvent(context.Background(), event)
+	assert.Equal(t, err, nil)
+}
+
+func TestRepositoryGitlabPushBranchDeleted(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	organization.GitSourceName = "gitlab"
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+
+	repositoryRef := "repositoryTest"
+	organization.Projects = make(map[string]model.Project)
+	organization.Projects[repositoryRef] = model.Project{AgolaProjectRef: repositoryRef, AgolaProjectID: "test", Branchs: map[string]model.Branch{"master": {Name: "master"}, "develop": {Name: "develop"}}}
+
+	webHookMessage := gitlab.PushEvent{
+		ProjectID:  1,
+		Repository: &gitlab.Repository{Name: repositoryRef},
+		Ref:        "refs/heads/develop",
+		Before:     "a1b2c3",
+		After:      "0000000000000000000000000000000000000000",
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	gitlabApi := mock_gitlab.NewMockGitlabInterface(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	gitlabApi.EXPECT().GetAgolaConfBranches(gomock.Any(), gomock.Any(), organization.GitPath, repositoryRef).Return(map[string]bool{"master": true}, nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{GitlabApi: gitlabApi},
+		AgolaApi:    agolaApi,
+		CommonMutex: &commonMutex,
+	}
+
+	data, _ := json.Marshal(webHookMessage)
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Equal(t, err, nil)
+
+	_, exists := organization.Projects[repositoryRef].Branchs["develop"]
+	assert.Check(t, !exists)
+	assert.Equal(t, len(organization.Projects[repositoryRef].Branchs), 1)
+}
+
+func TestGitlabProjectCreated(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	organization.GitSourceName = "gitlab"
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+
+	repositoryRef := "repositoryTest"
+
+	webHookMessage := gitlab.ProjectSystemEvent{
+		BaseSystemEvent:   gitlab.BaseSystemEvent{EventName: "project_create"},
+		Name:              repositoryRef,
+		Path:              repositoryRef,
+		PathWithNamespace: organization.GitPath + "/" + repositoryRef,
+		ProjectID:         1,
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	gitlabApi := mock_gitlab.NewMockGitlabInterface(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	gitlabApi.EXPECT().GetAgolaConfBranches(gomock.Any(), gomock.Any(), organization.GitPath, repositoryRef).Return(map[string]bool{"master": true}, nil)
+	agolaApi.EXPECT().CreateProject(gomock.Any(), repositoryRef, utils.ConvertToAgolaProjectRef(repositoryRef), gomock.Any(), gitSource.AgolaRemoteSource, gomock.Any()).Return("projectTestID", nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{GitlabApi: gitlabApi},
+		AgolaApi:    agolaApi,
+		CommonMutex: &commonMutex,
+	}
+
+	data, _ := json.Marshal(webHookMessage)
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Equal(t, err, nil)
+
+	project, exists := organization.Projects[repositoryRef]
+	assert.Check(t, exists)
+	assert.Equal(t, project.AgolaProjectID, "projectTestID")
+}
+
+func TestGitlabProjectDestroyed(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	organization.GitSourceName = "gitlab"
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+
+	repositoryRef := "repositoryTest"
+	organization.Projects = make(map[string]model.Project)
+	organization.Projects[repositoryRef] = model.Project{AgolaProjectRef: repositoryRef}
+
+	webHookMessage := gitlab.ProjectSystemEvent{
+		BaseSystemEvent:   gitlab.BaseSystemEvent{EventName: "project_destroy"},
+		Name:              repositoryRef,
+		PathWithNamespace: organization.GitPath + "/" + repositoryRef,
+		ProjectID:         1,
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	agolaApi.EXPECT().DeleteProject(gomock.Any(), gomock.Any(), utils.ConvertToAgolaProjectRef(repositoryRef), gomock.Any()).Return(nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{},
+		AgolaApi:    agolaApi,
+		CommonMutex: &commonMutex,
+	}
+
+	data, _ := json.Marshal(webHookMessage)
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Equal(t, err, nil)
+
+	_, exists := organization.Projects[repositoryRef]
+	assert.Check(t, !exists)
+}
+
+func TestGitlabProjectRenamed(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	organization.GitSourceName = "gitlab"
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+
+	oldRepositoryRef := "repositoryTest"
+	repositoryRef := "repositoryRenamed"
+	organization.Projects = make(map[string]model.Project)
+	organization.Projects[oldRepositoryRef] = model.Project{GitRepoPath: oldRepositoryRef, AgolaProjectRef: oldRepositoryRef, AgolaProjectID: "projectTestID"}
+
+	webHookMessage := gitlab.ProjectSystemEvent{
+		BaseSystemEvent:      gitlab.BaseSystemEvent{EventName: "project_rename"},
+		Name:                 repositoryRef,
+		PathWithNamespace:    organization.GitPath + "/" + repositoryRef,
+		OldPathWithNamespace: organization.GitPath + "/" + oldRepositoryRef,
+		ProjectID:            1,
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	agolaApi.EXPECT().RenameProject(gomock.Any(), gomock.Any(), oldRepositoryRef, utils.ConvertToAgolaProjectRef(repositoryRef), gomock.Any()).Return(nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{},
+		AgolaApi:    agolaApi,
+		CommonMutex: &commonMutex,
+	}
+
+	data, _ := json.Marshal(webHookMessage)
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Equal(t, err, nil)
+
+	_, exists := organization.Projects[oldRepositoryRef]
+	assert.Check(t, !exists)
+
+	project, exists := organization.Projects[repositoryRef]
+	assert.Check(t, exists)
+	assert.Equal(t, project.GitRepoPath, repositoryRef)
+	assert.Equal(t, project.AgolaProjectRef, repositoryRef)
+	assert.Equal(t, project.AgolaProjectID, "projectTestID")
+}
+
+func TestGithubRepositoryRenamed(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+
+	oldRepositoryRef := "repositoryTest"
+	repositoryRef := "repositoryRenamed"
+	organization.Projects = make(map[string]model.Project)
+	organization.Projects[oldRepositoryRef] = model.Project{GitRepoPath: oldRepositoryRef, AgolaProjectRef: oldRepositoryRef, AgolaProjectID: "projectTestID", Branchs: map[string]model.Branch{"master": {Name: "master"}}}
+
+	webHookMessage := dto.WebHookDto{
+		Repository: dto.RepositoryDto{ID: 1, Name: repositoryRef},
+		Action:     "renamed",
+		Changes:    &dto.ChangesDto{},
+	}
+	webHookMessage.Changes.Repository.Name.From = oldRepositoryRef
+
+	db := mock_repository.NewMockDatabase(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	agolaApi.EXPECT().RenameProject(gomock.Any(), gomock.Any(), oldRepositoryRef, utils.ConvertToAgolaProjectRef(repositoryRef), gomock.Any()).Return(nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{},
+		AgolaApi:    agolaApi,
+		CommonMutex: &commonMutex,
+	}
+
+	data, _ := json.Marshal(webHookMessage)
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Equal(t, err, nil)
+
+	project, exists := organization.Projects[repositoryRef]
+	assert.Check(t, exists)
+	assert.Equal(t, project.GitRepoID, 1)
+	assert.Equal(t, len(project.Branchs), 1)
+}
+
+func TestGithubRepositoryTransferredBetweenOrganizations(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organizations := *test.MakeOrganizationList()
+	organization := organizations[0]
+	fromOrganization := organizations[1]
+	fromOrganization.GitSourceName = organization.GitSourceName
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+
+	repositoryRef := "repositoryTest"
+	fromOrganization.Projects = make(map[string]model.Project)
+	fromOrganization.Projects[repositoryRef] = model.Project{GitRepoPath: repositoryRef, GitRepoID: 1, AgolaProjectRef: repositoryRef, AgolaProjectID: "projectTestID"}
+
+	webHookMessage := dto.WebHookDto{
+		Repository: dto.RepositoryDto{ID: 1, Name: repositoryRef, FullName: organization.GitPath + "/" + repositoryRef},
+		Action:     "transferred",
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil).Times(2)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	db.EXPECT().GetOrganizationsByGitSource(organization.GitSourceName).Return(&[]model.Organization{fromOrganization, organization}, nil)
+	db.EXPECT().GetOrganizationByAgolaRef(fromOrganization.AgolaOrganizationRef).Return(&fromOrganization, nil)
+	agolaApi.EXPECT().MoveProject(gomock.Any(), gomock.Any(), repositoryRef, gomock.Any(), utils.ConvertToAgolaProjectRef(repositoryRef), gomock.Any()).Return(nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil).Times(2)
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{},
+		AgolaApi:    agolaApi,
+		CommonMutex: &commonMutex,
+	}
+
+	data, _ := json.Marshal(webHookMessage)
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Equal(t, err, nil)
+
+	project, exists := organization.Projects[repositoryRef]
+	assert.Check(t, exists)
+	assert.Equal(t, project.AgolaProjectID, "projectTestID")
+	_, exists = fromOrganization.Projects[repositoryRef]
+	assert.Check(t, !exists)
+}
+
+func TestGithubRepositoryTransferredToManagedOrganizationNotDeleted(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organizations := *test.MakeOrganizationList()
+	organization := organizations[0]
+	toOrganization := organizations[1]
+	toOrganization.GitSourceName = organization.GitSourceName
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+
+	repositoryRef := "repositoryTest"
+	organization.Projects = make(map[string]model.Project)
+	organization.Projects[repositoryRef] = model.Project{GitRepoPath: repositoryRef, GitRepoID: 1, AgolaProjectRef: repositoryRef, AgolaProjectID: "projectTestID"}
+
+	webHookMessage := dto.WebHookDto{
+		Repository: dto.RepositoryDto{ID: 1, Name: repositoryRef, FullName: toOrganization.GitPath + "/" + repositoryRef},
+		Action:     "transferred",
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	db.EXPECT().GetOrganizationsByGitSource(organization.GitSourceName).Return(&[]model.Organization{organization, toOrganization}, nil)
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{},
+		AgolaApi:    agolaApi,
+		CommonMutex: &commonMutex,
+	}
+
+	data, _ := json.Marshal(webHookMessage)
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Equal(t, err, nil)
+
+	_, exists := organization.Projects[repositoryRef]
+	assert.Check(t, exists)
+}
+
+func TestRepositoryPushRenamedDetectedByGitRepoID(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+
+	oldRepositoryRef := "repositoryTest"
+	repositoryRef := "repositoryRenamed"
+	organization.Projects = make(map[string]model.Project)
+	organization.Projects[oldRepositoryRef] = model.Project{GitRepoPath: oldRepositoryRef, GitRepoID: 7, AgolaProjectRef: oldRepositoryRef, AgolaProjectID: "projectTestID", Branchs: map[string]model.Branch{"master": {Name: "master"}}}
+
+	webHookMessage := dto.WebHookDto{
+		Repository: dto.RepositoryDto{ID: 7, Name: repositoryRef},
+		Ref:        "refs/heads/master",
+		After:      "a1b2c3",
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	giteaApi := mock_gitea.NewMockGiteaInterface(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	agolaApi.EXPECT().RenameProject(gomock.Any(), gomock.Any(), oldRepositoryRef, utils.ConvertToAgolaProjectRef(repositoryRef), gomock.Any()).Return(nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
+	giteaApi.EXPECT().GetAgolaConfBranches(gomock.Any(), gomock.Any(), organization.GitPath, repositoryRef).Return(map[string]bool{"master": true}, nil)
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{GiteaApi: giteaApi},
+		AgolaApi:    agolaApi,
+		CommonMutex: &commonMutex,
+	}
+
+	data, _ := json.Marshal(webHookMessage)
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Equal(t, err, nil)
+
+	_, exists := organization.Projects[oldRepositoryRef]
+	assert.Check(t, !exists)
+
+	project, exists := organization.Projects[repositoryRef]
+	assert.Check(t, exists)
+	assert.Equal(t, project.AgolaProjectID, "projectTestID")
+	assert.Equal(t, project.AgolaProjectRef, repositoryRef)
+}
+
+func TestGitlabProjectTransferredOut(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	organization.GitSourceName = "gitlab"
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+
+	repositoryRef := "repositoryTest"
+	organization.Projects = make(map[string]model.Project)
+	organization.Projects[repositoryRef] = model.Project{AgolaProjectRef: repositoryRef}
+
+	webHookMessage := gitlab.ProjectSystemEvent{
+		BaseSystemEvent:      gitlab.BaseSystemEvent{EventName: "project_transfer"},
+		Name:                 repositoryRef,
+		PathWithNamespace:    "otherGroup/" + repositoryRef,
+		OldPathWithNamespace: organization.GitPath + "/" + repositoryRef,
+		ProjectID:            1,
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	db.EXPECT().GetOrganizationsByGitSource(organization.GitSourceName).Return(&[]model.Organization{organization}, nil)
+	agolaApi.EXPECT().DeleteProject(gomock.Any(), gomock.Any(), utils.ConvertToAgolaProjectRef(repositoryRef), gomock.Any()).Return(nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{},
+		AgolaApi:    agolaApi,
+		CommonMutex: &commonMutex,
+	}
+
+	data, _ := json.Marshal(webHookMessage)
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Equal(t, err, nil)
+
+	_, exists := organization.Projects[repositoryRef]
+	assert.Check(t, !exists)
+}
+
+func TestGitlabProjectEventOtherNamespaceIgnored(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	organization.GitSourceName = "gitlab"
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+
+	webHookMessage := gitlab.ProjectSystemEvent{
+		BaseSystemEvent:   gitlab.BaseSystemEvent{EventName: "project_create"},
+		Name:              "repositoryTest",
+		PathWithNamespace: "otherGroup/repositoryTest",
+		ProjectID:         1,
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{},
+		CommonMutex: &commonMutex,
+	}
+
+	data, _ := json.Marshal(webHookMessage)
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(organization.Projects), 0)
+}
+
+func TestGitlabSubgroupProjectCreated(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	organization.GitSourceName = "gitlab"
+	organization.IncludeSubgroups = true
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+
+	repositoryRef := "backend/repositoryTest"
+
+	webHookMessage := gitlab.ProjectSystemEvent{
+		BaseSystemEvent:   gitlab.BaseSystemEvent{EventName: "project_create"},
+		Name:              "repositoryTest",
+		Path:              "repositoryTest",
+		PathWithNamespace: organization.GitPath + "/" + repositoryRef,
+		ProjectID:         1,
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	gitlabApi := mock_gitlab.NewMockGitlabInterface(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	gitlabApi.EXPECT().GetAgolaConfBranches(gomock.Any(), gomock.Any(), organization.GitPath, repositoryRef).Return(map[string]bool{"master": true}, nil)
+	agolaApi.EXPECT().CreateProject(gomock.Any(), repositoryRef, repositoryRef, gomock.Any(), gitSource.AgolaRemoteSource, gomock.Any()).Return("projectTestID", nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{GitlabApi: gitlabApi},
+		AgolaApi:    agolaApi,
+		CommonMutex: &commonMutex,
+	}
+
+	data, _ := json.Marshal(webHookMessage)
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Equal(t, err, nil)
+
+	project, exists := organization.Projects[repositoryRef]
+	assert.Check(t, exists)
+	assert.Equal(t, project.AgolaProjectID, "projectTestID")
+}
+
+func TestGitlabSubgroupPushIgnoredWithoutIncludeSubgroups(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	organization.GitSourceName = "gitlab"
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+
+	data := []byte(`{"object_kind": "push", "project_id": 1, "checkout_sha": "test", "project": {"path_with_namespace": "` + organization.GitPath + `/backend/repositoryTest"}, "repository": {"name": "repositoryTest"}}`)
+
+	db := mock_repository.NewMockDatabase(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{},
+		CommonMutex: &commonMutex,
+	}
+
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(organization.Projects), 0)
+}
+
+func TestWebHookPayloadTooLarge(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	config.Config.WebHookQueue.MaxPayloadSize = 1
+	defer func() { config.Config.WebHookQueue.MaxPayloadSize = 0 }()
+
+	organization := (*test.MakeOrganizationList())[0]
+	organization.SetWebHookSecret("webHookSecret")
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+
+	db := mock_repository.NewMockDatabase(ctl)
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+
+	serviceWebHook := WebHookService{
+		Db:           db,
+		GitGateway:   &git.GitGateway{},
+		WebHookQueue: &trigger.WebHookQueue{Db: db},
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/{organizationRef}", serviceWebHook.WebHookOrganization)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client := ts.Client()
+	resp, err := client.Post(ts.URL+"/"+organization.AgolaOrganizationRef, "application/json", strings.NewReader(strings.Repeat("a", 2048)))
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusRequestEntityTooLarge)
+}
+
+func TestWebHookGiteaSignatureOK(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	organization.SetWebHookSecret("webHookSecret")
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+
+	repositoryRef := "repositoryTest"
+
+	webHookMessage := dto.WebHookDto{
+		Repository: dto.RepositoryDto{ID: 1, Name: repositoryRef},
+		Action:     "created",
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	giteaApi := mock_gitea.NewMockGiteaInterface(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().SaveWebHookEvent(gomock.Any()).Return(nil)
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{GiteaApi: giteaApi},
+		AgolaApi:    agolaApi,
+		CommonMutex: &commonMutex,
+
+		WebHookQueue: &trigger.WebHookQueue{Db: db},
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/{organizationRef}", serviceWebHook.WebHookOrganization)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client := ts.Client()
+	data, _ := json.Marshal(webHookMessage)
+	req, _ := http.NewRequest("POST", ts.URL+"/"+organization.AgolaOrganizationRef, strings.NewReader(string(data)))
+	req.Header.Set("X-Gitea-Signature", makeWebHookSignature("webHookSecret", data))
+	resp, err := client.Do(req)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusAccepted)
+	assert.Check(t, organization.Projects == nil)
+}
+
+func TestWebHookWithoutSecretUnauthorized(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+
+	webHookMessage := dto.WebHookDto{
+		Repository: dto.RepositoryDto{ID: 1, Name: "repositoryTest"},
+		Action:     "created",
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	var savedEvent *model.WebHookEvent
+	db.EXPECT().SaveWebHookEvent(gomock.Any()).DoAndReturn(func(event *model.WebHookEvent) error {
+		savedEvent = event
+		return nil
+	})
+
+	serviceWebHook := WebHookService{
+		Db:           db,
+		GitGateway:   &git.GitGateway{},
+		WebHookQueue: &trigger.WebHookQueue{Db: db},
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/{organizationRef}", serviceWebHook.WebHookOrganization)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client := ts.Client()
+	data, _ := json.Marshal(webHookMessage)
+	resp, err := client.Post(ts.URL+"/"+organization.AgolaOrganizationRef, "application/json", strings.NewReader(string(data)))
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusUnauthorized)
+	assert.Equal(t, savedEvent.Status, model.WebHookEventRejected)
+	assert.Equal(t, savedEvent.LastError, fmt.Sprintf("no webhook secret, payload of %d bytes not stored", len(data)))
+	assert.Equal(t, len(savedEvent.Payload), 0)
+}
+
+func TestWebHookDuplicatedDelivery(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	organization.SetWebHookSecret("webHookSecret")
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+
+	webHookMessage := dto.WebHookDto{
+		Repository: dto.RepositoryDto{ID: 1, Name: "repositoryTest"},
+		Action:     "created",
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil).Times(3)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil).Times(3)
+	db.EXPECT().SaveWebHookEvent(gomock.Any()).Return(nil).Times(2)
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{},
+		CommonMutex: &commonMutex,
+
+		WebHookQueue:  &trigger.WebHookQueue{Db: db},
+		DeliveryDedup: utils.NewDeliveryDedupStore(10, time.Hour),
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/webhookdedup", serviceWebHook.GetWebHookDedupStats)
+	router.HandleFunc("/{organizationRef}", serviceWebHook.WebHookOrganization)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client := ts.Client()
+	data, _ := json.Marshal(webHookMessage)
+
+	for _, delivery := range []struct {
+		deliveryID string
+		statusCode int
+	}{{"delivery1", http.StatusAccepted}, {"delivery1", http.StatusOK}, {"delivery2", http.StatusAccepted}} {
+		req, _ := http.NewRequest("POST", ts.URL+"/"+organization.AgolaOrganizationRef, strings.NewReader(string(data)))
+		req.Header.Set("X-Gitea-Signature", makeWebHookSignature("webHookSecret", data))
+		req.Header.Set("X-Gitea-Delivery", delivery.deliveryID)
+		resp, err := client.Do(req)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, resp.StatusCode, delivery.statusCode)
+	}
+
+	resp, err := client.Get(ts.URL + "/webhookdedup")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+
+	var stats dto.WebHookDedupStatsDto
+	test.ParseBody(resp, &stats)
+	assert.Equal(t, stats.Received, uint64(3))
+	assert.Equal(t, stats.Duplicates, uint64(1))
+	assert.Equal(t, stats.Size, 2)
+}
+
+func TestDeliveryDedupRemoveKeepsEvictionOrder(t *testing.T) {
+	store := utils.NewDeliveryDedupStore(2, time.Hour)
+
+	assert.Equal(t, store.CheckAndAdd("delivery1"), false)
+	assert.Equal(t, store.CheckAndAdd("delivery2"), false)
+	store.Remove("delivery1")
+	assert.Equal(t, store.CheckAndAdd("delivery3"), false)
+	assert.Equal(t, store.GetStats().Evicted, uint64(0))
+
+	//the store is full, the oldest delivery still stored is evicted
+	assert.Equal(t, store.CheckAndAdd("delivery4"), false)
+	assert.Equal(t, store.GetStats().Evicted, uint64(1))
+	assert.Equal(t, store.CheckAndAdd("delivery3"), true)
+	assert.Equal(t, store.CheckAndAdd("delivery4"), true)
+	assert.Equal(t, store.GetStats().Size, 2)
+}
+
+func TestDeliveryDedupPersistedAcrossRestart(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	db := mock_repository.NewMockDatabase(ctl)
+
+	storedDeliveries := map[string]time.Time{
+		"organization/delivery1": time.Now().Add(-time.Minute),
+		"organization/expired":   time.Now().Add(-2 * time.Hour),
+	}
+
+	db.EXPECT().GetWebHookDeliveries().Return(storedDeliveries, nil)
+	db.EXPECT().DeleteWebHookDelivery("organization/expired").Return(nil)
+	db.EXPECT().SaveWebHookDelivery("organization/delivery2", gomock.Any(), time.Hour).Return(nil)
+	db.EXPECT().DeleteWebHookDelivery("organization/delivery2").Return(nil)
+
+	store := utils.NewPersistentDeliveryDedupStore(db, 10, time.Hour)
+
+	assert.Equal(t, store.CheckAndAdd("organization/delivery1"), true)
+	assert.Equal(t, store.CheckAndAdd("organization/delivery2"), false)
+	store.Remove("organization/delivery2")
+	assert.Equal(t, store.GetStats().Size, 1)
+}
+
+func TestWebHookGiteaSignatureNotValid(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	organization.SetWebHookSecret("webHookSecret")
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+
+	webHookMessage := dto.WebHookDto{
+		Repository: dto.RepositoryDto{ID: 1, Name: "repositoryTest"},
+		Action:     "created",
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	giteaApi := mock_gitea.NewMockGiteaInterface(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	var savedEvent model.WebHookEvent
+	db.EXPECT().SaveWebHookEvent(gomock.Any()).DoAndReturn(func(event *model.WebHookEvent) error {
+		savedEvent = *event
+		return nil
+	})
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{GiteaApi: giteaApi},
+		AgolaApi:    agolaApi,
+		CommonMutex: &commonMutex,
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/{organizationRef}", serviceWebHook.WebHookOrganization)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client := ts.Client()
+	data, _ := json.Marshal(webHookMessage)
+	req, _ := http.NewRequest("POST", ts.URL+"/"+organization.AgolaOrganizationRef, strings.NewReader(string(data)))
+	req.Header.Set("X-Gitea-Signature", makeWebHookSignature("otherSecret", data))
+	resp, err := client.Do(req)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusUnauthorized)
+	assert.Equal(t, savedEvent.Status, model.WebHookEventRejected)
+	assert.Equal(t, len(savedEvent.Payload), 0)
+	assert.Equal(t, savedEvent.Header.Get("X-Gitea-Signature"), "")
+	assert.Check(t, organization.Projects == nil)
+}
+
+func TestWebHookGitlabTokenNotValid(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	organization.GitSourceName = "gitlab"
+	organization.SetWebHookSecret("webHookSecret")
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+
+	webHookMessage := gitlab.PushEvent{
+		ProjectID:   1,
+		Repository:  &gitlab.Repository{Name: "repositoryTest"},
+		CheckoutSHA: "test",
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	gitlabApi := mock_gitlab.NewMockGitlabInterface(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	var savedEvent model.WebHookEvent
+	db.EXPECT().SaveWebHookEvent(gomock.Any()).DoAndReturn(func(event *model.WebHookEvent) error {
+		savedEvent = *event
+		return nil
+	})
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{GitlabApi: gitlabApi},
+		AgolaApi:    agolaApi,
+		CommonMutex: &commonMutex,
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/{organizationRef}", serviceWebHook.WebHookOrganization)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client := ts.Client()
+	data, _ := json.Marshal(webHookMessage)
+	req, _ := http.NewRequest("POST", ts.URL+"/"+organization.AgolaOrganizationRef, strings.NewReader(string(data)))
+	req.Header.Set("X-Gitlab-Token", "otherSecret")
+	resp, err := client.Do(req)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusUnauthorized)
+	assert.Equal(t, savedEvent.Status, model.WebHookEventRejected)
+}
+
+func TestWebHookGitSourceSystemHook(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	organization.GitSourceName = "gitlab"
+	otherOrganization := organization
+	otherOrganization.GitPath = "otherGroup"
+	otherOrganization.AgolaOrganizationRef = "otherGroup"
+	organizations := []model.Organization{otherOrganization, organization}
+
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	gitSource.SetSystemHook(1, "systemHookSecret")
+
+	webHookMessage := gitlab.ProjectSystemEvent{
+		BaseSystemEvent:   gitlab.BaseSystemEvent{EventName: "project_create"},
+		Name:              "repositoryTest",
+		PathWithNamespace: organization.GitPath + "/repositoryTest",
+		ProjectID:         1,
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	gitlabApi := mock_gitlab.NewMockGitlabInterface(ctl)
+
+	db.EXPECT().GetGitSourceByName(gitSource.Name).Return(&gitSource, nil)
+	db.EXPECT().GetOrganizationsByGitSource(gitSource.Name).Return(&organizations, nil)
+	var savedEvent model.WebHookEvent
+	db.EXPECT().SaveWebHookEvent(gomock.Any()).DoAndReturn(func(event *model.WebHookEvent) error {
+		savedEvent = *event
+		return nil
+	})
+
+	serviceWebHook := WebHookService{
+		Db:         db,
+		GitGateway: &git.GitGateway{GitlabApi: gitlabApi},
+
+		WebHookQueue: &trigger.WebHookQueue{Db: db},
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/gitsource/{gitSourceName}", serviceWebHook.WebHookGitSource)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client := ts.Client()
+	data, _ := json.Marshal(webHookMessage)
+
+	req, _ := http.NewRequest("POST", ts.URL+"/gitsource/"+gitSource.Name, strings.NewReader(string(data)))
+	req.Header.Set("X-Gitlab-Token", "otherSecret")
+	resp, err := client.Do(req)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusUnauthorized)
+
+	db.EXPECT().GetGitSourceByName(gitSource.Name).Return(&gitSource, nil)
+	req, _ = http.NewRequest("POST", ts.URL+"/gitsource/"+gitSource.Name, strings.NewReader(string(data)))
+	req.Header.Set("X-Gitlab-Token", "systemHookSecret")
+	resp, err = client.Do(req)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusAccepted)
+	assert.Equal(t, savedEvent.OrganizationRef, organization.AgolaOrganizationRef)
+	assert.Check(t, len(savedEvent.Header.Get("X-Gitlab-Token")) == 0)
+}
+
+func TestWebHookNotValidDeliveryReceivedAgain(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	organization.SetWebHookSecret("webHookSecret")
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+
+	db := mock_repository.NewMockDatabase(ctl)
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil).Times(2)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil).Times(2)
+	db.EXPECT().SaveWebHookEvent(gomock.Any()).Return(nil).Times(2)
+
+	serviceWebHook := WebHookService{
+		Db:         db,
+		GitGateway: &git.GitGateway{},
+
+		WebHookQueue:  &trigger.WebHookQueue{Db: db},
+		DeliveryDedup: utils.NewDeliveryDedupStore(10, time.Hour),
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/{organizationRef}", serviceWebHook.WebHookOrganization)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client := ts.Client()
+	data := []byte("not valid")
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("POST", ts.URL+"/"+organization.AgolaOrganizationRef, strings.NewReader(string(data)))
+		req.Header.Set("X-Gitea-Signature", makeWebHookSignature("webHookSecret", data))
+		req.Header.Set("X-Gitea-Delivery", "delivery1")
+		resp, err := client.Do(req)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, resp.StatusCode, http.StatusInternalServerError)
+	}
+}
+
+func TestWebHookGitSourceNotValidReceivedAgain(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	organization.GitSourceName = "gitlab"
+	otherOrganization := organization
+	otherOrganization.GitPath = "otherGroup"
+	otherOrganization.AgolaOrganizationRef = "otherGroup"
+	organizations := []model.Organization{otherOrganization, organization}
+
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	gitSource.SetSystemHook(1, "systemHookSecret")
+
+	db := mock_repository.NewMockDatabase(ctl)
+	gitlabApi := mock_gitlab.NewMockGitlabInterface(ctl)
+
+	db.EXPECT().GetGitSourceByName(gitSource.Name).Return(&gitSource, nil).Times(2)
+	db.EXPECT().GetOrganizationsByGitSource(gitSource.Name).Return(&organizations, nil).Times(2)
+
+	serviceWebHook := WebHookService{
+		Db:         db,
+		GitGateway: &git.GitGateway{GitlabApi: gitlabApi},
+
+		WebHookQueue:  &trigger.WebHookQueue{Db: db},
+		DeliveryDedup: utils.NewDeliveryDedupStore(10, time.Hour),
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/gitsource/{gitSourceName}", serviceWebHook.WebHookGitSource)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client := ts.Client()
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("POST", ts.URL+"/gitsource/"+gitSource.Name, strings.NewReader("not valid"))
+		req.Header.Set("X-Gitlab-Token", "systemHookSecret")
+		req.Header.Set("X-Gitlab-Event-UUID", "delivery1")
+		resp, err := client.Do(req)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestWebHookQueueRetryAndDeadLetter(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+
+	repositoryRef := "repositoryTest"
+
+	webHookMessage := dto.WebHookDto{
+		Repository: dto.RepositoryDto{ID: 1, Name: repositoryRef},
+		Action:     "created",
+	}
+	data, _ := json.Marshal(webHookMessage)
+
+	db := mock_repository.NewMockDatabase(ctl)
+	giteaApi := mock_gitea.NewMockGiteaInterface(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{GiteaApi: giteaApi},
+		AgolaApi:    agolaApi,
+		CommonMutex: &commonMutex,
+	}
+
+	queue := trigger.WebHookQueue{Db: db, Processor: serviceWebHook.ProcessWebHookEvent, MaxAttempts: 2, RetryDelay: 0, HistorySize: 10}
+
+	firstEvent := makeWebHookEvent(organization.AgolaOrganizationRef, data)
+	firstEvent.ID = "1"
+	secondEvent := makeWebHookEvent(organization.AgolaOrganizationRef, data)
+	secondEvent.ID = "2"
+
+	// first attempt fails, the second event waits for the retry
+
+	var savedEvent model.WebHookEvent
+	db.EXPECT().GetWebHookEvents(organization.AgolaOrganizationRef).Return(&[]model.WebHookEvent{*firstEvent, *secondEvent}, nil)
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	giteaApi.EXPECT().GetAgolaConfBranches(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(map[string]bool{"master": true}, nil)
+	agolaApi.EXPECT().CreateProject(gomock.Any(), webHookMessage.Repository.Name, utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name), gomock.Any(), gitSource.AgolaRemoteSource, gomock.Any()).Return("", errors.New("test error"))
+	db.EXPECT().SaveWebHookEvent(gomock.Any()).DoAndReturn(func(event *model.WebHookEvent) error {
+		savedEvent = *event
+		return nil
+	})
+
+	queue.ProcessOrganizationEvents(organization.AgolaOrganizationRef)
+
+	assert.Equal(t, savedEvent.ID, firstEvent.ID)
+	assert.Equal(t, savedEvent.Attempts, 1)
+	assert.Check(t, savedEvent.IsPending())
+
+	// second attempt fails, the event is moved to dead letter and the second event is processed and kept in the history
+
+	db.EXPECT().GetWebHookEvents(organization.AgolaOrganizationRef).Return(&[]model.WebHookEvent{savedEvent, *secondEvent}, nil)
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil).Times(2)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil).Times(2)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil).Times(2)
+	giteaApi.EXPECT().GetAgolaConfBranches(gomock.Any(), gomock.Any(), organization.GitPath, webHookMessage.Repository.Name).Return(map[string]bool{"master": true}, nil).Times(2)
+	gomock.InOrder(
+		agolaApi.EXPECT().CreateProject(gomock.Any(), webHookMessage.Repository.Name, utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name), gomock.Any(), gitSource.AgolaRemoteSource, gomock.Any()).Return("", errors.New("test error")),
+		agolaApi.EXPECT().CreateProject(gomock.Any(), webHookMessage.Repository.Name, utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name), gomock.Any(), gitSource.AgolaRemoteSource, gomock.Any()).Return("projectTestID", nil),
+	)
+	savedEvents := make([]model.WebHookEvent, 0)
+	db.EXPECT().SaveWebHookEvent(gomock.Any()).DoAndReturn(func(event *model.WebHookEvent) error {
+		savedEvents = append(savedEvents, *event)
+		return nil
+	}).Times(2)
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
+
+	queue.ProcessOrganizationEvents(organization.AgolaOrganizationRef)
+
+	assert.Equal(t, len(savedEvents), 2)
+	assert.Equal(t, savedEvents[0].ID, firstEvent.ID)
+	assert.Equal(t, savedEvents[0].Status, model.WebHookEventDeadLetter)
+	assert.Equal(t, savedEvents[1].ID, secondEvent.ID)
+	assert.Equal(t, savedEvents[1].Status, model.WebHookEventProcessed)
+
+	_, exists := organization.Projects[repositoryRef]
+	assert.Check(t, exists)
+}
+
+func TestWebHookQueuePruneHistory(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organizationRef := "organizationTest"
+
+	events := []model.WebHookEvent{
+		{ID: "1", OrganizationRef: organizationRef, Status: model.WebHookEventProcessed},
+		{ID: "2", OrganizationRef: organizationRef, Status: model.WebHookEventRejected},
+		{ID: "3", OrganizationRef: organizationRef, Status: model.WebHookEventDeadLetter},
+		{ID: "4", OrganizationRef: organizationRef, Status: model.WebHookEventPending, NextAttempt: time.Now().Add(time.Hour)},
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	db.EXPECT().GetWebHookEvents(organizationRef).Return(&events, nil)
+	db.EXPECT().DeleteWebHookEvent(organizationRef, "1").Return(nil)
+
+	queue := trigger.WebHookQueue{Db: db, MaxAttempts: 2, HistorySize: 2}
+	queue.ProcessOrganizationEvents(organizationRef)
+}
+
+func TestGetWebHookDeliveries(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+
+	events := []model.WebHookEvent{
+		{ID: "1", OrganizationRef: organization.AgolaOrganizationRef, Payload: []byte("{}"), Status: model.WebHookEventProcessed, Attempts: 1, ProcessedAt: time.Now()},
+		{ID: "2", OrganizationRef: organization.AgolaOrganizationRef, Payload: []byte("{}"), Status: model.WebHookEventRejected, LastError: "signature not valid"},
+		{ID: "3", OrganizationRef: organization.AgolaOrganizationRef, Header: http.Header{"X-Gitlab-Token": {"secret"}, "X-Gitlab-Event": {"Push Hook"}}, Payload: []byte("{}"), Status: model.WebHookEventProcessed},
+	}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetWebHookEvents(organization.AgolaOrganizationRef).Return(&events, nil)
+
+	serviceWebHook := WebHookService{Db: db}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/{organizationRef}", serviceWebHook.GetWebHookDeliveries)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client := ts.Client()
+	resp, err := client.Get(ts.URL + "/" + organization.AgolaOrganizationRef)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+
+	var deliveries []dto.WebHookDeliveryDto
+	test.ParseBody(resp, &deliveries)
+
+	assert.Equal(t, len(deliveries), 3)
+	assert.Equal(t, deliveries[0].Payload, "{}")
+	assert.Check(t, deliveries[0].ProcessedAt != nil)
+	assert.Equal(t, deliveries[1].Status, string(model.WebHookEventRejected))
+	assert.Equal(t, deliveries[1].Error, "signature not valid")
+	assert.Equal(t, len(deliveries[2].Header["X-Gitlab-Token"]), 0)
+	assert.Equal(t, deliveries[2].Header["X-Gitlab-Event"][0], "Push Hook")
+}
+
+func TestReplayWebHookDelivery(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+
+	delivery := model.WebHookEvent{ID: "1", OrganizationRef: organization.AgolaOrganizationRef, Payload: []byte("{}"), Status: model.WebHookEventDeadLetter, Attempts: 8, LastError: "test error"}
+	pendingDelivery := model.WebHookEvent{ID: "2", OrganizationRef: organization.AgolaOrganizationRef, Payload: []byte("{}"), Status: model.WebHookEventPending}
+
+	db := mock_repository.NewMockDatabase(ctl)
+
+	serviceWebHook := WebHookService{Db: db, WebHookQueue: &trigger.WebHookQueue{Db: db}}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/{organizationRef}/{deliveryId}/replay", serviceWebHook.ReplayWebHookDelivery)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client := ts.Client()
+
+	// replay ok
+
+	var savedEvent model.WebHookEvent
+	db.EXPECT().GetWebHookEvent(organization.AgolaOrganizationRef, delivery.ID).Return(&delivery, nil)
+	db.EXPECT().SaveWebHookEvent(gomock.Any()).DoAndReturn(func(event *model.WebHookEvent) error {
+		savedEvent = *event
+		return nil
+	})
+
+	resp, err := client.Post(ts.URL+"/"+organization.AgolaOrganizationRef+"/"+delivery.ID+"/replay", "application/json", nil)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusAccepted)
+	assert.Equal(t, savedEvent.ReplayOf, delivery.ID)
+	assert.Equal(t, savedEvent.Status, model.WebHookEventPending)
+	assert.Equal(t, savedEvent.Attempts, 0)
+	assert.Equal(t, string(savedEvent.Payload), string(delivery.Payload))
+
+	// delivery pending
+
+	db.EXPECT().GetWebHookEvent(organization.AgolaOrganizationRef, pendingDelivery.ID).Return(&pendingDelivery, nil)
+
+	resp, err = client.Post(ts.URL+"/"+organization.AgolaOrganizationRef+"/"+pendingDelivery.ID+"/replay", "application/json", nil)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusUnprocessableEntity)
+
+	// delivery not found
+
+	db.EXPECT().GetWebHookEvent(organization.AgolaOrganizationRef, "3").Return(nil, nil)
+
+	resp, err = client.Post(ts.URL+"/"+organization.AgolaOrganizationRef+"/3/replay", "application/json", nil)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, resp.StatusCode, http.StatusNotFound)
+}
+
+func TestSynkWebHookOK(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	organization.WebHookID = 5
+	organization.SetWebHookSecret("secret")
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+
+	webHook := gitDto.WebHookDto{ID: 5, URL: controller.GetWebHookURL(organization.AgolaOrganizationRef), Active: true, Events: []string{"repository", "push", "create", "delete"}}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	giteaApi := mock_gitea.NewMockGiteaInterface(ctl)
+
+	giteaApi.EXPECT().GetWebHook(gomock.Any(), gomock.Any(), organization.GitPath, int64(5)).Return(&webHook, nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
+
+	err := webHookManager.SynkWebHook(db, user, &organization, &gitSource, &git.GitGateway{GiteaApi: giteaApi})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, organization.WebHookStatus, types.WebHookStatusOk)
+	assert.Equal(t, organization.WebHookID, int64(5))
+}
+
+func TestSynkWebHookRepaired(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	organization.WebHookID = 5
+	organization.SetWebHookSecret("secret")
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+
+	disabledWebHook := gitDto.WebHookDto{ID: 5, URL: controller.GetWebHookURL(organization.AgolaOrganizationRef), Active: false, Events: []string{"repository", "push", "create", "delete"}}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	giteaApi := mock_gitea.NewMockGiteaInterface(ctl)
+
+	giteaApi.EXPECT().GetWebHook(gomock.Any(), gomock.Any(), organization.GitPath, int64(5)).Return(&disabledWebHook, nil)
+	giteaApi.EXPECT().DeleteWebHook(gomock.Any(), gomock.Any(), organization.GitPath, int64(5)).Return(nil)
+	giteaApi.EXPECT().CreateWebHook(gomock.Any(), gomock.Any(), organization.GitPath, organization.AgolaOrganizationRef, gomock.Any()).Return(int64(6), nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
+
+	err := webHookManager.SynkWebHook(db, user, &organization, &gitSource, &git.GitGateway{GiteaApi: giteaApi})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, organization.WebHookStatus, types.WebHookStatusRepaired)
+	assert.Equal(t, organization.WebHookID, int64(6))
+	webHookSecret, _ := organization.GetWebHookSecret()
+	assert.Check(t, webHookSecret != "secret")
+}
+
+func TestSynkWebHookMissingAndNotRecreated(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	organization.WebHookID = 5
+	organization.SetWebHookSecret("secret")
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+
+	db := mock_repository.NewMockDatabase(ctl)
+	giteaApi := mock_gitea.NewMockGiteaInterface(ctl)
+
+	giteaApi.EXPECT().GetWebHook(gomock.Any(), gomock.Any(), organization.GitPath, int64(5)).Return(nil, nil)
+	giteaApi.EXPECT().CreateWebHook(gomock.Any(), gomock.Any(), organization.GitPath, organization.AgolaOrganizationRef, gomock.Any()).Return(int64(-1), errors.New("test error"))
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
+
+	err := webHookManager.SynkWebHook(db, user, &organization, &gitSource, &git.GitGateway{GiteaApi: giteaApi})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, organization.WebHookStatus, types.WebHookStatusBroken)
+	assert.Equal(t, organization.WebHookID, int64(5))
+}
+
+func TestSynkWebHookUserNamespaceRepaired(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	organization.UserNamespace = true
+	organization.SetWebHookSecret("secret")
+	organization.Projects = map[string]model.Project{
+		"repositoryOK":      {GitRepoPath: "repositoryOK", WebHookID: 5},
+		"repositoryMissing": {GitRepoPath: "repositoryMissing", WebHookID: 6},
+	}
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+
+	webHook := gitDto.WebHookDto{ID: 5, URL: controller.GetWebHookURL(organization.AgolaOrganizationRef), Active: true, Events: []string{"repository", "push", "create", "delete"}}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	giteaApi := mock_gitea.NewMockGiteaInterface(ctl)
+
+	giteaApi.EXPECT().GetRepositoryWebHook(gomock.Any(), gomock.Any(), organization.GitPath, "repositoryOK", int64(5)).Return(&webHook, nil)
+	giteaApi.EXPECT().GetRepositoryWebHook(gomock.Any(), gomock.Any(), organization.GitPath, "repositoryMissing", int64(6)).Return(nil, nil)
+	giteaApi.EXPECT().CreateRepositoryWebHook(gomock.Any(), gomock.Any(), organization.GitPath, "repositoryMissing", organization.AgolaOrganizationRef, "secret").Return(int64(7), nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
+
+	err := webHookManager.SynkWebHook(db, user, &organization, &gitSource, &git.GitGateway{GiteaApi: giteaApi})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, organization.WebHookStatus, types.WebHookStatusRepaired)
+	assert.Equal(t, organization.Projects["repositoryOK"].WebHookID, int64(5))
+	assert.Equal(t, organization.Projects["repositoryMissing"].WebHookID, int64(7))
+	webHookSecret, _ := organization.GetWebHookSecret()
+	assert.Equal(t, webHookSecret, "secret")
+}
+
+func TestSynkWebHookGitlabSystemHookRepaired(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	organization.GitSourceName = "gitlab"
+	organization.WebHookID = 5
+	organization.SetWebHookSecret("secret")
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	gitSource.SetSystemHook(3, "systemsecret")
+	storedGitSource := gitSource
+	user := test.MakeUser()
+
+	webHook := gitDto.WebHookDto{ID: 5, URL: controller.GetWebHookURL(organization.AgolaOrganizationRef), Active: true, Events: []string{"push"}}
+	disabledSystemHook := gitDto.WebHookDto{ID: 3, URL: controller.GetGitSourceWebHookURL(gitSource.Name), Active: false, Events: []string{}}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	gitlabApi := mock_gitlab.NewMockGitlabInterface(ctl)
+
+	gitlabApi.EXPECT().GetWebHook(gomock.Any(), gomock.Any(), organization.GitPath, int64(5)).Return(&webHook, nil)
+	db.EXPECT().GetGitSourceByName(gitSource.Name).Return(&storedGitSource, nil)
+	gitlabApi.EXPECT().GetSystemHook(gomock.Any(), gomock.Any(), int64(3)).Return(&disabledSystemHook, nil)
+	gitlabApi.EXPECT().CreateSystemHook(gomock.Any(), gomock.Any(), controller.GetGitSourceWebHookURL(gitSource.Name), gomock.Any()).Return(int64(4), nil)
+	db.EXPECT().SaveGitSource(gomock.Any()).Return(nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
+
+	err := webHookManager.SynkWebHook(db, user, &organization, &gitSource, &git.GitGateway{GitlabApi: gitlabApi})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, organization.WebHookStatus, types.WebHookStatusRepaired)
+	assert.Equal(t, organization.WebHookID, int64(5))
+	assert.Equal(t, gitSource.SystemHookID, int64(4))
+	systemHookSecret, _ := gitSource.GetSystemHookSecret()
+	assert.Check(t, systemHookSecret != "systemsecret")
+}
+
+func TestSynkWebHookGitlabUnexpectedEventRepaired(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	organization.GitSourceName = "gitlab"
+	organization.WebHookID = 5
+	organization.SetWebHookSecret("secret")
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	gitSource.SetSystemHook(3, "systemsecret")
+	storedGitSource := gitSource
+	user := test.MakeUser()
+
+	webHook := gitDto.WebHookDto{ID: 5, URL: controller.GetWebHookURL(organization.AgolaOrganizationRef), Active: true, Events: []string{"push", "tag_push"}}
+	systemHook := gitDto.WebHookDto{ID: 3, URL: controller.GetGitSourceWebHookURL(gitSource.Name), Active: true, Events: []string{}}
+
+	db := mock_repository.NewMockDatabase(ctl)
+	gitlabApi := mock_gitlab.NewMockGitlabInterface(ctl)
+
+	gitlabApi.EXPECT().GetWebHook(gomock.Any(), gomock.Any(), organization.GitPath, int64(5)).Return(&webHook, nil)
+	gitlabApi.EXPECT().DeleteWebHook(gomock.Any(), gomock.Any(), organization.GitPath, int64(5)).Return(nil)
+	gitlabApi.EXPECT().CreateWebHook(gomock.Any(), gomock.Any(), organization.GitPath, organization.AgolaOrganizationRef, gomock.Any()).Return(int64(6), nil)
+	db.EXPECT().GetGitSourceByName(gitSource.Name).Return(&storedGitSource, nil)
+	gitlabApi.EXPECT().GetSystemHook(gomock.Any(), gomock.Any(), int64(3)).Return(&systemHook, nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
+
+	err := webHookManager.SynkWebHook(db, user, &organization, &gitSource, &git.GitGateway{GitlabApi: gitlabApi})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, organization.WebHookStatus, types.WebHookStatusRepaired)
+	assert.Equal(t, organization.WebHookID, int64(6))
+	assert.Equal(t, gitSource.SystemHookID, int64(3))
+}
+
+func TestBitbucketPushWithAgolaConfAndProjectNotExists(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	organization.GitSourceName = "bitbucket"
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+
+	repositoryRef := "repositoryTest"
+
+	data := []byte(`{"eventKey": "repo:refs_changed",
+		"repository": {"id": 1, "slug": "` + repositoryRef + `", "name": "Repository Test", "project": {"key": "` + organization.GitPath + `"}},
+		"changes": [{"refId": "refs/heads/master", "fromHash": "0000000000000000000000000000000000000000", "toHash": "a1b2c3", "type": "ADD"}]}`)
+
+	db := mock_repository.NewMockDatabase(ctl)
+	bitbucketApi := mock_bitbucket.NewMockBitbucketInterface(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	bitbucketApi.EXPECT().GetAgolaConfBranches(gomock.Any(), gomock.Any(), organization.GitPath, repositoryRef).Return(map[string]bool{"master": true}, nil)
+	agolaApi.EXPECT().CreateProject(gomock.Any(), repositoryRef, utils.ConvertToAgolaProjectRef(repositoryRef), gomock.Any(), gitSource.AgolaRemoteSource, gomock.Any()).Return("projectTestID", nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil).Times(2)
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{BitbucketApi: bitbucketApi},
+		AgolaApi:    agolaApi,
+		CommonMutex: &commonMutex,
+	}
+
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Equal(t, err, nil)
+
+	project, exists := organization.Projects[repositoryRef]
+	assert.Check(t, exists)
+	assert.Equal(t, project.GitRepoID, 1)
+	assert.Equal(t, project.AgolaProjectID, "projectTestID")
+	assert.Equal(t, project.Branchs["master"].Name, "master")
+}
+
+func TestBitbucketRepositoryMovedOut(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	organization := (*test.MakeOrganizationList())[0]
+	organization.GitSourceName = "bitbucket"
+	gitSource := (*test.MakeGitSourceMap())[organization.GitSourceName]
+	user := test.MakeUser()
+
+	repositoryRef := "repositoryTest"
+	organization.Projects = make(map[string]model.Project)
+	organization.Projects[repositoryRef] = model.Project{AgolaProjectRef: repositoryRef}
+
+	data := []byte(`{"eventKey": "repo:modified",
+		"old": {"id": 1, "slug": "` + repositoryRef + `", "project": {"key": "` + organization.GitPath + `"}},
+		"new": {"id": 1, "slug": "` + repositoryRef + `", "project": {"key": "OTHER"}}}`)
+
+	db := mock_repository.NewMockDatabase(ctl)
+	agolaApi := mock_agola.NewMockAgolaApiInterface(ctl)
+	commonMutex := utils.NewEventMutex()
+
+	db.EXPECT().GetOrganizationByAgolaRef(organization.AgolaOrganizationRef).Return(&organization, nil)
+	db.EXPECT().GetGitSourceByName(organization.GitSourceName).Return(&gitSource, nil)
+	db.EXPECT().GetUserByUserId(*user.UserID).Return(user, nil)
+	db.EXPECT().GetOrganizationsByGitSource(organization.GitSourceName).Return(&[]model.Organization{organization}, nil)
+	agolaApi.EXPECT().DeleteProject(gomock.Any(), gomock.Any(), utils.ConvertToAgolaProjectRef(repositoryRef), gomock.Any()).Return(nil)
+	db.EXPECT().SaveOrganization(gomock.Any()).Return(nil)
+
+	serviceWebHook := WebHookService{
+		Db:          db,
+		GitGateway:  &git.GitGateway{},
+		AgolaApi:    agolaApi,
+		CommonMutex: &commonMutex,
+	}
+
+	err := serviceWebHook.ProcessWebHookEvent(context.Background(), makeWebHookEvent(organization.AgolaOrganizationRef, data))
+	assert.Equal(t, err, nil)
+
+	_, exists := organization.Projects[repositoryRef]
+	assert.Check(t, !exists)
+}
+
+func makeWebHookEvent(organizationRef string, payload []byte) *model.WebHookEvent {
+	return &model.WebHookEvent{OrganizationRef: organizationRef, Payload: payload, Status: model.WebHookEventPending}
+}
+
+func makeWebHookSignature(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
 }
 
 func setupBranchSynckMock(db *mock_repository.MockDatabase, giteaApi *mock_gitea.MockGiteaInterface, organizationName string, repositoryName string) {
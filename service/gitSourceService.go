@@ -2,6 +2,7 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -91,6 +92,21 @@ func (service *GitSourceService) AddGitSource(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	if !git.IsGitTypeSupported(gitSourceDto.GitType) {
+		log.Println("git type", gitSourceDto.GitType, "not supported")
+		UnprocessableEntityResponse(w, "git type "+string(gitSourceDto.GitType)+" not supported")
+		return
+	}
+
+	if gitSourceDto.GithubAppID != nil {
+		err = git.CheckGithubAppPrivateKey(*gitSourceDto.GithubAppPrivateKeyPath)
+		if err != nil {
+			log.Println("github app private key not valid:", err)
+			UnprocessableEntityResponse(w, "githubAppPrivateKeyPath is not valid")
+			return
+		}
+	}
+
 	if gitSourceDto.GitAPIURL == nil {
 		if gitSourceDto.GitType == types.Github {
 			gitUrl := githubDefaultApiUrl
@@ -108,9 +124,13 @@ func (service *GitSourceService) AddGitSource(w http.ResponseWriter, r *http.Req
 		GitClientID: gitSourceDto.GitClientID,
 		GitSecret:   gitSourceDto.GitClientSecret,
 	}
+	if gitSourceDto.GithubAppID != nil {
+		gitSource.GithubAppID = *gitSourceDto.GithubAppID
+		gitSource.GithubAppPrivateKeyPath = *gitSourceDto.GithubAppPrivateKeyPath
+	}
 
 	if gitSourceDto.AgolaRemoteSourceName == nil || len(*gitSourceDto.AgolaRemoteSourceName) == 0 {
-		gsList, err := service.AgolaApi.GetRemoteSources()
+		gsList, err := service.AgolaApi.GetRemoteSources(r.Context())
 		if err != nil {
 			log.Println("Error in GetRemoteSources:", err)
 			InternalServerError(w)
@@ -140,7 +160,7 @@ func (service *GitSourceService) AddGitSource(w http.ResponseWriter, r *http.Req
 
 		gitSourceDto.AgolaRemoteSourceName = &findRemoteSourceName
 
-		err = service.AgolaApi.CreateRemoteSource(*gitSourceDto.AgolaRemoteSourceName, string(gitSourceDto.GitType), *gitSourceDto.GitAPIURL, *gitSourceDto.AgolaClientID, *gitSourceDto.AgolaClientSecret)
+		err = service.AgolaApi.CreateRemoteSource(r.Context(), *gitSourceDto.AgolaRemoteSourceName, string(gitSourceDto.GitType), *gitSourceDto.GitAPIURL, *gitSourceDto.AgolaClientID, *gitSourceDto.AgolaClientSecret)
 		if err != nil {
 			log.Println("Error in CreateRemoteSource:", err)
 			InternalServerError(w)
@@ -191,7 +211,7 @@ func (service *GitSourceService) RemoveGitSource(w http.ResponseWriter, r *http.
 	service.deleteOrganizationsAndMembersByGitsourceRef(gitSourceName)
 
 	if deleteRemotesource {
-		err := service.AgolaApi.DeleteRemotesource(gitSource.AgolaRemoteSource)
+		err := service.AgolaApi.DeleteRemotesource(r.Context(), gitSource.AgolaRemoteSource)
 		if err != nil {
 			log.Println("DeleteRemotesource error:", err)
 			InternalServerError(w)
@@ -276,6 +296,26 @@ func (service *GitSourceService) UpdateGitSource(w http.ResponseWriter, r *http.
 	if req.GitClientSecret != nil {
 		oldGitSource.GitSecret = *req.GitClientSecret
 	}
+	if req.GithubAppID != nil {
+		oldGitSource.GithubAppID = *req.GithubAppID
+	}
+	if req.GithubAppPrivateKeyPath != nil {
+		oldGitSource.GithubAppPrivateKeyPath = *req.GithubAppPrivateKeyPath
+	}
+
+	if oldGitSource.GithubAppID > 0 {
+		if oldGitSource.GitType != types.Github {
+			UnprocessableEntityResponse(w, "githubApp is valid only for github")
+			return
+		}
+
+		err = git.CheckGithubAppPrivateKey(oldGitSource.GithubAppPrivateKeyPath)
+		if err != nil {
+			log.Println("github app private key not valid:", err)
+			UnprocessableEntityResponse(w, "githubAppPrivateKeyPath is not valid")
+			return
+		}
+	}
 
 	err = service.Db.SaveGitSource(oldGitSource)
 	if err != nil {
@@ -315,6 +355,11 @@ func (service *GitSourceService) GetGitOrganizations(w http.ResponseWriter, r *h
 	}
 
 	organizations, err := service.GitGateway.GetOrganizations(gitSource, user)
+	if errors.Is(err, git.ErrUnsupportedGitType) {
+		log.Println("GitGateway GetOrganizations error:", err.Error())
+		UnprocessableEntityResponse(w, err.Error())
+		return
+	}
 	if err != nil {
 		log.Println("GitGateway GetOrganizations error:", err.Error())
 		InternalServerError(w)
@@ -2,6 +2,7 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"sort"
@@ -10,11 +11,17 @@ import (
 	"github.com/gorilla/mux"
 	agolaApi "wecode.sorint.it/opensource/papagaio-api/api/agola"
 	"wecode.sorint.it/opensource/papagaio-api/api/git"
+	"wecode.sorint.it/opensource/papagaio-api/common"
+	"wecode.sorint.it/opensource/papagaio-api/config"
 	"wecode.sorint.it/opensource/papagaio-api/controller"
 	"wecode.sorint.it/opensource/papagaio-api/dto"
 	"wecode.sorint.it/opensource/papagaio-api/manager"
+	"wecode.sorint.it/opensource/papagaio-api/manager/membersManager"
+	"wecode.sorint.it/opensource/papagaio-api/manager/repositoryManager"
+	"wecode.sorint.it/opensource/papagaio-api/manager/webHookManager"
 	"wecode.sorint.it/opensource/papagaio-api/model"
 	"wecode.sorint.it/opensource/papagaio-api/repository"
+	"wecode.sorint.it/opensource/papagaio-api/types"
 	"wecode.sorint.it/opensource/papagaio-api/utils"
 )
 
@@ -29,13 +36,18 @@ func (service *OrganizationService) GetOrganizations(w http.ResponseWriter, r *h
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
-	org, err := service.Db.GetOrganizations()
-	if err != nil {
+	organizations, err := service.Db.GetOrganizations()
+	if err != nil || organizations == nil {
 		InternalServerError(w)
 		return
 	}
 
-	JSONokResponse(w, org)
+	response := make([]dto.OrganizationDataDto, 0)
+	for _, organization := range *organizations {
+		response = append(response, dto.NewOrganizationDataDto(organization))
+	}
+
+	JSONokResponse(w, response)
 }
 
 // @Summary Create a new Organization in Papagaio/Agola
@@ -104,7 +116,31 @@ func (service *OrganizationService) CreateOrganization(w http.ResponseWriter, r
 		return
 	}
 
-	gitOrganization, _ := service.GitGateway.GetOrganization(gitSource, user, org.GitPath)
+	if req.IsSubgroupPath() && !service.GitGateway.HasCapability(gitSource, git.CapabilitySubgroups) {
+		UnprocessableEntityResponse(w, "gitPath of a subgroup is not supported by the gitSource")
+		return
+	}
+
+	if req.IncludeSubgroups && !service.GitGateway.HasCapability(gitSource, git.CapabilitySubgroups) {
+		UnprocessableEntityResponse(w, "includeSubgroups is not supported by the gitSource")
+		return
+	}
+	org.IncludeSubgroups = req.IncludeSubgroups
+
+	if req.UserNamespace && !service.GitGateway.HasCapability(gitSource, git.CapabilityUserNamespaces) {
+		UnprocessableEntityResponse(w, "userNamespace is not supported by the gitSource")
+		return
+	}
+	org.UserNamespace = req.UserNamespace
+
+	org.CollaboratorsPolicy = req.CollaboratorsPolicy
+	if org.SynkCollaborators() && !service.GitGateway.HasCapability(gitSource, git.CapabilityCollaborators) {
+		UnprocessableEntityResponse(w, "collaboratorsPolicy is not supported by the gitSource")
+		return
+	}
+
+	//a user namespace is found only if it's the namespace of the user
+	gitOrganization, _ := service.GitGateway.GetNamespace(gitSource, user, org)
 	log.Println("gitOrgExists:", gitOrganization != nil)
 	if gitOrganization == nil {
 		log.Println("failed to find organization", org.GitPath, "from git")
@@ -118,8 +154,11 @@ func (service *OrganizationService) CreateOrganization(w http.ResponseWriter, r
 	} else {
 		org.GitName = req.GitPath
 	}
+	if org.UserNamespace {
+		org.GitPath = gitOrganization.Path
+	}
 
-	isOwner, _ := service.GitGateway.IsUserOwner(gitSource, user, org.GitPath)
+	isOwner, _ := service.GitGateway.IsNamespaceOwner(gitSource, user, org)
 	if !isOwner {
 		log.Println("User", user.UserID, "is not owner")
 		response := dto.CreateOrganizationResponseDto{ErrorCode: dto.UserNotOwnerError}
@@ -128,7 +167,12 @@ func (service *OrganizationService) CreateOrganization(w http.ResponseWriter, r
 	}
 
 	if user.AgolaUserRef == nil { //Se diverso da nil l'utente è registrato su Agola
-		agolaUserRef := utils.GetAgolaUserRefByGitUserID(service.AgolaApi, gitSource.AgolaRemoteSource, int64(user.ID))
+		agolaUserRef, err := utils.GetAgolaUserRefByGitUserID(r.Context(), service.AgolaApi, gitSource.AgolaRemoteSource, int64(user.ID))
+		if err != nil {
+			log.Println("GetAgolaUserRefByGitUserID error:", err)
+			InternalServerError(w)
+			return
+		}
 		if agolaUserRef == nil {
 			log.Println("User not found in Agola")
 			response := dto.CreateOrganizationResponseDto{ErrorCode: dto.UserAgolaRefNotFoundError}
@@ -139,7 +183,7 @@ func (service *OrganizationService) CreateOrganization(w http.ResponseWriter, r
 		user.AgolaUserRef = agolaUserRef
 
 		if user.AgolaToken == nil {
-			err = service.AgolaApi.CreateUserToken(user)
+			err = service.AgolaApi.CreateUserToken(r.Context(), user)
 			if err != nil {
 				log.Println("Error in CreateUserToken:", err)
 				InternalServerError(w)
@@ -147,7 +191,7 @@ func (service *OrganizationService) CreateOrganization(w http.ResponseWriter, r
 			}
 		}
 
-		err := service.Db.SaveUser(user)
+		err = service.Db.SaveUser(user)
 		if err != nil {
 			log.Println("Error in SaveUser:", err)
 			InternalServerError(w)
@@ -180,49 +224,83 @@ func (service *OrganizationService) CreateOrganization(w http.ResponseWriter, r
 		}
 	}
 
-	org.UserIDCreator = *user.UserID
-	org.UserIDConnected = *user.UserID
-
-	org.WebHookID, err = service.GitGateway.CreateWebHook(gitSource, user, org.GitPath, org.AgolaOrganizationRef)
-	if err != nil {
-		log.Println("failed to creare webhook:", err)
-		InternalServerError(w)
+	//the repositories events of some git providers are sent only by the system hook of the git source
+	err = webHookManager.CreateSystemHook(service.Db, user, gitSource, service.GitGateway)
+	if errors.Is(err, git.ErrSystemHookForbidden) {
+		log.Println("User", user.UserID, "can't create the system hook of", gitSource.Name, ":", err)
+		response := dto.CreateOrganizationResponseDto{ErrorCode: dto.GitSystemHookForbiddenError}
+		JSONokResponse(w, response)
 		return
 	}
-
-	agolaOrganizationExists, agolaOrganizationID, err := service.AgolaApi.CheckOrganizationExists(org)
 	if err != nil {
-		log.Println("Agola CheckOrganizationExists error:", err)
+		log.Println("failed to create the system hook:", err)
 		InternalServerError(w)
 		return
 	}
 
-	log.Println("agolaOrganizationExists:", agolaOrganizationExists)
-	if agolaOrganizationExists {
-		log.Println("organization", org.AgolaOrganizationRef, "just exists in Agola")
-		if !forceCreate {
-			err = service.GitGateway.DeleteWebHook(gitSource, user, org.GitPath, org.WebHookID)
-			if err != nil {
-				log.Println("DeleteWebHook error:", err)
-			}
+	org.UserIDCreator = *user.UserID
+	org.UserIDConnected = *user.UserID
 
-			response := dto.CreateOrganizationResponseDto{ErrorCode: dto.AgolaOrganizationExistsError}
-			JSONokResponse(w, response)
+	//the user namespaces have a webhook for every repository, created with the projects, and no Agola organization
+	if org.UserNamespace {
+		org.AgolaUserRef = *user.AgolaUserRef
+		webHookSecret, err := common.GenerateWebHookSecret()
+		if err == nil {
+			err = org.SetWebHookSecret(webHookSecret)
+		}
+		if err != nil {
+			log.Println("failed to generate webhook secret:", err)
+			InternalServerError(w)
 			return
 		}
-		org.ID = agolaOrganizationID
 	} else {
-		org.ID, err = service.AgolaApi.CreateOrganization(org, org.Visibility)
+		var webHookSecret string
+		org.WebHookID, webHookSecret, err = service.GitGateway.CreateWebHook(gitSource, user, org.GitPath, org.AgolaOrganizationRef)
 		if err != nil {
-			log.Println("failed to create organization", org.AgolaOrganizationRef, "in agola:", err)
-			err := service.GitGateway.DeleteWebHook(gitSource, user, org.GitPath, org.WebHookID)
-			if err != nil {
-				log.Println("DeleteWebHook error:", err)
-			}
+			log.Println("failed to creare webhook:", err)
+			InternalServerError(w)
+			return
+		}
+		if err = org.SetWebHookSecret(webHookSecret); err != nil {
+			log.Println("failed to encrypt webhook secret:", err)
+			InternalServerError(w)
+			return
+		}
 
+		agolaOrganizationExists, agolaOrganizationID, err := service.AgolaApi.CheckOrganizationExists(r.Context(), org)
+		if err != nil {
+			log.Println("Agola CheckOrganizationExists error:", err)
 			InternalServerError(w)
 			return
 		}
+
+		log.Println("agolaOrganizationExists:", agolaOrganizationExists)
+		if agolaOrganizationExists {
+			log.Println("organization", org.AgolaOrganizationRef, "just exists in Agola")
+			if !forceCreate {
+				err = service.GitGateway.DeleteWebHook(gitSource, user, org.GitPath, org.WebHookID)
+				if err != nil {
+					log.Println("DeleteWebHook error:", err)
+				}
+
+				response := dto.CreateOrganizationResponseDto{ErrorCode: dto.AgolaOrganizationExistsError}
+				JSONokResponse(w, response)
+				return
+			}
+			org.ID = agolaOrganizationID
+		} else {
+			org.ID, err = service.AgolaApi.CreateOrganization(r.Context(), org, org.Visibility)
+			if err != nil {
+				log.Println("failed to create organization", org.AgolaOrganizationRef, "in agola:", err)
+				err := service.GitGateway.DeleteWebHook(gitSource, user, org.GitPath, org.WebHookID)
+				if err != nil {
+					log.Println("DeleteWebHook error:", err)
+				}
+
+				InternalServerError(w)
+				return
+			}
+		}
 	}
 
 	log.Println("Organization created: ", org.AgolaOrganizationRef, " by:", org.UserIDCreator)
@@ -235,7 +313,7 @@ func (service *OrganizationService) CreateOrganization(w http.ResponseWriter, r
 		return
 	}
 
-	manager.StartOrganizationCheckout(service.Db, user, org, gitSource, service.AgolaApi, service.GitGateway)
+	manager.StartOrganizationCheckout(r.Context(), service.Db, user, org, gitSource, service.AgolaApi, service.GitGateway)
 
 	mutex.Unlock()
 	utils.ReleaseOrganizationMutex(org.AgolaOrganizationRef, service.CommonMutex)
@@ -298,7 +376,7 @@ func (service *OrganizationService) DeleteOrganization(w http.ResponseWriter, r
 		return
 	}
 
-	isOwner, _ := service.GitGateway.IsUserOwner(gitSource, userRequest, organization.GitPath)
+	isOwner, _ := service.GitGateway.IsNamespaceOwner(gitSource, userRequest, organization)
 	if !isOwner {
 		log.Println("User", userRequest.UserID, "is not owner")
 		response := dto.DeleteOrganizationResponseDto{ErrorCode: dto.UserNotOwnerError}
@@ -314,7 +392,7 @@ func (service *OrganizationService) DeleteOrganization(w http.ResponseWriter, r
 	}
 
 	if !internalonly {
-		err = service.AgolaApi.DeleteOrganization(organization, userCreator)
+		err = service.AgolaApi.DeleteOrganization(r.Context(), organization, userCreator)
 		if err != nil {
 			log.Println("error in agola DeleteOrganization:", err)
 			InternalServerError(w)
@@ -322,11 +400,15 @@ func (service *OrganizationService) DeleteOrganization(w http.ResponseWriter, r
 		}
 	}
 
-	err = service.GitGateway.DeleteWebHook(gitSource, userCreator, organization.GitPath, organization.WebHookID)
-	if err != nil {
-		log.Println("DeleteWebHook error:", err)
-		InternalServerError(w)
-		return
+	if organization.UserNamespace {
+		repositoryManager.DeleteRepositoryWebHooks(userCreator, organization, gitSource, service.GitGateway)
+	} else {
+		err = service.GitGateway.DeleteWebHook(gitSource, userCreator, organization.GitPath, organization.WebHookID)
+		if err != nil {
+			log.Println("DeleteWebHook error:", err)
+			InternalServerError(w)
+			return
+		}
 	}
 
 	err = service.Db.DeleteOrganization(organization.AgolaOrganizationRef)
@@ -390,7 +472,7 @@ func (service *OrganizationService) AddExternalUser(w http.ResponseWriter, r *ht
 		return
 	}
 
-	isOwner, _ := service.GitGateway.IsUserOwner(gitSource, user, organization.GitPath)
+	isOwner, _ := service.GitGateway.IsNamespaceOwner(gitSource, user, organization)
 	if !isOwner {
 		log.Println("User", userId, "is not owner")
 		JSONokResponse(w, dto.ExternalUsersDto{ErrorCode: dto.UserNotOwnerError})
@@ -472,7 +554,7 @@ func (service OrganizationService) GetExternalUsers(w http.ResponseWriter, r *ht
 		return
 	}
 
-	isOwner, _ := service.GitGateway.IsUserOwner(gitSource, user, organization.GitPath)
+	isOwner, _ := service.GitGateway.IsNamespaceOwner(gitSource, user, organization)
 	if !isOwner {
 		log.Println("User", userId, "is not owner")
 		JSONokResponse(w, dto.ExternalUsersDto{ErrorCode: dto.UserNotOwnerError})
@@ -535,7 +617,7 @@ func (service *OrganizationService) RemoveExternalUser(w http.ResponseWriter, r
 		return
 	}
 
-	isOwner, _ := service.GitGateway.IsUserOwner(gitSource, user, organization.GitPath)
+	isOwner, _ := service.GitGateway.IsNamespaceOwner(gitSource, user, organization)
 	if !isOwner {
 		log.Println("User", userId, "is not owner")
 
@@ -573,6 +655,469 @@ func (service *OrganizationService) RemoveExternalUser(w http.ResponseWriter, r
 	JSONokResponse(w, dto.ExternalUsersDto{ErrorCode: dto.NoError})
 }
 
+// @Summary Get the members mapping
+// @Description Return the Agola roles of the git teams and roles and the Agola users ignored by the members synk
+// @Tags Organization
+// @Produce  json
+// @Param organizationRef path string true "Organization Name"
+// @Success 200 {object} dto.MembersMappingResponseDto "ok"
+// @Failure 404 "not found"
+// @Router /membersmapping/{organizationRef} [get]
+// @Security ApiKeyToken
+func (service *OrganizationService) GetMembersMapping(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	vars := mux.Vars(r)
+	organizationRef := vars["organizationRef"]
+
+	userId := r.Context().Value(controller.UserIdParameter).(uint64)
+	user, _ := service.Db.GetUserByUserId(userId)
+	if user == nil {
+		log.Println("User", userId, "not found")
+		InternalServerError(w)
+		return
+	}
+
+	organization, err := service.Db.GetOrganizationByAgolaRef(organizationRef)
+	if err != nil || organization == nil {
+		NotFoundResponse(w)
+		return
+	}
+
+	gitSource, err := service.Db.GetGitSourceByName(organization.GitSourceName)
+	if err != nil || gitSource == nil {
+		log.Println("gitSource not found err:", err)
+		InternalServerError(w)
+		return
+	}
+
+	isOwner, _ := service.GitGateway.IsNamespaceOwner(gitSource, user, organization)
+	if !isOwner {
+		log.Println("User", userId, "is not owner")
+		JSONokResponse(w, dto.MembersMappingResponseDto{ErrorCode: dto.UserNotOwnerError})
+		return
+	}
+
+	membersMapping := dto.MembersMappingDto{
+		Teams:        organization.MembersMapping.Teams,
+		GitRoles:     organization.MembersMapping.GitRoles,
+		IgnoredUsers: organization.MembersMapping.IgnoredUsers,
+	}
+	if membersMapping.Teams == nil {
+		membersMapping.Teams = make(map[string]types.MemberRole)
+	}
+	if membersMapping.GitRoles == nil {
+		membersMapping.GitRoles = make(map[string]types.MemberRole)
+	}
+	if membersMapping.IgnoredUsers == nil {
+		membersMapping.IgnoredUsers = []string{}
+	}
+
+	JSONokResponse(w, dto.MembersMappingResponseDto{ErrorCode: dto.NoError, MembersMapping: &membersMapping})
+}
+
+// @Summary Save the members mapping
+// @Description Set the Agola roles (owner, member or none) of the git teams and, separately, of the git roles and the Agola users ignored by the members synk. The members without a mapped team or git role keep the default rule
+// @Tags Organization
+// @Produce  json
+// @Param organizationRef path string true "Organization Name"
+// @Param membersMapping body dto.MembersMappingDto true "Members mapping"
+// @Success 200 {object} dto.MembersMappingResponseDto "ok"
+// @Failure 404 "not found"
+// @Failure 422 "invalid mapping"
+// @Router /membersmapping/{organizationRef} [put]
+// @Security ApiKeyToken
+func (service *OrganizationService) SaveMembersMapping(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	vars := mux.Vars(r)
+	organizationRef := vars["organizationRef"]
+
+	userId := r.Context().Value(controller.UserIdParameter).(uint64)
+	user, _ := service.Db.GetUserByUserId(userId)
+	if user == nil {
+		log.Println("User", userId, "not found")
+		InternalServerError(w)
+		return
+	}
+
+	var req dto.MembersMappingDto
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Println("parsing error:", err)
+		UnprocessableEntityResponse(w, "invalid members mapping")
+		return
+	}
+	if err := req.IsValid(); err != nil {
+		UnprocessableEntityResponse(w, err.Error())
+		return
+	}
+
+	mutex := utils.ReserveOrganizationMutex(organizationRef, service.CommonMutex)
+	mutex.Lock()
+
+	locked := true
+	defer utils.ReleaseOrganizationMutexDefer(organizationRef, service.CommonMutex, mutex, &locked)
+
+	organization, err := service.Db.GetOrganizationByAgolaRef(organizationRef)
+	if err != nil || organization == nil {
+		NotFoundResponse(w)
+		return
+	}
+
+	gitSource, err := service.Db.GetGitSourceByName(organization.GitSourceName)
+	if err != nil || gitSource == nil {
+		log.Println("gitSource not found err:", err)
+		InternalServerError(w)
+		return
+	}
+
+	isOwner, _ := service.GitGateway.IsNamespaceOwner(gitSource, user, organization)
+	if !isOwner {
+		log.Println("User", userId, "is not owner")
+		JSONokResponse(w, dto.MembersMappingResponseDto{ErrorCode: dto.UserNotOwnerError})
+		return
+	}
+
+	organization.MembersMapping = model.MembersMapping{Teams: req.Teams, GitRoles: req.GitRoles, IgnoredUsers: req.IgnoredUsers}
+	err = service.Db.SaveOrganization(organization)
+
+	mutex.Unlock()
+	utils.ReleaseOrganizationMutex(organizationRef, service.CommonMutex)
+	locked = false
+
+	if err != nil {
+		log.Println("SaveOrganization error:", err)
+		InternalServerError(w)
+		return
+	}
+
+	JSONokResponse(w, dto.MembersMappingResponseDto{ErrorCode: dto.NoError, MembersMapping: &req})
+}
+
+// @Summary Members synk dry-run
+// @Description Return the Agola members the synk would add, update and remove, nothing is applied
+// @Tags Organization
+// @Produce  json
+// @Param organizationRef path string true "Organization Name"
+// @Success 200 {object} dto.MembersPlanResponseDto "ok"
+// @Failure 404 "not found"
+// @Router /membersplan/{organizationRef} [get]
+// @Security ApiKeyToken
+func (service *OrganizationService) GetMembersPlan(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	vars := mux.Vars(r)
+	organizationRef := vars["organizationRef"]
+
+	userId := r.Context().Value(controller.UserIdParameter).(uint64)
+	user, _ := service.Db.GetUserByUserId(userId)
+	if user == nil {
+		log.Println("User", userId, "not found")
+		InternalServerError(w)
+		return
+	}
+
+	organization, err := service.Db.GetOrganizationByAgolaRef(organizationRef)
+	if err != nil || organization == nil {
+		NotFoundResponse(w)
+		return
+	}
+
+	gitSource, err := service.Db.GetGitSourceByName(organization.GitSourceName)
+	if err != nil || gitSource == nil {
+		log.Println("gitSource not found err:", err)
+		InternalServerError(w)
+		return
+	}
+
+	isOwner, _ := service.GitGateway.IsNamespaceOwner(gitSource, user, organization)
+	if !isOwner {
+		log.Println("User", userId, "is not owner")
+		JSONokResponse(w, dto.MembersPlanResponseDto{ErrorCode: dto.UserNotOwnerError})
+		return
+	}
+
+	plan, err := membersManager.GetMembersPlan(r.Context(), organization, gitSource, service.AgolaApi, service.GitGateway, user)
+	if err != nil {
+		log.Println("GetMembersPlan error:", err)
+		InternalServerError(w)
+		return
+	}
+
+	JSONokResponse(w, dto.MembersPlanResponseDto{ErrorCode: dto.NoError, Plan: plan})
+}
+
+// @Summary Save the collaborators policy
+// @Description Set how the repository collaborators are synchronized: ignore, member (Agola organization members) or project (access to the runs of the projects of their repositories). Applied by the next members synk, an Agola owner is never downgraded
+// @Tags Organization
+// @Produce  json
+// @Param organizationRef path string true "Organization Name"
+// @Param collaboratorsPolicy body dto.CollaboratorsPolicyDto true "Collaborators policy"
+// @Success 200 {object} dto.CollaboratorsPolicyResponseDto "ok"
+// @Failure 404 "not found"
+// @Failure 422 "invalid policy"
+// @Router /collaboratorspolicy/{organizationRef} [put]
+// @Security ApiKeyToken
+func (service *OrganizationService) SaveCollaboratorsPolicy(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	vars := mux.Vars(r)
+	organizationRef := vars["organizationRef"]
+
+	userId := r.Context().Value(controller.UserIdParameter).(uint64)
+	user, _ := service.Db.GetUserByUserId(userId)
+	if user == nil {
+		log.Println("User", userId, "not found")
+		InternalServerError(w)
+		return
+	}
+
+	var req dto.CollaboratorsPolicyDto
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Println("parsing error:", err)
+		UnprocessableEntityResponse(w, "invalid collaborators policy")
+		return
+	}
+	if err := req.IsValid(); err != nil {
+		UnprocessableEntityResponse(w, err.Error())
+		return
+	}
+
+	mutex := utils.ReserveOrganizationMutex(organizationRef, service.CommonMutex)
+	mutex.Lock()
+
+	locked := true
+	defer utils.ReleaseOrganizationMutexDefer(organizationRef, service.CommonMutex, mutex, &locked)
+
+	organization, err := service.Db.GetOrganizationByAgolaRef(organizationRef)
+	if err != nil || organization == nil {
+		NotFoundResponse(w)
+		return
+	}
+
+	gitSource, err := service.Db.GetGitSourceByName(organization.GitSourceName)
+	if err != nil || gitSource == nil {
+		log.Println("gitSource not found err:", err)
+		InternalServerError(w)
+		return
+	}
+
+	isOwner, _ := service.GitGateway.IsNamespaceOwner(gitSource, user, organization)
+	if !isOwner {
+		log.Println("User", userId, "is not owner")
+		JSONokResponse(w, dto.CollaboratorsPolicyResponseDto{ErrorCode: dto.UserNotOwnerError})
+		return
+	}
+
+	organization.CollaboratorsPolicy = req.CollaboratorsPolicy
+	if organization.SynkCollaborators() && !service.GitGateway.HasCapability(gitSource, git.CapabilityCollaborators) {
+		UnprocessableEntityResponse(w, "collaboratorsPolicy is not supported by the gitSource")
+		return
+	}
+	err = service.Db.SaveOrganization(organization)
+
+	mutex.Unlock()
+	utils.ReleaseOrganizationMutex(organizationRef, service.CommonMutex)
+	locked = false
+
+	if err != nil {
+		log.Println("SaveOrganization error:", err)
+		InternalServerError(w)
+		return
+	}
+
+	JSONokResponse(w, dto.CollaboratorsPolicyResponseDto{ErrorCode: dto.NoError, CollaboratorsPolicy: req.CollaboratorsPolicy})
+}
+
+// @Summary Get the Agola secrets and variables
+// @Description Return the secrets and variables added to every Agola project of the organization, the values of the secrets are not returned
+// @Tags Organization
+// @Produce  json
+// @Param organizationRef path string true "Organization Name"
+// @Success 200 {object} dto.AgolaVariablesDto "ok"
+// @Failure 404 "not found"
+// @Router /agolavariables/{organizationRef} [get]
+// @Security ApiKeyToken
+func (service *OrganizationService) GetAgolaVariables(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	organizationRef := vars["organizationRef"]
+
+	organization, _ := service.Db.GetOrganizationByAgolaRef(organizationRef)
+	if organization == nil {
+		log.Println("organization", organizationRef, "not found")
+		NotFoundResponse(w)
+		return
+	}
+
+	JSONokResponse(w, dto.NewAgolaVariablesDto(&organization.AgolaVariables))
+}
+
+// @Summary Save an Agola secret
+// @Description Add or replace a secret of the Agola projects of the organization, the values are stored encrypted and applied by the organizations synk
+// @Tags Organization
+// @Produce  json
+// @Param organizationRef path string true "Organization Name"
+// @Param secretName path string true "Secret Name"
+// @Param secret body dto.SaveAgolaSecretRequestDto true "Secret data"
+// @Success 200 {object} dto.AgolaVariablesDto "ok"
+// @Failure 404 "not found"
+// @Failure 422 "invalid secret"
+// @Router /agolavariables/{organizationRef}/secrets/{secretName} [put]
+// @Security ApiKeyToken
+func (service *OrganizationService) SaveAgolaSecret(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	organizationRef := vars["organizationRef"]
+	secretName := vars["secretName"]
+
+	var req dto.SaveAgolaSecretRequestDto
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Println("parsing error:", err)
+		UnprocessableEntityResponse(w, "invalid secret")
+		return
+	}
+	if err := req.IsValid(); err != nil {
+		UnprocessableEntityResponse(w, err.Error())
+		return
+	}
+
+	secret := model.AgolaSecret{Name: secretName, Data: make(map[string]string)}
+	for key, value := range req.Data {
+		secret.Data[key], err = common.EncryptSecretValue(config.Config.SecretsEncryptionKey, value)
+		if err != nil {
+			log.Println("EncryptSecretValue error:", err)
+			InternalServerError(w)
+			return
+		}
+	}
+
+	service.changeAgolaVariables(w, organizationRef, func(agolaVariables *model.AgolaVariables) error {
+		agolaVariables.SetSecret(secret)
+		return nil
+	})
+}
+
+// @Summary Remove an Agola secret
+// @Description Remove the secret from the organization, it is deleted from the Agola projects by the organizations synk. A secret used by a variable can't be removed
+// @Tags Organization
+// @Produce  json
+// @Param organizationRef path string true "Organization Name"
+// @Param secretName path string true "Secret Name"
+// @Success 200 {object} dto.AgolaVariablesDto "ok"
+// @Failure 404 "not found"
+// @Failure 422 "secret used"
+// @Router /agolavariables/{organizationRef}/secrets/{secretName} [delete]
+// @Security ApiKeyToken
+func (service *OrganizationService) RemoveAgolaSecret(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	organizationRef := vars["organizationRef"]
+	secretName := vars["secretName"]
+
+	service.changeAgolaVariables(w, organizationRef, func(agolaVariables *model.AgolaVariables) error {
+		return agolaVariables.RemoveSecret(secretName)
+	})
+}
+
+// @Summary Save an Agola variable
+// @Description Add or replace a variable of the Agola projects of the organization, applied by the organizations synk
+// @Tags Organization
+// @Produce  json
+// @Param organizationRef path string true "Organization Name"
+// @Param variableName path string true "Variable Name"
+// @Param variable body dto.SaveAgolaVariableRequestDto true "Variable values"
+// @Success 200 {object} dto.AgolaVariablesDto "ok"
+// @Failure 404 "not found"
+// @Failure 422 "invalid variable"
+// @Router /agolavariables/{organizationRef}/variables/{variableName} [put]
+// @Security ApiKeyToken
+func (service *OrganizationService) SaveAgolaVariable(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	organizationRef := vars["organizationRef"]
+	variableName := vars["variableName"]
+
+	var req dto.SaveAgolaVariableRequestDto
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Println("parsing error:", err)
+		UnprocessableEntityResponse(w, "invalid variable")
+		return
+	}
+	if err := req.IsValid(); err != nil {
+		UnprocessableEntityResponse(w, err.Error())
+		return
+	}
+
+	variable := model.AgolaVariable{Name: variableName, Values: make([]model.AgolaVariableValue, 0)}
+	for _, value := range req.Values {
+		variable.Values = append(variable.Values, model.AgolaVariableValue{SecretName: value.SecretName, SecretVar: value.SecretVar})
+	}
+
+	service.changeAgolaVariables(w, organizationRef, func(agolaVariables *model.AgolaVariables) error {
+		agolaVariables.SetVariable(variable)
+		return nil
+	})
+}
+
+// @Summary Remove an Agola variable
+// @Description Remove the variable from the organization, it is deleted from the Agola projects by the organizations synk
+// @Tags Organization
+// @Produce  json
+// @Param organizationRef path string true "Organization Name"
+// @Param variableName path string true "Variable Name"
+// @Success 200 {object} dto.AgolaVariablesDto "ok"
+// @Failure 404 "not found"
+// @Router /agolavariables/{organizationRef}/variables/{variableName} [delete]
+// @Security ApiKeyToken
+func (service *OrganizationService) RemoveAgolaVariable(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	organizationRef := vars["organizationRef"]
+	variableName := vars["variableName"]
+
+	service.changeAgolaVariables(w, organizationRef, func(agolaVariables *model.AgolaVariables) error {
+		return agolaVariables.RemoveVariable(variableName)
+	})
+}
+
+//Apply the change to the secrets and variables of the organization and save it. An error of the change is a 404 if the item is not found, a 422 otherwise
+func (service *OrganizationService) changeAgolaVariables(w http.ResponseWriter, organizationRef string, change func(agolaVariables *model.AgolaVariables) error) {
+	mutex := utils.ReserveOrganizationMutex(organizationRef, service.CommonMutex)
+	mutex.Lock()
+
+	locked := true
+	defer utils.ReleaseOrganizationMutexDefer(organizationRef, service.CommonMutex, mutex, &locked)
+
+	organization, _ := service.Db.GetOrganizationByAgolaRef(organizationRef)
+	if organization == nil {
+		log.Println("organization", organizationRef, "not found")
+		NotFoundResponse(w)
+		return
+	}
+
+	if err := change(&organization.AgolaVariables); err != nil {
+		if errors.Is(err, model.ErrAgolaVariableNotFound) {
+			NotFoundResponse(w)
+		} else {
+			UnprocessableEntityResponse(w, err.Error())
+		}
+		return
+	}
+
+	err := service.Db.SaveOrganization(organization)
+	if err != nil {
+		log.Println("SaveOrganization error:", err)
+		InternalServerError(w)
+		return
+	}
+
+	JSONokResponse(w, dto.NewAgolaVariablesDto(&organization.AgolaVariables))
+}
+
 // @Summary Get Report
 // @Description Obtain a full report of all organizations. If the "onlyowner" query parameter is specified, only the organizations the user owns will be listed.
 // @Tags Organization
@@ -613,7 +1158,7 @@ func (service *OrganizationService) GetReport(w http.ResponseWriter, r *http.Req
 	for _, organization := range *organizations {
 		if strings.Compare(organization.GitSourceName, user.GitSourceName) == 0 {
 			if onlyOwner {
-				isOwner, _ := service.GitGateway.IsUserOwner(gitsource, user, organization.GitPath)
+				isOwner, _ := service.GitGateway.IsNamespaceOwner(gitsource, user, &organization)
 				if !isOwner {
 					continue
 				}
@@ -758,7 +1303,7 @@ func (service *OrganizationService) GetAgolaOrganizations(w http.ResponseWriter,
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
-	organizations, err := service.AgolaApi.GetOrganizations()
+	organizations, err := service.AgolaApi.GetOrganizations(r.Context())
 	if err != nil {
 		InternalServerError(w)
 		return
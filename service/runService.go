@@ -0,0 +1,345 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"wecode.sorint.it/opensource/papagaio-api/api/agola"
+	"wecode.sorint.it/opensource/papagaio-api/api/git"
+	"wecode.sorint.it/opensource/papagaio-api/controller"
+	"wecode.sorint.it/opensource/papagaio-api/dto"
+	"wecode.sorint.it/opensource/papagaio-api/model"
+	"wecode.sorint.it/opensource/papagaio-api/repository"
+	"wecode.sorint.it/opensource/papagaio-api/types"
+)
+
+type RunService struct {
+	Db         repository.Database
+	AgolaApi   agola.AgolaApiInterface
+	GitGateway *git.GitGateway
+}
+
+// @Summary Restart, stop or cancel a run
+// @Description Send the action to Agola with the token of the user, the Agola permissions of the user apply. The restart returns the new run
+// @Tags Runs
+// @Produce  json
+// @Param projectRef path string true "Agola project ID"
+// @Param runNumber path int true "Run number"
+// @Param action body dto.RunActionRequestDto true "Run action"
+// @Success 200 {object} agola.RunDto "ok"
+// @Failure 403 "forbidden"
+// @Failure 404 "not found"
+// @Failure 422 "invalid action"
+// @Router /runs/{projectRef}/{runNumber}/actions [post]
+// @Security ApiKeyToken
+func (service *RunService) RunAction(w http.ResponseWriter, r *http.Request) {
+	projectRef, runNumber, err := getRunParameters(r)
+	if err != nil {
+		UnprocessableEntityResponse(w, err.Error())
+		return
+	}
+
+	var req dto.RunActionRequestDto
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Println("parsing error:", err)
+		UnprocessableEntityResponse(w, "invalid run action")
+		return
+	}
+	if err := req.IsValid(); err != nil {
+		UnprocessableEntityResponse(w, err.Error())
+		return
+	}
+
+	user := service.getAgolaUser(w, r)
+	if user == nil {
+		return
+	}
+
+	var run *agola.RunDto
+	switch req.ActionType {
+	case dto.RunActionRestart:
+		run, err = service.AgolaApi.RestartRun(r.Context(), user, projectRef, runNumber, req.FromScratch)
+	case dto.RunActionStop:
+		run, err = service.AgolaApi.StopRun(r.Context(), user, projectRef, runNumber)
+	case dto.RunActionCancel:
+		run, err = service.AgolaApi.CancelRun(r.Context(), user, projectRef, runNumber)
+	}
+	if err != nil {
+		log.Println("run action", req.ActionType, "error:", err)
+		agolaErrorResponse(w, err)
+		return
+	}
+
+	JSONokResponse(w, run)
+}
+
+// @Summary Approve a task
+// @Description Approve a task waiting for approval with the token of the user, the Agola permissions of the user apply
+// @Tags Runs
+// @Produce  json
+// @Param projectRef path string true "Agola project ID"
+// @Param runNumber path int true "Run number"
+// @Param taskId path string true "Task ID"
+// @Success 200 "ok"
+// @Failure 403 "forbidden"
+// @Failure 404 "not found"
+// @Router /runs/{projectRef}/{runNumber}/tasks/{taskId}/approve [post]
+// @Security ApiKeyToken
+func (service *RunService) ApproveTask(w http.ResponseWriter, r *http.Request) {
+	projectRef, runNumber, err := getRunParameters(r)
+	if err != nil {
+		UnprocessableEntityResponse(w, err.Error())
+		return
+	}
+	taskID := mux.Vars(r)["taskId"]
+
+	user := service.getAgolaUser(w, r)
+	if user == nil {
+		return
+	}
+
+	err = service.AgolaApi.ApproveTask(r.Context(), user, projectRef, runNumber, taskID)
+	if err != nil {
+		log.Println("ApproveTask error:", err)
+		agolaErrorResponse(w, err)
+		return
+	}
+}
+
+// @Summary Stream the logs of a step
+// @Description Server-sent events with the log lines of the step, followed while the step runs. The event id is the line number: on reconnect the lines up to the Last-Event-ID header are skipped. The end event is sent when the step is finished
+// @Tags Runs
+// @Produce  text/event-stream
+// @Param projectRef path string true "Agola project ID"
+// @Param runNumber path int true "Run number"
+// @Param taskId path string true "Task ID"
+// @Param step path string true "Step number or setup"
+// @Param Last-Event-ID header int false "Last line received"
+// @Success 200 "ok"
+// @Failure 403 "forbidden"
+// @Failure 404 "not found"
+// @Router /runs/{projectRef}/{runNumber}/tasks/{taskId}/steps/{step}/logs [get]
+// @Security ApiKeyToken
+func (service *RunService) StreamLogs(w http.ResponseWriter, r *http.Request) {
+	projectRef, runNumber, err := getRunParameters(r)
+	if err != nil {
+		UnprocessableEntityResponse(w, err.Error())
+		return
+	}
+
+	vars := mux.Vars(r)
+	taskID := vars["taskId"]
+	step, err := getStepParameter(vars["step"])
+	if err != nil {
+		UnprocessableEntityResponse(w, err.Error())
+		return
+	}
+
+	var lastEventID uint64
+	if lastEventIDHeader := r.Header.Get("Last-Event-ID"); len(lastEventIDHeader) > 0 {
+		lastEventID, err = strconv.ParseUint(lastEventIDHeader, 10, 64)
+		if err != nil {
+			UnprocessableEntityResponse(w, "Last-Event-ID is not valid")
+			return
+		}
+	}
+
+	userId, _ := r.Context().Value(controller.UserIdParameter).(uint64)
+	user, _ := service.Db.GetUserByUserId(userId)
+	if user == nil {
+		log.Println("User", userId, "not found")
+		InternalServerError(w)
+		return
+	}
+
+	if !service.checkUserProject(w, user, projectRef) {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		log.Println("StreamLogs: streaming not supported by the response writer")
+		InternalServerError(w)
+		return
+	}
+
+	stream, err := service.AgolaApi.GetLogsStream(r.Context(), projectRef, runNumber, taskID, step)
+	if err != nil {
+		log.Println("GetLogsStream error:", err)
+		agolaErrorResponse(w, err)
+		return
+	}
+	defer stream.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	reader := bufio.NewReader(stream)
+	var lineNumber uint64
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			//the client reconnects and resumes from the last line received
+			log.Println("StreamLogs read error:", err)
+			return
+		}
+
+		if len(line) > 0 {
+			lineNumber++
+			if lineNumber > lastEventID {
+				writeLogEvent(w, lineNumber, line)
+				flusher.Flush()
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	fmt.Fprint(w, "event: end\ndata:\n\n")
+	flusher.Flush()
+}
+
+//Write the log line as an event, a carriage return in the line starts a new data field
+func writeLogEvent(w io.Writer, lineNumber uint64, line string) {
+	fmt.Fprintf(w, "id: %d\n", lineNumber)
+	for _, data := range strings.Split(strings.TrimRight(line, "\r\n"), "\r") {
+		fmt.Fprintf(w, "data: %s\n", data)
+	}
+	fmt.Fprint(w, "\n")
+}
+
+//The project must be in an organization of the git source of the user, a private organization must be visible to the user on the git source
+//or the user must be a collaborator of the project with the project collaborators policy.
+//Return false if the response is already written
+func (service *RunService) checkUserProject(w http.ResponseWriter, user *model.User, projectRef string) bool {
+	organizations, err := service.Db.GetOrganizationsByGitSource(user.GitSourceName)
+	if err != nil || organizations == nil {
+		log.Println("GetOrganizationsByGitSource error:", err)
+		InternalServerError(w)
+		return false
+	}
+
+	var organization *model.Organization
+	var userProject model.Project
+	for i := range *organizations {
+		for _, project := range (*organizations)[i].Projects {
+			if strings.Compare(project.AgolaProjectID, projectRef) == 0 {
+				organization = &(*organizations)[i]
+				userProject = project
+			}
+		}
+	}
+	if organization == nil {
+		log.Println("project", projectRef, "not found in the organizations of", user.GitSourceName)
+		NotFoundResponse(w)
+		return false
+	}
+
+	if organization.Visibility == types.Public {
+		return true
+	}
+	if organization.HasProjectCollaborators() && userProject.IsCollaborator(user.ID) {
+		return true
+	}
+
+	gitSource, _ := service.Db.GetGitSourceByName(user.GitSourceName)
+	if gitSource == nil {
+		log.Println("gitSource", user.GitSourceName, "not found")
+		InternalServerError(w)
+		return false
+	}
+
+	namespace, err := service.GitGateway.GetNamespace(gitSource, user, organization)
+	if err != nil {
+		log.Println("GetNamespace error:", err)
+		InternalServerError(w)
+		return false
+	}
+	if namespace == nil {
+		log.Println("user", user.ID, "not authorized to see organization", organization.AgolaOrganizationRef)
+		ForbiddenResponse(w)
+		return false
+	}
+
+	return true
+}
+
+//The step number, setup for the setup step
+func getStepParameter(step string) (int, error) {
+	if strings.Compare(step, "setup") == 0 {
+		return -1, nil
+	}
+
+	stepNumber, err := strconv.Atoi(step)
+	if err != nil || stepNumber < 0 {
+		return 0, errors.New("step is not valid")
+	}
+
+	return stepNumber, nil
+}
+
+//Return the logged user, nil if the response is already written because the user isn't found or isn't an Agola user
+func (service *RunService) getAgolaUser(w http.ResponseWriter, r *http.Request) *model.User {
+	userId, _ := r.Context().Value(controller.UserIdParameter).(uint64)
+	user, _ := service.Db.GetUserByUserId(userId)
+	if user == nil {
+		log.Println("User", userId, "not found")
+		InternalServerError(w)
+		return nil
+	}
+
+	if user.AgolaUserRef == nil {
+		log.Println("User", userId, "not found in Agola")
+		UnprocessableEntityResponse(w, "user not found in Agola")
+		return nil
+	}
+
+	return user
+}
+
+func getRunParameters(r *http.Request) (string, uint64, error) {
+	vars := mux.Vars(r)
+
+	projectRef, err := url.PathUnescape(vars["projectRef"])
+	if err != nil {
+		return "", 0, errors.New("projectRef is not valid")
+	}
+
+	runNumber, err := strconv.ParseUint(vars["runNumber"], 10, 64)
+	if err != nil {
+		return "", 0, errors.New("runNumber is not valid")
+	}
+
+	return projectRef, runNumber, nil
+}
+
+//Answer with the status of the Agola error: the run not found, the permissions of the user or the action not allowed for the run
+func agolaErrorResponse(w http.ResponseWriter, err error) {
+	var responseError *agola.ResponseError
+	switch {
+	case errors.Is(err, agola.ErrNotFound):
+		NotFoundResponse(w)
+	case errors.As(err, &responseError) && (responseError.StatusCode == http.StatusUnauthorized || responseError.StatusCode == http.StatusForbidden):
+		ForbiddenResponse(w)
+	case errors.As(err, &responseError) && responseError.StatusCode < http.StatusInternalServerError:
+		UnprocessableEntityResponse(w, responseError.Message)
+	default:
+		InternalServerError(w)
+	}
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"log"
@@ -23,6 +24,8 @@ type TriggersService struct {
 	CommonMutex *utils.CommonMutex
 	AgolaApi    agola.AgolaApiInterface
 	GitGateway  *git.GitGateway
+	//Context of the server, canceled on shutdown
+	Ctx context.Context
 
 	RtDtoOrganizationSynk  *triggerDto.TriggerRunTimeDto
 	RtDtoDiscoveryRunFails *triggerDto.TriggerRunTimeDto
@@ -449,7 +452,7 @@ func (service *TriggersService) startOrganizationSynkTrigger() error {
 		Chan: make(chan triggerDto.TriggerMessage, 1),
 	}
 
-	trigger.StartOrganizationSync(service.Db, service.Tr, service.CommonMutex, service.AgolaApi, service.GitGateway, &service.RtDtoOrganizationSynk)
+	trigger.StartOrganizationSync(service.getContext(), service.Db, service.Tr, service.CommonMutex, service.AgolaApi, service.GitGateway, &service.RtDtoOrganizationSynk)
 
 	return nil
 }
@@ -463,7 +466,7 @@ func (service *TriggersService) startRunsFailedDiscoveryTrigger() error {
 		Chan: make(chan triggerDto.TriggerMessage, 1),
 	}
 
-	trigger.StartRunFailsDiscovery(service.Db, service.Tr, service.CommonMutex, service.AgolaApi, service.GitGateway, &service.RtDtoDiscoveryRunFails)
+	trigger.StartRunFailsDiscovery(service.getContext(), service.Db, service.Tr, service.CommonMutex, service.AgolaApi, service.GitGateway, &service.RtDtoDiscoveryRunFails)
 
 	return nil
 }
@@ -477,7 +480,14 @@ func (service *TriggersService) startUsersSynkTrigger() error {
 		Chan: make(chan triggerDto.TriggerMessage, 1),
 	}
 
-	trigger.StartSynkUsers(service.Db, service.Tr, service.CommonMutex, service.AgolaApi, service.GitGateway, &service.RtDtoUserSynk)
+	trigger.StartSynkUsers(service.getContext(), service.Db, service.Tr, service.CommonMutex, service.AgolaApi, service.GitGateway, &service.RtDtoUserSynk)
 
 	return nil
 }
+
+func (service *TriggersService) getContext() context.Context {
+	if service.Ctx == nil {
+		return context.Background()
+	}
+	return service.Ctx
+}
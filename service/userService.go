@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"log"
@@ -82,7 +83,7 @@ func (service *UserService) GetAllAgolaRunningRuns(w http.ResponseWriter, r *htt
 	var err error
 
 	if isAdmin {
-		orgs, err = service.AgolaApi.GetOrganizations()
+		orgs, err = service.AgolaApi.GetOrganizations(r.Context())
 		if err != nil {
 			log.Println("GetUserOrganizations error:", err)
 			InternalServerError(w)
@@ -92,7 +93,7 @@ func (service *UserService) GetAllAgolaRunningRuns(w http.ResponseWriter, r *htt
 			projectgrouprefs = append(projectgrouprefs, url.QueryEscape("org/"+org.Name))
 		}
 
-		users, err := service.AgolaApi.GetUsers()
+		users, err := service.AgolaApi.GetUsers(r.Context())
 		if err != nil {
 			log.Println("GetUsers error:", err)
 			InternalServerError(w)
@@ -103,7 +104,7 @@ func (service *UserService) GetAllAgolaRunningRuns(w http.ResponseWriter, r *htt
 			projectgrouprefs = append(projectgrouprefs, userProjectgroupref)
 
 			//directruns
-			runs, err := service.AgolaApi.GetUserRuns(nil, true, user.Username, false, "running", nil, 0, false)
+			runs, err := service.AgolaApi.GetUserRuns(r.Context(), nil, true, user.Username, false, "running", nil, 0, false)
 			if err != nil {
 				log.Println("GetUserRuns error:", err)
 				InternalServerError(w)
@@ -115,7 +116,7 @@ func (service *UserService) GetAllAgolaRunningRuns(w http.ResponseWriter, r *htt
 			}
 		}
 	} else if user.AgolaUserRef != nil {
-		userOrgs, err := service.AgolaApi.GetUserOrganizations(user, isAdmin)
+		userOrgs, err := service.AgolaApi.GetUserOrganizations(r.Context(), user, isAdmin)
 		if err != nil {
 			log.Println("GetUserOrganizations error:", err)
 			InternalServerError(w)
@@ -125,7 +126,7 @@ func (service *UserService) GetAllAgolaRunningRuns(w http.ResponseWriter, r *htt
 			projectgrouprefs = append(projectgrouprefs, url.QueryEscape("org/"+userOrg.Organization.Name))
 		}
 
-		agolaUser, err := service.AgolaApi.GetUser(*user.AgolaUserRef)
+		agolaUser, err := service.AgolaApi.GetUser(r.Context(), *user.AgolaUserRef)
 		if err != nil {
 			log.Println("GetUser error:", err)
 			InternalServerError(w)
@@ -135,7 +136,7 @@ func (service *UserService) GetAllAgolaRunningRuns(w http.ResponseWriter, r *htt
 		projectgrouprefs = append(projectgrouprefs, url.QueryEscape("user/"+agolaUser.Username))
 
 		//directruns
-		runs, err := service.AgolaApi.GetUserRuns(user, false, *user.AgolaUserRef, false, "running", nil, 0, false)
+		runs, err := service.AgolaApi.GetUserRuns(r.Context(), user, false, *user.AgolaUserRef, false, "running", nil, 0, false)
 		if err != nil {
 			log.Println("GetUserRuns error:", err)
 			InternalServerError(w)
@@ -148,7 +149,7 @@ func (service *UserService) GetAllAgolaRunningRuns(w http.ResponseWriter, r *htt
 	}
 
 	for _, projectgroupref := range projectgrouprefs {
-		projects, err := service.getAllProjectgrouprefProjects(projectgroupref)
+		projects, err := service.getAllProjectgrouprefProjects(r.Context(), projectgroupref)
 		if err != nil {
 			log.Println("getAllProjectgrouprefProjects error:", err)
 			InternalServerError(w)
@@ -156,7 +157,7 @@ func (service *UserService) GetAllAgolaRunningRuns(w http.ResponseWriter, r *htt
 		}
 
 		for _, project := range projects {
-			runs, err := service.AgolaApi.GetRuns(project.ID, false, "running", nil, 0, false)
+			runs, err := service.AgolaApi.GetRuns(r.Context(), project.ID, false, "running", nil, 0, false)
 			if err != nil {
 				log.Println("GetRuns error:", err)
 				InternalServerError(w)
@@ -164,6 +165,7 @@ func (service *UserService) GetAllAgolaRunningRuns(w http.ResponseWriter, r *htt
 			}
 
 			for _, run := range runs {
+				run.ProjectRef = project.ID
 				resp = append(resp, run)
 			}
 		}
@@ -172,10 +174,10 @@ func (service *UserService) GetAllAgolaRunningRuns(w http.ResponseWriter, r *htt
 	JSONokResponse(w, resp)
 }
 
-func (service *UserService) getAllProjectgrouprefProjects(projectgroupref string) ([]*agola.ProjectDto, error) {
+func (service *UserService) getAllProjectgrouprefProjects(ctx context.Context, projectgroupref string) ([]*agola.ProjectDto, error) {
 	resp := make([]*agola.ProjectDto, 0)
 
-	projects, err := service.AgolaApi.GetProjectgroupProjects(projectgroupref)
+	projects, err := service.AgolaApi.GetProjectgroupProjects(ctx, projectgroupref)
 	if err != nil {
 		log.Println("GetProjectgroupProjects error:", err)
 		return nil, err
@@ -184,13 +186,13 @@ func (service *UserService) getAllProjectgrouprefProjects(projectgroupref string
 		resp = append(resp, project)
 	}
 
-	subgroups, err := service.getAllProjectgroupSubgroups(projectgroupref)
+	subgroups, err := service.getAllProjectgroupSubgroups(ctx, projectgroupref)
 	if err != nil {
 		log.Println("getAllProjectgroupSubgroups error:", err)
 		return nil, err
 	}
 	for _, subgroup := range subgroups {
-		projects, err = service.AgolaApi.GetProjectgroupProjects(subgroup.ID)
+		projects, err = service.AgolaApi.GetProjectgroupProjects(ctx, subgroup.ID)
 		if err != nil {
 			log.Println("GetProjectgroupProjects error:", err)
 			return nil, err
@@ -203,10 +205,10 @@ func (service *UserService) getAllProjectgrouprefProjects(projectgroupref string
 	return resp, nil
 }
 
-func (service *UserService) getAllProjectgroupSubgroups(projectgroupref string) ([]*agola.ProjectGroupDto, error) {
+func (service *UserService) getAllProjectgroupSubgroups(ctx context.Context, projectgroupref string) ([]*agola.ProjectGroupDto, error) {
 	resp := make([]*agola.ProjectGroupDto, 0)
 
-	subgroups, err := service.AgolaApi.GetProjectgroupSubgroups(projectgroupref)
+	subgroups, err := service.AgolaApi.GetProjectgroupSubgroups(ctx, projectgroupref)
 	if err != nil {
 		log.Println("GetProjectgroupSubgroups error:", err)
 		return nil, err
@@ -214,7 +216,7 @@ func (service *UserService) getAllProjectgroupSubgroups(projectgroupref string)
 	for _, subgroup := range subgroups {
 		resp = append(resp, subgroup)
 
-		subSubgroups, err := service.getAllProjectgroupSubgroups(subgroup.ID)
+		subSubgroups, err := service.getAllProjectgroupSubgroups(ctx, subgroup.ID)
 		if err != nil {
 			log.Println("getAllProjectgroupSubgroups error:", err)
 			return nil, err
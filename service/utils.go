@@ -57,6 +57,16 @@ func NotFoundResponse(w http.ResponseWriter) {
 	}
 }
 
+// UnauthorizedResponse make an unauthorized response
+func UnauthorizedResponse(w http.ResponseWriter) {
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+// ForbiddenResponse make a forbidden response
+func ForbiddenResponse(w http.ResponseWriter) {
+	http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+}
+
 // UnprocessableEntityResponse make an unprocessable entity response with a specific message
 func UnprocessableEntityResponse(w http.ResponseWriter, message string) {
 	w.Header().Set("Content-Type", "text/plain")
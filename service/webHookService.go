@@ -1,19 +1,31 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 	gitlab "github.com/xanzy/go-gitlab"
 	agolaApi "wecode.sorint.it/opensource/papagaio-api/api/agola"
 	"wecode.sorint.it/opensource/papagaio-api/api/git"
+	"wecode.sorint.it/opensource/papagaio-api/api/git/bitbucket"
+	"wecode.sorint.it/opensource/papagaio-api/config"
 	"wecode.sorint.it/opensource/papagaio-api/dto"
 	"wecode.sorint.it/opensource/papagaio-api/manager/repositoryManager"
+	"wecode.sorint.it/opensource/papagaio-api/manager/variablesManager"
 	"wecode.sorint.it/opensource/papagaio-api/model"
 	"wecode.sorint.it/opensource/papagaio-api/repository"
+	"wecode.sorint.it/opensource/papagaio-api/trigger"
 	"wecode.sorint.it/opensource/papagaio-api/types"
 	"wecode.sorint.it/opensource/papagaio-api/utils"
 )
@@ -23,6 +35,9 @@ type WebHookService struct {
 	CommonMutex *utils.CommonMutex
 	AgolaApi    agolaApi.AgolaApiInterface
 	GitGateway  *git.GitGateway
+
+	WebHookQueue  *trigger.WebHookQueue
+	DeliveryDedup *utils.DeliveryDedupStore
 }
 
 func (service *WebHookService) WebHookOrganization(w http.ResponseWriter, r *http.Request) {
@@ -31,12 +46,6 @@ func (service *WebHookService) WebHookOrganization(w http.ResponseWriter, r *htt
 	vars := mux.Vars(r)
 	organizationRef := vars["organizationRef"]
 
-	mutex := utils.ReserveOrganizationMutex(organizationRef, service.CommonMutex)
-	mutex.Lock()
-
-	locked := true
-	defer utils.ReleaseOrganizationMutexDefer(organizationRef, service.CommonMutex, mutex, &locked)
-
 	organization, _ := service.Db.GetOrganizationByAgolaRef(organizationRef)
 	if organization == nil {
 		log.Println("warning!!! Organization", organizationRef, "not found in db")
@@ -51,74 +60,500 @@ func (service *WebHookService) WebHookOrganization(w http.ResponseWriter, r *htt
 		return
 	}
 
-	var webHookMessage dto.WebHookDto
-	data, _ := ioutil.ReadAll(r.Body)
+	//the webhook endpoint is called without authentication, the signature is checked after reading the body
+	data, ok := readWebHookPayload(w, r)
+	if !ok {
+		return
+	}
 
-	if gitSource.GitType == types.Gitlab {
-		var gitLabHookMessage gitlab.PushEvent
-		err := json.Unmarshal(data, &gitLabHookMessage)
+	//the organizations without a secret get it by the webhook repair, until then their events are discarded
+	webHookSecret, err := organization.GetWebHookSecret()
+	if !organization.HasWebHookSecret() {
+		log.Println("warning!!! Organization", organizationRef, "has no webhook secret, event discarded")
+		service.saveUnauthenticatedWebHookEvent(organizationRef, r.Header, "no webhook secret", len(data))
+		UnauthorizedResponse(w)
+		return
+	} else if err != nil {
+		log.Println("webhook secret of organization", organizationRef, "not readable:", err)
+		InternalServerError(w)
+		return
+	} else if !service.GitGateway.ValidateWebHookSignature(gitSource, r.Header, data, webHookSecret) {
+		log.Println("webhook signature not valid for organization", organizationRef)
+		service.saveUnauthenticatedWebHookEvent(organizationRef, r.Header, "signature not valid", len(data))
+		UnauthorizedResponse(w)
+		return
+	}
+
+	//the git servers send again the deliveries not answered in time
+	deliveryID := service.GitGateway.GetWebHookDeliveryID(gitSource, r.Header)
+	dedupKey := organizationRef + "/" + deliveryID
+	if service.DeliveryDedup != nil && len(deliveryID) > 0 && service.DeliveryDedup.CheckAndAdd(dedupKey) {
+		log.Println("webhook delivery", deliveryID, "of organization", organizationRef, "already received")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	service.GitGateway.InvalidateCache(organization)
+
+	webHookMessage, err := service.parseWebHookMessage(gitSource, organization, r.Header, data)
+	if err != nil {
+		log.Println("webHook message unmarshal error:", err)
+		service.saveRejectedWebHookEvent(organizationRef, r.Header, data, "unmarshal error: "+err.Error())
+		service.removeDelivery(dedupKey, deliveryID)
+		InternalServerError(w)
+		return
+	}
+	if webHookMessage == nil {
+		log.Println("webHook message ignored, it doesn't concern organization", organizationRef)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if !utils.EvaluateBehaviour(organization, webHookMessage.Repository.Name) {
+		log.Println("webhook", webHookMessage.Repository.Name, "excluded by behaviour settings")
+		service.saveRejectedWebHookEvent(organizationRef, r.Header, data, "behaviour exclude")
+		service.removeDelivery(dedupKey, deliveryID)
+		UnprocessableEntityResponse(w, "behaviour exclude")
+		return
+	}
+
+	event := model.WebHookEvent{OrganizationRef: organizationRef, DeliveryID: deliveryID, Header: redactWebHookHeader(r.Header), Payload: data}
+	err = service.WebHookQueue.Enqueue(&event)
+	if err != nil {
+		log.Println("webHook Enqueue error:", err)
+		service.removeDelivery(dedupKey, deliveryID)
+		InternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+
+	log.Println("WebHookOrganization end...")
+}
+
+//Called by the hooks of the git source: the event is queued for every organization of the git source that it concerns
+func (service *WebHookService) WebHookGitSource(w http.ResponseWriter, r *http.Request) {
+	log.Println("WebHookGitSource start...")
+
+	vars := mux.Vars(r)
+	gitSourceName := vars["gitSourceName"]
+
+	gitSource, _ := service.Db.GetGitSourceByName(gitSourceName)
+	if gitSource == nil {
+		log.Println("gitSource", gitSourceName, "not found")
+		NotFoundResponse(w)
+		return
+	}
+
+	data, ok := readWebHookPayload(w, r)
+	if !ok {
+		return
+	}
+
+	if !gitSource.HasSystemHook() {
+		log.Println("warning!!! gitSource", gitSourceName, "has no system hook, event discarded")
+		UnauthorizedResponse(w)
+		return
+	}
+	systemHookSecret, err := gitSource.GetSystemHookSecret()
+	if err != nil {
+		log.Println("system hook secret of gitSource", gitSourceName, "not readable:", err)
+		InternalServerError(w)
+		return
+	}
+	if !service.GitGateway.ValidateWebHookSignature(gitSource, r.Header, data, systemHookSecret) {
+		log.Println("system hook signature not valid for gitSource", gitSourceName)
+		UnauthorizedResponse(w)
+		return
+	}
+
+	deliveryID := service.GitGateway.GetWebHookDeliveryID(gitSource, r.Header)
+	dedupKey := "gitsource/" + gitSourceName + "/" + deliveryID
+	if service.DeliveryDedup != nil && len(deliveryID) > 0 && service.DeliveryDedup.CheckAndAdd(dedupKey) {
+		log.Println("system hook delivery", deliveryID, "of gitSource", gitSourceName, "already received")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	organizations, err := service.Db.GetOrganizationsByGitSource(gitSourceName)
+	if err != nil || organizations == nil {
+		log.Println("GetOrganizationsByGitSource error:", err)
+		service.removeDelivery(dedupKey, deliveryID)
+		InternalServerError(w)
+		return
+	}
+
+	//the organizations are independent, a message not valid for one of them doesn't discard the others
+	queued := false
+	var parseErr error
+	for i := range *organizations {
+		organization := &(*organizations)[i]
+
+		webHookMessage, err := service.parseWebHookMessage(gitSource, organization, r.Header, data)
 		if err != nil {
-			log.Println("gitlab unmarshal error:", err)
-			InternalServerError(w)
-			return
+			log.Println("system hook message unmarshal error for", organization.AgolaOrganizationRef, ":", err)
+			parseErr = err
+			continue
+		}
+		if webHookMessage == nil {
+			continue
 		}
 
-		webHookMessage.Action = ""
-		webHookMessage.Sha = gitLabHookMessage.CheckoutSHA
-		webHookMessage.Repository.Name = gitLabHookMessage.Repository.Name
-		webHookMessage.Repository.ID = gitLabHookMessage.ProjectID
-	} else {
-		err := json.Unmarshal(data, &webHookMessage)
+		if !utils.EvaluateBehaviour(organization, webHookMessage.Repository.Name) {
+			log.Println("system hook", webHookMessage.Repository.Name, "excluded by behaviour settings of", organization.AgolaOrganizationRef)
+			service.saveRejectedWebHookEvent(organization.AgolaOrganizationRef, r.Header, data, "behaviour exclude")
+			continue
+		}
+
+		service.GitGateway.InvalidateCache(organization)
+
+		event := model.WebHookEvent{OrganizationRef: organization.AgolaOrganizationRef, DeliveryID: deliveryID, Header: redactWebHookHeader(r.Header), Payload: data}
+		err = service.WebHookQueue.Enqueue(&event)
 		if err != nil {
-			log.Println("unmarshal error:", err)
+			log.Println("webHook Enqueue error:", err)
+			service.removeDelivery(dedupKey, deliveryID)
 			InternalServerError(w)
 			return
 		}
+		queued = true
 	}
 
-	log.Println("webHook message: ", webHookMessage)
+	if queued {
+		w.WriteHeader(http.StatusAccepted)
+	} else if parseErr != nil {
+		service.removeDelivery(dedupKey, deliveryID)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+	} else {
+		log.Println("system hook message ignored, it doesn't concern the organizations of", gitSourceName)
+		w.WriteHeader(http.StatusOK)
+	}
 
-	if !utils.EvaluateBehaviour(organization, webHookMessage.Repository.Name) {
-		log.Println("webhook", webHookMessage.Repository.Name, "excluded by behaviour settings")
-		UnprocessableEntityResponse(w, "behaviour exclude")
+	log.Println("WebHookGitSource end...")
+}
+
+//The delivery not accepted is received again when the git server retries it
+func (service *WebHookService) removeDelivery(dedupKey string, deliveryID string) {
+	if service.DeliveryDedup != nil && len(deliveryID) > 0 {
+		service.DeliveryDedup.Remove(dedupKey)
+	}
+}
+
+//Read the body of a webhook request, the size is limited because the endpoints are called without authentication
+func readWebHookPayload(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	maxPayloadSize := config.GetWebHookMaxPayloadSize()
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadSize))
+	if err != nil {
+		log.Println("webhook body not readable:", err)
+		if int64(len(data)) >= maxPayloadSize {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		} else {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		}
+		return nil, false
+	}
+
+	return data, true
+}
+
+func (service *WebHookService) saveRejectedWebHookEvent(organizationRef string, header http.Header, data []byte, reason string) {
+	now := time.Now()
+	event := model.WebHookEvent{
+		OrganizationRef: organizationRef,
+		Header:          redactWebHookHeader(header),
+		Payload:         data,
+		Status:          model.WebHookEventRejected,
+		LastError:       reason,
+		ReceivedAt:      now,
+		ProcessedAt:     now,
+	}
+
+	err := service.Db.SaveWebHookEvent(&event)
+	if err != nil {
+		log.Println("SaveWebHookEvent error:", err)
+	}
+}
+
+//Headers with the webhook secret or other credentials, never stored
+var webHookSecretHeaders = []string{"X-Gitlab-Token", "X-Hub-Signature", "X-Hub-Signature-256", "X-Gitea-Signature", "X-Gogs-Signature", "Authorization", "Proxy-Authorization", "Cookie"}
+
+//Headers stored for the requests with a signature not valid, their values are truncated
+var webHookMetadataHeaders = []string{"User-Agent", "Content-Type", "Content-Length", "X-Gitea-Event", "X-Gitea-Delivery", "X-GitHub-Event", "X-GitHub-Delivery", "X-Gitlab-Event", "X-Gitlab-Event-UUID", "X-Event-Key", "X-Request-Id"}
+
+const webHookMetadataMaxSize int = 256
+
+func redactWebHookHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, secretHeader := range webHookSecretHeaders {
+		redacted.Del(secretHeader)
+	}
+
+	return redacted
+}
+
+//The request isn't authenticated: only some metadata are stored, the payload is discarded
+func (service *WebHookService) saveUnauthenticatedWebHookEvent(organizationRef string, header http.Header, reason string, payloadSize int) {
+	metadata := make(http.Header)
+	for _, metadataHeader := range webHookMetadataHeaders {
+		value := header.Get(metadataHeader)
+		if len(value) > webHookMetadataMaxSize {
+			value = value[:webHookMetadataMaxSize]
+		}
+		if len(value) > 0 {
+			metadata.Set(metadataHeader, value)
+		}
+	}
+
+	now := time.Now()
+	event := model.WebHookEvent{
+		OrganizationRef: organizationRef,
+		Header:          metadata,
+		Status:          model.WebHookEventRejected,
+		LastError:       fmt.Sprintf("%s, payload of %d bytes not stored", reason, payloadSize),
+		ReceivedAt:      now,
+		ProcessedAt:     now,
+	}
+
+	err := service.Db.SaveWebHookEvent(&event)
+	if err != nil {
+		log.Println("SaveWebHookEvent error:", err)
+	}
+}
+
+// @Summary Return the webhook deliveries
+// @Description Return the webhook deliveries received for the organization, from the oldest to the newest
+// @Tags WebHook
+// @Produce  json
+// @Param organizationRef path string true "Organization Name"
+// @Success 200 {array} dto.WebHookDeliveryDto "ok"
+// @Failure 404 "not found"
+// @Router /webhookdeliveries/{organizationRef} [get]
+// @Security ApiKeyToken
+func (service *WebHookService) GetWebHookDeliveries(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	organizationRef := vars["organizationRef"]
+
+	organization, _ := service.Db.GetOrganizationByAgolaRef(organizationRef)
+	if organization == nil {
+		log.Println("organization", organizationRef, "not found")
+		NotFoundResponse(w)
+		return
+	}
+
+	events, err := service.Db.GetWebHookEvents(organizationRef)
+	if err != nil {
+		log.Println("GetWebHookEvents error:", err)
+		InternalServerError(w)
 		return
 	}
 
+	retVal := make([]dto.WebHookDeliveryDto, 0)
+	for i := range *events {
+		retVal = append(retVal, makeWebHookDeliveryDto(&(*events)[i]))
+	}
+
+	JSONokResponse(w, retVal)
+}
+
+// @Summary Replay a webhook delivery
+// @Description Queue again the payload of a webhook delivery, it is processed as a new delivery
+// @Tags WebHook
+// @Produce  json
+// @Param organizationRef path string true "Organization Name"
+// @Param deliveryId path string true "Delivery ID"
+// @Success 202 {object} dto.WebHookDeliveryDto "accepted"
+// @Failure 404 "not found"
+// @Failure 422 "delivery pending"
+// @Router /webhookdeliveries/{organizationRef}/{deliveryId}/replay [post]
+// @Security ApiKeyToken
+func (service *WebHookService) ReplayWebHookDelivery(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	organizationRef := vars["organizationRef"]
+	deliveryID := vars["deliveryId"]
+
+	delivery, err := service.Db.GetWebHookEvent(organizationRef, deliveryID)
+	if err != nil {
+		log.Println("GetWebHookEvent error:", err)
+		InternalServerError(w)
+		return
+	}
+	if delivery == nil {
+		log.Println("delivery", deliveryID, "of organization", organizationRef, "not found")
+		NotFoundResponse(w)
+		return
+	}
+	if delivery.IsPending() {
+		UnprocessableEntityResponse(w, "delivery still pending")
+		return
+	}
+	if len(delivery.Payload) == 0 {
+		UnprocessableEntityResponse(w, "delivery without payload")
+		return
+	}
+
+	event := model.WebHookEvent{OrganizationRef: organizationRef, DeliveryID: delivery.DeliveryID, Header: delivery.Header, Payload: delivery.Payload, ReplayOf: delivery.ID}
+	err = service.WebHookQueue.Enqueue(&event)
+	if err != nil {
+		log.Println("webHook Enqueue error:", err)
+		InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	err = json.NewEncoder(w).Encode(makeWebHookDeliveryDto(&event))
+	if err != nil {
+		log.Println("encode error:", err)
+	}
+}
+
+// @Summary Return the webhook deliveries deduplication counters
+// @Description Return the number of deliveries received and of the duplicated deliveries discarded since the start
+// @Tags WebHook
+// @Produce  json
+// @Success 200 {object} dto.WebHookDedupStatsDto "ok"
+// @Router /webhookdedup [get]
+// @Security ApiKeyToken
+func (service *WebHookService) GetWebHookDedupStats(w http.ResponseWriter, r *http.Request) {
+	retVal := dto.WebHookDedupStatsDto{}
+
+	if service.DeliveryDedup != nil {
+		stats := service.DeliveryDedup.GetStats()
+		retVal = dto.WebHookDedupStatsDto{
+			Size:       stats.Size,
+			MaxSize:    stats.MaxSize,
+			Received:   stats.Received,
+			Duplicates: stats.Duplicates,
+			Expired:    stats.Expired,
+			Evicted:    stats.Evicted,
+		}
+	}
+
+	JSONokResponse(w, retVal)
+}
+
+func makeWebHookDeliveryDto(event *model.WebHookEvent) dto.WebHookDeliveryDto {
+	delivery := dto.WebHookDeliveryDto{
+		ID:         event.ID,
+		DeliveryID: event.DeliveryID,
+		Header:     redactWebHookHeader(event.Header),
+		Payload:    string(event.Payload),
+		Status:     string(event.Status),
+		Attempts:   event.Attempts,
+		Error:      event.LastError,
+		ReceivedAt: event.ReceivedAt,
+		ReplayOf:   event.ReplayOf,
+	}
+
+	if !event.ProcessedAt.IsZero() {
+		processedAt := event.ProcessedAt
+		delivery.ProcessedAt = &processedAt
+	}
+
+	return delivery
+}
+
+//Called by the webhook queue workers, a returned error schedules a retry of the event
+func (service *WebHookService) ProcessWebHookEvent(ctx context.Context, event *model.WebHookEvent) error {
+	organizationRef := event.OrganizationRef
+
+	mutex := utils.ReserveOrganizationMutex(organizationRef, service.CommonMutex)
+	mutex.Lock()
+	defer func() {
+		mutex.Unlock()
+		utils.ReleaseOrganizationMutex(organizationRef, service.CommonMutex)
+	}()
+
+	organization, _ := service.Db.GetOrganizationByAgolaRef(organizationRef)
+	if organization == nil {
+		log.Println("warning!!! Organization", organizationRef, "not found in db, webhook event discarded")
+		return nil
+	}
+
+	gitSource, _ := service.Db.GetGitSourceByName(organization.GitSourceName)
+	if gitSource == nil {
+		return errors.New("gitSource " + organization.GitSourceName + " not found")
+	}
+
+	webHookMessage, err := service.parseWebHookMessage(gitSource, organization, event.Header, event.Payload)
+	if err != nil || webHookMessage == nil {
+		return err
+	}
+
+	log.Println("webHook message: ", webHookMessage)
+
 	if organization.Projects == nil {
 		organization.Projects = make(map[string]model.Project)
 	}
 
 	user, _ := service.Db.GetUserByUserId(organization.UserIDConnected)
 	if user == nil {
-		log.Println("user not found")
-		InternalServerError(w)
-		return
+		return fmt.Errorf("user %d not found", organization.UserIDConnected)
+	}
+
+	//the project of a repository renamed or transferred is found by the old name or by the stable git ID
+	oldRepositoryName := webHookMessage.OldRepositoryName
+	if _, ok := organization.Projects[oldRepositoryName]; !ok && !webHookMessage.IsRepositoryDeleted() {
+		oldRepositoryName = repositoryManager.GetProjectNameByGitRepoID(organization, webHookMessage.Repository.ID)
+	}
+	if _, ok := organization.Projects[oldRepositoryName]; ok && strings.Compare(oldRepositoryName, webHookMessage.Repository.Name) != 0 {
+		log.Println("repository renamed: ", oldRepositoryName, "to", webHookMessage.Repository.Name)
+
+		err := repositoryManager.RenameProject(ctx, service.Db, organization, oldRepositoryName, webHookMessage.Repository.Name, webHookMessage.Repository.ID, service.AgolaApi, user)
+		if err != nil {
+			return fmt.Errorf("RenameProject error: %w", err)
+		}
+	}
+
+	if webHookMessage.IsRepositoryRenamed() {
+		if _, ok := organization.Projects[webHookMessage.Repository.Name]; ok {
+			return nil
+		}
+
+		log.Println("renamed repository", webHookMessage.OldRepositoryName, "not found in db, handled as created")
+		webHookMessage.Action = dto.RepositoryCreatedAction
 	}
 
 	if webHookMessage.IsRepositoryCreated() {
 		log.Println("repository created: ", webHookMessage.Repository.Name)
-		project := model.Project{GitRepoPath: webHookMessage.Repository.Name, Archivied: true, AgolaProjectRef: utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name)}
 
-		agolaConfExists, _ := service.GitGateway.CheckRepositoryAgolaConfExists(gitSource, user, organization.GitPath, webHookMessage.Repository.Name)
-		if agolaConfExists {
-			projectID, err := service.AgolaApi.CreateProject(webHookMessage.Repository.Name, project.AgolaProjectRef, organization, gitSource.AgolaRemoteSource, user)
+		if _, ok := organization.Projects[webHookMessage.Repository.Name]; ok {
+			log.Println("project", webHookMessage.Repository.Name, "already exists")
+			return nil
+		}
+
+		moved, err := service.moveTransferredProject(ctx, gitSource, organization, mutex, webHookMessage, user)
+		if err != nil {
+			return fmt.Errorf("MoveProjectToOrganization error: %w", err)
+		}
+		if moved {
+			return nil
+		}
+
+		project := model.Project{GitRepoPath: webHookMessage.Repository.Name, GitRepoID: webHookMessage.Repository.ID, Archivied: true, AgolaProjectRef: utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name)}
+
+		agolaConfBranches, err := service.GitGateway.GetAgolaConfBranches(gitSource, user, organization.GitPath, webHookMessage.Repository.Name)
+		if err != nil && !errors.Is(err, git.ErrNotFound) {
+			return fmt.Errorf("GetAgolaConfBranches error: %w", err)
+		}
+		project.AgolaConfBranches = agolaConfBranches
+		if project.HasAgolaConf() {
+			projectID, err := service.AgolaApi.CreateProject(ctx, webHookMessage.Repository.Name, project.AgolaProjectRef, organization, gitSource.AgolaRemoteSource, user)
 			project.AgolaProjectID = projectID
 			if err != nil {
-				log.Println("warning!!! Agola CreateProject API error!")
-				InternalServerError(w)
-				return
+				return fmt.Errorf("Agola CreateProject API error: %w", err)
 			} else {
 				project.Archivied = false
+				if err := variablesManager.SynkProjectVariables(ctx, organization, &project, service.AgolaApi); err != nil {
+					log.Println("SynkProjectVariables error:", err)
+				}
 			}
 		}
 
 		organization.Projects[webHookMessage.Repository.Name] = project
-		err := service.Db.SaveOrganization(organization)
+		err = service.Db.SaveOrganization(organization)
 
 		if err != nil {
-			log.Println("SaveOrganization error:", err)
-			InternalServerError(w)
-			return
+			return fmt.Errorf("SaveOrganization error: %w", err)
 		}
 	} else if webHookMessage.IsRepositoryDeleted() {
 		log.Println("repository deleted: ", webHookMessage.Repository.Name)
@@ -126,16 +561,18 @@ func (service *WebHookService) WebHookOrganization(w http.ResponseWriter, r *htt
 		orgProject, ok := organization.Projects[webHookMessage.Repository.Name]
 
 		if !ok {
-			log.Println("warning!!! project", orgProject, "not found in db")
-			UnprocessableEntityResponse(w, "repository not found")
-			return
+			log.Println("warning!!! project", webHookMessage.Repository.Name, "not found in db")
+			return nil
 		}
 
-		err := service.AgolaApi.DeleteProject(organization, orgProject.AgolaProjectRef, user)
-		if err != nil {
-			log.Println("agola DeleteProject error:", err)
-			InternalServerError(w)
-			return
+		if destination := service.getTransferDestination(gitSource, organization, webHookMessage.TransferredTo); destination != nil {
+			log.Println("repository", webHookMessage.Repository.Name, "transferred to", destination.AgolaOrganizationRef, ", the project is moved by the event of that organization")
+			return nil
+		}
+
+		err := service.AgolaApi.DeleteProject(ctx, organization, orgProject.AgolaProjectRef, user)
+		if err != nil && !errors.Is(err, agolaApi.ErrNotFound) {
+			return fmt.Errorf("agola DeleteProject error: %w", err)
 		}
 
 		delete(organization.Projects, webHookMessage.Repository.Name)
@@ -146,82 +583,438 @@ func (service *WebHookService) WebHookOrganization(w http.ResponseWriter, r *htt
 		err = service.Db.SaveOrganization(organization)
 
 		if err != nil {
-			log.Println("SaveOrganization error:", err)
-			InternalServerError(w)
-			return
+			return fmt.Errorf("SaveOrganization error: %w", err)
 		}
 	} else if webHookMessage.IsPush() {
 		log.Println("repository push: ", webHookMessage.Repository.Name)
 
 		project, projectExist := organization.Projects[webHookMessage.Repository.Name]
-		agolaConfExists, _ := service.GitGateway.CheckRepositoryAgolaConfExists(gitSource, user, organization.GitPath, webHookMessage.Repository.Name)
+		agolaConfBranches, err := service.getAgolaConfBranches(gitSource, user, organization, &project, projectExist, webHookMessage)
+		if err != nil && !errors.Is(err, git.ErrNotFound) {
+			return fmt.Errorf("GetAgolaConfBranches error: %w", err)
+		}
+		//the branches checked for the first time are saved with the next change of the organization
+		agolaConfChanged := projectExist && project.AgolaConfBranches != nil && !reflect.DeepEqual(project.AgolaConfBranches, agolaConfBranches)
+		project.AgolaConfBranches = agolaConfBranches
+		if projectExist {
+			organization.Projects[webHookMessage.Repository.Name] = project
+		}
 
-		if agolaConfExists {
+		if project.HasAgolaConf() {
 			if !projectExist || !project.ExistsInAgola() {
 				agolaProjectRef := utils.ConvertToAgolaProjectRef(webHookMessage.Repository.Name)
-				projectID, err := service.AgolaApi.CreateProject(webHookMessage.Repository.Name, agolaProjectRef, organization, gitSource.AgolaRemoteSource, user)
+				projectID, err := service.AgolaApi.CreateProject(ctx, webHookMessage.Repository.Name, agolaProjectRef, organization, gitSource.AgolaRemoteSource, user)
 				if err != nil {
-					log.Println("warning!!! Agola CreateProject API error!")
-					InternalServerError(w)
-					return
+					return fmt.Errorf("Agola CreateProject API error: %w", err)
 				}
 
 				if !projectExist {
-					project = model.Project{GitRepoPath: webHookMessage.Repository.Name, AgolaProjectID: projectID, AgolaProjectRef: agolaProjectRef}
+					project = model.Project{GitRepoPath: webHookMessage.Repository.Name, GitRepoID: webHookMessage.Repository.ID, AgolaProjectID: projectID, AgolaProjectRef: agolaProjectRef, AgolaConfBranches: agolaConfBranches}
 				} else {
 					project.AgolaProjectID = projectID
 					project.AgolaProjectRef = agolaProjectRef
 				}
+				if err := variablesManager.SynkProjectVariables(ctx, organization, &project, service.AgolaApi); err != nil {
+					log.Println("SynkProjectVariables error:", err)
+				}
 				organization.Projects[webHookMessage.Repository.Name] = project
 				err = service.Db.SaveOrganization(organization)
 
 				if err != nil {
-					log.Println("SaveOrganization error:", err)
-					InternalServerError(w)
-					return
+					return fmt.Errorf("SaveOrganization error: %w", err)
 				}
 			} else if project.Archivied {
-				err := service.AgolaApi.UnarchiveProject(organization, project.AgolaProjectRef)
+				err := service.AgolaApi.UnarchiveProject(ctx, organization, project.AgolaProjectRef)
 				if err != nil {
-					log.Println("UnarchiveProject error:", err)
-					InternalServerError(w)
-					return
+					return fmt.Errorf("UnarchiveProject error: %w", err)
 				}
 				project.Archivied = false
 				organization.Projects[webHookMessage.Repository.Name] = project
 				err = service.Db.SaveOrganization(organization)
 				if err != nil {
-					log.Println("SaveOrganization error:", err)
-					InternalServerError(w)
-					return
+					return fmt.Errorf("SaveOrganization error: %w", err)
+				}
+			} else if agolaConfChanged {
+				err = service.Db.SaveOrganization(organization)
+				if err != nil {
+					return fmt.Errorf("SaveOrganization error: %w", err)
 				}
 			}
 		} else {
 			if projectExist && !project.Archivied {
-				err := service.AgolaApi.ArchiveProject(organization, project.AgolaProjectRef)
+				err := service.AgolaApi.ArchiveProject(ctx, organization, project.AgolaProjectRef)
 				if err != nil {
-					log.Println("ArchiveProject error:", err)
-					InternalServerError(w)
-					return
+					return fmt.Errorf("ArchiveProject error: %w", err)
 				}
 				project.Archivied = true
 				organization.Projects[webHookMessage.Repository.Name] = project
 				err = service.Db.SaveOrganization(organization)
 
 				if err != nil {
-					log.Println("SaveOrganization error:", err)
-					InternalServerError(w)
-					return
+					return fmt.Errorf("SaveOrganization error: %w", err)
+				}
+			} else if agolaConfChanged {
+				err = service.Db.SaveOrganization(organization)
+				if err != nil {
+					return fmt.Errorf("SaveOrganization error: %w", err)
 				}
 			}
 		}
 
+		if !webHookMessage.IsTagRef() {
+			if branchName, ok := webHookMessage.GetBranchName(); ok {
+				return repositoryManager.BranchUpdate(service.Db, organization, webHookMessage.Repository.Name, branchName, webHookMessage.IsRefRemovedByPush())
+			}
+
+			repositoryManager.BranchSynck(service.Db, user, gitSource, organization, webHookMessage.Repository.Name, service.GitGateway)
+		}
+	} else if webHookMessage.IsRefEvent() {
+		log.Println("repository ref event: ", webHookMessage.Repository.Name, webHookMessage.EventType, webHookMessage.RefType, webHookMessage.Ref)
+
+		if webHookMessage.IsTagRef() {
+			return nil
+		}
+
+		branchName, ok := webHookMessage.GetBranchName()
+		if ok && webHookMessage.IsBranchCreated() {
+			return repositoryManager.BranchUpdate(service.Db, organization, webHookMessage.Repository.Name, branchName, false)
+		} else if ok && webHookMessage.IsBranchDeleted() {
+			return repositoryManager.BranchUpdate(service.Db, organization, webHookMessage.Repository.Name, branchName, true)
+		}
+
 		repositoryManager.BranchSynck(service.Db, user, gitSource, organization, webHookMessage.Repository.Name, service.GitGateway)
 	}
 
-	mutex.Unlock()
-	utils.ReleaseOrganizationMutex(organizationRef, service.CommonMutex)
-	locked = false
+	return nil
+}
+
+/*
+The project of a repository transferred from another organization of the git source is moved, so the Agola project keeps its runs.
+The mutexes of the two organizations are always locked in the order of their refs. Return false if no organization had the repository
+*/
+func (service *WebHookService) moveTransferredProject(ctx context.Context, gitSource *model.GitSource, organization *model.Organization, mutex *sync.Mutex, webHookMessage *dto.WebHookDto, user *model.User) (bool, error) {
+	if !webHookMessage.TransferredIn || organization.UserNamespace || webHookMessage.Repository.ID == 0 {
+		return false, nil
+	}
+
+	organizations, err := service.Db.GetOrganizationsByGitSource(gitSource.Name)
+	if err != nil || organizations == nil {
+		return false, err
+	}
 
-	log.Println("WebHookOrganization end...")
+	fromOrganizationRef := ""
+	for _, other := range *organizations {
+		if other.UserNamespace || strings.Compare(other.AgolaOrganizationRef, organization.AgolaOrganizationRef) == 0 {
+			continue
+		}
+		if len(repositoryManager.GetProjectNameByGitRepoID(&other, webHookMessage.Repository.ID)) > 0 {
+			fromOrganizationRef = other.AgolaOrganizationRef
+			break
+		}
+	}
+	if len(fromOrganizationRef) == 0 {
+		return false, nil
+	}
+
+	fromMutex := utils.ReserveOrganizationMutex(fromOrganizationRef, service.CommonMutex)
+	if strings.Compare(fromOrganizationRef, organization.AgolaOrganizationRef) < 0 {
+		mutex.Unlock()
+		fromMutex.Lock()
+		mutex.Lock()
+	} else {
+		fromMutex.Lock()
+	}
+	defer func() {
+		fromMutex.Unlock()
+		utils.ReleaseOrganizationMutex(fromOrganizationRef, service.CommonMutex)
+	}()
+
+	//the organizations are read again, they could be changed while the mutexes were not locked
+	current, _ := service.Db.GetOrganizationByAgolaRef(organization.AgolaOrganizationRef)
+	if current == nil {
+		return false, fmt.Errorf("organization %s not found", organization.AgolaOrganizationRef)
+	}
+	*organization = *current
+	if _, ok := organization.Projects[webHookMessage.Repository.Name]; ok {
+		return true, nil
+	}
+	fromOrganization, _ := service.Db.GetOrganizationByAgolaRef(fromOrganizationRef)
+	if fromOrganization == nil {
+		return false, nil
+	}
+
+	moved, err := repositoryManager.MoveProjectToOrganization(ctx, service.Db, fromOrganization, organization, webHookMessage.Repository.Name, webHookMessage.Repository.ID, service.AgolaApi, user)
+	if err != nil || !moved {
+		return moved, err
+	}
+
+	//the variables of the organization replace the ones of the previous organization
+	project := organization.Projects[webHookMessage.Repository.Name]
+	if project.ExistsInAgola() && !project.IsAgolaVariablesApplied(&organization.AgolaVariables) {
+		if err := variablesManager.SynkProjectVariables(ctx, organization, &project, service.AgolaApi); err != nil {
+			log.Println("SynkProjectVariables error:", err)
+		}
+		organization.Projects[webHookMessage.Repository.Name] = project
+		if err := service.Db.SaveOrganization(organization); err != nil {
+			log.Println("SaveOrganization error:", err)
+		}
+	}
+
+	return true, nil
+}
+
+//Other organization of the git source where the repository has been transferred, nil if it isn't managed by papagaio
+func (service *WebHookService) getTransferDestination(gitSource *model.GitSource, organization *model.Organization, transferredTo string) *model.Organization {
+	if len(transferredTo) == 0 {
+		return nil
+	}
+
+	organizations, err := service.Db.GetOrganizationsByGitSource(gitSource.Name)
+	if err != nil || organizations == nil {
+		log.Println("GetOrganizationsByGitSource error:", err)
+		return nil
+	}
+
+	for i := range *organizations {
+		other := &(*organizations)[i]
+		if other.UserNamespace || strings.Compare(other.AgolaOrganizationRef, organization.AgolaOrganizationRef) == 0 {
+			continue
+		}
+
+		inOrganization := strings.Compare(path.Dir(transferredTo), other.GitPath) == 0
+		if gitSource.GitType == types.Gitlab {
+			_, inOrganization = getGitlabProjectName(other.GitPath, other.IncludeSubgroups, transferredTo)
+		}
+		if inOrganization {
+			return other
+		}
+	}
+
+	return nil
+}
+
+//Branches with the Agola config after a push: when the branches of the project are known only the pushed one is checked
+func (service *WebHookService) getAgolaConfBranches(gitSource *model.GitSource, user *model.User, organization *model.Organization, project *model.Project, projectExist bool, webHookMessage *dto.WebHookDto) (map[string]bool, error) {
+	if !projectExist || project.AgolaConfBranches == nil {
+		return service.GitGateway.GetAgolaConfBranches(gitSource, user, organization.GitPath, webHookMessage.Repository.Name)
+	}
+
+	branchName, ok := webHookMessage.GetBranchName()
+	if webHookMessage.IsTagRef() || !ok {
+		return project.AgolaConfBranches, nil
+	}
+
+	agolaConfBranches := make(map[string]bool)
+	for branch := range project.AgolaConfBranches {
+		if strings.Compare(branch, branchName) != 0 {
+			agolaConfBranches[branch] = true
+		}
+	}
+
+	if webHookMessage.IsRefRemovedByPush() {
+		return agolaConfBranches, nil
+	}
+
+	agolaConfExists, err := service.GitGateway.CheckBranchAgolaConfExists(gitSource, user, organization.GitPath, webHookMessage.Repository.Name, branchName)
+	if agolaConfExists {
+		agolaConfBranches[branchName] = true
+	}
+
+	return agolaConfBranches, err
+}
+
+//Return nil when the event doesn't concern the organization
+func (service *WebHookService) parseWebHookMessage(gitSource *model.GitSource, organization *model.Organization, header http.Header, data []byte) (*dto.WebHookDto, error) {
+	var webHookMessage *dto.WebHookDto
+
+	if gitSource.GitType == types.Gitlab {
+		var err error
+		webHookMessage, err = parseGitlabWebHookMessage(organization.GitPath, organization.IncludeSubgroups, data)
+		if err != nil || webHookMessage == nil {
+			return nil, err
+		}
+	} else if gitSource.GitType == types.Bitbucket {
+		var err error
+		webHookMessage, err = parseBitbucketWebHookMessage(organization.GitPath, data)
+		if err != nil || webHookMessage == nil {
+			return nil, err
+		}
+	} else {
+		webHookMessage = &dto.WebHookDto{}
+		err := json.Unmarshal(data, webHookMessage)
+		if err != nil {
+			return nil, err
+		}
+
+		if webHookMessage.Changes != nil {
+			webHookMessage.OldRepositoryName = webHookMessage.Changes.Repository.Name.From
+		}
+
+		//a repository transferred from or to another organization
+		if webHookMessage.IsRepositoryTransferred() {
+			if strings.Compare(path.Dir(webHookMessage.Repository.FullName), organization.GitPath) == 0 {
+				webHookMessage.Action = dto.RepositoryCreatedAction
+				webHookMessage.TransferredIn = true
+			} else {
+				webHookMessage.Action = dto.RepositoryDeletedAction
+				webHookMessage.TransferredTo = webHookMessage.Repository.FullName
+			}
+		}
+	}
+
+	webHookMessage.EventType = service.GitGateway.GetWebHookEventType(gitSource, header)
+
+	return webHookMessage, nil
+}
+
+/*
+Gitlab group hooks send push events, the project events are sent by the system hooks for all the instance
+so they are filtered by the namespace of the organization
+*/
+func parseGitlabWebHookMessage(gitPath string, includeSubgroups bool, data []byte) (*dto.WebHookDto, error) {
+	var systemHookEvent gitlab.BaseSystemEvent
+	err := json.Unmarshal(data, &systemHookEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	switch systemHookEvent.EventName {
+	case "", "push", "tag_push":
+		var gitLabHookMessage gitlab.PushEvent
+		err := json.Unmarshal(data, &gitLabHookMessage)
+		if err != nil {
+			return nil, err
+		}
+
+		webHookMessage := dto.WebHookDto{
+			Sha:    gitLabHookMessage.CheckoutSHA,
+			Ref:    gitLabHookMessage.Ref,
+			Before: gitLabHookMessage.Before,
+			After:  gitLabHookMessage.After,
+		}
+		if gitLabHookMessage.Repository != nil {
+			webHookMessage.Repository.Name = gitLabHookMessage.Repository.Name
+		}
+		//the group hooks send also the pushes of the subgroups projects
+		if len(gitLabHookMessage.Project.PathWithNamespace) > 0 {
+			name, inOrganization := getGitlabProjectName(gitPath, includeSubgroups, gitLabHookMessage.Project.PathWithNamespace)
+			if !inOrganization {
+				return nil, nil
+			}
+			webHookMessage.Repository.Name = name
+		}
+		webHookMessage.Repository.ID = gitLabHookMessage.ProjectID
+
+		return &webHookMessage, nil
+	case "project_create", "project_destroy", "project_rename", "project_transfer":
+		var projectEvent gitlab.ProjectSystemEvent
+		err := json.Unmarshal(data, &projectEvent)
+		if err != nil {
+			return nil, err
+		}
+
+		name, inOrganization := getGitlabProjectName(gitPath, includeSubgroups, projectEvent.PathWithNamespace)
+		oldName, wasInOrganization := getGitlabProjectName(gitPath, includeSubgroups, projectEvent.OldPathWithNamespace)
+		wasInOrganization = wasInOrganization && len(projectEvent.OldPathWithNamespace) > 0
+
+		webHookMessage := dto.WebHookDto{Repository: dto.RepositoryDto{ID: projectEvent.ProjectID, Name: name}}
+
+		switch {
+		case projectEvent.EventName == "project_create" && inOrganization:
+			webHookMessage.Action = dto.RepositoryCreatedAction
+		case projectEvent.EventName == "project_destroy" && inOrganization:
+			webHookMessage.Action = dto.RepositoryDeletedAction
+		case inOrganization && wasInOrganization:
+			webHookMessage.Action = dto.RepositoryRenamedAction
+			webHookMessage.OldRepositoryName = oldName
+		case inOrganization:
+			webHookMessage.Action = dto.RepositoryCreatedAction
+			webHookMessage.TransferredIn = true
+		case wasInOrganization:
+			webHookMessage.Action = dto.RepositoryDeletedAction
+			webHookMessage.Repository.Name = oldName
+			webHookMessage.TransferredTo = projectEvent.PathWithNamespace
+		default:
+			return nil, nil
+		}
+
+		return &webHookMessage, nil
+	default:
+		return nil, nil
+	}
+}
+
+/*
+Name of the project in the organization, the projects of the subgroups are named by their path relative to the organization.
+Return false if the project is not in the organization
+*/
+func getGitlabProjectName(gitPath string, includeSubgroups bool, pathWithNamespace string) (string, bool) {
+	if strings.EqualFold(path.Dir(pathWithNamespace), gitPath) {
+		return path.Base(pathWithNamespace), true
+	}
+
+	prefix := gitPath + "/"
+	if includeSubgroups && len(pathWithNamespace) > len(prefix) && strings.EqualFold(pathWithNamespace[:len(prefix)], prefix) {
+		return pathWithNamespace[len(prefix):], true
+	}
+
+	return "", false
+}
+
+/*
+Bitbucket project webhooks send the pushes as refs changes, renames and moves as repository modified events.
+The repository name is the slug, used by the Bitbucket api
+*/
+func parseBitbucketWebHookMessage(gitPath string, data []byte) (*dto.WebHookDto, error) {
+	var event bitbucket.WebHookEvent
+	err := json.Unmarshal(data, &event)
+	if err != nil {
+		return nil, err
+	}
+
+	switch event.EventKey {
+	case bitbucket.RefsChangedEvent:
+		webHookMessage := dto.WebHookDto{Repository: dto.RepositoryDto{ID: event.Repository.ID, Name: event.Repository.Slug}}
+		if len(event.Changes) > 0 {
+			change := event.Changes[0]
+			webHookMessage.Ref = change.RefID
+			webHookMessage.Before = change.FromHash
+			webHookMessage.After = change.ToHash
+			webHookMessage.Sha = change.ToHash
+			webHookMessage.Deleted = strings.Compare(change.Type, bitbucket.RefDeletedChange) == 0
+		}
+
+		return &webHookMessage, nil
+	case bitbucket.RepoModifiedEvent:
+		if event.Old == nil || event.New == nil {
+			return nil, nil
+		}
+
+		inOrganization := strings.Compare(event.New.Project.Key, gitPath) == 0
+		wasInOrganization := strings.Compare(event.Old.Project.Key, gitPath) == 0
+
+		webHookMessage := dto.WebHookDto{Repository: dto.RepositoryDto{ID: event.New.ID, Name: event.New.Slug}}
+
+		switch {
+		case inOrganization && wasInOrganization:
+			if strings.Compare(event.Old.Slug, event.New.Slug) == 0 {
+				return nil, nil
+			}
+			webHookMessage.Action = dto.RepositoryRenamedAction
+			webHookMessage.OldRepositoryName = event.Old.Slug
+		case inOrganization:
+			webHookMessage.Action = dto.RepositoryCreatedAction
+			webHookMessage.TransferredIn = true
+		case wasInOrganization:
+			webHookMessage.Action = dto.RepositoryDeletedAction
+			webHookMessage.Repository.Name = event.Old.Slug
+			webHookMessage.TransferredTo = event.New.Project.Key + "/" + event.New.Slug
+		default:
+			return nil, nil
+		}
+
+		return &webHookMessage, nil
+	default:
+		return nil, nil
+	}
 }
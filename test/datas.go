@@ -19,6 +19,7 @@ func MakeGitSourceMap() *map[string]model.GitSource {
 	retVal["gitea"] = model.GitSource{Name: "gitea", GitType: types.Gitea, AgolaRemoteSource: "gitea"}
 	retVal["github"] = model.GitSource{Name: "github", GitType: types.Github, AgolaRemoteSource: "github"}
 	retVal["gitlab"] = model.GitSource{Name: "gitlab", GitType: types.Gitlab, AgolaRemoteSource: "gitlab"}
+	retVal["bitbucket"] = model.GitSource{Name: "bitbucket", GitType: types.Bitbucket, AgolaRemoteSource: "bitbucket"}
 
 	return &retVal
 }
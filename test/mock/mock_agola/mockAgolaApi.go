@@ -5,7 +5,9 @@
 package mock_agola
 
 import (
+	context "context"
 	gomock "github.com/golang/mock/gomock"
+	io "io"
 	reflect "reflect"
 	agola "wecode.sorint.it/opensource/papagaio-api/api/agola"
 	model "wecode.sorint.it/opensource/papagaio-api/model"
@@ -36,9 +38,9 @@ func (m *MockAgolaApiInterface) EXPECT() *MockAgolaApiInterfaceMockRecorder {
 }
 
 // CheckOrganizationExists mocks base method
-func (m *MockAgolaApiInterface) CheckOrganizationExists(organization *model.Organization) (bool, string, error) {
+func (m *MockAgolaApiInterface) CheckOrganizationExists(ctx context.Context, organization *model.Organization) (bool, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CheckOrganizationExists", organization)
+	ret := m.ctrl.Call(m, "CheckOrganizationExists", ctx, organization)
 	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(string)
 	ret2, _ := ret[2].(error)
@@ -46,403 +48,635 @@ func (m *MockAgolaApiInterface) CheckOrganizationExists(organization *model.Orga
 }
 
 // CheckOrganizationExists indicates an expected call of CheckOrganizationExists
-func (mr *MockAgolaApiInterfaceMockRecorder) CheckOrganizationExists(organization interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) CheckOrganizationExists(ctx, organization interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckOrganizationExists", reflect.TypeOf((*MockAgolaApiInterface)(nil).CheckOrganizationExists), organization)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckOrganizationExists", reflect.TypeOf((*MockAgolaApiInterface)(nil).CheckOrganizationExists), ctx, organization)
 }
 
 // CheckProjectExists mocks base method
-func (m *MockAgolaApiInterface) CheckProjectExists(organization *model.Organization, projectName string) (bool, string) {
+func (m *MockAgolaApiInterface) CheckProjectExists(ctx context.Context, organization *model.Organization, projectName string) (bool, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CheckProjectExists", organization, projectName)
+	ret := m.ctrl.Call(m, "CheckProjectExists", ctx, organization, projectName)
 	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(string)
-	return ret0, ret1
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // CheckProjectExists indicates an expected call of CheckProjectExists
-func (mr *MockAgolaApiInterfaceMockRecorder) CheckProjectExists(organization, projectName interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) CheckProjectExists(ctx, organization, projectName interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckProjectExists", reflect.TypeOf((*MockAgolaApiInterface)(nil).CheckProjectExists), organization, projectName)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckProjectExists", reflect.TypeOf((*MockAgolaApiInterface)(nil).CheckProjectExists), ctx, organization, projectName)
 }
 
 // CreateOrganization mocks base method
-func (m *MockAgolaApiInterface) CreateOrganization(organization *model.Organization, visibility types.VisibilityType) (string, error) {
+func (m *MockAgolaApiInterface) CreateOrganization(ctx context.Context, organization *model.Organization, visibility types.VisibilityType) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateOrganization", organization, visibility)
+	ret := m.ctrl.Call(m, "CreateOrganization", ctx, organization, visibility)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateOrganization indicates an expected call of CreateOrganization
-func (mr *MockAgolaApiInterfaceMockRecorder) CreateOrganization(organization, visibility interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) CreateOrganization(ctx, organization, visibility interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrganization", reflect.TypeOf((*MockAgolaApiInterface)(nil).CreateOrganization), organization, visibility)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrganization", reflect.TypeOf((*MockAgolaApiInterface)(nil).CreateOrganization), ctx, organization, visibility)
 }
 
 // DeleteOrganization mocks base method
-func (m *MockAgolaApiInterface) DeleteOrganization(organization *model.Organization, user *model.User) error {
+func (m *MockAgolaApiInterface) DeleteOrganization(ctx context.Context, organization *model.Organization, user *model.User) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteOrganization", organization, user)
+	ret := m.ctrl.Call(m, "DeleteOrganization", ctx, organization, user)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteOrganization indicates an expected call of DeleteOrganization
-func (mr *MockAgolaApiInterfaceMockRecorder) DeleteOrganization(organization, user interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) DeleteOrganization(ctx, organization, user interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOrganization", reflect.TypeOf((*MockAgolaApiInterface)(nil).DeleteOrganization), organization, user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOrganization", reflect.TypeOf((*MockAgolaApiInterface)(nil).DeleteOrganization), ctx, organization, user)
 }
 
 // CreateProject mocks base method
-func (m *MockAgolaApiInterface) CreateProject(projectName, agolaProjectRef string, organization *model.Organization, remoteSourceName string, user *model.User) (string, error) {
+func (m *MockAgolaApiInterface) CreateProject(ctx context.Context, projectName, agolaProjectRef string, organization *model.Organization, remoteSourceName string, user *model.User) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateProject", projectName, agolaProjectRef, organization, remoteSourceName, user)
+	ret := m.ctrl.Call(m, "CreateProject", ctx, projectName, agolaProjectRef, organization, remoteSourceName, user)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateProject indicates an expected call of CreateProject
-func (mr *MockAgolaApiInterfaceMockRecorder) CreateProject(projectName, agolaProjectRef, organization, remoteSourceName, user interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) CreateProject(ctx, projectName, agolaProjectRef, organization, remoteSourceName, user interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateProject", reflect.TypeOf((*MockAgolaApiInterface)(nil).CreateProject), projectName, agolaProjectRef, organization, remoteSourceName, user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateProject", reflect.TypeOf((*MockAgolaApiInterface)(nil).CreateProject), ctx, projectName, agolaProjectRef, organization, remoteSourceName, user)
 }
 
 // DeleteProject mocks base method
-func (m *MockAgolaApiInterface) DeleteProject(organization *model.Organization, agolaProjectRef string, user *model.User) error {
+func (m *MockAgolaApiInterface) DeleteProject(ctx context.Context, organization *model.Organization, agolaProjectRef string, user *model.User) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteProject", organization, agolaProjectRef, user)
+	ret := m.ctrl.Call(m, "DeleteProject", ctx, organization, agolaProjectRef, user)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteProject indicates an expected call of DeleteProject
-func (mr *MockAgolaApiInterfaceMockRecorder) DeleteProject(organization, agolaProjectRef, user interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) DeleteProject(ctx, organization, agolaProjectRef, user interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProject", reflect.TypeOf((*MockAgolaApiInterface)(nil).DeleteProject), organization, agolaProjectRef, user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProject", reflect.TypeOf((*MockAgolaApiInterface)(nil).DeleteProject), ctx, organization, agolaProjectRef, user)
 }
 
 // AddOrUpdateOrganizationMember mocks base method
-func (m *MockAgolaApiInterface) AddOrUpdateOrganizationMember(organization *model.Organization, agolaUserRef, role string) error {
+func (m *MockAgolaApiInterface) AddOrUpdateOrganizationMember(ctx context.Context, organization *model.Organization, agolaUserRef, role string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddOrUpdateOrganizationMember", organization, agolaUserRef, role)
+	ret := m.ctrl.Call(m, "AddOrUpdateOrganizationMember", ctx, organization, agolaUserRef, role)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // AddOrUpdateOrganizationMember indicates an expected call of AddOrUpdateOrganizationMember
-func (mr *MockAgolaApiInterfaceMockRecorder) AddOrUpdateOrganizationMember(organization, agolaUserRef, role interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) AddOrUpdateOrganizationMember(ctx, organization, agolaUserRef, role interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOrUpdateOrganizationMember", reflect.TypeOf((*MockAgolaApiInterface)(nil).AddOrUpdateOrganizationMember), organization, agolaUserRef, role)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOrUpdateOrganizationMember", reflect.TypeOf((*MockAgolaApiInterface)(nil).AddOrUpdateOrganizationMember), ctx, organization, agolaUserRef, role)
 }
 
 // RemoveOrganizationMember mocks base method
-func (m *MockAgolaApiInterface) RemoveOrganizationMember(organization *model.Organization, agolaUserRef string) error {
+func (m *MockAgolaApiInterface) RemoveOrganizationMember(ctx context.Context, organization *model.Organization, agolaUserRef string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RemoveOrganizationMember", organization, agolaUserRef)
+	ret := m.ctrl.Call(m, "RemoveOrganizationMember", ctx, organization, agolaUserRef)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // RemoveOrganizationMember indicates an expected call of RemoveOrganizationMember
-func (mr *MockAgolaApiInterfaceMockRecorder) RemoveOrganizationMember(organization, agolaUserRef interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) RemoveOrganizationMember(ctx, organization, agolaUserRef interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveOrganizationMember", reflect.TypeOf((*MockAgolaApiInterface)(nil).RemoveOrganizationMember), organization, agolaUserRef)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveOrganizationMember", reflect.TypeOf((*MockAgolaApiInterface)(nil).RemoveOrganizationMember), ctx, organization, agolaUserRef)
 }
 
 // GetOrganizationMembers mocks base method
-func (m *MockAgolaApiInterface) GetOrganizationMembers(organization *model.Organization) (*agola.OrganizationMembersResponseDto, error) {
+func (m *MockAgolaApiInterface) GetOrganizationMembers(ctx context.Context, organization *model.Organization) (*agola.OrganizationMembersResponseDto, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrganizationMembers", organization)
+	ret := m.ctrl.Call(m, "GetOrganizationMembers", ctx, organization)
 	ret0, _ := ret[0].(*agola.OrganizationMembersResponseDto)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetOrganizationMembers indicates an expected call of GetOrganizationMembers
-func (mr *MockAgolaApiInterfaceMockRecorder) GetOrganizationMembers(organization interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) GetOrganizationMembers(ctx, organization interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganizationMembers", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetOrganizationMembers), organization)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganizationMembers", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetOrganizationMembers), ctx, organization)
 }
 
 // ArchiveProject mocks base method
-func (m *MockAgolaApiInterface) ArchiveProject(organization *model.Organization, agolaProjectRef string) error {
+func (m *MockAgolaApiInterface) ArchiveProject(ctx context.Context, organization *model.Organization, agolaProjectRef string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ArchiveProject", organization, agolaProjectRef)
+	ret := m.ctrl.Call(m, "ArchiveProject", ctx, organization, agolaProjectRef)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // ArchiveProject indicates an expected call of ArchiveProject
-func (mr *MockAgolaApiInterfaceMockRecorder) ArchiveProject(organization, agolaProjectRef interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) ArchiveProject(ctx, organization, agolaProjectRef interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveProject", reflect.TypeOf((*MockAgolaApiInterface)(nil).ArchiveProject), organization, agolaProjectRef)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveProject", reflect.TypeOf((*MockAgolaApiInterface)(nil).ArchiveProject), ctx, organization, agolaProjectRef)
 }
 
 // UnarchiveProject mocks base method
-func (m *MockAgolaApiInterface) UnarchiveProject(organization *model.Organization, agolaProjectRef string) error {
+func (m *MockAgolaApiInterface) UnarchiveProject(ctx context.Context, organization *model.Organization, agolaProjectRef string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UnarchiveProject", organization, agolaProjectRef)
+	ret := m.ctrl.Call(m, "UnarchiveProject", ctx, organization, agolaProjectRef)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UnarchiveProject indicates an expected call of UnarchiveProject
-func (mr *MockAgolaApiInterfaceMockRecorder) UnarchiveProject(organization, agolaProjectRef interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) UnarchiveProject(ctx, organization, agolaProjectRef interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnarchiveProject", reflect.TypeOf((*MockAgolaApiInterface)(nil).UnarchiveProject), organization, agolaProjectRef)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnarchiveProject", reflect.TypeOf((*MockAgolaApiInterface)(nil).UnarchiveProject), ctx, organization, agolaProjectRef)
 }
 
 // GetRuns mocks base method
-func (m *MockAgolaApiInterface) GetRuns(projectRef string, lastRun bool, phase string, startRunNumber *uint64, limit uint, asc bool) ([]*agola.RunsDto, error) {
+func (m *MockAgolaApiInterface) GetRuns(ctx context.Context, projectRef string, lastRun bool, phase string, startRunNumber *uint64, limit uint, asc bool) ([]*agola.RunsDto, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetRuns", projectRef, lastRun, phase, startRunNumber, limit, asc)
+	ret := m.ctrl.Call(m, "GetRuns", ctx, projectRef, lastRun, phase, startRunNumber, limit, asc)
 	ret0, _ := ret[0].([]*agola.RunsDto)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetRuns indicates an expected call of GetRuns
-func (mr *MockAgolaApiInterfaceMockRecorder) GetRuns(projectRef, lastRun, phase, startRunNumber, limit, asc interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) GetRuns(ctx, projectRef, lastRun, phase, startRunNumber, limit, asc interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRuns", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetRuns), projectRef, lastRun, phase, startRunNumber, limit, asc)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRuns", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetRuns), ctx, projectRef, lastRun, phase, startRunNumber, limit, asc)
 }
 
 // GetRun mocks base method
-func (m *MockAgolaApiInterface) GetRun(projectRef string, runNumber uint64) (*agola.RunDto, error) {
+func (m *MockAgolaApiInterface) GetRun(ctx context.Context, projectRef string, runNumber uint64) (*agola.RunDto, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetRun", projectRef, runNumber)
+	ret := m.ctrl.Call(m, "GetRun", ctx, projectRef, runNumber)
 	ret0, _ := ret[0].(*agola.RunDto)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetRun indicates an expected call of GetRun
-func (mr *MockAgolaApiInterfaceMockRecorder) GetRun(projectRef, runNumber interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) GetRun(ctx, projectRef, runNumber interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRun", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetRun), projectRef, runNumber)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRun", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetRun), ctx, projectRef, runNumber)
 }
 
 // GetTask mocks base method
-func (m *MockAgolaApiInterface) GetTask(projectRef string, runNumber uint64, taskID string) (*agola.TaskDto, error) {
+func (m *MockAgolaApiInterface) GetTask(ctx context.Context, projectRef string, runNumber uint64, taskID string) (*agola.TaskDto, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTask", projectRef, runNumber, taskID)
+	ret := m.ctrl.Call(m, "GetTask", ctx, projectRef, runNumber, taskID)
 	ret0, _ := ret[0].(*agola.TaskDto)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetTask indicates an expected call of GetTask
-func (mr *MockAgolaApiInterfaceMockRecorder) GetTask(projectRef, runNumber, taskID interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) GetTask(ctx, projectRef, runNumber, taskID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTask", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetTask), projectRef, runNumber, taskID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTask", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetTask), ctx, projectRef, runNumber, taskID)
 }
 
 // GetLogs mocks base method
-func (m *MockAgolaApiInterface) GetLogs(projectRef string, runNumber uint64, taskID string, step int) (string, error) {
+func (m *MockAgolaApiInterface) GetLogs(ctx context.Context, projectRef string, runNumber uint64, taskID string, step int) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetLogs", projectRef, runNumber, taskID, step)
+	ret := m.ctrl.Call(m, "GetLogs", ctx, projectRef, runNumber, taskID, step)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetLogs indicates an expected call of GetLogs
-func (mr *MockAgolaApiInterfaceMockRecorder) GetLogs(projectRef, runNumber, taskID, step interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) GetLogs(ctx, projectRef, runNumber, taskID, step interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLogs", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetLogs), projectRef, runNumber, taskID, step)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLogs", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetLogs), ctx, projectRef, runNumber, taskID, step)
 }
 
 // GetRemoteSource mocks base method
-func (m *MockAgolaApiInterface) GetRemoteSource(agolaRemoteSource string) (*agola.RemoteSourceDto, error) {
+func (m *MockAgolaApiInterface) GetRemoteSource(ctx context.Context, agolaRemoteSource string) (*agola.RemoteSourceDto, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetRemoteSource", agolaRemoteSource)
+	ret := m.ctrl.Call(m, "GetRemoteSource", ctx, agolaRemoteSource)
 	ret0, _ := ret[0].(*agola.RemoteSourceDto)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetRemoteSource indicates an expected call of GetRemoteSource
-func (mr *MockAgolaApiInterfaceMockRecorder) GetRemoteSource(agolaRemoteSource interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) GetRemoteSource(ctx, agolaRemoteSource interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRemoteSource", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetRemoteSource), agolaRemoteSource)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRemoteSource", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetRemoteSource), ctx, agolaRemoteSource)
 }
 
 // GetUsers mocks base method
-func (m *MockAgolaApiInterface) GetUsers() ([]*agola.UserDto, error) {
+func (m *MockAgolaApiInterface) GetUsers(ctx context.Context) ([]*agola.UserDto, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUsers")
+	ret := m.ctrl.Call(m, "GetUsers", ctx)
 	ret0, _ := ret[0].([]*agola.UserDto)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUsers indicates an expected call of GetUsers
-func (mr *MockAgolaApiInterfaceMockRecorder) GetUsers() *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) GetUsers(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetUsers))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetUsers), ctx)
 }
 
 // GetUser mocks base method
-func (m *MockAgolaApiInterface) GetUser(userRef string) (*agola.UserDto, error) {
+func (m *MockAgolaApiInterface) GetUser(ctx context.Context, userRef string) (*agola.UserDto, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUser", userRef)
+	ret := m.ctrl.Call(m, "GetUser", ctx, userRef)
 	ret0, _ := ret[0].(*agola.UserDto)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUser indicates an expected call of GetUser
-func (mr *MockAgolaApiInterfaceMockRecorder) GetUser(userRef interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) GetUser(ctx, userRef interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetUser), userRef)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetUser), ctx, userRef)
 }
 
 // GetUsersFilterbyRemoteUser mocks base method
-func (m *MockAgolaApiInterface) GetUsersFilterbyRemoteUser(remoteSourceID string, remoteUserID int64) ([]*agola.UserDto, error) {
+func (m *MockAgolaApiInterface) GetUsersFilterbyRemoteUser(ctx context.Context, remoteSourceID string, remoteUserID int64) ([]*agola.UserDto, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUsersFilterbyRemoteUser", remoteSourceID, remoteUserID)
+	ret := m.ctrl.Call(m, "GetUsersFilterbyRemoteUser", ctx, remoteSourceID, remoteUserID)
 	ret0, _ := ret[0].([]*agola.UserDto)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUsersFilterbyRemoteUser indicates an expected call of GetUsersFilterbyRemoteUser
-func (mr *MockAgolaApiInterfaceMockRecorder) GetUsersFilterbyRemoteUser(remoteSourceID, remoteUserID interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) GetUsersFilterbyRemoteUser(ctx, remoteSourceID, remoteUserID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersFilterbyRemoteUser", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetUsersFilterbyRemoteUser), remoteSourceID, remoteUserID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersFilterbyRemoteUser", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetUsersFilterbyRemoteUser), ctx, remoteSourceID, remoteUserID)
 }
 
 // GetOrganizations mocks base method
-func (m *MockAgolaApiInterface) GetOrganizations() ([]*agola.OrganizationDto, error) {
+func (m *MockAgolaApiInterface) GetOrganizations(ctx context.Context) ([]*agola.OrganizationDto, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrganizations")
+	ret := m.ctrl.Call(m, "GetOrganizations", ctx)
 	ret0, _ := ret[0].([]*agola.OrganizationDto)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetOrganizations indicates an expected call of GetOrganizations
-func (mr *MockAgolaApiInterfaceMockRecorder) GetOrganizations() *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) GetOrganizations(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganizations", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetOrganizations))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganizations", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetOrganizations), ctx)
 }
 
 // GetUserOrganizations mocks base method
-func (m *MockAgolaApiInterface) GetUserOrganizations(user *model.User, isAdminUser bool) ([]*agola.UserOrgDto, error) {
+func (m *MockAgolaApiInterface) GetUserOrganizations(ctx context.Context, user *model.User, isAdminUser bool) ([]*agola.UserOrgDto, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserOrganizations", user, isAdminUser)
+	ret := m.ctrl.Call(m, "GetUserOrganizations", ctx, user, isAdminUser)
 	ret0, _ := ret[0].([]*agola.UserOrgDto)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUserOrganizations indicates an expected call of GetUserOrganizations
-func (mr *MockAgolaApiInterfaceMockRecorder) GetUserOrganizations(user, isAdminUser interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) GetUserOrganizations(ctx, user, isAdminUser interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserOrganizations", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetUserOrganizations), user, isAdminUser)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserOrganizations", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetUserOrganizations), ctx, user, isAdminUser)
 }
 
 // GetProjectgroupProjects mocks base method
-func (m *MockAgolaApiInterface) GetProjectgroupProjects(projectgroupref string) ([]*agola.ProjectDto, error) {
+func (m *MockAgolaApiInterface) GetProjectgroupProjects(ctx context.Context, projectgroupref string) ([]*agola.ProjectDto, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetProjectgroupProjects", projectgroupref)
+	ret := m.ctrl.Call(m, "GetProjectgroupProjects", ctx, projectgroupref)
 	ret0, _ := ret[0].([]*agola.ProjectDto)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetProjectgroupProjects indicates an expected call of GetProjectgroupProjects
-func (mr *MockAgolaApiInterfaceMockRecorder) GetProjectgroupProjects(projectgroupref interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) GetProjectgroupProjects(ctx, projectgroupref interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProjectgroupProjects", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetProjectgroupProjects), projectgroupref)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProjectgroupProjects", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetProjectgroupProjects), ctx, projectgroupref)
 }
 
 // GetUserRuns mocks base method
-func (m *MockAgolaApiInterface) GetUserRuns(user *model.User, isAdminUser bool, userRef string, lastRun bool, phase string, startRunNumber *uint64, limit uint, asc bool) ([]*agola.RunsDto, error) {
+func (m *MockAgolaApiInterface) GetUserRuns(ctx context.Context, user *model.User, isAdminUser bool, userRef string, lastRun bool, phase string, startRunNumber *uint64, limit uint, asc bool) ([]*agola.RunsDto, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserRuns", user, isAdminUser, userRef, lastRun, phase, startRunNumber, limit, asc)
+	ret := m.ctrl.Call(m, "GetUserRuns", ctx, user, isAdminUser, userRef, lastRun, phase, startRunNumber, limit, asc)
 	ret0, _ := ret[0].([]*agola.RunsDto)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUserRuns indicates an expected call of GetUserRuns
-func (mr *MockAgolaApiInterfaceMockRecorder) GetUserRuns(user, isAdminUser, userRef, lastRun, phase, startRunNumber, limit, asc interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) GetUserRuns(ctx, user, isAdminUser, userRef, lastRun, phase, startRunNumber, limit, asc interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRuns", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetUserRuns), user, isAdminUser, userRef, lastRun, phase, startRunNumber, limit, asc)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRuns", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetUserRuns), ctx, user, isAdminUser, userRef, lastRun, phase, startRunNumber, limit, asc)
 }
 
 // GetProjectgroupSubgroups mocks base method
-func (m *MockAgolaApiInterface) GetProjectgroupSubgroups(projectgroupref string) ([]*agola.ProjectGroupDto, error) {
+func (m *MockAgolaApiInterface) GetProjectgroupSubgroups(ctx context.Context, projectgroupref string) ([]*agola.ProjectGroupDto, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetProjectgroupSubgroups", projectgroupref)
+	ret := m.ctrl.Call(m, "GetProjectgroupSubgroups", ctx, projectgroupref)
 	ret0, _ := ret[0].([]*agola.ProjectGroupDto)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetProjectgroupSubgroups indicates an expected call of GetProjectgroupSubgroups
-func (mr *MockAgolaApiInterfaceMockRecorder) GetProjectgroupSubgroups(projectgroupref interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) GetProjectgroupSubgroups(ctx, projectgroupref interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProjectgroupSubgroups", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetProjectgroupSubgroups), projectgroupref)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProjectgroupSubgroups", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetProjectgroupSubgroups), ctx, projectgroupref)
 }
 
 // CreateUserToken mocks base method
-func (m *MockAgolaApiInterface) CreateUserToken(user *model.User) error {
+func (m *MockAgolaApiInterface) CreateUserToken(ctx context.Context, user *model.User) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUserToken", user)
+	ret := m.ctrl.Call(m, "CreateUserToken", ctx, user)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateUserToken indicates an expected call of CreateUserToken
-func (mr *MockAgolaApiInterfaceMockRecorder) CreateUserToken(user interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) CreateUserToken(ctx, user interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserToken", reflect.TypeOf((*MockAgolaApiInterface)(nil).CreateUserToken), user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserToken", reflect.TypeOf((*MockAgolaApiInterface)(nil).CreateUserToken), ctx, user)
 }
 
 // GetRemoteSources mocks base method
-func (m *MockAgolaApiInterface) GetRemoteSources() (*[]agola.RemoteSourceDto, error) {
+func (m *MockAgolaApiInterface) GetRemoteSources(ctx context.Context) (*[]agola.RemoteSourceDto, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetRemoteSources")
+	ret := m.ctrl.Call(m, "GetRemoteSources", ctx)
 	ret0, _ := ret[0].(*[]agola.RemoteSourceDto)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetRemoteSources indicates an expected call of GetRemoteSources
-func (mr *MockAgolaApiInterfaceMockRecorder) GetRemoteSources() *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) GetRemoteSources(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRemoteSources", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetRemoteSources))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRemoteSources", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetRemoteSources), ctx)
 }
 
 // CreateRemoteSource mocks base method
-func (m *MockAgolaApiInterface) CreateRemoteSource(remoteSourceName, gitType, apiUrl, oauth2ClientId, oauth2ClientSecret string) error {
+func (m *MockAgolaApiInterface) CreateRemoteSource(ctx context.Context, remoteSourceName, gitType, apiUrl, oauth2ClientId, oauth2ClientSecret string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateRemoteSource", remoteSourceName, gitType, apiUrl, oauth2ClientId, oauth2ClientSecret)
+	ret := m.ctrl.Call(m, "CreateRemoteSource", ctx, remoteSourceName, gitType, apiUrl, oauth2ClientId, oauth2ClientSecret)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateRemoteSource indicates an expected call of CreateRemoteSource
-func (mr *MockAgolaApiInterfaceMockRecorder) CreateRemoteSource(remoteSourceName, gitType, apiUrl, oauth2ClientId, oauth2ClientSecret interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) CreateRemoteSource(ctx, remoteSourceName, gitType, apiUrl, oauth2ClientId, oauth2ClientSecret interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRemoteSource", reflect.TypeOf((*MockAgolaApiInterface)(nil).CreateRemoteSource), remoteSourceName, gitType, apiUrl, oauth2ClientId, oauth2ClientSecret)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRemoteSource", reflect.TypeOf((*MockAgolaApiInterface)(nil).CreateRemoteSource), ctx, remoteSourceName, gitType, apiUrl, oauth2ClientId, oauth2ClientSecret)
 }
 
 // DeleteRemotesource mocks base method
-func (m *MockAgolaApiInterface) DeleteRemotesource(remoteSourceName string) error {
+func (m *MockAgolaApiInterface) DeleteRemotesource(ctx context.Context, remoteSourceName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRemotesource", ctx, remoteSourceName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RenameProject mocks base method
+func (m *MockAgolaApiInterface) RenameProject(ctx context.Context, organization *model.Organization, agolaProjectRef, newAgolaProjectRef string, user *model.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenameProject", ctx, organization, agolaProjectRef, newAgolaProjectRef, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateProjectSecret mocks base method
+func (m *MockAgolaApiInterface) CreateProjectSecret(ctx context.Context, organization *model.Organization, agolaProjectRef, secretName string, data map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateProjectSecret", ctx, organization, agolaProjectRef, secretName, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateProjectVariable mocks base method
+func (m *MockAgolaApiInterface) CreateProjectVariable(ctx context.Context, organization *model.Organization, agolaProjectRef, variableName string, values []agola.VariableValueDto) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateProjectVariable", ctx, organization, agolaProjectRef, variableName, values)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteProjectSecret mocks base method
+func (m *MockAgolaApiInterface) DeleteProjectSecret(ctx context.Context, organization *model.Organization, agolaProjectRef, secretName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteProjectSecret", ctx, organization, agolaProjectRef, secretName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteProjectVariable mocks base method
+func (m *MockAgolaApiInterface) DeleteProjectVariable(ctx context.Context, organization *model.Organization, agolaProjectRef, variableName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteProjectVariable", ctx, organization, agolaProjectRef, variableName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetProjectSecrets mocks base method
+func (m *MockAgolaApiInterface) GetProjectSecrets(ctx context.Context, organization *model.Organization, agolaProjectRef string) ([]*agola.SecretDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProjectSecrets", ctx, organization, agolaProjectRef)
+	ret0, _ := ret[0].([]*agola.SecretDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProjectVariables mocks base method
+func (m *MockAgolaApiInterface) GetProjectVariables(ctx context.Context, organization *model.Organization, agolaProjectRef string) ([]*agola.VariableDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProjectVariables", ctx, organization, agolaProjectRef)
+	ret0, _ := ret[0].([]*agola.VariableDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateProjectSecret mocks base method
+func (m *MockAgolaApiInterface) UpdateProjectSecret(ctx context.Context, organization *model.Organization, agolaProjectRef, secretName string, data map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProjectSecret", ctx, organization, agolaProjectRef, secretName, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateProjectVariable mocks base method
+func (m *MockAgolaApiInterface) UpdateProjectVariable(ctx context.Context, organization *model.Organization, agolaProjectRef, variableName string, values []agola.VariableValueDto) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProjectVariable", ctx, organization, agolaProjectRef, variableName, values)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApproveTask mocks base method
+func (m *MockAgolaApiInterface) ApproveTask(ctx context.Context, user *model.User, projectRef string, runNumber uint64, taskID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApproveTask", ctx, user, projectRef, runNumber, taskID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelRun mocks base method
+func (m *MockAgolaApiInterface) CancelRun(ctx context.Context, user *model.User, projectRef string, runNumber uint64) (*agola.RunDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelRun", ctx, user, projectRef, runNumber)
+	ret0, _ := ret[0].(*agola.RunDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestartRun mocks base method
+func (m *MockAgolaApiInterface) RestartRun(ctx context.Context, user *model.User, projectRef string, runNumber uint64, fromStart bool) (*agola.RunDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestartRun", ctx, user, projectRef, runNumber, fromStart)
+	ret0, _ := ret[0].(*agola.RunDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StopRun mocks base method
+func (m *MockAgolaApiInterface) StopRun(ctx context.Context, user *model.User, projectRef string, runNumber uint64) (*agola.RunDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StopRun", ctx, user, projectRef, runNumber)
+	ret0, _ := ret[0].(*agola.RunDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLogsStream mocks base method
+func (m *MockAgolaApiInterface) GetLogsStream(ctx context.Context, projectRef string, runNumber uint64, taskID string, step int) (io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLogsStream", ctx, projectRef, runNumber, taskID, step)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRunEventsStream mocks base method
+func (m *MockAgolaApiInterface) GetRunEventsStream(ctx context.Context) (io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRunEventsStream", ctx)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MoveProject mocks base method
+func (m *MockAgolaApiInterface) MoveProject(ctx context.Context, organization *model.Organization, agolaProjectRef string, newOrganization *model.Organization, newAgolaProjectRef string, user *model.User) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteRemotesource", remoteSourceName)
+	ret := m.ctrl.Call(m, "MoveProject", ctx, organization, agolaProjectRef, newOrganization, newAgolaProjectRef, user)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteRemotesource indicates an expected call of DeleteRemotesource
-func (mr *MockAgolaApiInterfaceMockRecorder) DeleteRemotesource(remoteSourceName interface{}) *gomock.Call {
+func (mr *MockAgolaApiInterfaceMockRecorder) DeleteRemotesource(ctx, remoteSourceName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRemotesource", reflect.TypeOf((*MockAgolaApiInterface)(nil).DeleteRemotesource), ctx, remoteSourceName)
+}
+
+// RenameProject indicates an expected call of RenameProject
+func (mr *MockAgolaApiInterfaceMockRecorder) RenameProject(ctx, organization, agolaProjectRef, newAgolaProjectRef, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameProject", reflect.TypeOf((*MockAgolaApiInterface)(nil).RenameProject), ctx, organization, agolaProjectRef, newAgolaProjectRef, user)
+}
+
+// CreateProjectSecret indicates an expected call of CreateProjectSecret
+func (mr *MockAgolaApiInterfaceMockRecorder) CreateProjectSecret(ctx, organization, agolaProjectRef, secretName, data interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateProjectSecret", reflect.TypeOf((*MockAgolaApiInterface)(nil).CreateProjectSecret), ctx, organization, agolaProjectRef, secretName, data)
+}
+
+// CreateProjectVariable indicates an expected call of CreateProjectVariable
+func (mr *MockAgolaApiInterfaceMockRecorder) CreateProjectVariable(ctx, organization, agolaProjectRef, variableName, values interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateProjectVariable", reflect.TypeOf((*MockAgolaApiInterface)(nil).CreateProjectVariable), ctx, organization, agolaProjectRef, variableName, values)
+}
+
+// DeleteProjectSecret indicates an expected call of DeleteProjectSecret
+func (mr *MockAgolaApiInterfaceMockRecorder) DeleteProjectSecret(ctx, organization, agolaProjectRef, secretName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProjectSecret", reflect.TypeOf((*MockAgolaApiInterface)(nil).DeleteProjectSecret), ctx, organization, agolaProjectRef, secretName)
+}
+
+// DeleteProjectVariable indicates an expected call of DeleteProjectVariable
+func (mr *MockAgolaApiInterfaceMockRecorder) DeleteProjectVariable(ctx, organization, agolaProjectRef, variableName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProjectVariable", reflect.TypeOf((*MockAgolaApiInterface)(nil).DeleteProjectVariable), ctx, organization, agolaProjectRef, variableName)
+}
+
+// GetProjectSecrets indicates an expected call of GetProjectSecrets
+func (mr *MockAgolaApiInterfaceMockRecorder) GetProjectSecrets(ctx, organization, agolaProjectRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProjectSecrets", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetProjectSecrets), ctx, organization, agolaProjectRef)
+}
+
+// GetProjectVariables indicates an expected call of GetProjectVariables
+func (mr *MockAgolaApiInterfaceMockRecorder) GetProjectVariables(ctx, organization, agolaProjectRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProjectVariables", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetProjectVariables), ctx, organization, agolaProjectRef)
+}
+
+// UpdateProjectSecret indicates an expected call of UpdateProjectSecret
+func (mr *MockAgolaApiInterfaceMockRecorder) UpdateProjectSecret(ctx, organization, agolaProjectRef, secretName, data interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProjectSecret", reflect.TypeOf((*MockAgolaApiInterface)(nil).UpdateProjectSecret), ctx, organization, agolaProjectRef, secretName, data)
+}
+
+// UpdateProjectVariable indicates an expected call of UpdateProjectVariable
+func (mr *MockAgolaApiInterfaceMockRecorder) UpdateProjectVariable(ctx, organization, agolaProjectRef, variableName, values interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProjectVariable", reflect.TypeOf((*MockAgolaApiInterface)(nil).UpdateProjectVariable), ctx, organization, agolaProjectRef, variableName, values)
+}
+
+// ApproveTask indicates an expected call of ApproveTask
+func (mr *MockAgolaApiInterfaceMockRecorder) ApproveTask(ctx, user, projectRef, runNumber, taskID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApproveTask", reflect.TypeOf((*MockAgolaApiInterface)(nil).ApproveTask), ctx, user, projectRef, runNumber, taskID)
+}
+
+// CancelRun indicates an expected call of CancelRun
+func (mr *MockAgolaApiInterfaceMockRecorder) CancelRun(ctx, user, projectRef, runNumber interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelRun", reflect.TypeOf((*MockAgolaApiInterface)(nil).CancelRun), ctx, user, projectRef, runNumber)
+}
+
+// RestartRun indicates an expected call of RestartRun
+func (mr *MockAgolaApiInterfaceMockRecorder) RestartRun(ctx, user, projectRef, runNumber, fromStart interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestartRun", reflect.TypeOf((*MockAgolaApiInterface)(nil).RestartRun), ctx, user, projectRef, runNumber, fromStart)
+}
+
+// StopRun indicates an expected call of StopRun
+func (mr *MockAgolaApiInterfaceMockRecorder) StopRun(ctx, user, projectRef, runNumber interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopRun", reflect.TypeOf((*MockAgolaApiInterface)(nil).StopRun), ctx, user, projectRef, runNumber)
+}
+
+// GetLogsStream indicates an expected call of GetLogsStream
+func (mr *MockAgolaApiInterfaceMockRecorder) GetLogsStream(ctx, projectRef, runNumber, taskID, step interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLogsStream", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetLogsStream), ctx, projectRef, runNumber, taskID, step)
+}
+
+// GetRunEventsStream indicates an expected call of GetRunEventsStream
+func (mr *MockAgolaApiInterfaceMockRecorder) GetRunEventsStream(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRunEventsStream", reflect.TypeOf((*MockAgolaApiInterface)(nil).GetRunEventsStream), ctx)
+}
+
+// MoveProject indicates an expected call of MoveProject
+func (mr *MockAgolaApiInterfaceMockRecorder) MoveProject(ctx, organization, agolaProjectRef, newOrganization, newAgolaProjectRef, user interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRemotesource", reflect.TypeOf((*MockAgolaApiInterface)(nil).DeleteRemotesource), remoteSourceName)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveProject", reflect.TypeOf((*MockAgolaApiInterface)(nil).MoveProject), ctx, organization, agolaProjectRef, newOrganization, newAgolaProjectRef, user)
 }
@@ -0,0 +1,306 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: api/git/bitbucket/bitbucketApi.go
+
+// Package mock_bitbucket is a generated GoMock package.
+package mock_bitbucket
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+	bitbucket "wecode.sorint.it/opensource/papagaio-api/api/git/bitbucket"
+	dto "wecode.sorint.it/opensource/papagaio-api/api/git/dto"
+	common "wecode.sorint.it/opensource/papagaio-api/common"
+	model "wecode.sorint.it/opensource/papagaio-api/model"
+)
+
+// MockBitbucketInterface is a mock of BitbucketInterface interface
+type MockBitbucketInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockBitbucketInterfaceMockRecorder
+}
+
+// MockBitbucketInterfaceMockRecorder is the mock recorder for MockBitbucketInterface
+type MockBitbucketInterfaceMockRecorder struct {
+	mock *MockBitbucketInterface
+}
+
+// NewMockBitbucketInterface creates a new mock instance
+func NewMockBitbucketInterface(ctrl *gomock.Controller) *MockBitbucketInterface {
+	mock := &MockBitbucketInterface{ctrl: ctrl}
+	mock.recorder = &MockBitbucketInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockBitbucketInterface) EXPECT() *MockBitbucketInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CreateWebHook mocks base method
+func (m *MockBitbucketInterface) CreateWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef, organizationRef, webHookSecret string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebHook", gitSource, user, gitOrgRef, organizationRef, webHookSecret)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebHook indicates an expected call of CreateWebHook
+func (mr *MockBitbucketInterfaceMockRecorder) CreateWebHook(gitSource, user, gitOrgRef, organizationRef, webHookSecret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebHook", reflect.TypeOf((*MockBitbucketInterface)(nil).CreateWebHook), gitSource, user, gitOrgRef, organizationRef, webHookSecret)
+}
+
+// DeleteWebHook mocks base method
+func (m *MockBitbucketInterface) DeleteWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebHook", gitSource, user, gitOrgRef, webHookID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebHook indicates an expected call of DeleteWebHook
+func (mr *MockBitbucketInterfaceMockRecorder) DeleteWebHook(gitSource, user, gitOrgRef, webHookID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebHook", reflect.TypeOf((*MockBitbucketInterface)(nil).DeleteWebHook), gitSource, user, gitOrgRef, webHookID)
+}
+
+// GetWebHook mocks base method
+func (m *MockBitbucketInterface) GetWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) (*dto.WebHookDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebHook", gitSource, user, gitOrgRef, webHookID)
+	ret0, _ := ret[0].(*dto.WebHookDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebHook indicates an expected call of GetWebHook
+func (mr *MockBitbucketInterfaceMockRecorder) GetWebHook(gitSource, user, gitOrgRef, webHookID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebHook", reflect.TypeOf((*MockBitbucketInterface)(nil).GetWebHook), gitSource, user, gitOrgRef, webHookID)
+}
+
+// GetRepositories mocks base method
+func (m *MockBitbucketInterface) GetRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRepositories", gitSource, user, gitOrgRef)
+	ret0, _ := ret[0].(*[]dto.RepositoryDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRepositories indicates an expected call of GetRepositories
+func (mr *MockBitbucketInterfaceMockRecorder) GetRepositories(gitSource, user, gitOrgRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRepositories", reflect.TypeOf((*MockBitbucketInterface)(nil).GetRepositories), gitSource, user, gitOrgRef)
+}
+
+// GetEmailsRepositoryUsersOwner mocks base method
+func (m *MockBitbucketInterface) GetEmailsRepositoryUsersOwner(gitSource *model.GitSource, user *model.User, gitOrgRef, repositoryRef string) (*[]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEmailsRepositoryUsersOwner", gitSource, user, gitOrgRef, repositoryRef)
+	ret0, _ := ret[0].(*[]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEmailsRepositoryUsersOwner indicates an expected call of GetEmailsRepositoryUsersOwner
+func (mr *MockBitbucketInterfaceMockRecorder) GetEmailsRepositoryUsersOwner(gitSource, user, gitOrgRef, repositoryRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmailsRepositoryUsersOwner", reflect.TypeOf((*MockBitbucketInterface)(nil).GetEmailsRepositoryUsersOwner), gitSource, user, gitOrgRef, repositoryRef)
+}
+
+// GetOrganizationMembers mocks base method
+func (m *MockBitbucketInterface) GetOrganizationMembers(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]bitbucket.BitbucketUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrganizationMembers", gitSource, user, gitOrgRef)
+	ret0, _ := ret[0].(*[]bitbucket.BitbucketUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrganizationMembers indicates an expected call of GetOrganizationMembers
+func (mr *MockBitbucketInterfaceMockRecorder) GetOrganizationMembers(gitSource, user, gitOrgRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganizationMembers", reflect.TypeOf((*MockBitbucketInterface)(nil).GetOrganizationMembers), gitSource, user, gitOrgRef)
+}
+
+// GetBranches mocks base method
+func (m *MockBitbucketInterface) GetBranches(gitSource *model.GitSource, user *model.User, gitOrgRef, repositoryRef string) (map[string]bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBranches", gitSource, user, gitOrgRef, repositoryRef)
+	ret0, _ := ret[0].(map[string]bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBranches indicates an expected call of GetBranches
+func (mr *MockBitbucketInterfaceMockRecorder) GetBranches(gitSource, user, gitOrgRef, repositoryRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBranches", reflect.TypeOf((*MockBitbucketInterface)(nil).GetBranches), gitSource, user, gitOrgRef, repositoryRef)
+}
+
+// GetCommitMetadata mocks base method
+func (m *MockBitbucketInterface) GetCommitMetadata(gitSource *model.GitSource, user *model.User, gitOrgRef, repositoryRef, commitSha string) (*dto.CommitMetadataDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCommitMetadata", gitSource, user, gitOrgRef, repositoryRef, commitSha)
+	ret0, _ := ret[0].(*dto.CommitMetadataDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCommitMetadata indicates an expected call of GetCommitMetadata
+func (mr *MockBitbucketInterfaceMockRecorder) GetCommitMetadata(gitSource, user, gitOrgRef, repositoryRef, commitSha interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommitMetadata", reflect.TypeOf((*MockBitbucketInterface)(nil).GetCommitMetadata), gitSource, user, gitOrgRef, repositoryRef, commitSha)
+}
+
+// GetPullRequest mocks base method
+func (m *MockBitbucketInterface) GetPullRequest(gitSource *model.GitSource, user *model.User, gitOrgRef, repositoryRef string, pullRequestNumber int) (*dto.PullRequestDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPullRequest", gitSource, user, gitOrgRef, repositoryRef, pullRequestNumber)
+	ret0, _ := ret[0].(*dto.PullRequestDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPullRequest indicates an expected call of GetPullRequest
+func (mr *MockBitbucketInterfaceMockRecorder) GetPullRequest(gitSource, user, gitOrgRef, repositoryRef, pullRequestNumber interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPullRequest", reflect.TypeOf((*MockBitbucketInterface)(nil).GetPullRequest), gitSource, user, gitOrgRef, repositoryRef, pullRequestNumber)
+}
+
+// GetOrganization mocks base method
+func (m *MockBitbucketInterface) GetOrganization(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*dto.OrganizationDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrganization", gitSource, user, gitOrgRef)
+	ret0, _ := ret[0].(*dto.OrganizationDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrganization indicates an expected call of GetOrganization
+func (mr *MockBitbucketInterfaceMockRecorder) GetOrganization(gitSource, user, gitOrgRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganization", reflect.TypeOf((*MockBitbucketInterface)(nil).GetOrganization), gitSource, user, gitOrgRef)
+}
+
+// GetOrganizations mocks base method
+func (m *MockBitbucketInterface) GetOrganizations(gitSource *model.GitSource, user *model.User) (*[]dto.OrganizationDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrganizations", gitSource, user)
+	ret0, _ := ret[0].(*[]dto.OrganizationDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrganizations indicates an expected call of GetOrganizations
+func (mr *MockBitbucketInterfaceMockRecorder) GetOrganizations(gitSource, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganizations", reflect.TypeOf((*MockBitbucketInterface)(nil).GetOrganizations), gitSource, user)
+}
+
+// IsUserOwner mocks base method
+func (m *MockBitbucketInterface) IsUserOwner(gitSource *model.GitSource, user *model.User, gitOrgRef string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsUserOwner", gitSource, user, gitOrgRef)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsUserOwner indicates an expected call of IsUserOwner
+func (mr *MockBitbucketInterfaceMockRecorder) IsUserOwner(gitSource, user, gitOrgRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsUserOwner", reflect.TypeOf((*MockBitbucketInterface)(nil).IsUserOwner), gitSource, user, gitOrgRef)
+}
+
+// GetUserInfo mocks base method
+func (m *MockBitbucketInterface) GetUserInfo(gitSource *model.GitSource, user *model.User) (*dto.UserInfoDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserInfo", gitSource, user)
+	ret0, _ := ret[0].(*dto.UserInfoDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserInfo indicates an expected call of GetUserInfo
+func (mr *MockBitbucketInterfaceMockRecorder) GetUserInfo(gitSource, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserInfo", reflect.TypeOf((*MockBitbucketInterface)(nil).GetUserInfo), gitSource, user)
+}
+
+// GetUserByLogin mocks base method
+func (m *MockBitbucketInterface) GetUserByLogin(gitSource *model.GitSource, login string) (*dto.UserInfoDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByLogin", gitSource, login)
+	ret0, _ := ret[0].(*dto.UserInfoDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByLogin indicates an expected call of GetUserByLogin
+func (mr *MockBitbucketInterfaceMockRecorder) GetUserByLogin(gitSource, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByLogin", reflect.TypeOf((*MockBitbucketInterface)(nil).GetUserByLogin), gitSource, login)
+}
+
+// GetOauth2AccessToken mocks base method
+func (m *MockBitbucketInterface) GetOauth2AccessToken(gitSource *model.GitSource, code string) (*common.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOauth2AccessToken", gitSource, code)
+	ret0, _ := ret[0].(*common.Token)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOauth2AccessToken indicates an expected call of GetOauth2AccessToken
+func (mr *MockBitbucketInterfaceMockRecorder) GetOauth2AccessToken(gitSource, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOauth2AccessToken", reflect.TypeOf((*MockBitbucketInterface)(nil).GetOauth2AccessToken), gitSource, code)
+}
+
+// RefreshToken mocks base method
+func (m *MockBitbucketInterface) RefreshToken(gitSource *model.GitSource, refreshToken string) (*common.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshToken", gitSource, refreshToken)
+	ret0, _ := ret[0].(*common.Token)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckBranchAgolaConfExists mocks base method
+func (m *MockBitbucketInterface) CheckBranchAgolaConfExists(gitSource *model.GitSource, user *model.User, gitOrgRef, repositoryRef, branchName string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckBranchAgolaConfExists", gitSource, user, gitOrgRef, repositoryRef, branchName)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAgolaConfBranches mocks base method
+func (m *MockBitbucketInterface) GetAgolaConfBranches(gitSource *model.GitSource, user *model.User, gitOrgRef, repositoryRef string) (map[string]bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAgolaConfBranches", gitSource, user, gitOrgRef, repositoryRef)
+	ret0, _ := ret[0].(map[string]bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshToken indicates an expected call of RefreshToken
+func (mr *MockBitbucketInterfaceMockRecorder) RefreshToken(gitSource, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshToken", reflect.TypeOf((*MockBitbucketInterface)(nil).RefreshToken), gitSource, refreshToken)
+}
+
+// CheckBranchAgolaConfExists indicates an expected call of CheckBranchAgolaConfExists
+func (mr *MockBitbucketInterfaceMockRecorder) CheckBranchAgolaConfExists(gitSource, user, gitOrgRef, repositoryRef, branchName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckBranchAgolaConfExists", reflect.TypeOf((*MockBitbucketInterface)(nil).CheckBranchAgolaConfExists), gitSource, user, gitOrgRef, repositoryRef, branchName)
+}
+
+// GetAgolaConfBranches indicates an expected call of GetAgolaConfBranches
+func (mr *MockBitbucketInterfaceMockRecorder) GetAgolaConfBranches(gitSource, user, gitOrgRef, repositoryRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAgolaConfBranches", reflect.TypeOf((*MockBitbucketInterface)(nil).GetAgolaConfBranches), gitSource, user, gitOrgRef, repositoryRef)
+}
@@ -36,18 +36,18 @@ func (m *MockGiteaInterface) EXPECT() *MockGiteaInterfaceMockRecorder {
 }
 
 // CreateWebHook mocks base method
-func (m *MockGiteaInterface) CreateWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef, organizationRef string) (int64, error) {
+func (m *MockGiteaInterface) CreateWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef, organizationRef, webHookSecret string) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateWebHook", gitSource, user, gitOrgRef, organizationRef)
+	ret := m.ctrl.Call(m, "CreateWebHook", gitSource, user, gitOrgRef, organizationRef, webHookSecret)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateWebHook indicates an expected call of CreateWebHook
-func (mr *MockGiteaInterfaceMockRecorder) CreateWebHook(gitSource, user, gitOrgRef, organizationRef interface{}) *gomock.Call {
+func (mr *MockGiteaInterfaceMockRecorder) CreateWebHook(gitSource, user, gitOrgRef, organizationRef, webHookSecret interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebHook", reflect.TypeOf((*MockGiteaInterface)(nil).CreateWebHook), gitSource, user, gitOrgRef, organizationRef)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebHook", reflect.TypeOf((*MockGiteaInterface)(nil).CreateWebHook), gitSource, user, gitOrgRef, organizationRef, webHookSecret)
 }
 
 // DeleteWebHook mocks base method
@@ -65,10 +65,10 @@ func (mr *MockGiteaInterfaceMockRecorder) DeleteWebHook(gitSource, user, gitOrgR
 }
 
 // GetRepositories mocks base method
-func (m *MockGiteaInterface) GetRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]string, error) {
+func (m *MockGiteaInterface) GetRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetRepositories", gitSource, user, gitOrgRef)
-	ret0, _ := ret[0].(*[]string)
+	ret0, _ := ret[0].(*[]dto.RepositoryDto)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -169,21 +169,6 @@ func (mr *MockGiteaInterfaceMockRecorder) GetBranches(gitSource, user, gitOrgRef
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBranches", reflect.TypeOf((*MockGiteaInterface)(nil).GetBranches), gitSource, user, gitOrgRef, repositoryRef)
 }
 
-// CheckRepositoryAgolaConfExists mocks base method
-func (m *MockGiteaInterface) CheckRepositoryAgolaConfExists(gitSource *model.GitSource, user *model.User, gitOrgRef, repositoryRef string) (bool, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CheckRepositoryAgolaConfExists", gitSource, user, gitOrgRef, repositoryRef)
-	ret0, _ := ret[0].(bool)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
-// CheckRepositoryAgolaConfExists indicates an expected call of CheckRepositoryAgolaConfExists
-func (mr *MockGiteaInterfaceMockRecorder) CheckRepositoryAgolaConfExists(gitSource, user, gitOrgRef, repositoryRef interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckRepositoryAgolaConfExists", reflect.TypeOf((*MockGiteaInterface)(nil).CheckRepositoryAgolaConfExists), gitSource, user, gitOrgRef, repositoryRef)
-}
-
 // GetCommitMetadata mocks base method
 func (m *MockGiteaInterface) GetCommitMetadata(gitSource *model.GitSource, user *model.User, gitOrgRef, repositoryRef, commitSha string) (*dto.CommitMetadataDto, error) {
 	m.ctrl.T.Helper()
@@ -283,8 +268,142 @@ func (m *MockGiteaInterface) RefreshToken(gitSource *model.GitSource, refreshTok
 	return ret0, ret1
 }
 
+// GetWebHook mocks base method
+func (m *MockGiteaInterface) GetWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) (*dto.WebHookDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebHook", gitSource, user, gitOrgRef, webHookID)
+	ret0, _ := ret[0].(*dto.WebHookDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPullRequest mocks base method
+func (m *MockGiteaInterface) GetPullRequest(gitSource *model.GitSource, user *model.User, gitOrgRef, repositoryRef string, pullRequestNumber int) (*dto.PullRequestDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPullRequest", gitSource, user, gitOrgRef, repositoryRef, pullRequestNumber)
+	ret0, _ := ret[0].(*dto.PullRequestDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRepositoryWebHook mocks base method
+func (m *MockGiteaInterface) CreateRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef, repositoryRef, organizationRef, webHookSecret string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRepositoryWebHook", gitSource, user, gitOrgRef, repositoryRef, organizationRef, webHookSecret)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteRepositoryWebHook mocks base method
+func (m *MockGiteaInterface) DeleteRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef, repositoryRef string, webHookID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRepositoryWebHook", gitSource, user, gitOrgRef, repositoryRef, webHookID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetRepositoryWebHook mocks base method
+func (m *MockGiteaInterface) GetRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef, repositoryRef string, webHookID int64) (*dto.WebHookDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRepositoryWebHook", gitSource, user, gitOrgRef, repositoryRef, webHookID)
+	ret0, _ := ret[0].(*dto.WebHookDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserRepositories mocks base method
+func (m *MockGiteaInterface) GetUserRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRepositories", gitSource, user, gitOrgRef)
+	ret0, _ := ret[0].(*[]dto.RepositoryDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckBranchAgolaConfExists mocks base method
+func (m *MockGiteaInterface) CheckBranchAgolaConfExists(gitSource *model.GitSource, user *model.User, gitOrgRef, repositoryRef, branchName string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckBranchAgolaConfExists", gitSource, user, gitOrgRef, repositoryRef, branchName)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAgolaConfBranches mocks base method
+func (m *MockGiteaInterface) GetAgolaConfBranches(gitSource *model.GitSource, user *model.User, gitOrgRef, repositoryRef string) (map[string]bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAgolaConfBranches", gitSource, user, gitOrgRef, repositoryRef)
+	ret0, _ := ret[0].(map[string]bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRepositoryCollaborators mocks base method
+func (m *MockGiteaInterface) GetRepositoryCollaborators(gitSource *model.GitSource, user *model.User, gitOrgRef, repositoryRef string) (*[]dto.UserTeamResponseDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRepositoryCollaborators", gitSource, user, gitOrgRef, repositoryRef)
+	ret0, _ := ret[0].(*[]dto.UserTeamResponseDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // RefreshToken indicates an expected call of RefreshToken
 func (mr *MockGiteaInterfaceMockRecorder) RefreshToken(gitSource, refreshToken interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshToken", reflect.TypeOf((*MockGiteaInterface)(nil).RefreshToken), gitSource, refreshToken)
 }
+
+// GetWebHook indicates an expected call of GetWebHook
+func (mr *MockGiteaInterfaceMockRecorder) GetWebHook(gitSource, user, gitOrgRef, webHookID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebHook", reflect.TypeOf((*MockGiteaInterface)(nil).GetWebHook), gitSource, user, gitOrgRef, webHookID)
+}
+
+// GetPullRequest indicates an expected call of GetPullRequest
+func (mr *MockGiteaInterfaceMockRecorder) GetPullRequest(gitSource, user, gitOrgRef, repositoryRef, pullRequestNumber interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPullRequest", reflect.TypeOf((*MockGiteaInterface)(nil).GetPullRequest), gitSource, user, gitOrgRef, repositoryRef, pullRequestNumber)
+}
+
+// CreateRepositoryWebHook indicates an expected call of CreateRepositoryWebHook
+func (mr *MockGiteaInterfaceMockRecorder) CreateRepositoryWebHook(gitSource, user, gitOrgRef, repositoryRef, organizationRef, webHookSecret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRepositoryWebHook", reflect.TypeOf((*MockGiteaInterface)(nil).CreateRepositoryWebHook), gitSource, user, gitOrgRef, repositoryRef, organizationRef, webHookSecret)
+}
+
+// DeleteRepositoryWebHook indicates an expected call of DeleteRepositoryWebHook
+func (mr *MockGiteaInterfaceMockRecorder) DeleteRepositoryWebHook(gitSource, user, gitOrgRef, repositoryRef, webHookID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRepositoryWebHook", reflect.TypeOf((*MockGiteaInterface)(nil).DeleteRepositoryWebHook), gitSource, user, gitOrgRef, repositoryRef, webHookID)
+}
+
+// GetRepositoryWebHook indicates an expected call of GetRepositoryWebHook
+func (mr *MockGiteaInterfaceMockRecorder) GetRepositoryWebHook(gitSource, user, gitOrgRef, repositoryRef, webHookID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRepositoryWebHook", reflect.TypeOf((*MockGiteaInterface)(nil).GetRepositoryWebHook), gitSource, user, gitOrgRef, repositoryRef, webHookID)
+}
+
+// GetUserRepositories indicates an expected call of GetUserRepositories
+func (mr *MockGiteaInterfaceMockRecorder) GetUserRepositories(gitSource, user, gitOrgRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRepositories", reflect.TypeOf((*MockGiteaInterface)(nil).GetUserRepositories), gitSource, user, gitOrgRef)
+}
+
+// CheckBranchAgolaConfExists indicates an expected call of CheckBranchAgolaConfExists
+func (mr *MockGiteaInterfaceMockRecorder) CheckBranchAgolaConfExists(gitSource, user, gitOrgRef, repositoryRef, branchName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckBranchAgolaConfExists", reflect.TypeOf((*MockGiteaInterface)(nil).CheckBranchAgolaConfExists), gitSource, user, gitOrgRef, repositoryRef, branchName)
+}
+
+// GetAgolaConfBranches indicates an expected call of GetAgolaConfBranches
+func (mr *MockGiteaInterfaceMockRecorder) GetAgolaConfBranches(gitSource, user, gitOrgRef, repositoryRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAgolaConfBranches", reflect.TypeOf((*MockGiteaInterface)(nil).GetAgolaConfBranches), gitSource, user, gitOrgRef, repositoryRef)
+}
+
+// GetRepositoryCollaborators indicates an expected call of GetRepositoryCollaborators
+func (mr *MockGiteaInterfaceMockRecorder) GetRepositoryCollaborators(gitSource, user, gitOrgRef, repositoryRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRepositoryCollaborators", reflect.TypeOf((*MockGiteaInterface)(nil).GetRepositoryCollaborators), gitSource, user, gitOrgRef, repositoryRef)
+}
@@ -37,18 +37,18 @@ func (m *MockGitlabInterface) EXPECT() *MockGitlabInterfaceMockRecorder {
 }
 
 // CreateWebHook mocks base method
-func (m *MockGitlabInterface) CreateWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef, organizationRef string) (int64, error) {
+func (m *MockGitlabInterface) CreateWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef, organizationRef, webHookSecret string) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateWebHook", gitSource, user, gitOrgRef, organizationRef)
+	ret := m.ctrl.Call(m, "CreateWebHook", gitSource, user, gitOrgRef, organizationRef, webHookSecret)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateWebHook indicates an expected call of CreateWebHook
-func (mr *MockGitlabInterfaceMockRecorder) CreateWebHook(gitSource, user, gitOrgRef, organizationRef interface{}) *gomock.Call {
+func (mr *MockGitlabInterfaceMockRecorder) CreateWebHook(gitSource, user, gitOrgRef, organizationRef, webHookSecret interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebHook", reflect.TypeOf((*MockGitlabInterface)(nil).CreateWebHook), gitSource, user, gitOrgRef, organizationRef)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebHook", reflect.TypeOf((*MockGitlabInterface)(nil).CreateWebHook), gitSource, user, gitOrgRef, organizationRef, webHookSecret)
 }
 
 // DeleteWebHook mocks base method
@@ -66,10 +66,10 @@ func (mr *MockGitlabInterfaceMockRecorder) DeleteWebHook(gitSource, user, gitOrg
 }
 
 // GetRepositories mocks base method
-func (m *MockGitlabInterface) GetRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]string, error) {
+func (m *MockGitlabInterface) GetRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetRepositories", gitSource, user, gitOrgRef)
-	ret0, _ := ret[0].(*[]string)
+	ret0, _ := ret[0].(*[]dto.RepositoryDto)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -125,21 +125,6 @@ func (mr *MockGitlabInterfaceMockRecorder) GetBranches(gitSource, user, gitOrgRe
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBranches", reflect.TypeOf((*MockGitlabInterface)(nil).GetBranches), gitSource, user, gitOrgRef, repositoryRef)
 }
 
-// CheckRepositoryAgolaConfExists mocks base method
-func (m *MockGitlabInterface) CheckRepositoryAgolaConfExists(gitSource *model.GitSource, user *model.User, gitOrgRef, repositoryRef string) (bool, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CheckRepositoryAgolaConfExists", gitSource, user, gitOrgRef, repositoryRef)
-	ret0, _ := ret[0].(bool)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
-// CheckRepositoryAgolaConfExists indicates an expected call of CheckRepositoryAgolaConfExists
-func (mr *MockGitlabInterfaceMockRecorder) CheckRepositoryAgolaConfExists(gitSource, user, gitOrgRef, repositoryRef interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckRepositoryAgolaConfExists", reflect.TypeOf((*MockGitlabInterface)(nil).CheckRepositoryAgolaConfExists), gitSource, user, gitOrgRef, repositoryRef)
-}
-
 // GetCommitMetadata mocks base method
 func (m *MockGitlabInterface) GetCommitMetadata(gitSource *model.GitSource, user *model.User, gitOrgRef, repositoryRef, commitSha string) (*dto.CommitMetadataDto, error) {
 	m.ctrl.T.Helper()
@@ -254,8 +239,201 @@ func (m *MockGitlabInterface) RefreshToken(gitSource *model.GitSource, refreshTo
 	return ret0, ret1
 }
 
+// GetWebHook mocks base method
+func (m *MockGitlabInterface) GetWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef string, webHookID int64) (*dto.WebHookDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebHook", gitSource, user, gitOrgRef, webHookID)
+	ret0, _ := ret[0].(*dto.WebHookDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPullRequest mocks base method
+func (m *MockGitlabInterface) GetPullRequest(gitSource *model.GitSource, user *model.User, gitOrgRef, repositoryRef string, pullRequestNumber int) (*dto.PullRequestDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPullRequest", gitSource, user, gitOrgRef, repositoryRef, pullRequestNumber)
+	ret0, _ := ret[0].(*dto.PullRequestDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRepositoriesWithSubgroups mocks base method
+func (m *MockGitlabInterface) GetRepositoriesWithSubgroups(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRepositoriesWithSubgroups", gitSource, user, gitOrgRef)
+	ret0, _ := ret[0].(*[]dto.RepositoryDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRepositoryWebHook mocks base method
+func (m *MockGitlabInterface) CreateRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef, repositoryRef, organizationRef, webHookSecret string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRepositoryWebHook", gitSource, user, gitOrgRef, repositoryRef, organizationRef, webHookSecret)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteRepositoryWebHook mocks base method
+func (m *MockGitlabInterface) DeleteRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef, repositoryRef string, webHookID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRepositoryWebHook", gitSource, user, gitOrgRef, repositoryRef, webHookID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetRepositoryWebHook mocks base method
+func (m *MockGitlabInterface) GetRepositoryWebHook(gitSource *model.GitSource, user *model.User, gitOrgRef, repositoryRef string, webHookID int64) (*dto.WebHookDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRepositoryWebHook", gitSource, user, gitOrgRef, repositoryRef, webHookID)
+	ret0, _ := ret[0].(*dto.WebHookDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserRepositories mocks base method
+func (m *MockGitlabInterface) GetUserRepositories(gitSource *model.GitSource, user *model.User, gitOrgRef string) (*[]dto.RepositoryDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRepositories", gitSource, user, gitOrgRef)
+	ret0, _ := ret[0].(*[]dto.RepositoryDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckBranchAgolaConfExists mocks base method
+func (m *MockGitlabInterface) CheckBranchAgolaConfExists(gitSource *model.GitSource, user *model.User, gitOrgRef, repositoryRef, branchName string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckBranchAgolaConfExists", gitSource, user, gitOrgRef, repositoryRef, branchName)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAgolaConfBranches mocks base method
+func (m *MockGitlabInterface) GetAgolaConfBranches(gitSource *model.GitSource, user *model.User, gitOrgRef, repositoryRef string) (map[string]bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAgolaConfBranches", gitSource, user, gitOrgRef, repositoryRef)
+	ret0, _ := ret[0].(map[string]bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRepositoryCollaborators mocks base method
+func (m *MockGitlabInterface) GetRepositoryCollaborators(gitSource *model.GitSource, user *model.User, gitOrgRef, repositoryRef string) (*[]dto.UserTeamResponseDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRepositoryCollaborators", gitSource, user, gitOrgRef, repositoryRef)
+	ret0, _ := ret[0].(*[]dto.UserTeamResponseDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSystemHook mocks base method
+func (m *MockGitlabInterface) CreateSystemHook(gitSource *model.GitSource, user *model.User, webHookURL, webHookSecret string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSystemHook", gitSource, user, webHookURL, webHookSecret)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteSystemHook mocks base method
+func (m *MockGitlabInterface) DeleteSystemHook(gitSource *model.GitSource, user *model.User, systemHookID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSystemHook", gitSource, user, systemHookID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetSystemHook mocks base method
+func (m *MockGitlabInterface) GetSystemHook(gitSource *model.GitSource, user *model.User, systemHookID int64) (*dto.WebHookDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSystemHook", gitSource, user, systemHookID)
+	ret0, _ := ret[0].(*dto.WebHookDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // RefreshToken indicates an expected call of RefreshToken
 func (mr *MockGitlabInterfaceMockRecorder) RefreshToken(gitSource, refreshToken interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshToken", reflect.TypeOf((*MockGitlabInterface)(nil).RefreshToken), gitSource, refreshToken)
 }
+
+// GetWebHook indicates an expected call of GetWebHook
+func (mr *MockGitlabInterfaceMockRecorder) GetWebHook(gitSource, user, gitOrgRef, webHookID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebHook", reflect.TypeOf((*MockGitlabInterface)(nil).GetWebHook), gitSource, user, gitOrgRef, webHookID)
+}
+
+// GetPullRequest indicates an expected call of GetPullRequest
+func (mr *MockGitlabInterfaceMockRecorder) GetPullRequest(gitSource, user, gitOrgRef, repositoryRef, pullRequestNumber interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPullRequest", reflect.TypeOf((*MockGitlabInterface)(nil).GetPullRequest), gitSource, user, gitOrgRef, repositoryRef, pullRequestNumber)
+}
+
+// GetRepositoriesWithSubgroups indicates an expected call of GetRepositoriesWithSubgroups
+func (mr *MockGitlabInterfaceMockRecorder) GetRepositoriesWithSubgroups(gitSource, user, gitOrgRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRepositoriesWithSubgroups", reflect.TypeOf((*MockGitlabInterface)(nil).GetRepositoriesWithSubgroups), gitSource, user, gitOrgRef)
+}
+
+// CreateRepositoryWebHook indicates an expected call of CreateRepositoryWebHook
+func (mr *MockGitlabInterfaceMockRecorder) CreateRepositoryWebHook(gitSource, user, gitOrgRef, repositoryRef, organizationRef, webHookSecret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRepositoryWebHook", reflect.TypeOf((*MockGitlabInterface)(nil).CreateRepositoryWebHook), gitSource, user, gitOrgRef, repositoryRef, organizationRef, webHookSecret)
+}
+
+// DeleteRepositoryWebHook indicates an expected call of DeleteRepositoryWebHook
+func (mr *MockGitlabInterfaceMockRecorder) DeleteRepositoryWebHook(gitSource, user, gitOrgRef, repositoryRef, webHookID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRepositoryWebHook", reflect.TypeOf((*MockGitlabInterface)(nil).DeleteRepositoryWebHook), gitSource, user, gitOrgRef, repositoryRef, webHookID)
+}
+
+// GetRepositoryWebHook indicates an expected call of GetRepositoryWebHook
+func (mr *MockGitlabInterfaceMockRecorder) GetRepositoryWebHook(gitSource, user, gitOrgRef, repositoryRef, webHookID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRepositoryWebHook", reflect.TypeOf((*MockGitlabInterface)(nil).GetRepositoryWebHook), gitSource, user, gitOrgRef, repositoryRef, webHookID)
+}
+
+// GetUserRepositories indicates an expected call of GetUserRepositories
+func (mr *MockGitlabInterfaceMockRecorder) GetUserRepositories(gitSource, user, gitOrgRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRepositories", reflect.TypeOf((*MockGitlabInterface)(nil).GetUserRepositories), gitSource, user, gitOrgRef)
+}
+
+// CheckBranchAgolaConfExists indicates an expected call of CheckBranchAgolaConfExists
+func (mr *MockGitlabInterfaceMockRecorder) CheckBranchAgolaConfExists(gitSource, user, gitOrgRef, repositoryRef, branchName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckBranchAgolaConfExists", reflect.TypeOf((*MockGitlabInterface)(nil).CheckBranchAgolaConfExists), gitSource, user, gitOrgRef, repositoryRef, branchName)
+}
+
+// GetAgolaConfBranches indicates an expected call of GetAgolaConfBranches
+func (mr *MockGitlabInterfaceMockRecorder) GetAgolaConfBranches(gitSource, user, gitOrgRef, repositoryRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAgolaConfBranches", reflect.TypeOf((*MockGitlabInterface)(nil).GetAgolaConfBranches), gitSource, user, gitOrgRef, repositoryRef)
+}
+
+// GetRepositoryCollaborators indicates an expected call of GetRepositoryCollaborators
+func (mr *MockGitlabInterfaceMockRecorder) GetRepositoryCollaborators(gitSource, user, gitOrgRef, repositoryRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRepositoryCollaborators", reflect.TypeOf((*MockGitlabInterface)(nil).GetRepositoryCollaborators), gitSource, user, gitOrgRef, repositoryRef)
+}
+
+// CreateSystemHook indicates an expected call of CreateSystemHook
+func (mr *MockGitlabInterfaceMockRecorder) CreateSystemHook(gitSource, user, webHookURL, webHookSecret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSystemHook", reflect.TypeOf((*MockGitlabInterface)(nil).CreateSystemHook), gitSource, user, webHookURL, webHookSecret)
+}
+
+// DeleteSystemHook indicates an expected call of DeleteSystemHook
+func (mr *MockGitlabInterfaceMockRecorder) DeleteSystemHook(gitSource, user, systemHookID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSystemHook", reflect.TypeOf((*MockGitlabInterface)(nil).DeleteSystemHook), gitSource, user, systemHookID)
+}
+
+// GetSystemHook indicates an expected call of GetSystemHook
+func (mr *MockGitlabInterfaceMockRecorder) GetSystemHook(gitSource, user, systemHookID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSystemHook", reflect.TypeOf((*MockGitlabInterface)(nil).GetSystemHook), gitSource, user, systemHookID)
+}
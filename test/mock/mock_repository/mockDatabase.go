@@ -7,6 +7,7 @@ package mock_repository
 import (
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
+	time "time"
 	model "wecode.sorint.it/opensource/papagaio-api/model"
 )
 
@@ -375,8 +376,109 @@ func (m *MockDatabase) DeleteUser(userId uint64) error {
 	return ret0
 }
 
+// DeleteWebHookEvent mocks base method
+func (m *MockDatabase) DeleteWebHookEvent(organizationRef, eventID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebHookEvent", organizationRef, eventID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetWebHookEvents mocks base method
+func (m *MockDatabase) GetWebHookEvents(organizationRef string) (*[]model.WebHookEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebHookEvents", organizationRef)
+	ret0, _ := ret[0].(*[]model.WebHookEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveWebHookEvent mocks base method
+func (m *MockDatabase) SaveWebHookEvent(event *model.WebHookEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveWebHookEvent", event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetWebHookEvent mocks base method
+func (m *MockDatabase) GetWebHookEvent(organizationRef, eventID string) (*model.WebHookEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebHookEvent", organizationRef, eventID)
+	ret0, _ := ret[0].(*model.WebHookEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteWebHookDelivery mocks base method
+func (m *MockDatabase) DeleteWebHookDelivery(deliveryKey string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebHookDelivery", deliveryKey)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetWebHookDeliveries mocks base method
+func (m *MockDatabase) GetWebHookDeliveries() (map[string]time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebHookDeliveries")
+	ret0, _ := ret[0].(map[string]time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveWebHookDelivery mocks base method
+func (m *MockDatabase) SaveWebHookDelivery(deliveryKey string, receivedAt time.Time, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveWebHookDelivery", deliveryKey, receivedAt, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
 // DeleteUser indicates an expected call of DeleteUser
 func (mr *MockDatabaseMockRecorder) DeleteUser(userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockDatabase)(nil).DeleteUser), userId)
 }
+
+// DeleteWebHookEvent indicates an expected call of DeleteWebHookEvent
+func (mr *MockDatabaseMockRecorder) DeleteWebHookEvent(organizationRef, eventID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebHookEvent", reflect.TypeOf((*MockDatabase)(nil).DeleteWebHookEvent), organizationRef, eventID)
+}
+
+// GetWebHookEvents indicates an expected call of GetWebHookEvents
+func (mr *MockDatabaseMockRecorder) GetWebHookEvents(organizationRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebHookEvents", reflect.TypeOf((*MockDatabase)(nil).GetWebHookEvents), organizationRef)
+}
+
+// SaveWebHookEvent indicates an expected call of SaveWebHookEvent
+func (mr *MockDatabaseMockRecorder) SaveWebHookEvent(event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveWebHookEvent", reflect.TypeOf((*MockDatabase)(nil).SaveWebHookEvent), event)
+}
+
+// GetWebHookEvent indicates an expected call of GetWebHookEvent
+func (mr *MockDatabaseMockRecorder) GetWebHookEvent(organizationRef, eventID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebHookEvent", reflect.TypeOf((*MockDatabase)(nil).GetWebHookEvent), organizationRef, eventID)
+}
+
+// DeleteWebHookDelivery indicates an expected call of DeleteWebHookDelivery
+func (mr *MockDatabaseMockRecorder) DeleteWebHookDelivery(deliveryKey interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebHookDelivery", reflect.TypeOf((*MockDatabase)(nil).DeleteWebHookDelivery), deliveryKey)
+}
+
+// GetWebHookDeliveries indicates an expected call of GetWebHookDeliveries
+func (mr *MockDatabaseMockRecorder) GetWebHookDeliveries() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebHookDeliveries", reflect.TypeOf((*MockDatabase)(nil).GetWebHookDeliveries))
+}
+
+// SaveWebHookDelivery indicates an expected call of SaveWebHookDelivery
+func (mr *MockDatabaseMockRecorder) SaveWebHookDelivery(deliveryKey, receivedAt, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveWebHookDelivery", reflect.TypeOf((*MockDatabase)(nil).SaveWebHookDelivery), deliveryKey, receivedAt, ttl)
+}
@@ -1,6 +1,7 @@
 package trigger
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"time"
@@ -9,17 +10,18 @@ import (
 	"wecode.sorint.it/opensource/papagaio-api/api/git"
 	"wecode.sorint.it/opensource/papagaio-api/manager/membersManager"
 	"wecode.sorint.it/opensource/papagaio-api/manager/repositoryManager"
+	"wecode.sorint.it/opensource/papagaio-api/manager/webHookManager"
 	"wecode.sorint.it/opensource/papagaio-api/repository"
 	"wecode.sorint.it/opensource/papagaio-api/trigger/dto"
 	"wecode.sorint.it/opensource/papagaio-api/utils"
 )
 
-func StartOrganizationSync(db repository.Database, tr utils.ConfigUtils, commonMutex *utils.CommonMutex, agolaApi agola.AgolaApiInterface, gitGateway *git.GitGateway, rtDto **dto.TriggerRunTimeDto) {
-	go syncOrganizationRun(db, tr, commonMutex, agolaApi, gitGateway, rtDto)
+func StartOrganizationSync(ctx context.Context, db repository.Database, tr utils.ConfigUtils, commonMutex *utils.CommonMutex, agolaApi agola.AgolaApiInterface, gitGateway *git.GitGateway, rtDto **dto.TriggerRunTimeDto) {
+	go syncOrganizationRun(ctx, db, tr, commonMutex, agolaApi, gitGateway, rtDto)
 }
 
 //Synchronize projects and members of organizations
-func syncOrganizationRun(db repository.Database, tr utils.ConfigUtils, commonMutex *utils.CommonMutex, agolaApi agola.AgolaApiInterface, gitGateway *git.GitGateway, rtDtoP **dto.TriggerRunTimeDto) {
+func syncOrganizationRun(ctx context.Context, db repository.Database, tr utils.ConfigUtils, commonMutex *utils.CommonMutex, agolaApi agola.AgolaApiInterface, gitGateway *git.GitGateway, rtDtoP **dto.TriggerRunTimeDto) {
 	defer func() {
 		*rtDtoP = nil
 		log.Println("syncOrganizationRun stopped")
@@ -35,6 +37,10 @@ func syncOrganizationRun(db repository.Database, tr utils.ConfigUtils, commonMut
 
 		organizationsRef, _ := db.GetOrganizationsRef()
 		for _, organizationRef := range organizationsRef {
+			if ctx.Err() != nil {
+				break
+			}
+
 			log.Println("syncOrganizationRun organizationRef:", organizationRef)
 			mutex := utils.ReserveOrganizationMutex(organizationRef, commonMutex)
 			mutex.Lock()
@@ -70,9 +76,9 @@ func syncOrganizationRun(db repository.Database, tr utils.ConfigUtils, commonMut
 			}
 
 			//if organization deleted in git, delete in Agola, else update data in db
-			gitOrganization, err := gitGateway.GetOrganization(gitSource, user, org.GitPath)
+			gitOrganization, err := gitGateway.GetNamespace(gitSource, user, org)
 			if err != nil {
-				log.Println("GetOrganization error:", err)
+				log.Println("GetNamespace error:", err)
 
 				mutex.Unlock()
 				utils.ReleaseOrganizationMutex(organizationRef, commonMutex)
@@ -83,7 +89,7 @@ func syncOrganizationRun(db repository.Database, tr utils.ConfigUtils, commonMut
 			if gitOrganization == nil {
 				log.Println("organization", organizationRef, "not found")
 
-				err = agolaApi.DeleteOrganization(org, user)
+				err = agolaApi.DeleteOrganization(ctx, org, user)
 				if err == nil {
 					err := db.DeleteOrganization(organizationRef)
 					if err != nil {
@@ -111,7 +117,7 @@ func syncOrganizationRun(db repository.Database, tr utils.ConfigUtils, commonMut
 			}
 
 			//If organization deleted in Agola, recreate
-			agolaOrganizationExists, _, err := agolaApi.CheckOrganizationExists(org)
+			agolaOrganizationExists, _, err := agolaApi.CheckOrganizationExists(ctx, org)
 			if err != nil {
 				log.Println("Agola CheckOrganizationExists error:", err)
 
@@ -121,8 +127,17 @@ func syncOrganizationRun(db repository.Database, tr utils.ConfigUtils, commonMut
 				continue
 			}
 
+			if !agolaOrganizationExists && org.UserNamespace {
+				log.Println("agola user", org.AgolaUserRef, "of organization", organizationRef, "not found")
+
+				mutex.Unlock()
+				utils.ReleaseOrganizationMutex(organizationRef, commonMutex)
+
+				continue
+			}
+
 			if !agolaOrganizationExists {
-				orgID, err := agolaApi.CreateOrganization(org, org.Visibility)
+				orgID, err := agolaApi.CreateOrganization(ctx, org, org.Visibility)
 				if err != nil {
 					log.Println("failed to recreate organization", org.AgolaOrganizationRef, "in agola:", err)
 
@@ -142,12 +157,17 @@ func syncOrganizationRun(db repository.Database, tr utils.ConfigUtils, commonMut
 
 			log.Println("start synk organization", org.GitPath)
 
-			err = membersManager.SynkMembers(org, gitSource, agolaApi, gitGateway, user)
+			err = webHookManager.SynkWebHook(db, user, org, gitSource, gitGateway)
+			if err != nil {
+				log.Println("SynkWebHook error:", err)
+			}
+
+			err = membersManager.SynkMembers(ctx, org, gitSource, agolaApi, gitGateway, user)
 			if err != nil {
 				log.Println("SynkMembers error:", err)
 			}
 
-			err = repositoryManager.SynkGitRepositorys(db, user, org, gitSource, agolaApi, gitGateway)
+			err = repositoryManager.SynkGitRepositorys(ctx, db, user, org, gitSource, agolaApi, gitGateway)
 			if err != nil {
 				log.Println("SynkGitRepositorys error:", err)
 			}
@@ -169,6 +189,11 @@ func syncOrganizationRun(db repository.Database, tr utils.ConfigUtils, commonMut
 		}
 
 		select {
+		case <-ctx.Done():
+			log.Println("syncOrganizationRun stopping on shutdown")
+
+			return
+
 		case message := <-rtDto.Chan:
 			if message == dto.Stop {
 				log.Println("syncOrganizationRun stopping")
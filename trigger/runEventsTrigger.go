@@ -0,0 +1,308 @@
+package trigger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"wecode.sorint.it/opensource/papagaio-api/api/agola"
+	"wecode.sorint.it/opensource/papagaio-api/api/git"
+	"wecode.sorint.it/opensource/papagaio-api/repository"
+	"wecode.sorint.it/opensource/papagaio-api/utils"
+)
+
+const runEventsMinReconnectDelay = 5 * time.Second
+const runEventsMaxReconnectDelay = 5 * time.Minute
+
+//Projects waiting for the update of their runs, when the queue is full the polling is done
+const runEventsQueueSize = 100
+
+//The index isn't built again for a project not found in the last minute, it's not a papagaio project
+const runEventsUnknownProjectDelay = time.Minute
+
+/*
+Listener of the Agola run events stream: when a run is finished only the runs of its project are updated.
+The projects are updated by a worker, so a slow update doesn't stop the reading of the stream.
+While the stream is disconnected and after every reconnect the runs of all the projects must be checked by the polling
+*/
+type RunEventsListener struct {
+	Db          repository.Database
+	CommonMutex *utils.CommonMutex
+	AgolaApi    agola.AgolaApiInterface
+	GitGateway  *git.GitGateway
+	//First delay of the reconnect backoff
+	MinReconnectDelay time.Duration
+
+	mutex     sync.Mutex
+	connected bool
+	//the events could be lost since the last polling
+	reconcile bool
+	//wake up the polling on connect and disconnect
+	changed chan struct{}
+
+	projects chan string
+	//projects in the queue, a project is queued once
+	pending map[string]bool
+
+	//used only by the worker
+	projectsIndex   map[string]runEventsProject
+	unknownProjects map[string]time.Time
+}
+
+//Project of an Agola project ID
+type runEventsProject struct {
+	organizationRef string
+	projectName     string
+}
+
+func NewRunEventsListener(db repository.Database, commonMutex *utils.CommonMutex, agolaApi agola.AgolaApiInterface, gitGateway *git.GitGateway) *RunEventsListener {
+	return &RunEventsListener{
+		Db:                db,
+		CommonMutex:       commonMutex,
+		AgolaApi:          agolaApi,
+		GitGateway:        gitGateway,
+		MinReconnectDelay: runEventsMinReconnectDelay,
+		reconcile:         true,
+		changed:           make(chan struct{}, 1),
+		projects:          make(chan string, runEventsQueueSize),
+		pending:           make(map[string]bool),
+		projectsIndex:     make(map[string]runEventsProject),
+		unknownProjects:   make(map[string]time.Time),
+	}
+}
+
+func StartRunEventsListener(ctx context.Context, db repository.Database, commonMutex *utils.CommonMutex, agolaApi agola.AgolaApiInterface, gitGateway *git.GitGateway) *RunEventsListener {
+	listener := NewRunEventsListener(db, commonMutex, agolaApi, gitGateway)
+	listener.Start(ctx)
+
+	return listener
+}
+
+//Connect the stream and update the projects in background until the context is cancelled
+func (listener *RunEventsListener) Start(ctx context.Context) {
+	go listener.run(ctx)
+	go listener.updateProjects(ctx)
+}
+
+//Return true if the polling of all the projects is needed, the pending reconciliation is taken
+func (listener *RunEventsListener) NeedsPolling() bool {
+	if listener == nil {
+		return true
+	}
+
+	listener.mutex.Lock()
+	defer listener.mutex.Unlock()
+
+	needsPolling := !listener.connected || listener.reconcile
+	listener.reconcile = false
+
+	return needsPolling
+}
+
+//Notified when the stream is connected or disconnected, nil without listener
+func (listener *RunEventsListener) Changed() <-chan struct{} {
+	if listener == nil {
+		return nil
+	}
+
+	return listener.changed
+}
+
+func (listener *RunEventsListener) setConnected(connected bool) {
+	listener.mutex.Lock()
+	listener.connected = connected
+	//the runs finished while disconnected are found by the polling
+	if connected {
+		listener.reconcile = true
+	}
+	listener.mutex.Unlock()
+
+	listener.notifyChanged()
+}
+
+func (listener *RunEventsListener) notifyChanged() {
+	select {
+	case listener.changed <- struct{}{}:
+	default:
+	}
+}
+
+//Keep the stream connected, after a failure it reconnects with an exponential backoff
+func (listener *RunEventsListener) run(ctx context.Context) {
+	delay := listener.MinReconnectDelay
+
+	for ctx.Err() == nil {
+		stream, err := listener.AgolaApi.GetRunEventsStream(ctx)
+		if err != nil {
+			log.Println("runEvents connection error:", err)
+		} else {
+			log.Println("runEvents connected")
+			listener.setConnected(true)
+			delay = listener.MinReconnectDelay
+
+			err = listener.ReadEvents(ctx, stream)
+			stream.Close()
+
+			log.Println("runEvents disconnected:", err)
+			listener.setConnected(false)
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > runEventsMaxReconnectDelay {
+			delay = runEventsMaxReconnectDelay
+		}
+	}
+
+	log.Println("runEvents stopped")
+}
+
+//Read the server-sent events until the stream ends, the data lines of an event are joined
+func (listener *RunEventsListener) ReadEvents(ctx context.Context, stream io.Reader) error {
+	reader := bufio.NewReader(stream)
+	data := make([]string, 0)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				return io.ErrUnexpectedEOF
+			}
+			return err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if len(line) > 0 {
+			if strings.HasPrefix(line, "data:") {
+				data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+			}
+			continue
+		}
+
+		if len(data) == 0 {
+			continue
+		}
+
+		var event agola.RunEventDto
+		if err := json.Unmarshal([]byte(strings.Join(data, "\n")), &event); err != nil {
+			log.Println("runEvents event not valid:", err)
+		} else {
+			listener.ProcessRunEvent(ctx, &event)
+		}
+		data = data[:0]
+	}
+}
+
+//Queue the project of the run when the run is finished, its runs are updated by the worker
+func (listener *RunEventsListener) ProcessRunEvent(ctx context.Context, event *agola.RunEventDto) {
+	if event.Phase != agola.RunPhaseFinished {
+		return
+	}
+
+	projectID := event.GetProjectID()
+	if len(projectID) == 0 {
+		return
+	}
+
+	listener.mutex.Lock()
+	defer listener.mutex.Unlock()
+
+	if listener.pending[projectID] {
+		return
+	}
+
+	select {
+	case listener.projects <- projectID:
+		listener.pending[projectID] = true
+	default:
+		log.Println("runEvents queue full, the runs of project", projectID, "are updated by the polling")
+		listener.reconcile = true
+		listener.notifyChanged()
+	}
+}
+
+func (listener *RunEventsListener) updateProjects(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case projectID := <-listener.projects:
+			listener.mutex.Lock()
+			delete(listener.pending, projectID)
+			listener.mutex.Unlock()
+
+			listener.UpdateProjectRuns(ctx, projectID)
+		}
+	}
+}
+
+/*
+Update the runs of the project with the Agola project ID.
+The project is found by the index of the projects, which is built again when the project isn't found or was moved
+*/
+func (listener *RunEventsListener) UpdateProjectRuns(ctx context.Context, projectID string) {
+	project, ok := listener.projectsIndex[projectID]
+	if ok && !listener.isIndexed(project, projectID) {
+		ok = false
+	}
+	if !ok {
+		if unknownAt, unknown := listener.unknownProjects[projectID]; unknown && time.Since(unknownAt) < runEventsUnknownProjectDelay {
+			return
+		}
+
+		listener.buildProjectsIndex()
+		project, ok = listener.projectsIndex[projectID]
+	}
+	if !ok {
+		log.Println("runEvents project", projectID, "not found")
+		listener.unknownProjects[projectID] = time.Now()
+		return
+	}
+
+	discoveryOrganizationRuns(ctx, listener.Db, listener.CommonMutex, listener.AgolaApi, listener.GitGateway, project.organizationRef, project.projectName)
+}
+
+//The projects can be removed or moved after the index is built
+func (listener *RunEventsListener) isIndexed(project runEventsProject, projectID string) bool {
+	organization, _ := listener.Db.GetOrganizationByAgolaRef(project.organizationRef)
+	if organization == nil {
+		return false
+	}
+
+	indexedProject, ok := organization.Projects[project.projectName]
+	return ok && strings.Compare(indexedProject.AgolaProjectID, projectID) == 0
+}
+
+//On error the previous index is kept
+func (listener *RunEventsListener) buildProjectsIndex() {
+	organizations, err := listener.Db.GetOrganizations()
+	if err != nil || organizations == nil {
+		log.Println("runEvents GetOrganizations error:", err)
+		return
+	}
+
+	projectsIndex := make(map[string]runEventsProject)
+	for _, organization := range *organizations {
+		for projectName, project := range organization.Projects {
+			if len(project.AgolaProjectID) > 0 {
+				projectsIndex[project.AgolaProjectID] = runEventsProject{organizationRef: organization.AgolaOrganizationRef, projectName: projectName}
+			}
+		}
+	}
+
+	listener.projectsIndex = projectsIndex
+	for projectID, unknownAt := range listener.unknownProjects {
+		if time.Since(unknownAt) >= runEventsUnknownProjectDelay {
+			delete(listener.unknownProjects, projectID)
+		}
+	}
+}
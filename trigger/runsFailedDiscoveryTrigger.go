@@ -1,8 +1,10 @@
 package trigger
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"wecode.sorint.it/opensource/papagaio-api/api/agola"
@@ -15,15 +17,15 @@ import (
 	"wecode.sorint.it/opensource/papagaio-api/utils"
 )
 
-func StartRunFailsDiscovery(db repository.Database, tr utils.ConfigUtils, commonMutex *utils.CommonMutex, agolaApi agola.AgolaApiInterface, gitGateway *git.GitGateway, rtDto **dto.TriggerRunTimeDto) {
-	go discoveryRunFails(db, tr, commonMutex, agolaApi, gitGateway, rtDto)
+func StartRunFailsDiscovery(ctx context.Context, db repository.Database, tr utils.ConfigUtils, commonMutex *utils.CommonMutex, agolaApi agola.AgolaApiInterface, gitGateway *git.GitGateway, rtDto **dto.TriggerRunTimeDto) {
+	go discoveryRunFails(ctx, db, tr, commonMutex, agolaApi, gitGateway, rtDto)
 }
 
 /*
 Scan Agola project runs and store it for elaborating of reports.
 If find failed runs send email to users
 */
-func discoveryRunFails(db repository.Database, tr utils.ConfigUtils, commonMutex *utils.CommonMutex, agolaApi agola.AgolaApiInterface, gitGateway *git.GitGateway, rtDtoP **dto.TriggerRunTimeDto) {
+func discoveryRunFails(ctx context.Context, db repository.Database, tr utils.ConfigUtils, commonMutex *utils.CommonMutex, agolaApi agola.AgolaApiInterface, gitGateway *git.GitGateway, rtDtoP **dto.TriggerRunTimeDto) {
 	defer func() {
 		*rtDtoP = nil
 		log.Println("discoveryRunFails stopped")
@@ -31,114 +33,34 @@ func discoveryRunFails(db repository.Database, tr utils.ConfigUtils, commonMutex
 
 	rtDto := *rtDtoP
 
+	var runEvents *RunEventsListener
+	if config.Config.TriggersConfig.RunEvents && len(config.Config.Agola.RunserviceAddr) == 0 {
+		log.Println("discoveryRunFails: RunEvents needs Agola.RunserviceAddr, the runs are updated only by the polling")
+	} else if config.Config.TriggersConfig.RunEvents {
+		runEventsCtx, cancelRunEvents := context.WithCancel(ctx)
+		defer cancelRunEvents()
+
+		runEvents = StartRunEventsListener(runEventsCtx, db, commonMutex, agolaApi, gitGateway)
+	}
+
 	for {
 		rtDto.IsRunning = true
 		rtDto.LastRun = time.Now()
 
-		log.Println("Start discoveryRunFails")
-
-		organizationsRef, _ := db.GetOrganizationsRef()
-
-		for _, organizationRef := range organizationsRef {
-			mutex := utils.ReserveOrganizationMutex(organizationRef, commonMutex)
-			mutex.Lock()
-
-			org, _ := db.GetOrganizationByAgolaRef(organizationRef)
-			if org == nil {
-				log.Println("discoveryRunFails organization ", organizationRef, "not found")
-
-				mutex.Unlock()
-				utils.ReleaseOrganizationMutex(organizationRef, commonMutex)
-
-				continue
-			}
-
-			gitSource, err := db.GetGitSourceByName(org.GitSourceName)
-			if gitSource == nil || err != nil || org.Projects == nil {
-				log.Println("discoveryRunFails gitsource not fount for", organizationRef, "organization")
-
-				mutex.Unlock()
-				utils.ReleaseOrganizationMutex(organizationRef, commonMutex)
-
-				continue
-			}
+		if runEvents.NeedsPolling() {
+			log.Println("Start discoveryRunFails")
 
-			user, _ := db.GetUserByUserId(org.UserIDConnected)
+			organizationsRef, _ := db.GetOrganizationsRef()
 
-			for projectName, project := range org.Projects {
-				if project.Archivied {
-					continue
+			for _, organizationRef := range organizationsRef {
+				if ctx.Err() != nil {
+					break
 				}
 
-				checkNewRuns := CheckIfNewRunsPresent(&project, agolaApi)
-				if !checkNewRuns {
-					log.Println("no new runs found for project", projectName)
-					continue
-				}
-
-				//If there are new runs asks for other runs
-				lastRun := project.GetLastRun()
-				runList, _ := agolaApi.GetRuns(project.AgolaProjectID, false, "finished", &lastRun.Number, 0, true)
-
-				runList = takeWebhookTrigger(runList)
-
-				for _, run := range runList {
-					if !run.IsBranch() { //skip tags
-						continue
-					}
-
-					newRun := model.RunInfo{
-						Number: run.Number,
-						Branch: run.GetBranchName(),
-						Phase:  types.RunPhase(run.Phase),
-						Result: types.RunResult(run.Result),
-					}
-					if run.StartTime != nil {
-						newRun.RunStartDate = *run.StartTime
-					}
-					if run.EndTime != nil {
-						newRun.RunEndDate = *run.EndTime
-					}
-					project.PushNewRun(newRun)
-
-					//
-
-					if run.Result == agola.RunResultFailed && run.StartTime.After(lastRun.RunStartDate) {
-						r, err := agolaApi.GetRun(project.AgolaProjectID, run.Number)
-						if err != nil {
-							log.Println("Failed to get run:", project.AgolaProjectID, run.Number)
-							continue
-						}
-
-						log.Println("Found run failed!")
-						emailMap := getUsersEmailMap(gitSource, user, org, project.GitRepoPath, r, gitGateway)
-						log.Println("send emails to:", emailMap)
-
-						body, err := makeBody(org, project.AgolaProjectID, project.GitRepoPath, r, agolaApi)
-						if err != nil {
-							log.Println("Failed to make email body")
-							continue
-						}
-						subject := makeSubject(org, project.GitRepoPath, r)
-
-						if utils.CanSendEmail() {
-							utils.SendConfirmEmail(emailMap, nil, subject, body)
-						} else {
-							log.Println("Can not send email, settings are not correct")
-						}
-					}
-				}
-
-				org.Projects[projectName] = project
+				discoveryOrganizationRuns(ctx, db, commonMutex, agolaApi, gitGateway, organizationRef, "")
 			}
-			err = db.SaveOrganization(org)
-
-			if err != nil {
-				log.Println("error in SaveOrganization:", err)
-			}
-
-			mutex.Unlock()
-			utils.ReleaseOrganizationMutex(organizationRef, commonMutex)
+		} else {
+			log.Println("discoveryRunFails skipped, the runs are updated by the Agola run events")
 		}
 
 		rtDto.IsRunning = false
@@ -154,6 +76,11 @@ func discoveryRunFails(db repository.Database, tr utils.ConfigUtils, commonMutex
 		}
 
 		select {
+		case <-ctx.Done():
+			log.Println("discoveryRunFails stopping on shutdown")
+
+			return
+
 		case message := <-rtDto.Chan:
 			fmt.Println("discoveryRunFails message:", message)
 			if message == dto.Stop {
@@ -162,11 +89,128 @@ func discoveryRunFails(db repository.Database, tr utils.ConfigUtils, commonMutex
 				return
 			}
 
+		case <-runEvents.Changed():
+
 		case <-time.After(time.Duration(time.Minute.Nanoseconds() * int64(rtDto.TriggerTime))):
 		}
 	}
 }
 
+//Store the new finished runs of the organization projects and send the emails for the failed runs, onlyProject limits the check to a project
+func discoveryOrganizationRuns(ctx context.Context, db repository.Database, commonMutex *utils.CommonMutex, agolaApi agola.AgolaApiInterface, gitGateway *git.GitGateway, organizationRef string, onlyProject string) {
+	mutex := utils.ReserveOrganizationMutex(organizationRef, commonMutex)
+	mutex.Lock()
+
+	org, _ := db.GetOrganizationByAgolaRef(organizationRef)
+	if org == nil {
+		log.Println("discoveryRunFails organization ", organizationRef, "not found")
+
+		mutex.Unlock()
+		utils.ReleaseOrganizationMutex(organizationRef, commonMutex)
+
+		return
+	}
+
+	gitSource, err := db.GetGitSourceByName(org.GitSourceName)
+	if gitSource == nil || err != nil || org.Projects == nil {
+		log.Println("discoveryRunFails gitsource not fount for", organizationRef, "organization")
+
+		mutex.Unlock()
+		utils.ReleaseOrganizationMutex(organizationRef, commonMutex)
+
+		return
+	}
+
+	user, _ := db.GetUserByUserId(org.UserIDConnected)
+
+	for projectName, project := range org.Projects {
+		if project.Archivied || (len(onlyProject) > 0 && strings.Compare(projectName, onlyProject) != 0) {
+			continue
+		}
+
+		checkNewRuns := CheckIfNewRunsPresent(ctx, &project, agolaApi)
+		if !checkNewRuns {
+			log.Println("no new runs found for project", projectName)
+			continue
+		}
+
+		//If there are new runs asks for other runs
+		lastRun := project.GetLastRun()
+		runList, _ := agolaApi.GetRuns(ctx, project.AgolaProjectID, false, "finished", &lastRun.Number, 0, true)
+
+		runList = takeWebhookTrigger(runList)
+
+		for _, run := range runList {
+			newRun := model.RunInfo{
+				Number: run.Number,
+				Phase:  types.RunPhase(run.Phase),
+				Result: types.RunResult(run.Result),
+			}
+			if run.StartTime != nil {
+				newRun.RunStartDate = *run.StartTime
+			}
+			if run.EndTime != nil {
+				newRun.RunEndDate = *run.EndTime
+			}
+
+			var pullRequest *model.PullRequest
+			if run.IsBranch() {
+				newRun.Branch = run.GetBranchName()
+				project.PushNewRun(newRun)
+			} else if pullRequestNumber, ok := run.GetPullRequestNumber(); ok {
+				newRun.PullRequest = pullRequestNumber
+				pullRequest = getPullRequest(gitSource, user, org, &project, pullRequestNumber, gitGateway)
+				pullRequest.PushNewRun(newRun)
+				project.PushPullRequest(*pullRequest)
+			} else { //skip tags
+				continue
+			}
+
+			if run.Result == agola.RunResultFailed && run.StartTime.After(lastRun.RunStartDate) {
+				r, err := agolaApi.GetRun(ctx, project.AgolaProjectID, run.Number)
+				if err != nil {
+					log.Println("Failed to get run:", project.AgolaProjectID, run.Number)
+					continue
+				}
+
+				log.Println("Found run failed!")
+				var emailMap map[string]bool
+				var subject string
+				if pullRequest != nil {
+					emailMap = getPullRequestUsersEmailMap(gitSource, user, org, project.GitRepoPath, pullRequest, r, gitGateway)
+					subject = makePullRequestSubject(org, project.GitRepoPath, pullRequest)
+				} else {
+					emailMap = getUsersEmailMap(gitSource, user, org, project.GitRepoPath, r, gitGateway)
+					subject = makeSubject(org, project.GitRepoPath, r)
+				}
+				log.Println("send emails to:", emailMap)
+
+				body, err := makeBody(ctx, org, project.AgolaProjectID, project.GitRepoPath, r, agolaApi)
+				if err != nil {
+					log.Println("Failed to make email body")
+					continue
+				}
+
+				if utils.CanSendEmail() {
+					utils.SendConfirmEmail(emailMap, nil, subject, body)
+				} else {
+					log.Println("Can not send email, settings are not correct")
+				}
+			}
+		}
+
+		org.Projects[projectName] = project
+	}
+	err = db.SaveOrganization(org)
+
+	if err != nil {
+		log.Println("error in SaveOrganization:", err)
+	}
+
+	mutex.Unlock()
+	utils.ReleaseOrganizationMutex(organizationRef, commonMutex)
+}
+
 func getUsersEmailMap(gitSource *model.GitSource, user *model.User, organization *model.Organization, gitRepoPath string, failedRun *agola.RunDto, gitGateway *git.GitGateway) map[string]bool {
 	emails := make(map[string]bool)
 
@@ -176,7 +220,14 @@ func getUsersEmailMap(gitSource *model.GitSource, user *model.User, organization
 	//Users owner of the organization and users owner of the repository
 	var usersRepoOwners *[]string
 
-	usersRepoOwners, _ = gitGateway.GetEmailsRepositoryUsersOwner(gitSource, user, organization.GitPath, gitRepoPath)
+	//the owner of a user namespace is the connected user
+	if organization.UserNamespace {
+		if len(user.Email) > 0 {
+			usersRepoOwners = &[]string{user.Email}
+		}
+	} else {
+		usersRepoOwners, _ = gitGateway.GetEmailsRepositoryUsersOwner(gitSource, user, organization.GitPath, gitRepoPath)
+	}
 
 	for _, email := range emailUsersCommitted {
 		emails[email] = true
@@ -197,38 +248,80 @@ func getUsersEmailMap(gitSource *model.GitSource, user *model.User, organization
 	return emails
 }
 
+//Return the stored pull request, title author and source branch are read from git the first time
+func getPullRequest(gitSource *model.GitSource, user *model.User, organization *model.Organization, project *model.Project, pullRequestNumber int, gitGateway *git.GitGateway) *model.PullRequest {
+	pullRequest, ok := project.PullRequests[pullRequestNumber]
+	if !ok {
+		pullRequest = model.PullRequest{Number: pullRequestNumber}
+	}
+
+	if !pullRequest.IsEnriched() {
+		pullRequestDto, err := gitGateway.GetPullRequest(gitSource, user, organization.GitPath, project.GitRepoPath, pullRequestNumber)
+		if err != nil {
+			log.Println("GetPullRequest error:", err)
+		} else if pullRequestDto != nil {
+			pullRequest.Title = pullRequestDto.Title
+			pullRequest.Author = pullRequestDto.AuthorLogin
+			pullRequest.AuthorEmail = pullRequestDto.AuthorEmail
+			pullRequest.SourceBranch = pullRequestDto.SourceBranch
+		}
+	}
+
+	return &pullRequest
+}
+
+//The failed runs of a pull request are notified to its author, or to the committers when the author email is unknown
+func getPullRequestUsersEmailMap(gitSource *model.GitSource, user *model.User, organization *model.Organization, gitRepoPath string, pullRequest *model.PullRequest, failedRun *agola.RunDto, gitGateway *git.GitGateway) map[string]bool {
+	emails := make(map[string]bool)
+
+	if len(pullRequest.AuthorEmail) > 0 {
+		emails[pullRequest.AuthorEmail] = true
+	} else {
+		for _, email := range getEmailByRun(failedRun, gitSource, user, organization.GitPath, gitRepoPath, gitGateway) {
+			emails[email] = true
+		}
+	}
+
+	return emails
+}
+
 const bodyMessageTemplate string = "[%s/%s] FIX Agola Run (#%s)\n"
 const bodyLinkTemplate string = `See: <a href="%s">click here</a>`
 const subjectTemplate string = "Run failed in Agola: %s » %s » release #%s"
-const runAgolaPath string = "%s/org/%s/projects/%s.proj/runs/%d"
+const pullRequestSubjectTemplate string = "Run failed in Agola: %s » %s » pull request #%d %s"
+const runAgolaPath string = "%s/%s/projects/%s.proj/runs/%d"
+
+func makePullRequestSubject(organization *model.Organization, projectName string, pullRequest *model.PullRequest) string {
+	return fmt.Sprintf(pullRequestSubjectTemplate, organization.GitPath, projectName, pullRequest.Number, pullRequest.Title)
+}
 
 func makeSubject(organization *model.Organization, projectName string, failedRun *agola.RunDto) string {
 	return fmt.Sprintf(subjectTemplate, organization.GitPath, projectName, fmt.Sprint(failedRun.Number))
 }
 
 func getRunAgolaUrl(organization *model.Organization, projectName string, runNumber uint64) string {
-	return fmt.Sprintf(runAgolaPath, config.Config.Agola.AgolaAddr, organization.AgolaOrganizationRef, projectName, runNumber)
+	return fmt.Sprintf(runAgolaPath, config.Config.Agola.AgolaAddr, organization.AgolaParentRef(), projectName, runNumber)
 }
 
-func makeBody(organization *model.Organization, projectRef string, projectName string, failedRun *agola.RunDto, agolaApi agola.AgolaApiInterface) (string, error) {
+func makeBody(ctx context.Context, organization *model.Organization, projectRef string, projectName string, failedRun *agola.RunDto, agolaApi agola.AgolaApiInterface) (string, error) {
 	runUrl := getRunAgolaUrl(organization, projectName, failedRun.Number)
 	body := fmt.Sprintf(bodyMessageTemplate, organization.GitPath, projectName, fmt.Sprint(failedRun.Number))
 	body += fmt.Sprintf(bodyLinkTemplate, runUrl)
 
-	run, err := agolaApi.GetRun(projectRef, failedRun.Number)
+	run, err := agolaApi.GetRun(ctx, projectRef, failedRun.Number)
 	if err != nil {
 		return "", err
 	}
 
 	for _, task := range run.Tasks {
 		if task.Status == agola.RunTaskStatusFailed {
-			taskFailed, err := agolaApi.GetTask(projectRef, run.Number, task.ID)
+			taskFailed, err := agolaApi.GetTask(ctx, projectRef, run.Number, task.ID)
 			if err != nil {
 				return "", err
 			}
 
 			if taskFailed.SetupStep.Phase == agola.ExecutorTaskPhaseFailed {
-				logs, err := agolaApi.GetLogs(projectRef, run.Number, task.ID, -1)
+				logs, err := agolaApi.GetLogs(ctx, projectRef, run.Number, task.ID, -1)
 				if err != nil {
 					return "", err
 				}
@@ -239,7 +332,7 @@ func makeBody(organization *model.Organization, projectRef string, projectName s
 			for stepID, step := range taskFailed.Steps {
 				if step.Phase == agola.ExecutorTaskPhaseFailed {
 
-					logs, err := agolaApi.GetLogs(projectRef, run.Number, task.ID, stepID)
+					logs, err := agolaApi.GetLogs(ctx, projectRef, run.Number, task.ID, stepID)
 					if err != nil {
 						return "", err
 					}
@@ -255,9 +348,9 @@ func makeBody(organization *model.Organization, projectRef string, projectName s
 	return body, nil
 }
 
-func CheckIfNewRunsPresent(project *model.Project, agolaApi agola.AgolaApiInterface) bool {
+func CheckIfNewRunsPresent(ctx context.Context, project *model.Project, agolaApi agola.AgolaApiInterface) bool {
 	lastRun := project.GetLastRun()
-	runList, _ := agolaApi.GetRuns(project.AgolaProjectID, true, "finished", nil, 1, false)
+	runList, _ := agolaApi.GetRuns(ctx, project.AgolaProjectID, true, "finished", nil, 1, false)
 
 	return runList != nil && len(runList) != 0 && runList[0].Number > lastRun.Number
 }
@@ -1,6 +1,7 @@
 package trigger
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
@@ -15,11 +16,11 @@ import (
 	"wecode.sorint.it/opensource/papagaio-api/utils"
 )
 
-func StartSynkUsers(db repository.Database, tr utils.ConfigUtils, commonMutex *utils.CommonMutex, agolaApi agola.AgolaApiInterface, gitGateway *git.GitGateway, rtDto **dto.TriggerRunTimeDto) {
-	go synkUsersRun(db, tr, commonMutex, agolaApi, gitGateway, rtDto)
+func StartSynkUsers(ctx context.Context, db repository.Database, tr utils.ConfigUtils, commonMutex *utils.CommonMutex, agolaApi agola.AgolaApiInterface, gitGateway *git.GitGateway, rtDto **dto.TriggerRunTimeDto) {
+	go synkUsersRun(ctx, db, tr, commonMutex, agolaApi, gitGateway, rtDto)
 }
 
-func synkUsersRun(db repository.Database, tr utils.ConfigUtils, commonMutex *utils.CommonMutex, agolaApi agola.AgolaApiInterface, gitGateway *git.GitGateway, rtDtoP **dto.TriggerRunTimeDto) {
+func synkUsersRun(ctx context.Context, db repository.Database, tr utils.ConfigUtils, commonMutex *utils.CommonMutex, agolaApi agola.AgolaApiInterface, gitGateway *git.GitGateway, rtDtoP **dto.TriggerRunTimeDto) {
 	defer func() {
 		*rtDtoP = nil
 		log.Println("synkUsersRun stopped")
@@ -42,6 +43,10 @@ func synkUsersRun(db repository.Database, tr utils.ConfigUtils, commonMutex *uti
 		}
 
 		for _, userId := range usersId {
+			if ctx.Err() != nil {
+				break
+			}
+
 			user, err := db.GetUserByUserId(userId)
 			if err != nil || user == nil {
 				log.Println("user not found in db")
@@ -55,7 +60,7 @@ func synkUsersRun(db repository.Database, tr utils.ConfigUtils, commonMutex *uti
 				log.Println("gitSource", user.GitSourceName, "not found in db:", err)
 				continue
 			}
-			userGitNotFound, err := verifyUserAccount(user, db, gitGateway, agolaApi, gitSource)
+			userGitNotFound, err := verifyUserAccount(ctx, user, db, gitGateway, agolaApi, gitSource)
 
 			if userGitNotFound {
 				log.Println("delete user:", user.UserID)
@@ -79,6 +84,10 @@ func synkUsersRun(db repository.Database, tr utils.ConfigUtils, commonMutex *uti
 
 		organizationsRef, _ := db.GetOrganizationsRef()
 		for _, organizationRef := range organizationsRef {
+			if ctx.Err() != nil {
+				break
+			}
+
 			mutex := utils.ReserveOrganizationMutex(organizationRef, commonMutex)
 			mutex.Lock()
 
@@ -104,8 +113,21 @@ func synkUsersRun(db repository.Database, tr utils.ConfigUtils, commonMutex *uti
 				continue
 			}
 
+			//with a GitHub App the git calls are made by the installation, the connected user is kept while it exists, also when its oauth2 token isn't valid
+			if gitSource.IsGithubApp() {
+				if user == nil {
+					user, _ = db.GetUserByUserId(org.UserIDConnected)
+				}
+				if user != nil {
+					mutex.Unlock()
+					utils.ReleaseOrganizationMutex(organizationRef, commonMutex)
+
+					continue
+				}
+			}
+
 			if user != nil {
-				isOwner, _ := gitGateway.IsUserOwner(gitSource, user, org.GitPath)
+				isOwner, _ := gitGateway.IsNamespaceOwner(gitSource, user, org)
 				if isOwner {
 					mutex.Unlock()
 					utils.ReleaseOrganizationMutex(organizationRef, commonMutex)
@@ -115,7 +137,7 @@ func synkUsersRun(db repository.Database, tr utils.ConfigUtils, commonMutex *uti
 			}
 
 			log.Println("findUserToConnect for organization ", org.GitPath)
-			user = findUserToConnect(db, gitGateway, agolaApi, gitSource, org.GitPath, usersVerifiedOK)
+			user = findUserToConnect(db, gitGateway, agolaApi, gitSource, org, usersVerifiedOK)
 			if user != nil {
 				log.Println("findUserToConnect result UserID", user.UserID)
 				org.UserIDConnected = *user.UserID
@@ -143,6 +165,11 @@ func synkUsersRun(db repository.Database, tr utils.ConfigUtils, commonMutex *uti
 		}
 
 		select {
+		case <-ctx.Done():
+			log.Println("synkUsersRun stopping on shutdown")
+
+			return
+
 		case message := <-rtDto.Chan:
 			fmt.Println("synkUsersRun message:", message)
 			if message == dto.Stop {
@@ -156,9 +183,9 @@ func synkUsersRun(db repository.Database, tr utils.ConfigUtils, commonMutex *uti
 	}
 }
 
-func findUserToConnect(db repository.Database, gitGateway *git.GitGateway, agolaApi agola.AgolaApiInterface, gitSource *model.GitSource, gitOrgRef string, usersVerifiedOK map[uint64]*model.User) *model.User {
+func findUserToConnect(db repository.Database, gitGateway *git.GitGateway, agolaApi agola.AgolaApiInterface, gitSource *model.GitSource, organization *model.Organization, usersVerifiedOK map[uint64]*model.User) *model.User {
 	for _, user := range usersVerifiedOK {
-		isOwner, _ := gitGateway.IsUserOwner(gitSource, user, gitOrgRef)
+		isOwner, _ := gitGateway.IsNamespaceOwner(gitSource, user, organization)
 		if isOwner {
 			return user
 		}
@@ -167,14 +194,14 @@ func findUserToConnect(db repository.Database, gitGateway *git.GitGateway, agola
 	return nil
 }
 
-func verifyUserAccount(user *model.User, db repository.Database, gitGateway *git.GitGateway, agolaApi agola.AgolaApiInterface, gitSource *model.GitSource) (bool, error) {
+func verifyUserAccount(ctx context.Context, user *model.User, db repository.Database, gitGateway *git.GitGateway, agolaApi agola.AgolaApiInterface, gitSource *model.GitSource) (bool, error) {
 	userGitNotFound, err := verifyUserGiteaAccount(user, gitGateway, gitSource)
 
 	if userGitNotFound || err != nil {
 		return userGitNotFound, err
 	}
 
-	err = verifyUserAgolaAccount(user, agolaApi, gitSource)
+	err = verifyUserAgolaAccount(ctx, user, agolaApi, gitSource)
 	return false, err
 }
 
@@ -209,8 +236,11 @@ func verifyUserGiteaAccount(user *model.User, gitGateway *git.GitGateway, gitSou
 	return false, nil
 }
 
-func verifyUserAgolaAccount(user *model.User, agolaApi agola.AgolaApiInterface, gitSource *model.GitSource) error {
-	agolaUserRef := utils.GetAgolaUserRefByGitUserID(agolaApi, gitSource.AgolaRemoteSource, int64(user.ID))
+func verifyUserAgolaAccount(ctx context.Context, user *model.User, agolaApi agola.AgolaApiInterface, gitSource *model.GitSource) error {
+	agolaUserRef, err := utils.GetAgolaUserRefByGitUserID(ctx, agolaApi, gitSource.AgolaRemoteSource, int64(user.ID))
+	if err != nil {
+		return err
+	}
 	if agolaUserRef == nil {
 		user.AgolaUserRef = nil
 		return errors.New("user not present in Agola")
@@ -0,0 +1,211 @@
+package trigger
+
+import (
+	"context"
+	"hash/fnv"
+	"log"
+	"time"
+
+	"wecode.sorint.it/opensource/papagaio-api/model"
+	"wecode.sorint.it/opensource/papagaio-api/repository"
+)
+
+const webHookQueueRetryCheckInterval = 5 * time.Second
+
+type WebHookProcessor func(ctx context.Context, event *model.WebHookEvent) error
+
+type WebHookQueue struct {
+	Db          repository.Database
+	Processor   WebHookProcessor
+	MaxAttempts uint
+	RetryDelay  time.Duration
+	HistorySize uint
+
+	workers []chan string
+	//canceled on shutdown, the events being processed are left pending
+	ctx context.Context
+}
+
+func StartWebHookQueue(ctx context.Context, db repository.Database, processor WebHookProcessor, workers uint, maxAttempts uint, retryDelay time.Duration, historySize uint) *WebHookQueue {
+	queue := &WebHookQueue{
+		Db:          db,
+		Processor:   processor,
+		MaxAttempts: maxAttempts,
+		RetryDelay:  retryDelay,
+		HistorySize: historySize,
+		workers:     make([]chan string, workers),
+		ctx:         ctx,
+	}
+
+	for i := range queue.workers {
+		queue.workers[i] = make(chan string, 100)
+		go queue.workerRun(queue.workers[i])
+	}
+
+	go queue.retryRun()
+
+	return queue
+}
+
+//Store the event and notify the worker of the organization
+func (queue *WebHookQueue) Enqueue(event *model.WebHookEvent) error {
+	event.ID = ""
+	event.Status = model.WebHookEventPending
+	event.Attempts = 0
+	event.LastError = ""
+	event.ReceivedAt = time.Now()
+	event.NextAttempt = event.ReceivedAt
+
+	err := queue.Db.SaveWebHookEvent(event)
+	if err != nil {
+		return err
+	}
+
+	queue.notify(event.OrganizationRef)
+
+	return nil
+}
+
+func (queue *WebHookQueue) notify(organizationRef string) {
+	if len(queue.workers) == 0 {
+		return
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(organizationRef))
+	worker := queue.workers[h.Sum32()%uint32(len(queue.workers))]
+
+	//if the worker is busy the organization will be checked by retryRun
+	select {
+	case worker <- organizationRef:
+	default:
+	}
+}
+
+func (queue *WebHookQueue) getContext() context.Context {
+	if queue.ctx == nil {
+		return context.Background()
+	}
+	return queue.ctx
+}
+
+func (queue *WebHookQueue) workerRun(worker chan string) {
+	ctx := queue.getContext()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case organizationRef := <-worker:
+			queue.ProcessOrganizationEvents(organizationRef)
+		}
+	}
+}
+
+//Periodically wake up the workers for the retries and for the events stored before a restart
+func (queue *WebHookQueue) retryRun() {
+	ctx := queue.getContext()
+	for {
+		organizationsRef, err := queue.Db.GetOrganizationsRef()
+		if err != nil {
+			log.Println("webHookQueue GetOrganizationsRef error:", err)
+		}
+
+		for _, organizationRef := range organizationsRef {
+			queue.notify(organizationRef)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(webHookQueueRetryCheckInterval):
+		}
+	}
+}
+
+/*
+Process the pending events of the organization in arrival order.
+When an event fails the next ones wait for its retry, after MaxAttempts it is moved to the dead letter state.
+The completed events are kept as delivery history
+*/
+func (queue *WebHookQueue) ProcessOrganizationEvents(organizationRef string) {
+	events, err := queue.Db.GetWebHookEvents(organizationRef)
+	if err != nil {
+		log.Println("webHookQueue GetWebHookEvents error:", err)
+		return
+	}
+
+	defer queue.pruneHistory(organizationRef, *events)
+
+	ctx := queue.getContext()
+
+	for i := range *events {
+		event := &(*events)[i]
+		if !event.IsPending() {
+			continue
+		}
+
+		if event.NextAttempt.After(time.Now()) {
+			return
+		}
+
+		err := queue.Processor(ctx, event)
+		if err != nil && ctx.Err() != nil {
+			log.Println("webhook event", event.ID, "of organization", organizationRef, "interrupted by shutdown")
+			return
+		}
+		event.Attempts++
+
+		if err == nil {
+			event.Status = model.WebHookEventProcessed
+			event.LastError = ""
+			event.ProcessedAt = time.Now()
+		} else {
+			event.LastError = err.Error()
+			log.Println("webhook event", event.ID, "of organization", organizationRef, "failed attempt", event.Attempts, ":", err)
+
+			if uint(event.Attempts) >= queue.MaxAttempts {
+				log.Println("webhook event", event.ID, "of organization", organizationRef, "moved to dead letter")
+				event.Status = model.WebHookEventDeadLetter
+				event.ProcessedAt = time.Now()
+			} else {
+				event.NextAttempt = time.Now().Add(queue.RetryDelay << (event.Attempts - 1))
+			}
+		}
+
+		err = queue.Db.SaveWebHookEvent(event)
+		if err != nil {
+			log.Println("webHookQueue SaveWebHookEvent error:", err)
+			return
+		}
+
+		if event.IsPending() {
+			return
+		}
+	}
+}
+
+//Delete the oldest completed events exceeding HistorySize
+func (queue *WebHookQueue) pruneHistory(organizationRef string, events []model.WebHookEvent) {
+	completed := 0
+	for _, event := range events {
+		if !event.IsPending() {
+			completed++
+		}
+	}
+
+	for _, event := range events {
+		if uint(completed) <= queue.HistorySize {
+			return
+		}
+		if event.IsPending() {
+			continue
+		}
+
+		err := queue.Db.DeleteWebHookEvent(organizationRef, event.ID)
+		if err != nil {
+			log.Println("webHookQueue DeleteWebHookEvent error:", err)
+			return
+		}
+		completed--
+	}
+}
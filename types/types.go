@@ -5,14 +5,15 @@ import "errors"
 type GitType string
 
 const (
-	Gitea  GitType = "gitea"
-	Github GitType = "github"
-	Gitlab GitType = "gitlab"
+	Gitea     GitType = "gitea"
+	Github    GitType = "github"
+	Gitlab    GitType = "gitlab"
+	Bitbucket GitType = "bitbucket"
 )
 
 func (gt GitType) IsValid() error {
 	switch gt {
-	case Gitea, Github, Gitlab:
+	case Gitea, Github, Gitlab, Bitbucket:
 		return nil
 	}
 	return errors.New("invalid git type")
@@ -49,6 +50,44 @@ func (bt BehaviourType) IsValid() error {
 	return errors.New("invalid visibility type")
 }
 
+//Sync of the repository collaborators that aren't members of the git organization
+type CollaboratorsPolicy string
+
+const (
+	CollaboratorsIgnore CollaboratorsPolicy = "ignore"
+	//Agola has no project members, the collaborators become organization members with the member role
+	CollaboratorsMember CollaboratorsPolicy = "member"
+	//The collaborators aren't added to the Agola organization, they can see the runs of the projects of their repositories in papagaio
+	CollaboratorsProject CollaboratorsPolicy = "project"
+)
+
+//Empty is the ignore policy
+func (cp CollaboratorsPolicy) IsValid() error {
+	switch cp {
+	case "", CollaboratorsIgnore, CollaboratorsMember, CollaboratorsProject:
+		return nil
+	}
+	return errors.New("invalid collaborators policy")
+}
+
+//Agola role given by a git team or git role in the members mapping
+type MemberRole string
+
+const (
+	MemberRoleOwner  MemberRole = "owner"
+	MemberRoleMember MemberRole = "member"
+	//The team or role doesn't give access to the Agola organization
+	MemberRoleNone MemberRole = "none"
+)
+
+func (mr MemberRole) IsValid() error {
+	switch mr {
+	case MemberRoleOwner, MemberRoleMember, MemberRoleNone:
+		return nil
+	}
+	return errors.New("invalid member role")
+}
+
 type RunState string
 
 const (
@@ -76,3 +115,12 @@ const (
 	RunResultSuccess RunResult = "success"
 	RunResultFailed  RunResult = "failed"
 )
+
+type WebHookStatusType string
+
+const (
+	WebHookStatusUnknown  WebHookStatusType = "unknown"
+	WebHookStatusOk       WebHookStatusType = "ok"
+	WebHookStatusRepaired WebHookStatusType = "repaired"
+	WebHookStatusBroken   WebHookStatusType = "broken"
+)
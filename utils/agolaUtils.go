@@ -1,20 +1,21 @@
 package utils
 
 import (
+	"context"
+	"errors"
 	"strings"
 
 	"wecode.sorint.it/opensource/papagaio-api/api/agola"
-	"wecode.sorint.it/opensource/papagaio-api/api/git/dto"
 	"wecode.sorint.it/opensource/papagaio-api/config"
 	"wecode.sorint.it/opensource/papagaio-api/model"
 )
 
 func GetOrganizationUrl(organization *model.Organization) string {
-	return config.Config.Agola.AgolaAddr + "/org/" + organization.AgolaOrganizationRef
+	return config.Config.Agola.AgolaAddr + "/" + organization.AgolaParentRef()
 }
 
 func GetProjectUrl(organization *model.Organization, project *model.Project) *string {
-	url := config.Config.Agola.AgolaAddr + "/org/" + organization.AgolaOrganizationRef + "/projects/" + project.AgolaProjectRef + ".proj"
+	url := config.Config.Agola.AgolaAddr + "/" + organization.AgolaParentRef() + "/projects/" + project.AgolaProjectRef + ".proj"
 	return &url
 }
 
@@ -25,35 +26,23 @@ func ConvertToAgolaProjectRef(projectName string) string {
 	return agolaProjectName
 }
 
-//Return the users map by the agola remoteSource. Key is the git username and value agola userref
-func GetUsersMapByRemotesource(agolaApi agola.AgolaApiInterface, agolaRemoteSource string, gitUsers map[int64]dto.UserTeamResponseDto) *map[string]string {
-	usersMap := make(map[string]string)
-
-	remotesource, _ := agolaApi.GetRemoteSource(agolaRemoteSource)
-	if remotesource == nil {
-		return nil
-	}
-
-	for _, u := range gitUsers {
-		user, _ := agolaApi.GetUsersFilterbyRemoteUser(remotesource.ID, u.ID)
-		if len(user) == 1 {
-			usersMap[u.Username] = user[0].Username
-		}
+//Return nil without error if the remotesource or the user are not found in Agola
+func GetAgolaUserRefByGitUserID(ctx context.Context, agolaApi agola.AgolaApiInterface, agolaRemoteSource string, gitUserID int64) (*string, error) {
+	remotesource, err := agolaApi.GetRemoteSource(ctx, agolaRemoteSource)
+	if err != nil && !errors.Is(err, agola.ErrNotFound) {
+		return nil, err
 	}
-
-	return &usersMap
-}
-
-func GetAgolaUserRefByGitUserID(agolaApi agola.AgolaApiInterface, agolaRemoteSource string, gitUserID int64) *string {
-	remotesource, _ := agolaApi.GetRemoteSource(agolaRemoteSource)
 	if remotesource == nil {
-		return nil
+		return nil, nil
 	}
 
-	users, _ := agolaApi.GetUsersFilterbyRemoteUser(remotesource.ID, gitUserID)
+	users, err := agolaApi.GetUsersFilterbyRemoteUser(ctx, remotesource.ID, gitUserID)
+	if err != nil {
+		return nil, err
+	}
 	if len(users) == 1 {
-		return &users[0].Username
+		return &users[0].Username, nil
 	}
 
-	return nil
+	return nil, nil
 }
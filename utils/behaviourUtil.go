@@ -1,8 +1,10 @@
 package utils
 
 import (
+	"path"
 	"path/filepath"
 	"regexp"
+	"strings"
 
 	"wecode.sorint.it/opensource/papagaio-api/model"
 	"wecode.sorint.it/opensource/papagaio-api/types"
@@ -24,16 +26,24 @@ func EvaluateBehaviour(organization *model.Organization, repositoryName string)
 		return regexp.MustCompile(organization.BehaviourInclude).MatchString(repositoryName)
 	} else {
 		if len(organization.BehaviourExclude) > 0 {
-			isMatch, _ := filepath.Match(organization.BehaviourExclude, repositoryName)
-			if isMatch {
+			if matchWildcard(organization.BehaviourExclude, repositoryName) {
 				return false
 			}
 		}
-		matched, _ := filepath.Match(organization.BehaviourInclude, repositoryName)
-		return matched
+		return matchWildcard(organization.BehaviourInclude, repositoryName)
 	}
 }
 
+//The repositories of the subgroups are named subgroup/repository: a pattern without / is matched with the repository name only
+func matchWildcard(pattern string, repositoryName string) bool {
+	if !strings.Contains(pattern, "/") {
+		repositoryName = path.Base(repositoryName)
+	}
+
+	matched, _ := filepath.Match(pattern, repositoryName)
+	return matched
+}
+
 func ValidateBehaviour(organization *model.Organization) bool {
 	if organization.BehaviourType == types.None {
 		return true
@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"wecode.sorint.it/opensource/papagaio-api/repository"
+)
+
+//Store of the received webhook deliveries, used to discard the deliveries sent again by the git servers
+type DeliveryDedupStore struct {
+	mutex   sync.Mutex
+	db      repository.Database //when set the deliveries are persisted, so they are recognized after a restart
+	maxSize int
+	ttl     time.Duration
+
+	deliveries map[string]time.Time
+	order      []deliveryDedupEntry //deliveries in arrival order, the oldest are expired first
+
+	received   uint64
+	duplicates uint64
+	expired    uint64
+	evicted    uint64
+}
+
+type deliveryDedupEntry struct {
+	key        string
+	receivedAt time.Time
+}
+
+type DeliveryDedupStats struct {
+	Size       int
+	MaxSize    int
+	Received   uint64
+	Duplicates uint64
+	Expired    uint64
+	Evicted    uint64
+}
+
+func NewDeliveryDedupStore(maxSize uint, ttl time.Duration) *DeliveryDedupStore {
+	return &DeliveryDedupStore{
+		maxSize:    int(maxSize),
+		ttl:        ttl,
+		deliveries: make(map[string]time.Time),
+		order:      make([]deliveryDedupEntry, 0),
+	}
+}
+
+//Create a store persisted in the database, loading the deliveries received before the restart
+func NewPersistentDeliveryDedupStore(db repository.Database, maxSize uint, ttl time.Duration) *DeliveryDedupStore {
+	store := NewDeliveryDedupStore(maxSize, ttl)
+	store.db = db
+
+	deliveries, err := db.GetWebHookDeliveries()
+	if err != nil {
+		log.Println("GetWebHookDeliveries error:", err)
+		return store
+	}
+
+	for key, receivedAt := range deliveries {
+		store.order = append(store.order, deliveryDedupEntry{key: key, receivedAt: receivedAt})
+	}
+	sort.Slice(store.order, func(i, j int) bool {
+		return store.order[i].receivedAt.Before(store.order[j].receivedAt)
+	})
+	for _, entry := range store.order {
+		store.deliveries[entry.key] = entry.receivedAt
+	}
+
+	store.removeExpired(time.Now())
+	for len(store.deliveries) > store.maxSize && len(store.order) > 0 {
+		store.removeOldest()
+	}
+
+	return store
+}
+
+//Record the delivery, return true if it was already received
+func (store *DeliveryDedupStore) CheckAndAdd(key string) bool {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+	store.removeExpired(now)
+
+	store.received++
+	if _, ok := store.deliveries[key]; ok {
+		store.duplicates++
+		return true
+	}
+
+	for len(store.deliveries) >= store.maxSize && len(store.order) > 0 {
+		if store.removeOldest() {
+			store.evicted++
+		}
+	}
+
+	store.deliveries[key] = now
+	store.order = append(store.order, deliveryDedupEntry{key: key, receivedAt: now})
+
+	if store.db != nil {
+		if err := store.db.SaveWebHookDelivery(key, now, store.ttl); err != nil {
+			log.Println("SaveWebHookDelivery error:", err)
+		}
+	}
+
+	return false
+}
+
+//Forget the delivery, so it is processed when the git server sends it again
+func (store *DeliveryDedupStore) Remove(key string) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if _, ok := store.deliveries[key]; !ok {
+		return
+	}
+
+	delete(store.deliveries, key)
+	for i, entry := range store.order {
+		if entry.key == key {
+			store.order = append(store.order[:i], store.order[i+1:]...)
+			break
+		}
+	}
+
+	store.deleteFromDb(key)
+}
+
+func (store *DeliveryDedupStore) GetStats() DeliveryDedupStats {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.removeExpired(time.Now())
+
+	return DeliveryDedupStats{
+		Size:       len(store.deliveries),
+		MaxSize:    store.maxSize,
+		Received:   store.received,
+		Duplicates: store.duplicates,
+		Expired:    store.expired,
+		Evicted:    store.evicted,
+	}
+}
+
+func (store *DeliveryDedupStore) removeExpired(now time.Time) {
+	for len(store.order) > 0 && now.Sub(store.order[0].receivedAt) >= store.ttl {
+		if store.removeOldest() {
+			store.expired++
+		}
+	}
+}
+
+//Return false if the oldest entry was already removed
+func (store *DeliveryDedupStore) removeOldest() bool {
+	entry := store.order[0]
+	store.order = store.order[1:]
+
+	if receivedAt, ok := store.deliveries[entry.key]; ok && receivedAt.Equal(entry.receivedAt) {
+		delete(store.deliveries, entry.key)
+		store.deleteFromDb(entry.key)
+		return true
+	}
+
+	return false
+}
+
+func (store *DeliveryDedupStore) deleteFromDb(key string) {
+	if store.db == nil {
+		return
+	}
+
+	if err := store.db.DeleteWebHookDelivery(key); err != nil {
+		log.Println("DeleteWebHookDelivery error:", err)
+	}
+}